@@ -2,20 +2,34 @@ package config
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ethpandaops/contributoor-installer/cmd/cli/options"
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/logrotate"
+	"github.com/ethpandaops/contributoor-installer/internal/service"
 	"github.com/ethpandaops/contributoor-installer/internal/sidecar"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
 	"github.com/ethpandaops/contributoor-installer/internal/tui"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/mitchellh/go-homedir"
 	"github.com/rivo/tview"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
+	"google.golang.org/protobuf/proto"
 )
 
 func RegisterCommands(app *cli.App, opts *options.CommandOpts) {
 	app.Commands = append(app.Commands, cli.Command{
 		Name:      opts.Name(),
 		Usage:     "Configure Contributoor settings",
-		UsageText: "contributoor config",
+		UsageText: "contributoor config [command]",
 		Action: func(c *cli.Context) error {
 			log := opts.Logger()
 
@@ -24,20 +38,704 @@ func RegisterCommands(app *cli.App, opts *options.CommandOpts) {
 				return fmt.Errorf("%serror loading config: %v%s", tui.TerminalColorRed, err, tui.TerminalColorReset)
 			}
 
-			return configureContributoor(c, log, sidecarCfg)
+			return configureContributoor(c, log, sidecarCfg, opts.InstallerConfig())
+		},
+		Subcommands: []cli.Command{
+			{
+				Name:      "get",
+				Usage:     "Print the value of a config field",
+				UsageText: "contributoor config get <path>",
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 1 {
+						return cli.NewExitError("expected exactly one argument: <path>", 1)
+					}
+
+					sidecarCfg, err := sidecar.NewConfigService(opts.Logger(), c.GlobalString("config-path"))
+					if err != nil {
+						return cli.NewExitError(fmt.Sprintf("error loading config: %v", err), 1)
+					}
+
+					return getConfigValue(c.App.Writer, sidecarCfg, c.Args().Get(0))
+				},
+			},
+			{
+				Name:      "set",
+				Usage:     "Set the value of a config field",
+				UsageText: "contributoor config set <path> <value>",
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 2 {
+						return cli.NewExitError("expected exactly two arguments: <path> <value>", 1)
+					}
+
+					sidecarCfg, err := sidecar.NewConfigService(opts.Logger(), c.GlobalString("config-path"))
+					if err != nil {
+						return cli.NewExitError(fmt.Sprintf("error loading config: %v", err), 1)
+					}
+
+					return setConfigValue(c.App.Writer, sidecarCfg, c.Args().Get(0), c.Args().Get(1))
+				},
+			},
+			{
+				Name:      "unset",
+				Usage:     "Reset a config field to its default",
+				UsageText: "contributoor config unset <path>",
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 1 {
+						return cli.NewExitError("expected exactly one argument: <path>", 1)
+					}
+
+					sidecarCfg, err := sidecar.NewConfigService(opts.Logger(), c.GlobalString("config-path"))
+					if err != nil {
+						return cli.NewExitError(fmt.Sprintf("error loading config: %v", err), 1)
+					}
+
+					return unsetConfigValue(c.App.Writer, sidecarCfg, c.Args().Get(0))
+				},
+			},
+			{
+				Name:      "show",
+				Usage:     "Print the config",
+				UsageText: "contributoor config show [options]",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "format, f",
+						Usage: "Output `format` (yaml or json)",
+						Value: sidecar.FormatYAML,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					sidecarCfg, err := sidecar.NewConfigService(opts.Logger(), c.GlobalString("config-path"))
+					if err != nil {
+						return cli.NewExitError(fmt.Sprintf("error loading config: %v", err), 1)
+					}
+
+					return showConfig(c.App.Writer, sidecarCfg, c.String("format"))
+				},
+			},
+			{
+				Name:      "migrate",
+				Usage:     "Migrate the config to the latest schema",
+				UsageText: "contributoor config migrate [options]",
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Show the changes the migration would make, without applying them",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					// Deliberately not loaded through the config service, which applies any
+					// pending migrations as it loads.
+					configDir, err := homedir.Expand(c.GlobalString("config-path"))
+					if err != nil {
+						return cli.NewExitError(fmt.Sprintf("error expanding config path: %v", err), 1)
+					}
+
+					return migrateConfig(c.App.Writer, configDir, c.Bool("dry-run"))
+				},
+			},
 		},
 	})
 }
 
-func configureContributoor(c *cli.Context, log *logrus.Logger, sidecarCfg sidecar.ConfigManager) error {
+func configureContributoor(
+	c *cli.Context,
+	log *logrus.Logger,
+	sidecarCfg sidecar.ConfigManager,
+	installerCfg *installer.Config,
+) error {
 	var (
-		app     = tview.NewApplication()
-		display = NewConfigDisplay(log, app, sidecarCfg)
+		app               = tview.NewApplication()
+		display           = NewConfigDisplay(log, app, sidecarCfg)
+		previousRunMethod = sidecarCfg.Get().RunMethod
+		previousScope     = systemdScope(sidecarCfg)
 	)
 
 	if err := display.Run(); err != nil {
 		return fmt.Errorf("%sdisplay error: %w%s", tui.TerminalColorRed, err, tui.TerminalColorReset)
 	}
 
+	// Moving to another run method takes more than the config, so it's rolled back if
+	// contributoor can't be moved over.
+	runMethod, scope := sidecarCfg.Get().RunMethod, systemdScope(sidecarCfg)
+	if runMethod != previousRunMethod {
+		return switchRunMethod(c.App.Writer, log, sidecarCfg, installerCfg, previousRunMethod, runMethod)
+	}
+
+	// Switching between system and user units needs a unit file of the new scope, which
+	// launchd users install themselves.
+	if scope == previousScope || runMethod != config.RunMethod_RUN_METHOD_SYSTEMD || runtime.GOOS == sidecar.ArchDarwin {
+		return nil
+	}
+
+	binarySidecar, err := sidecar.NewBinarySidecar(log, sidecarCfg, installerCfg)
+	if err != nil {
+		return fmt.Errorf("error creating binary sidecar service: %w", err)
+	}
+
+	systemdSidecar, err := sidecar.NewSystemdSidecar(log, sidecarCfg, installerCfg)
+	if err != nil {
+		return fmt.Errorf("error creating systemd sidecar service: %w", err)
+	}
+
+	if err := switchScope(c.App.Writer, sidecarCfg, systemdSidecar, previousScope, scope); err != nil {
+		return err
+	}
+
+	return setUpSystemd(c.App.Writer, binarySidecar, systemdSidecar)
+}
+
+// switchScope moves the unit to the scope chosen in the TUI, which has already been
+// saved to the installer state. It's put back until the unit of the old scope has been
+// removed, which is where it stays if that fails.
+func switchScope(
+	w io.Writer,
+	sidecarCfg sidecar.ConfigManager,
+	systemd sidecar.SystemdSidecar,
+	from, to installer.SystemdScope,
+) error {
+	state, err := installer.LoadState(filepath.Dir(sidecarCfg.GetConfigPath()))
+	if err != nil {
+		return err
+	}
+
+	state.SystemdScope = from
+
+	if err := state.Save(); err != nil {
+		return fmt.Errorf("failed to restore systemd scope: %w", err)
+	}
+
+	if err := sidecar.SwitchScope(w, sidecarCfg, systemd, to); err != nil {
+		return fmt.Errorf("failed to switch to a %s unit, it's still a %s unit: %w", to, from, err)
+	}
+
+	return nil
+}
+
+// switchRunMethod moves contributoor to the run method chosen in the TUI, which has
+// already been saved to the config. It's put back until contributoor has been moved.
+func switchRunMethod(
+	w io.Writer,
+	log *logrus.Logger,
+	sidecarCfg sidecar.ConfigManager,
+	installerCfg *installer.Config,
+	from, to config.RunMethod,
+) error {
+	if err := sidecarCfg.Update(func(cfg *config.Config) {
+		cfg.RunMethod = from
+	}); err != nil {
+		return fmt.Errorf("failed to restore run method: %w", err)
+	}
+
+	dockerSidecar, err := sidecar.NewDockerSidecar(log, sidecarCfg, installerCfg)
+	if err != nil {
+		return fmt.Errorf("error creating docker sidecar service: %w", err)
+	}
+
+	systemdSidecar, err := sidecar.NewSystemdSidecar(log, sidecarCfg, installerCfg)
+	if err != nil {
+		return fmt.Errorf("error creating systemd sidecar service: %w", err)
+	}
+
+	binarySidecar, err := sidecar.NewBinarySidecar(log, sidecarCfg, installerCfg)
+	if err != nil {
+		return fmt.Errorf("error creating binary sidecar service: %w", err)
+	}
+
+	fmt.Fprintf(w, "%sMigrating Contributoor from %s to %s%s\n", tui.TerminalColorLightBlue,
+		strings.ToLower(from.DisplayName()), strings.ToLower(to.DisplayName()), tui.TerminalColorReset)
+
+	if err := sidecar.SwitchRunMethod(w, sidecarCfg, &sidecar.Runners{
+		Docker:  dockerSidecar,
+		Systemd: systemdSidecar,
+		Binary:  binarySidecar,
+	}, from, to); err != nil {
+		return fmt.Errorf("failed to switch run method, it's still %s: %w", strings.ToLower(from.DisplayName()), err)
+	}
+
+	return nil
+}
+
+// systemdScope returns the scope of the systemd unit recorded in the installer state.
+func systemdScope(sidecarCfg sidecar.ConfigManager) installer.SystemdScope {
+	state, err := installer.LoadState(filepath.Dir(sidecarCfg.GetConfigPath()))
+	if err != nil {
+		return installer.SystemdScopeSystem
+	}
+
+	return state.GetSystemdScope()
+}
+
+// setUpSystemd installs what the systemd run method needs: the binary, which the other
+// run methods may not have downloaded, and the unit file that runs it.
+func setUpSystemd(w io.Writer, binary sidecar.BinarySidecar, systemd sidecar.SystemdSidecar) error {
+	details, err := binary.Details()
+	if err != nil {
+		return fmt.Errorf("failed to check for the binary: %w", err)
+	}
+
+	if !details.Installed {
+		if err := binary.Update(); err != nil {
+			return fmt.Errorf("failed to install the binary for systemd: %w", err)
+		}
+	}
+
+	change, err := systemd.InstallUnit()
+	if err != nil {
+		return fmt.Errorf("failed to install the systemd unit: %w", err)
+	}
+
+	if change != runner.UnitUnchanged {
+		fmt.Fprintf(w, "%sSystemd unit %s%s\n", tui.TerminalColorGreen, change, tui.TerminalColorReset)
+	}
+
+	return nil
+}
+
+// stateValue is a setting addressed like a config field in config get, set and unset,
+// but kept in the installer state, as config.yaml has no room for it. Setting it to an
+// empty value resets it to its default.
+type stateValue struct {
+	get func(state *installer.State) string
+	set func(state *installer.State, value string) error
+}
+
+// stateValues are the settings kept in the installer state, by path.
+var stateValues = map[string]stateValue{
+	"pullPolicy": {
+		get: func(state *installer.State) string {
+			return string(state.GetPullPolicy())
+		},
+		set: func(state *installer.State, value string) error {
+			if value == "" {
+				state.PullPolicy = ""
+
+				return nil
+			}
+
+			policy, err := installer.ParsePullPolicy(value)
+			if err != nil {
+				return err
+			}
+
+			state.PullPolicy = policy
+
+			return nil
+		},
+	},
+	"channel": {
+		get: func(state *installer.State) string {
+			return string(state.GetChannel())
+		},
+		set: func(state *installer.State, value string) error {
+			if value == "" {
+				state.Channel = ""
+
+				return nil
+			}
+
+			channel, err := installer.ParseChannel(value)
+			if err != nil {
+				return err
+			}
+
+			state.Channel = channel
+
+			return nil
+		},
+	},
+	"containerRuntime": {
+		get: func(state *installer.State) string {
+			if state.ContainerRuntime == "" {
+				return "auto"
+			}
+
+			return string(state.ContainerRuntime)
+		},
+		set: func(state *installer.State, value string) error {
+			if value == "" || value == "auto" {
+				state.ContainerRuntime = ""
+
+				return nil
+			}
+
+			runtime, err := installer.ParseContainerRuntime(value)
+			if err != nil {
+				return err
+			}
+
+			state.ContainerRuntime = runtime
+
+			return nil
+		},
+	},
+	"releaseSource": {
+		get: func(state *installer.State) string {
+			if state.ReleaseSource == "" {
+				return service.ReleaseSourceGitHub
+			}
+
+			return state.ReleaseSource
+		},
+		set: func(state *installer.State, value string) error {
+			source, err := service.ParseReleaseSource(value)
+			if err != nil {
+				return err
+			}
+
+			state.ReleaseSource = source
+
+			return nil
+		},
+	},
+	"cosignPublicKey": {
+		get: func(state *installer.State) string {
+			return state.CosignPublicKey
+		},
+		set: func(state *installer.State, value string) error {
+			if value == "" {
+				state.CosignPublicKey = ""
+
+				return nil
+			}
+
+			// The key's read whenever an image is pinned, so it must stay where it is.
+			path, err := homedir.Expand(value)
+			if err != nil {
+				return err
+			}
+
+			if path, err = filepath.Abs(path); err != nil {
+				return err
+			}
+
+			if _, err := os.Stat(path); err != nil {
+				return fmt.Errorf("invalid cosign public key: %w", err)
+			}
+
+			state.CosignPublicKey = path
+
+			return nil
+		},
+	},
+	"container.network": containerValue(
+		func(c *installer.ContainerSettings) string { return c.Network },
+		func(c *installer.ContainerSettings, value string) error {
+			c.Network = value
+
+			return nil
+		},
+	),
+	"container.cpus": containerValue(
+		func(c *installer.ContainerSettings) string { return formatCPUs(c.CPUs) },
+		func(c *installer.ContainerSettings, value string) error {
+			cpus, err := parseCPUs(value)
+			if err != nil {
+				return err
+			}
+
+			c.CPUs = cpus
+
+			return nil
+		},
+	),
+	"container.memory": containerValue(
+		func(c *installer.ContainerSettings) string { return c.Memory },
+		func(c *installer.ContainerSettings, value string) error {
+			c.Memory = value
+
+			return nil
+		},
+	),
+	"container.logDriver": containerValue(
+		func(c *installer.ContainerSettings) string { return c.LogDriver },
+		func(c *installer.ContainerSettings, value string) error {
+			c.LogDriver = value
+
+			return nil
+		},
+	),
+	"logRotation.maxSize": logRotationValue(
+		func(p logrotate.Policy) string { return formatLogSize(p.MaxSize) },
+		func(l *installer.LogRotationSettings, value string) error {
+			l.MaxSize = value
+
+			return nil
+		},
+	),
+	"logRotation.maxAge": logRotationValue(
+		func(p logrotate.Policy) string { return formatLogAge(p.MaxAge) },
+		func(l *installer.LogRotationSettings, value string) error {
+			l.MaxAge = value
+
+			return nil
+		},
+	),
+	"logRotation.retention": logRotationValue(
+		func(p logrotate.Policy) string { return strconv.Itoa(p.Retention) },
+		func(l *installer.LogRotationSettings, value string) error {
+			if value == "" {
+				l.Retention = nil
+
+				return nil
+			}
+
+			retention, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid log retention %q, expected a number", value)
+			}
+
+			l.Retention = &retention
+
+			return nil
+		},
+	),
+	"logRotation.compress": logRotationValue(
+		func(p logrotate.Policy) string { return strconv.FormatBool(p.Compress) },
+		func(l *installer.LogRotationSettings, value string) error {
+			if value == "" {
+				l.Compress = nil
+
+				return nil
+			}
+
+			compress, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid log compress %q, expected true or false", value)
+			}
+
+			l.Compress = &compress
+
+			return nil
+		},
+	),
+}
+
+// logRotationValue is a stateValue for one of the log rotation settings. It's shown as
+// the policy logs are rotated with, and is validated as a whole once it's set.
+func logRotationValue(
+	get func(p logrotate.Policy) string,
+	set func(l *installer.LogRotationSettings, value string) error,
+) stateValue {
+	return stateValue{
+		get: func(state *installer.State) string {
+			policy, _ := state.LogRotation.Policy(installer.NewConfig().LogRotation)
+
+			return get(policy)
+		},
+		set: func(state *installer.State, value string) error {
+			if err := set(&state.LogRotation, value); err != nil {
+				return err
+			}
+
+			_, err := state.LogRotation.Policy(logrotate.Policy{})
+
+			return err
+		},
+	}
+}
+
+// formatLogSize formats a log size in the largest unit it's a whole number of, eg: 100m.
+func formatLogSize(size int64) string {
+	for _, unit := range []struct {
+		suffix string
+		bytes  int64
+	}{{"g", 1 << 30}, {"m", 1 << 20}, {"k", 1 << 10}} {
+		if size > 0 && size%unit.bytes == 0 {
+			return fmt.Sprintf("%d%s", size/unit.bytes, unit.suffix)
+		}
+	}
+
+	return strconv.FormatInt(size, 10)
+}
+
+// formatLogAge formats a log age, dropping the zero minutes and seconds of whole hours,
+// eg: 168h rather than 168h0m0s.
+func formatLogAge(age time.Duration) string {
+	if age > 0 && age%time.Hour == 0 {
+		return fmt.Sprintf("%dh", age/time.Hour)
+	}
+
+	return age.String()
+}
+
+// containerValue is a stateValue for one of the container settings, which are validated
+// as a whole once it's set.
+func containerValue(
+	get func(c *installer.ContainerSettings) string,
+	set func(c *installer.ContainerSettings, value string) error,
+) stateValue {
+	return stateValue{
+		get: func(state *installer.State) string {
+			return get(&state.Container)
+		},
+		set: func(state *installer.State, value string) error {
+			if err := set(&state.Container, value); err != nil {
+				return err
+			}
+
+			return state.Container.Validate()
+		},
+	}
+}
+
+// getConfigValue prints the value of the config field at the given path.
+func getConfigValue(w io.Writer, sidecarCfg sidecar.ConfigManager, path string) error {
+	if value, ok := stateValues[path]; ok {
+		state, err := installer.LoadState(filepath.Dir(sidecarCfg.GetConfigPath()))
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+
+		fmt.Fprintln(w, value.get(state))
+
+		return nil
+	}
+
+	value, err := sidecar.GetConfigValue(sidecarCfg.Get(), path)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	fmt.Fprintln(w, value)
+
+	return nil
+}
+
+// setConfigValue sets the config field at the given path, and saves the config.
+func setConfigValue(w io.Writer, sidecarCfg sidecar.ConfigManager, path, value string) error {
+	var err error
+
+	if stateVal, ok := stateValues[path]; ok {
+		err = setStateValue(sidecarCfg, stateVal, value)
+	} else {
+		err = updateConfig(sidecarCfg, func(cfg *config.Config) error {
+			return sidecar.SetConfigValue(cfg, path, value)
+		})
+	}
+
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	fmt.Fprintf(w, "%sSet %s%s\n", tui.TerminalColorGreen, path, tui.TerminalColorReset)
+
+	return nil
+}
+
+// unsetConfigValue resets the config field at the given path, and saves the config.
+func unsetConfigValue(w io.Writer, sidecarCfg sidecar.ConfigManager, path string) error {
+	var err error
+
+	if stateVal, ok := stateValues[path]; ok {
+		err = setStateValue(sidecarCfg, stateVal, "")
+	} else {
+		err = updateConfig(sidecarCfg, func(cfg *config.Config) error {
+			return sidecar.UnsetConfigValue(cfg, path)
+		})
+	}
+
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	fmt.Fprintf(w, "%sUnset %s%s\n", tui.TerminalColorGreen, path, tui.TerminalColorReset)
+
+	return nil
+}
+
+// setStateValue sets a setting kept in the installer state, and saves the state.
+func setStateValue(sidecarCfg sidecar.ConfigManager, stateVal stateValue, value string) error {
+	state, err := installer.LoadState(filepath.Dir(sidecarCfg.GetConfigPath()))
+	if err != nil {
+		return err
+	}
+
+	if err := stateVal.set(state, value); err != nil {
+		return err
+	}
+
+	return state.Save()
+}
+
+// showConfig prints the whole config in the given format.
+func showConfig(w io.Writer, sidecarCfg sidecar.ConfigManager, format string) error {
+	data, err := sidecar.MarshalConfig(sidecarCfg.Get(), format)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return cli.NewExitError(fmt.Sprintf("failed to write config: %v", err), 1)
+	}
+
 	return nil
 }
+
+// migrateConfig migrates the config to the latest schema. With dryRun, the changes it
+// would make are shown instead.
+func migrateConfig(w io.Writer, configDir string, dryRun bool) error {
+	plan, err := sidecar.PlanMigration(configDir)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	if !plan.Pending() {
+		fmt.Fprintf(w, "Config is up to date at schema version %d\n", plan.FromVersion)
+
+		return nil
+	}
+
+	fmt.Fprintf(w, "Migrating config from schema version %d to %d:\n", plan.FromVersion, plan.ToVersion)
+
+	for i, step := range plan.Steps {
+		fmt.Fprintf(w, "  %d. %s\n", plan.FromVersion+i+1, step)
+	}
+
+	if dryRun {
+		if !plan.Changed() {
+			fmt.Fprintf(w, "\nNo changes to config.yaml\n")
+
+			return nil
+		}
+
+		diff, err := plan.Diff()
+		if err != nil {
+			return cli.NewExitError(err.Error(), 1)
+		}
+
+		fmt.Fprintf(w, "\n%s", diff)
+
+		return nil
+	}
+
+	backupPath, err := sidecar.ApplyMigration(plan)
+	if err != nil {
+		return cli.NewExitError(err.Error(), 1)
+	}
+
+	if backupPath != "" {
+		fmt.Fprintf(w, "Previous config backed up to %s\n", backupPath)
+	}
+
+	fmt.Fprintf(w, "%sConfig migrated to schema version %d%s\n", tui.TerminalColorGreen, plan.ToVersion, tui.TerminalColorReset)
+
+	return nil
+}
+
+// updateConfig applies fn to a copy of the config, and then saves it through the config
+// manager, so that it's validated and written atomically.
+func updateConfig(sidecarCfg sidecar.ConfigManager, fn func(*config.Config) error) error {
+	updated, ok := proto.Clone(sidecarCfg.Get()).(*config.Config)
+	if !ok {
+		return fmt.Errorf("failed to clone config")
+	}
+
+	if err := fn(updated); err != nil {
+		return err
+	}
+
+	return sidecarCfg.Update(func(cfg *config.Config) {
+		proto.Reset(cfg)
+		proto.Merge(cfg, updated)
+	})
+}
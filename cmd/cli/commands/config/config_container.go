@@ -0,0 +1,318 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/tui"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// ContainerSettingsPage is a page that allows the user to customise the container the
+// docker run mode creates.
+type ContainerSettingsPage struct {
+	display     *ConfigDisplay
+	page        *tui.Page
+	content     tview.Primitive
+	form        *tview.Form
+	description *tview.TextView
+	state       *installer.State
+	stateErr    error
+}
+
+// NewContainerSettingsPage creates a new ContainerSettingsPage.
+func NewContainerSettingsPage(cd *ConfigDisplay) *ContainerSettingsPage {
+	containerPage := &ContainerSettingsPage{
+		display: cd,
+	}
+
+	containerPage.initPage()
+	containerPage.page = tui.NewPage(
+		cd.homePage,
+		"config-container",
+		"Container Settings",
+		"Configure the docker container's network, resource limits, extra mounts, environment and logging",
+		containerPage.content,
+	)
+
+	return containerPage
+}
+
+// GetPage returns the page.
+func (p *ContainerSettingsPage) GetPage() *tui.Page {
+	return p.page
+}
+
+// initPage initializes the page.
+func (p *ContainerSettingsPage) initPage() {
+	// Create a form to collect user input.
+	form := tview.NewForm()
+	p.form = form
+	form.SetBackgroundColor(tui.ColorFormBackground)
+
+	// Create a description box to display help text.
+	p.description = tview.NewTextView()
+	p.description.
+		SetDynamicColors(true).
+		SetWordWrap(true).
+		SetTextAlign(tview.AlignLeft).
+		SetBackgroundColor(tui.ColorFormBackground)
+	p.description.SetBorder(true)
+	p.description.SetTitle(tui.TitleDescription)
+	p.description.SetBorderPadding(0, 0, 1, 1)
+	p.description.SetBorderColor(tui.ColorBorder)
+
+	// The container settings live in the installer state, alongside the config.
+	p.state, p.stateErr = p.display.state, p.display.stateErr
+
+	settings := p.settings()
+
+	logOptions := make([]string, 0, len(settings.LogOptions))
+	for k, v := range settings.LogOptions {
+		logOptions = append(logOptions, k+"="+v)
+	}
+
+	sort.Strings(logOptions)
+
+	// Add our form fields. Lists are entered one item per line.
+	form.AddInputField("Network", settings.Network, 0, nil, nil)
+	form.AddInputField("CPUs", formatCPUs(settings.CPUs), 0, nil, nil)
+	form.AddInputField("Memory", settings.Memory, 0, nil, nil)
+	form.AddTextArea("Volumes", strings.Join(settings.Volumes, "\n"), 0, 3, 0, nil)
+	form.AddTextArea("Environment", strings.Join(settings.Env, "\n"), 0, 3, 0, nil)
+	form.AddInputField("Log Driver", settings.LogDriver, 0, nil, nil)
+	form.AddTextArea("Log Options", strings.Join(logOptions, "\n"), 0, 3, 0, nil)
+
+	descriptions := []string{
+		"The network to put the container on, eg: your beacon node's network, or a network mode, eg: host. Leave empty for the default bridge.",
+		"The most CPUs the container can use, eg: 1.5. Leave empty for no limit.",
+		"The most memory the container can use, eg: 512m or 2g. Leave empty for no limit.",
+		"Extra mounts, one per line, eg: /data/jwt:/jwt:ro.",
+		"Extra environment variables, one per line, eg: TZ=UTC.",
+		"The logging driver, eg: json-file or journald. Leave empty for the runtime's default.",
+		"Options for the logging driver, one per line, eg: max-size=10m.",
+	}
+
+	for i, description := range descriptions {
+		if box, ok := form.GetFormItem(i).(interface {
+			SetFocusFunc(func()) *tview.Box
+		}); ok {
+			box.SetFocusFunc(func() {
+				p.description.SetText(description)
+				fmt.Fprint(p.description, "\n\nOnly applies to the docker run mode. The container's recreated with these settings when contributoor next starts.")
+			})
+		}
+	}
+
+	// Add a save button and ensure we validate the input.
+	saveButton := tview.NewButton(tui.ButtonSaveSettings)
+	saveButton.SetSelectedFunc(func() {
+		validateAndUpdateContainer(p)
+	})
+	saveButton.SetBackgroundColorActivated(tui.ColorButtonActivated)
+	saveButton.SetLabelColorActivated(tui.ColorButtonText)
+
+	// Define key bindings for the save button.
+	saveButton.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyTab, tcell.KeyBacktab:
+			p.display.app.SetFocus(form)
+
+			return nil
+		}
+
+		return event
+	})
+
+	// Define key bindings for the form.
+	form.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		formIndex, _ := form.GetFocusedItemIndex()
+
+		switch event.Key() {
+		case tcell.KeyTab:
+			if formIndex == form.GetFormItemCount()-1 {
+				p.display.app.SetFocus(saveButton)
+
+				return nil
+			}
+
+			return event
+		case tcell.KeyBacktab:
+			if formIndex == 0 {
+				p.display.app.SetFocus(saveButton)
+
+				return nil
+			}
+
+			return event
+		default:
+			return event
+		}
+	})
+
+	// We wrap the form in a frame to add a border and title.
+	formFrame := tview.NewFrame(form)
+	formFrame.SetBorder(true)
+	formFrame.SetTitle("Container Settings")
+	formFrame.SetBorderPadding(0, 0, 1, 1)
+	formFrame.SetBorderColor(tui.ColorBorder)
+	formFrame.SetBackgroundColor(tui.ColorFormBackground)
+
+	// Create a button container to hold the save button.
+	buttonFlex := tview.NewFlex().
+		SetDirection(tview.FlexColumn).
+		AddItem(nil, 0, 1, false).
+		AddItem(saveButton, len(tui.ButtonSaveSettings)+4, 0, true).
+		AddItem(nil, 0, 1, false)
+
+	// Create a horizontal flex to hold the form and description.
+	formDescriptionFlex := tview.NewFlex().
+		SetDirection(tview.FlexColumn).
+		AddItem(formFrame, 0, 2, true).
+		AddItem(p.description, 0, 1, false)
+
+	// Create a main layout both the flexes.
+	mainFlex := tview.NewFlex().
+		SetDirection(tview.FlexRow).
+		AddItem(formDescriptionFlex, 0, 1, true).
+		AddItem(nil, 1, 0, false).
+		AddItem(buttonFlex, 1, 0, false).
+		AddItem(nil, 1, 0, false)
+	mainFlex.SetBackgroundColor(tui.ColorBackground)
+
+	p.content = mainFlex
+}
+
+func validateAndUpdateContainer(p *ContainerSettingsPage) {
+	if p.stateErr != nil {
+		p.openErrorModal(p.stateErr)
+
+		return
+	}
+
+	text := func(i int) string {
+		switch item := p.form.GetFormItem(i).(type) {
+		case *tview.InputField:
+			return strings.TrimSpace(item.GetText())
+		case *tview.TextArea:
+			return item.GetText()
+		default:
+			return ""
+		}
+	}
+
+	settings, err := parseContainerSettings(text(0), text(1), text(2), text(3), text(4), text(5), text(6))
+	if err != nil {
+		p.openErrorModal(err)
+
+		return
+	}
+
+	p.state.Container = *settings
+
+	if err := p.state.Save(); err != nil {
+		p.openErrorModal(err)
+
+		return
+	}
+
+	p.display.markConfigChanged()
+	p.display.setPage(p.display.homePage)
+}
+
+// parseContainerSettings parses the container settings as entered in the form, with
+// lists entered one item per line, and validates them.
+func parseContainerSettings(network, cpus, memory, volumes, env, logDriver, logOptions string) (*installer.ContainerSettings, error) {
+	settings := &installer.ContainerSettings{
+		Network:   network,
+		Memory:    memory,
+		Volumes:   lines(volumes),
+		Env:       lines(env),
+		LogDriver: logDriver,
+	}
+
+	var err error
+
+	if settings.CPUs, err = parseCPUs(cpus); err != nil {
+		return nil, err
+	}
+
+	for _, option := range lines(logOptions) {
+		key, value, ok := strings.Cut(option, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid log option %q, expected key=value", option)
+		}
+
+		if settings.LogOptions == nil {
+			settings.LogOptions = make(map[string]string)
+		}
+
+		settings.LogOptions[key] = value
+	}
+
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// parseCPUs parses a CPU limit, eg: 1.5, where empty is no limit.
+func parseCPUs(cpus string) (float64, error) {
+	if cpus == "" {
+		return 0, nil
+	}
+
+	value, err := strconv.ParseFloat(cpus, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cpus %q, expected a number, eg: 1.5", cpus)
+	}
+
+	return value, nil
+}
+
+// formatCPUs formats a CPU limit, where no limit is empty.
+func formatCPUs(cpus float64) string {
+	if cpus == 0 {
+		return ""
+	}
+
+	return strconv.FormatFloat(cpus, 'f', -1, 64)
+}
+
+// lines splits text into its non-empty lines, trimmed of spaces.
+func lines(text string) []string {
+	var result []string
+
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			result = append(result, line)
+		}
+	}
+
+	return result
+}
+
+// settings returns the container settings in the installer state.
+func (p *ContainerSettingsPage) settings() installer.ContainerSettings {
+	if p.state == nil {
+		return installer.ContainerSettings{}
+	}
+
+	return p.state.Container
+}
+
+func (p *ContainerSettingsPage) openErrorModal(err error) {
+	p.display.app.SetRoot(tui.CreateErrorModal(
+		p.display.app,
+		err.Error(),
+		func() {
+			p.display.app.SetRoot(p.display.frame, true)
+			p.display.app.SetFocus(p.form)
+		},
+	), true)
+}
@@ -1,9 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"runtime"
 	"strings"
 
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
 	"github.com/ethpandaops/contributoor-installer/internal/tui"
 	"github.com/ethpandaops/contributoor/pkg/config/v1"
 	"github.com/gdamore/tcell/v2"
@@ -11,11 +13,24 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// Available run modes.
-var runModes = []config.RunMethod{
-	config.RunMethod_RUN_METHOD_DOCKER,
-	config.RunMethod_RUN_METHOD_SYSTEMD,
-	config.RunMethod_RUN_METHOD_BINARY,
+// runMode is a run method, and for systemd, the scope of its unit.
+type runMode struct {
+	method config.RunMethod
+	scope  installer.SystemdScope
+}
+
+// getRunModes returns the available run modes. User units are systemd only.
+func getRunModes() []runMode {
+	modes := []runMode{
+		{method: config.RunMethod_RUN_METHOD_DOCKER},
+		{method: config.RunMethod_RUN_METHOD_SYSTEMD, scope: installer.SystemdScopeSystem},
+	}
+
+	if runtime.GOOS != "darwin" {
+		modes = append(modes, runMode{method: config.RunMethod_RUN_METHOD_SYSTEMD, scope: installer.SystemdScopeUser})
+	}
+
+	return append(modes, runMode{method: config.RunMethod_RUN_METHOD_BINARY})
 }
 
 // ContributoorSettingsPage is a page that allows the user to configure core contributoor settings.
@@ -25,6 +40,9 @@ type ContributoorSettingsPage struct {
 	content     tview.Primitive
 	form        *tview.Form
 	description *tview.TextView
+	runModes    []runMode
+	state       *installer.State
+	stateErr    error
 }
 
 // NewContributoorSettingsPage creates a new ContributoorSettingsPage.
@@ -90,11 +108,21 @@ func (p *ContributoorSettingsPage) initPage() {
 		}
 	}
 
+	// The systemd scope, pull policy, container runtime and channel live in the installer
+	// state, alongside the config.
+	p.state, p.stateErr = p.display.state, p.display.stateErr
+
+	p.runModes = getRunModes()
+
 	// Find current run mode index
-	currentRunMode := p.display.sidecarCfg.Get().RunMethod
+	currentRunMode := runMode{method: p.display.sidecarCfg.Get().RunMethod}
+	if currentRunMode.method == config.RunMethod_RUN_METHOD_SYSTEMD {
+		currentRunMode.scope = p.systemdScope()
+	}
+
 	currentRunModeIndex := 0 // Default to docker
 
-	for i, mode := range runModes {
+	for i, mode := range p.runModes {
 		if mode == currentRunMode {
 			currentRunModeIndex = i
 
@@ -103,13 +131,16 @@ func (p *ContributoorSettingsPage) initPage() {
 	}
 
 	// Create display labels that show launchd on macOS
-	runModeLabels := make([]string, len(runModes))
+	runModeLabels := make([]string, len(p.runModes))
 
-	for i, mode := range runModes {
-		if mode == config.RunMethod_RUN_METHOD_SYSTEMD {
+	for i, mode := range p.runModes {
+		switch {
+		case mode.scope == installer.SystemdScopeUser:
+			runModeLabels[i] = "systemd (user)"
+		case mode.method == config.RunMethod_RUN_METHOD_SYSTEMD:
 			runModeLabels[i] = getServiceManagerLabel()
-		} else {
-			runModeLabels[i] = strings.ToLower(mode.DisplayName())
+		default:
+			runModeLabels[i] = strings.ToLower(mode.method.DisplayName())
 		}
 	}
 
@@ -119,13 +150,100 @@ func (p *ContributoorSettingsPage) initPage() {
 	})
 
 	form.AddDropDown("Run Mode", runModeLabels, currentRunModeIndex, func(option string, index int) {
-		if option == config.RunMethod_RUN_METHOD_DOCKER.String() {
+		switch mode := p.runModes[index]; {
+		case mode.method == config.RunMethod_RUN_METHOD_DOCKER:
 			p.description.SetText("Run using Docker containers (recommended)")
-		} else if runModes[index] == config.RunMethod_RUN_METHOD_SYSTEMD {
+		case mode.scope == installer.SystemdScopeUser:
+			p.description.SetText("Run using your own systemd user service manager, without root. Enable lingering with 'loginctl enable-linger' to keep it running after you log out.")
+		case mode.method == config.RunMethod_RUN_METHOD_SYSTEMD:
 			p.description.SetText(getServiceManagerDescription())
-		} else {
+		default:
 			p.description.SetText("Run directly as a binary on your system")
 		}
+
+		if p.runModes[index].method != p.runModes[currentRunModeIndex].method {
+			fmt.Fprint(p.description, "\n\nContributoor is moved over to it when you close the settings, and left as it was if that fails.")
+		}
+	})
+
+	// Find current pull policy index
+	currentPullPolicyIndex := 0 // Default to missing
+
+	pullPolicies := make([]string, len(installer.PullPolicies))
+
+	for i, policy := range installer.PullPolicies {
+		pullPolicies[i] = string(policy)
+
+		if policy == p.pullPolicy() {
+			currentPullPolicyIndex = i
+		}
+	}
+
+	form.AddDropDown("Pull Policy", pullPolicies, currentPullPolicyIndex, func(option string, index int) {
+		switch installer.PullPolicies[index] {
+		case installer.PullPolicyAlways:
+			p.description.SetText("Pull the image every time contributoor starts, picking up changes to the tag.")
+		case installer.PullPolicyNever:
+			p.description.SetText("Never pull the image when contributoor starts. Updates still pull the new version.")
+		default:
+			p.description.SetText("Only pull the image when contributoor starts if it isn't already available (recommended on metered connections).")
+		}
+
+		fmt.Fprint(p.description, "\n\nOnly applies to the docker run mode.")
+	})
+
+	// Find current container runtime index
+	currentContainerRuntimeIndex := 0 // Default to auto
+
+	runtimeLabels := make([]string, len(containerRuntimes))
+
+	for i, runtime := range containerRuntimes {
+		runtimeLabels[i] = containerRuntimeLabel(runtime)
+
+		if runtime == p.containerRuntime() {
+			currentContainerRuntimeIndex = i
+		}
+	}
+
+	form.AddDropDown("Container Runtime", runtimeLabels, currentContainerRuntimeIndex, func(option string, index int) {
+		switch containerRuntimes[index] {
+		case installer.ContainerRuntimeDocker:
+			p.description.SetText("Run the container with docker.")
+		case installer.ContainerRuntimePodman:
+			p.description.SetText("Run the container with podman, through its docker compatible API. Rootless podman needs 'systemctl --user enable --now podman.socket'.")
+		default:
+			p.description.SetText("Run the container with docker if it's found, or podman otherwise.")
+		}
+
+		fmt.Fprint(p.description, "\n\nOnly applies to the docker run mode. Stop contributoor before switching, as its container isn't moved over.")
+	})
+
+	// Find current channel index
+	currentChannelIndex := 0 // Default to stable
+
+	channels := make([]string, len(installer.Channels))
+
+	for i, channel := range installer.Channels {
+		channels[i] = string(channel)
+
+		if channel == p.channel() {
+			currentChannelIndex = i
+		}
+	}
+
+	form.AddDropDown("Channel", channels, currentChannelIndex, func(option string, index int) {
+		switch installer.Channels[index] {
+		case installer.ChannelBeta:
+			p.description.SetText("Update to the newest release, or beta and release candidate, eg: 1.2.0-rc.1.")
+		case installer.ChannelNightly:
+			p.description.SetText("Update to the newest release or pre-release of any kind. Expect rough edges.")
+		case installer.ChannelPinned:
+			p.description.SetText("Stay on the installed version, until 'contributoor update --version' moves it.")
+		default:
+			p.description.SetText("Update to the newest stable release (recommended).")
+		}
+
+		fmt.Fprint(p.description, "\n\nTakes effect on the next 'contributoor update'.")
 	})
 
 	// Add a save button and ensure we validate the input.
@@ -210,14 +328,47 @@ func (p *ContributoorSettingsPage) initPage() {
 func validateAndUpdateContributoor(p *ContributoorSettingsPage) {
 	logLevel, _ := p.form.GetFormItem(0).(*tview.DropDown)
 	runMode, _ := p.form.GetFormItem(1).(*tview.DropDown)
+	pullPolicy, _ := p.form.GetFormItem(2).(*tview.DropDown)
+	containerRuntime, _ := p.form.GetFormItem(3).(*tview.DropDown)
+	channelDropDown, _ := p.form.GetFormItem(4).(*tview.DropDown)
 
 	_, logLevelText := logLevel.GetCurrentOption()
 	runModeIndex, _ := runMode.GetCurrentOption()
-	runModeText := runModes[runModeIndex]
+	mode := p.runModes[runModeIndex]
+	pullPolicyIndex, _ := pullPolicy.GetCurrentOption()
+	policy := installer.PullPolicies[pullPolicyIndex]
+	containerRuntimeIndex, _ := containerRuntime.GetCurrentOption()
+	runtime := containerRuntimes[containerRuntimeIndex]
+	channelIndex, _ := channelDropDown.GetCurrentOption()
+	channel := installer.Channels[channelIndex]
+
+	scopeChanged := mode.scope != "" && mode.scope != p.systemdScope()
+
+	if scopeChanged || policy != p.pullPolicy() || runtime != p.containerRuntime() || channel != p.channel() {
+		if p.stateErr != nil {
+			p.openErrorModal(p.stateErr)
+
+			return
+		}
+
+		if scopeChanged {
+			p.state.SystemdScope = mode.scope
+		}
+
+		p.state.PullPolicy = policy
+		p.state.ContainerRuntime = runtime
+		p.state.Channel = channel
+
+		if err := p.state.Save(); err != nil {
+			p.openErrorModal(err)
+
+			return
+		}
+	}
 
 	if err := p.display.sidecarCfg.Update(func(cfg *config.Config) {
 		cfg.LogLevel = logLevelText
-		cfg.RunMethod = runModeText
+		cfg.RunMethod = mode.method
 	}); err != nil {
 		p.openErrorModal(err)
 
@@ -228,6 +379,59 @@ func validateAndUpdateContributoor(p *ContributoorSettingsPage) {
 	p.display.setPage(p.display.homePage)
 }
 
+// systemdScope returns the scope of the systemd unit, which is system-wide unless the
+// installer state says otherwise.
+func (p *ContributoorSettingsPage) systemdScope() installer.SystemdScope {
+	if p.state == nil {
+		return installer.SystemdScopeSystem
+	}
+
+	return p.state.GetSystemdScope()
+}
+
+// pullPolicy returns the docker pull policy, which only pulls missing images unless the
+// installer state says otherwise.
+func (p *ContributoorSettingsPage) pullPolicy() installer.PullPolicy {
+	if p.state == nil {
+		return installer.PullPolicyMissing
+	}
+
+	return p.state.GetPullPolicy()
+}
+
+// containerRuntimes are the container runtimes offered, where empty means whichever is
+// found.
+var containerRuntimes = []installer.ContainerRuntime{"", installer.ContainerRuntimeDocker, installer.ContainerRuntimePodman}
+
+// containerRuntimeLabel returns the label of a container runtime in the dropdown.
+func containerRuntimeLabel(runtime installer.ContainerRuntime) string {
+	if runtime == "" {
+		return "auto"
+	}
+
+	return string(runtime)
+}
+
+// containerRuntime returns the container runtime chosen in the installer state, or
+// empty if it's detected.
+func (p *ContributoorSettingsPage) containerRuntime() installer.ContainerRuntime {
+	if p.state == nil {
+		return ""
+	}
+
+	return p.state.ContainerRuntime
+}
+
+// channel returns the channel updates follow, which is stable unless the installer state
+// says otherwise.
+func (p *ContributoorSettingsPage) channel() installer.Channel {
+	if p.state == nil {
+		return installer.ChannelStable
+	}
+
+	return p.state.GetChannel()
+}
+
 func (p *ContributoorSettingsPage) openErrorModal(err error) {
 	p.display.app.SetRoot(tui.CreateErrorModal(
 		p.display.app,
@@ -0,0 +1,651 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/mock"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestGetConfigValue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockConfig := mock.NewMockConfigManager(ctrl)
+	mockConfig.EXPECT().Get().Return(&config.Config{
+		BeaconNodeAddress: "http://localhost:5052",
+	}).AnyTimes()
+
+	var out bytes.Buffer
+
+	require.NoError(t, getConfigValue(&out, mockConfig, "beaconNodeAddress"))
+	assert.Equal(t, "http://localhost:5052\n", out.String())
+
+	err := getConfigValue(&out, mockConfig, "beaconNode")
+	assert.ErrorContains(t, err, "unknown config field: beaconNode")
+
+	var exitErr cli.ExitCoder
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, 1, exitErr.ExitCode())
+}
+
+func TestSetConfigValue(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          string
+		value         string
+		setupMocks    func(*mock.MockConfigManager, **config.Config)
+		expected      *config.Config
+		expectedError string
+	}{
+		{
+			name:  "sets and saves value",
+			path:  "logLevel",
+			value: "debug",
+			setupMocks: func(m *mock.MockConfigManager, updated **config.Config) {
+				m.EXPECT().Update(gomock.Any()).DoAndReturn(func(fn func(*config.Config)) error {
+					*updated = &config.Config{Version: "stale"}
+					fn(*updated)
+
+					return nil
+				})
+			},
+			expected: &config.Config{
+				Version:   "1.0.0",
+				LogLevel:  "debug",
+				RunMethod: config.RunMethod_RUN_METHOD_DOCKER,
+			},
+		},
+		{
+			name:          "invalid value",
+			path:          "runMethod",
+			value:         "kubernetes",
+			setupMocks:    func(m *mock.MockConfigManager, updated **config.Config) {},
+			expectedError: "invalid value for runMethod",
+		},
+		{
+			name:  "fails validation",
+			path:  "version",
+			value: "",
+			setupMocks: func(m *mock.MockConfigManager, updated **config.Config) {
+				m.EXPECT().Update(gomock.Any()).Return(errors.New("invalid config: version is required"))
+			},
+			expectedError: "version is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var updated *config.Config
+
+			mockConfig := mock.NewMockConfigManager(ctrl)
+			mockConfig.EXPECT().Get().Return(&config.Config{
+				Version:   "1.0.0",
+				RunMethod: config.RunMethod_RUN_METHOD_DOCKER,
+			}).AnyTimes()
+
+			tt.setupMocks(mockConfig, &updated)
+
+			err := setConfigValue(&bytes.Buffer{}, mockConfig, tt.path, tt.value)
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, proto.Equal(tt.expected, updated), "expected %v, got %v", tt.expected, updated)
+		})
+	}
+}
+
+func TestUnsetConfigValue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var updated *config.Config
+
+	mockConfig := mock.NewMockConfigManager(ctrl)
+	mockConfig.EXPECT().Get().Return(&config.Config{
+		Version:      "1.0.0",
+		OutputServer: &config.OutputServer{Address: "https://xatu.example.com", Credentials: "creds"},
+	}).AnyTimes()
+	mockConfig.EXPECT().Update(gomock.Any()).DoAndReturn(func(fn func(*config.Config)) error {
+		updated = &config.Config{}
+		fn(updated)
+
+		return nil
+	})
+
+	require.NoError(t, unsetConfigValue(&bytes.Buffer{}, mockConfig, "outputServer.credentials"))
+
+	expected := &config.Config{
+		Version:      "1.0.0",
+		OutputServer: &config.OutputServer{Address: "https://xatu.example.com"},
+	}
+	assert.True(t, proto.Equal(expected, updated), "expected %v, got %v", expected, updated)
+}
+
+func TestPullPolicyConfigValue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir := t.TempDir()
+
+	mockConfig := mock.NewMockConfigManager(ctrl)
+	mockConfig.EXPECT().GetConfigPath().Return(filepath.Join(dir, "config.yaml")).AnyTimes()
+
+	get := func() string {
+		var out bytes.Buffer
+
+		require.NoError(t, getConfigValue(&out, mockConfig, "pullPolicy"))
+
+		return out.String()
+	}
+
+	assert.Equal(t, "missing\n", get())
+
+	require.NoError(t, setConfigValue(&bytes.Buffer{}, mockConfig, "pullPolicy", "never"))
+	assert.Equal(t, "never\n", get())
+
+	state, err := installer.LoadState(dir)
+	require.NoError(t, err)
+	assert.Equal(t, installer.PullPolicyNever, state.PullPolicy)
+
+	err = setConfigValue(&bytes.Buffer{}, mockConfig, "pullPolicy", "sometimes")
+	assert.ErrorContains(t, err, `invalid pull policy "sometimes"`)
+
+	require.NoError(t, unsetConfigValue(&bytes.Buffer{}, mockConfig, "pullPolicy"))
+	assert.Equal(t, "missing\n", get())
+}
+
+func TestContainerRuntimeConfigValue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir := t.TempDir()
+
+	mockConfig := mock.NewMockConfigManager(ctrl)
+	mockConfig.EXPECT().GetConfigPath().Return(filepath.Join(dir, "config.yaml")).AnyTimes()
+
+	get := func() string {
+		var out bytes.Buffer
+
+		require.NoError(t, getConfigValue(&out, mockConfig, "containerRuntime"))
+
+		return out.String()
+	}
+
+	assert.Equal(t, "auto\n", get())
+
+	require.NoError(t, setConfigValue(&bytes.Buffer{}, mockConfig, "containerRuntime", "podman"))
+	assert.Equal(t, "podman\n", get())
+
+	state, err := installer.LoadState(dir)
+	require.NoError(t, err)
+	assert.Equal(t, installer.ContainerRuntimePodman, state.ContainerRuntime)
+
+	err = setConfigValue(&bytes.Buffer{}, mockConfig, "containerRuntime", "containerd")
+	assert.ErrorContains(t, err, `invalid container runtime "containerd"`)
+
+	require.NoError(t, setConfigValue(&bytes.Buffer{}, mockConfig, "containerRuntime", "auto"))
+	assert.Equal(t, "auto\n", get())
+
+	require.NoError(t, setConfigValue(&bytes.Buffer{}, mockConfig, "containerRuntime", "docker"))
+	require.NoError(t, unsetConfigValue(&bytes.Buffer{}, mockConfig, "containerRuntime"))
+	assert.Equal(t, "auto\n", get())
+}
+
+func TestChannelConfigValue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir := t.TempDir()
+
+	mockConfig := mock.NewMockConfigManager(ctrl)
+	mockConfig.EXPECT().GetConfigPath().Return(filepath.Join(dir, "config.yaml")).AnyTimes()
+
+	get := func() string {
+		var out bytes.Buffer
+
+		require.NoError(t, getConfigValue(&out, mockConfig, "channel"))
+
+		return out.String()
+	}
+
+	assert.Equal(t, "stable\n", get())
+
+	require.NoError(t, setConfigValue(&bytes.Buffer{}, mockConfig, "channel", "pinned"))
+	assert.Equal(t, "pinned\n", get())
+
+	require.NoError(t, setConfigValue(&bytes.Buffer{}, mockConfig, "channel", "beta"))
+	assert.Equal(t, "beta\n", get())
+
+	err := setConfigValue(&bytes.Buffer{}, mockConfig, "channel", "sometimes")
+	assert.ErrorContains(t, err, `invalid channel "sometimes"`)
+
+	require.NoError(t, unsetConfigValue(&bytes.Buffer{}, mockConfig, "channel"))
+	assert.Equal(t, "stable\n", get())
+}
+
+func TestReleaseSourceConfigValue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir := t.TempDir()
+
+	mockConfig := mock.NewMockConfigManager(ctrl)
+	mockConfig.EXPECT().GetConfigPath().Return(filepath.Join(dir, "config.yaml")).AnyTimes()
+
+	get := func() string {
+		var out bytes.Buffer
+
+		require.NoError(t, getConfigValue(&out, mockConfig, "releaseSource"))
+
+		return out.String()
+	}
+
+	assert.Equal(t, "github\n", get())
+
+	require.NoError(t, setConfigValue(&bytes.Buffer{}, mockConfig, "releaseSource", "https://mirror.example.com/contributoor"))
+	assert.Equal(t, "https://mirror.example.com/contributoor\n", get())
+
+	// Local paths are kept absolute.
+	require.NoError(t, setConfigValue(&bytes.Buffer{}, mockConfig, "releaseSource", filepath.Join(dir, "releases", "..", "contributoor.tar.gz")))
+	assert.Equal(t, filepath.Join(dir, "contributoor.tar.gz")+"\n", get())
+
+	err := setConfigValue(&bytes.Buffer{}, mockConfig, "releaseSource", "https://")
+	assert.ErrorContains(t, err, `invalid release mirror "https://"`)
+
+	require.NoError(t, unsetConfigValue(&bytes.Buffer{}, mockConfig, "releaseSource"))
+	assert.Equal(t, "github\n", get())
+}
+
+func TestCosignPublicKeyConfigValue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		dir = t.TempDir()
+		key = filepath.Join(dir, "cosign.pub")
+	)
+
+	require.NoError(t, os.WriteFile(key, []byte("-----BEGIN PUBLIC KEY-----\n"), 0600))
+
+	mockConfig := mock.NewMockConfigManager(ctrl)
+	mockConfig.EXPECT().GetConfigPath().Return(filepath.Join(dir, "config.yaml")).AnyTimes()
+
+	err := setConfigValue(&bytes.Buffer{}, mockConfig, "cosignPublicKey", filepath.Join(dir, "missing.pub"))
+	assert.ErrorContains(t, err, "invalid cosign public key")
+
+	require.NoError(t, setConfigValue(&bytes.Buffer{}, mockConfig, "cosignPublicKey", key))
+
+	var out bytes.Buffer
+
+	require.NoError(t, getConfigValue(&out, mockConfig, "cosignPublicKey"))
+	assert.Equal(t, key+"\n", out.String())
+
+	require.NoError(t, unsetConfigValue(&bytes.Buffer{}, mockConfig, "cosignPublicKey"))
+
+	state, err := installer.LoadState(dir)
+	require.NoError(t, err)
+	assert.Empty(t, state.CosignPublicKey)
+}
+
+func TestContainerSettingsConfigValue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir := t.TempDir()
+
+	mockConfig := mock.NewMockConfigManager(ctrl)
+	mockConfig.EXPECT().GetConfigPath().Return(filepath.Join(dir, "config.yaml")).AnyTimes()
+
+	require.NoError(t, setConfigValue(&bytes.Buffer{}, mockConfig, "container.network", "eth-docker_default"))
+	require.NoError(t, setConfigValue(&bytes.Buffer{}, mockConfig, "container.cpus", "1.5"))
+	require.NoError(t, setConfigValue(&bytes.Buffer{}, mockConfig, "container.memory", "2g"))
+
+	var out bytes.Buffer
+
+	require.NoError(t, getConfigValue(&out, mockConfig, "container.cpus"))
+	assert.Equal(t, "1.5\n", out.String())
+
+	state, err := installer.LoadState(dir)
+	require.NoError(t, err)
+	assert.Equal(t, installer.ContainerSettings{Network: "eth-docker_default", CPUs: 1.5, Memory: "2g"}, state.Container)
+
+	err = setConfigValue(&bytes.Buffer{}, mockConfig, "container.memory", "lots")
+	assert.ErrorContains(t, err, `invalid memory "lots"`)
+
+	err = setConfigValue(&bytes.Buffer{}, mockConfig, "container.cpus", "many")
+	assert.ErrorContains(t, err, `invalid cpus "many"`)
+
+	require.NoError(t, unsetConfigValue(&bytes.Buffer{}, mockConfig, "container.cpus"))
+
+	state, err = installer.LoadState(dir)
+	require.NoError(t, err)
+	assert.Zero(t, state.Container.CPUs)
+	assert.Equal(t, "2g", state.Container.Memory)
+}
+
+func TestLogRotationConfigValue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir := t.TempDir()
+
+	mockConfig := mock.NewMockConfigManager(ctrl)
+	mockConfig.EXPECT().GetConfigPath().Return(filepath.Join(dir, "config.yaml")).AnyTimes()
+
+	get := func(path string) string {
+		var out bytes.Buffer
+
+		require.NoError(t, getConfigValue(&out, mockConfig, path))
+
+		return out.String()
+	}
+
+	assert.Equal(t, "100m\n", get("logRotation.maxSize"))
+	assert.Equal(t, "168h\n", get("logRotation.maxAge"))
+	assert.Equal(t, "5\n", get("logRotation.retention"))
+	assert.Equal(t, "true\n", get("logRotation.compress"))
+
+	require.NoError(t, setConfigValue(&bytes.Buffer{}, mockConfig, "logRotation.maxSize", "1g"))
+	require.NoError(t, setConfigValue(&bytes.Buffer{}, mockConfig, "logRotation.maxAge", "0"))
+	require.NoError(t, setConfigValue(&bytes.Buffer{}, mockConfig, "logRotation.retention", "10"))
+	require.NoError(t, setConfigValue(&bytes.Buffer{}, mockConfig, "logRotation.compress", "false"))
+
+	assert.Equal(t, "1g\n", get("logRotation.maxSize"))
+	assert.Equal(t, "0s\n", get("logRotation.maxAge"))
+	assert.Equal(t, "10\n", get("logRotation.retention"))
+	assert.Equal(t, "false\n", get("logRotation.compress"))
+
+	err := setConfigValue(&bytes.Buffer{}, mockConfig, "logRotation.maxAge", "7d")
+	assert.ErrorContains(t, err, `invalid log max age "7d"`)
+
+	err = setConfigValue(&bytes.Buffer{}, mockConfig, "logRotation.retention", "-1")
+	assert.ErrorContains(t, err, "invalid log retention -1")
+
+	err = setConfigValue(&bytes.Buffer{}, mockConfig, "logRotation.compress", "maybe")
+	assert.ErrorContains(t, err, `invalid log compress "maybe"`)
+
+	require.NoError(t, unsetConfigValue(&bytes.Buffer{}, mockConfig, "logRotation.maxSize"))
+	assert.Equal(t, "100m\n", get("logRotation.maxSize"))
+
+	state, err := installer.LoadState(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "0", state.LogRotation.MaxAge)
+	assert.Empty(t, state.LogRotation.MaxSize)
+}
+
+func TestParseContainerSettings(t *testing.T) {
+	settings, err := parseContainerSettings(
+		"host", "0.5", "512m",
+		"/data/jwt:/jwt:ro\n\n  sentry-data:/data  \n",
+		"TZ=UTC\nGREETING=hello, world",
+		"json-file",
+		"max-size=10m\nmax-file=3",
+	)
+	require.NoError(t, err)
+	assert.Equal(t, &installer.ContainerSettings{
+		Network:    "host",
+		CPUs:       0.5,
+		Memory:     "512m",
+		Volumes:    []string{"/data/jwt:/jwt:ro", "sentry-data:/data"},
+		Env:        []string{"TZ=UTC", "GREETING=hello, world"},
+		LogDriver:  "json-file",
+		LogOptions: map[string]string{"max-size": "10m", "max-file": "3"},
+	}, settings)
+
+	settings, err = parseContainerSettings("", "", "", "", "", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, &installer.ContainerSettings{}, settings)
+
+	_, err = parseContainerSettings("", "lots", "", "", "", "", "")
+	assert.EqualError(t, err, `invalid cpus "lots", expected a number, eg: 1.5`)
+
+	_, err = parseContainerSettings("", "", "", "", "", "json-file", "max-size")
+	assert.EqualError(t, err, `invalid log option "max-size", expected key=value`)
+
+	_, err = parseContainerSettings("", "", "", "/data", "", "", "")
+	assert.ErrorContains(t, err, `invalid volume "/data"`)
+}
+
+func TestShowConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		format        string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "yaml",
+			format:   "yaml",
+			expected: "networkName: NETWORK_NAME_MAINNET\nversion: 1.0.0\n",
+		},
+		{
+			name:     "json",
+			format:   "json",
+			expected: "{\n  \"networkName\": \"NETWORK_NAME_MAINNET\",\n  \"version\": \"1.0.0\"\n}\n",
+		},
+		{
+			name:          "unsupported format",
+			format:        "toml",
+			expectedError: "unsupported format: toml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockConfig := mock.NewMockConfigManager(ctrl)
+			mockConfig.EXPECT().Get().Return(&config.Config{
+				Version:     "1.0.0",
+				NetworkName: config.NetworkName_NETWORK_NAME_MAINNET,
+			})
+
+			var out bytes.Buffer
+
+			err := showConfig(&out, mockConfig, tt.format)
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, out.String())
+		})
+	}
+}
+
+func TestMigrateConfig(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	original := "version: latest\nrunMethod: RUN_METHOD_DOCKER\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(original), 0600))
+
+	var out bytes.Buffer
+
+	// A dry run reports what's pending, but doesn't apply it.
+	require.NoError(t, migrateConfig(&out, dir, true))
+	assert.Contains(t, out.String(), "Migrating config from schema version 0 to")
+
+	state, err := installer.LoadState(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 0, state.ConfigSchemaVersion)
+
+	out.Reset()
+	require.NoError(t, migrateConfig(&out, dir, false))
+	assert.Contains(t, out.String(), "Config migrated to schema version")
+
+	state, err = installer.LoadState(dir)
+	require.NoError(t, err)
+	assert.Equal(t, sidecar.LatestConfigSchemaVersion(), state.ConfigSchemaVersion)
+
+	out.Reset()
+	require.NoError(t, migrateConfig(&out, dir, true))
+	assert.Contains(t, out.String(), "Config is up to date")
+
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, original, string(data))
+}
+
+func TestMigrateConfig_MissingConfig(t *testing.T) {
+	err := migrateConfig(&bytes.Buffer{}, t.TempDir(), true)
+	assert.ErrorContains(t, err, "failed to read config")
+}
+
+func TestSetUpSystemd(t *testing.T) {
+	tests := []struct {
+		name           string
+		setupMocks     func(*mock.MockBinarySidecar, *mock.MockSystemdSidecar)
+		expectedOutput string
+		expectedError  string
+	}{
+		{
+			name: "downloads the binary and installs the unit",
+			setupMocks: func(b *mock.MockBinarySidecar, s *mock.MockSystemdSidecar) {
+				b.EXPECT().Details().Return(&runner.Details{}, nil)
+				b.EXPECT().Update().Return(nil)
+				s.EXPECT().InstallUnit().Return(runner.UnitCreated, nil)
+			},
+			expectedOutput: "Systemd unit created",
+		},
+		{
+			name: "keeps the installed binary and unit",
+			setupMocks: func(b *mock.MockBinarySidecar, s *mock.MockSystemdSidecar) {
+				b.EXPECT().Details().Return(&runner.Details{Installed: true}, nil)
+				s.EXPECT().InstallUnit().Return(runner.UnitUnchanged, nil)
+			},
+		},
+		{
+			name: "binary download fails",
+			setupMocks: func(b *mock.MockBinarySidecar, s *mock.MockSystemdSidecar) {
+				b.EXPECT().Details().Return(&runner.Details{}, nil)
+				b.EXPECT().Update().Return(errors.New("checksum not found"))
+			},
+			expectedError: "failed to install the binary for systemd: checksum not found",
+		},
+		{
+			name: "unit install fails",
+			setupMocks: func(b *mock.MockBinarySidecar, s *mock.MockSystemdSidecar) {
+				b.EXPECT().Details().Return(&runner.Details{Installed: true}, nil)
+				s.EXPECT().InstallUnit().Return(runner.UnitChange(""), errors.New("permission denied"))
+			},
+			expectedError: "failed to install the systemd unit: permission denied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockBinary := mock.NewMockBinarySidecar(ctrl)
+			mockSystemd := mock.NewMockSystemdSidecar(ctrl)
+			tt.setupMocks(mockBinary, mockSystemd)
+
+			var out bytes.Buffer
+
+			err := setUpSystemd(&out, mockBinary, mockSystemd)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			if tt.expectedOutput == "" {
+				assert.Empty(t, out.String())
+			} else {
+				assert.Contains(t, out.String(), tt.expectedOutput)
+			}
+		})
+	}
+}
+
+func TestSwitchScope(t *testing.T) {
+	tests := []struct {
+		name          string
+		setupMocks    func(*mock.MockSystemdSidecar)
+		expectedScope installer.SystemdScope
+		expectedError string
+	}{
+		{
+			name: "removes the old unit",
+			setupMocks: func(s *mock.MockSystemdSidecar) {
+				s.EXPECT().IsRunning().Return(false, nil)
+				s.EXPECT().UninstallUnit().Return(nil)
+			},
+			expectedScope: installer.SystemdScopeUser,
+		},
+		{
+			name: "keeps the old scope when its unit can't be removed",
+			setupMocks: func(s *mock.MockSystemdSidecar) {
+				s.EXPECT().IsRunning().Return(false, nil)
+				s.EXPECT().UninstallUnit().Return(errors.New("permission denied"))
+			},
+			expectedScope: installer.SystemdScopeSystem,
+			expectedError: "failed to switch to a user unit, it's still a system unit: failed to remove the system unit, so it's kept: permission denied",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			configDir := t.TempDir()
+
+			// The TUI has already saved the new scope.
+			state, err := installer.LoadState(configDir)
+			require.NoError(t, err)
+
+			state.SystemdScope = installer.SystemdScopeUser
+			require.NoError(t, state.Save())
+
+			mockConfig := mock.NewMockConfigManager(ctrl)
+			mockConfig.EXPECT().GetConfigPath().Return(filepath.Join(configDir, "config.yaml")).AnyTimes()
+
+			mockSystemd := mock.NewMockSystemdSidecar(ctrl)
+			tt.setupMocks(mockSystemd)
+
+			var out bytes.Buffer
+
+			err = switchScope(&out, mockConfig, mockSystemd, installer.SystemdScopeSystem, installer.SystemdScopeUser)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			state, err = installer.LoadState(configDir)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedScope, state.GetSystemdScope())
+		})
+	}
+}
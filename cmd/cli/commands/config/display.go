@@ -2,7 +2,9 @@ package config
 
 import (
 	"fmt"
+	"path/filepath"
 
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
 	"github.com/ethpandaops/contributoor-installer/internal/sidecar"
 	"github.com/ethpandaops/contributoor-installer/internal/tui"
 	"github.com/gdamore/tcell/v2"
@@ -26,6 +28,9 @@ type ConfigDisplay struct {
 	networkConfigPage      *NetworkConfigPage
 	outputServerConfigPage *OutputServerConfigPage
 	settingsPage           *ContributoorSettingsPage
+	containerPage          *ContainerSettingsPage
+	state                  *installer.State
+	stateErr               error
 	hasChanges             bool
 }
 
@@ -40,14 +45,23 @@ func NewConfigDisplay(log *logrus.Logger, app *tview.Application, sidecarCfg sid
 
 	display.homePage = tui.NewPage(nil, "config-home", "Categories", "", nil)
 
+	// Settings kept in the installer state are shared by the pages, so that saving one
+	// doesn't undo another's changes.
+	display.state, display.stateErr = installer.LoadState(filepath.Dir(sidecarCfg.GetConfigPath()))
+	if display.stateErr != nil {
+		log.Warnf("Failed to load installer state: %v", display.stateErr)
+	}
+
 	// Create all the config sub-pages.
 	display.networkConfigPage = NewNetworkConfigPage(display)
 	display.outputServerConfigPage = NewOutputServerConfigPage(display)
 	display.settingsPage = NewContributoorSettingsPage(display)
+	display.containerPage = NewContainerSettingsPage(display)
 	display.settingsPages = []tui.PageInterface{
 		display.networkConfigPage,
 		display.outputServerConfigPage,
 		display.settingsPage,
+		display.containerPage,
 	}
 
 	// Add all the sub-pages to the tui.
@@ -0,0 +1,156 @@
+package container
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethpandaops/contributoor-installer/cmd/cli/options"
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+	"github.com/ethpandaops/contributoor-installer/internal/tui"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/urfave/cli"
+)
+
+func RegisterCommands(app *cli.App, opts *options.CommandOpts) {
+	// newDockerSidecar loads the config, and creates the sidecar managing the container.
+	newDockerSidecar := func(c *cli.Context) (sidecar.ConfigManager, sidecar.DockerSidecar, error) {
+		log := opts.Logger()
+
+		sidecarCfg, err := sidecar.NewConfigService(log, c.GlobalString("config-path"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error loading config: %w", err)
+		}
+
+		dockerSidecar, err := sidecar.NewDockerSidecar(log, sidecarCfg, opts.InstallerConfig())
+		if err != nil {
+			return nil, nil, fmt.Errorf("error creating docker sidecar service: %w", err)
+		}
+
+		return sidecarCfg, dockerSidecar, nil
+	}
+
+	app.Commands = append(app.Commands, cli.Command{
+		Name:      opts.Name(),
+		Aliases:   opts.Aliases(),
+		Usage:     "Inspect the container runtime, and export the container's definition",
+		UsageText: "contributoor container [command]",
+		Subcommands: []cli.Command{
+			{
+				Name:      "runtime",
+				Usage:     "Show the container runtime contributoor runs on",
+				UsageText: "contributoor container runtime",
+				Action: func(c *cli.Context) error {
+					_, dockerSidecar, err := newDockerSidecar(c)
+					if err != nil {
+						return err
+					}
+
+					return showRuntime(c.App.Writer, dockerSidecar)
+				},
+			},
+			{
+				Name:      "export",
+				Usage:     "Export the container as a compose file, or a podman quadlet",
+				UsageText: "contributoor container export [--format compose|quadlet] [--output file]",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "format",
+						Usage: "The format to export, compose or quadlet",
+						Value: string(runner.DefinitionCompose),
+					},
+					cli.StringFlag{
+						Name:  "output",
+						Usage: "Write the definition to a file, rather than stdout",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					sidecarCfg, dockerSidecar, err := newDockerSidecar(c)
+					if err != nil {
+						return err
+					}
+
+					return exportDefinition(c.App.Writer, sidecarCfg, dockerSidecar, c.String("format"), c.String("output"))
+				},
+			},
+		},
+	})
+}
+
+// showRuntime prints the detected container runtime, and where its API was found.
+func showRuntime(w io.Writer, docker sidecar.DockerSidecar) error {
+	runtime, err := docker.ContainerRuntime()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "%-20s: %s\n", "Container Runtime", runtime)
+	fmt.Fprintf(w, "%-20s: %s\n", "API", runtime.Host)
+
+	return nil
+}
+
+// exportDefinition writes the container's definition to stdout, or to a file along with
+// how to use it.
+func exportDefinition(w io.Writer, sidecarCfg sidecar.ConfigManager, docker sidecar.DockerSidecar, format, output string) error {
+	if cfg := sidecarCfg.Get(); cfg.RunMethod != config.RunMethod_RUN_METHOD_DOCKER {
+		return fmt.Errorf("the container is only used by the docker run method, not %s", strings.ToLower(cfg.RunMethod.DisplayName()))
+	}
+
+	definitionFormat, err := runner.ParseDefinitionFormat(format)
+	if err != nil {
+		return err
+	}
+
+	definition, err := docker.Definition(definitionFormat)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		fmt.Fprint(w, definition)
+
+		return nil
+	}
+
+	if err := os.WriteFile(output, []byte(definition), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+
+	fmt.Fprintf(w, "%sWrote the %s definition to %s%s\n", tui.TerminalColorGreen, definitionFormat, output, tui.TerminalColorReset)
+
+	// Both run the container themselves, so the installer's must go first.
+	fmt.Fprintf(w, "Stop and remove the installer's container before using it:\n")
+	fmt.Fprintf(w, "    contributoor stop --down\n")
+
+	runtime, err := docker.ContainerRuntime()
+	if err != nil {
+		return err
+	}
+
+	if definitionFormat == runner.DefinitionCompose {
+		fmt.Fprintf(w, "Then start it with:\n")
+		fmt.Fprintf(w, "    %s compose -f %s up -d\n", runtime.Name, output)
+
+		return nil
+	}
+
+	dir, systemctl := "/etc/containers/systemd", "systemctl"
+	if runtime.Rootless {
+		dir, systemctl = "~/.config/containers/systemd", "systemctl --user"
+	}
+
+	fmt.Fprintf(w, "Then copy it to %s, and start it with:\n", filepath.Join(dir, "contributoor.container"))
+	fmt.Fprintf(w, "    %s daemon-reload\n", systemctl)
+	fmt.Fprintf(w, "    %s start contributoor\n", systemctl)
+
+	if runtime.Name != installer.ContainerRuntimePodman {
+		fmt.Fprintf(w, "%sQuadlets are run by podman, but the container runtime is %s%s\n", tui.TerminalColorYellow, runtime.Name, tui.TerminalColorReset)
+	}
+
+	return nil
+}
@@ -0,0 +1,252 @@
+package container
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/contributoor-installer/cmd/cli/options"
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/mock"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+	"go.uber.org/mock/gomock"
+)
+
+func TestShowRuntime(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDocker := mock.NewMockDockerSidecar(ctrl)
+	mockDocker.EXPECT().ContainerRuntime().Return(&runner.ContainerRuntime{
+		Name:     installer.ContainerRuntimePodman,
+		Version:  "5.2.2",
+		Host:     "unix:///run/user/1000/podman/podman.sock",
+		Rootless: true,
+	}, nil)
+
+	var out bytes.Buffer
+
+	require.NoError(t, showRuntime(&out, mockDocker))
+	assert.Equal(t, "Container Runtime   : podman 5.2.2 (rootless)\nAPI                 : unix:///run/user/1000/podman/podman.sock\n", out.String())
+
+	mockDocker.EXPECT().ContainerRuntime().Return(nil, errors.New("failed to detect the container runtime"))
+	assert.ErrorContains(t, showRuntime(&out, mockDocker), "failed to detect the container runtime")
+}
+
+func TestExportDefinition(t *testing.T) {
+	const quadlet = "[Container]\nImage=docker.io/ethpandaops/contributoor:1.0.0\n"
+
+	var (
+		rootless = &runner.ContainerRuntime{Name: installer.ContainerRuntimePodman, Version: "5.2.2", Rootless: true}
+		rootful  = &runner.ContainerRuntime{Name: installer.ContainerRuntimePodman, Version: "5.2.2"}
+		docker   = &runner.ContainerRuntime{Name: installer.ContainerRuntimeDocker, Version: "27.3.1"}
+	)
+
+	tests := []struct {
+		name           string
+		runMethod      config.RunMethod
+		format         string
+		output         bool
+		setupMocks     func(*mock.MockDockerSidecar)
+		expectedOutput []string
+		expectedError  string
+	}{
+		{
+			name:      "prints the definition",
+			runMethod: config.RunMethod_RUN_METHOD_DOCKER,
+			format:    "quadlet",
+			setupMocks: func(d *mock.MockDockerSidecar) {
+				d.EXPECT().Definition(runner.DefinitionQuadlet).Return(quadlet, nil)
+			},
+			expectedOutput: []string{quadlet},
+		},
+		{
+			name:      "writes a rootless quadlet",
+			runMethod: config.RunMethod_RUN_METHOD_DOCKER,
+			format:    "quadlet",
+			output:    true,
+			setupMocks: func(d *mock.MockDockerSidecar) {
+				d.EXPECT().Definition(runner.DefinitionQuadlet).Return(quadlet, nil)
+				d.EXPECT().ContainerRuntime().Return(rootless, nil)
+			},
+			expectedOutput: []string{
+				"contributoor stop --down",
+				"~/.config/containers/systemd/contributoor.container",
+				"systemctl --user daemon-reload",
+			},
+		},
+		{
+			name:      "writes a rootful quadlet",
+			runMethod: config.RunMethod_RUN_METHOD_DOCKER,
+			format:    "quadlet",
+			output:    true,
+			setupMocks: func(d *mock.MockDockerSidecar) {
+				d.EXPECT().Definition(runner.DefinitionQuadlet).Return(quadlet, nil)
+				d.EXPECT().ContainerRuntime().Return(rootful, nil)
+			},
+			expectedOutput: []string{
+				"/etc/containers/systemd/contributoor.container",
+				"    systemctl daemon-reload",
+			},
+		},
+		{
+			name:      "writes a compose file",
+			runMethod: config.RunMethod_RUN_METHOD_DOCKER,
+			format:    "compose",
+			output:    true,
+			setupMocks: func(d *mock.MockDockerSidecar) {
+				d.EXPECT().Definition(runner.DefinitionCompose).Return("services: {}\n", nil)
+				d.EXPECT().ContainerRuntime().Return(docker, nil)
+			},
+			expectedOutput: []string{"docker compose -f"},
+		},
+		{
+			name:          "invalid format",
+			runMethod:     config.RunMethod_RUN_METHOD_DOCKER,
+			format:        "helm",
+			expectedError: `invalid definition format "helm", expected compose or quadlet`,
+		},
+		{
+			name:          "other run methods",
+			runMethod:     config.RunMethod_RUN_METHOD_SYSTEMD,
+			format:        "compose",
+			expectedError: "the container is only used by the docker run method, not systemd",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockConfig := mock.NewMockConfigManager(ctrl)
+			mockConfig.EXPECT().Get().Return(&config.Config{RunMethod: tt.runMethod}).AnyTimes()
+
+			mockDocker := mock.NewMockDockerSidecar(ctrl)
+			if tt.setupMocks != nil {
+				tt.setupMocks(mockDocker)
+			}
+
+			var output string
+			if tt.output {
+				output = filepath.Join(t.TempDir(), "contributoor.container")
+			}
+
+			var out bytes.Buffer
+
+			err := exportDefinition(&out, mockConfig, mockDocker, tt.format, output)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			for _, expected := range tt.expectedOutput {
+				assert.Contains(t, out.String(), expected)
+			}
+
+			if output != "" {
+				data, err := os.ReadFile(output)
+				require.NoError(t, err)
+				assert.NotEmpty(t, data)
+			}
+		})
+	}
+}
+
+func TestRegisterCommands(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name          string
+		configPath    string
+		expectedError string
+	}{
+		{
+			name:       "successfully registers command",
+			configPath: "testdata/valid", // "testdata" is an ancillary dir provided by go-test.
+		},
+		{
+			name:          "fails when config service fails",
+			configPath:    "/invalid/path/that/doesnt/exist",
+			expectedError: "error loading config",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create CLI app, with the config flag.
+			app := cli.NewApp()
+			app.Flags = []cli.Flag{
+				cli.StringFlag{
+					Name: "config-path",
+				},
+			}
+
+			// Ensure we set the config path flag.
+			globalSet := flag.NewFlagSet("test", flag.ContinueOnError)
+			globalSet.String("config-path", "", "")
+			err := globalSet.Set("config-path", tt.configPath)
+			require.NoError(t, err)
+
+			// Create the cmd context.
+			globalCtx := cli.NewContext(app, globalSet, nil)
+			app.Metadata = map[string]interface{}{
+				"flagContext": globalCtx,
+			}
+
+			// Now test!
+			RegisterCommands(
+				app,
+				options.NewCommandOpts(
+					options.WithName("container"),
+					options.WithLogger(logrus.New()),
+				),
+			)
+
+			if tt.expectedError != "" {
+				// Ensure the command registration succeeded.
+				assert.NoError(t, err)
+
+				// Assert that each subcommand's action fails as expected.
+				for _, cmd := range app.Commands[0].Subcommands {
+					ctx := cli.NewContext(app, nil, globalCtx)
+
+					// Assert that the action is the func we expect, mainly because the linter is having a fit otherwise.
+					action, ok := cmd.Action.(func(*cli.Context) error)
+					require.True(t, ok, "expected action to be func(*cli.Context) error")
+
+					// Execute the action and assert the error.
+					actionErr := action(ctx)
+					assert.Error(t, actionErr)
+					assert.ErrorContains(t, actionErr, tt.expectedError)
+				}
+			} else {
+				// Ensure the command registration succeeded.
+				assert.NoError(t, err)
+				assert.Len(t, app.Commands, 1)
+
+				// Ensure the command is registered as expected by dumping the command.
+				cmd := app.Commands[0]
+				assert.Equal(t, "container", cmd.Name)
+				assert.Equal(t, "Inspect the container runtime, and export the container's definition", cmd.Usage)
+				assert.Equal(t, "contributoor container [command]", cmd.UsageText)
+				require.Len(t, cmd.Subcommands, 2)
+				assert.Equal(t, "runtime", cmd.Subcommands[0].Name)
+				assert.Equal(t, "export", cmd.Subcommands[1].Name)
+			}
+		})
+	}
+}
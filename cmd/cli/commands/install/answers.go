@@ -0,0 +1,255 @@
+package install
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/service"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar"
+	"github.com/ethpandaops/contributoor-installer/internal/validate"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v3"
+)
+
+// exitCodeInvalidAnswers is the exit code used when a non-interactive install is given
+// answers that fail validation.
+const exitCodeInvalidAnswers = 2
+
+// Answers holds the responses to the install wizard, allowing contributoor to be
+// installed without any interaction. Empty answers fall back to the existing config.
+type Answers struct {
+	Network           string              `yaml:"network"`
+	BeaconNodeAddress string              `yaml:"beaconNodeAddress"`
+	OutputServer      OutputServerAnswers `yaml:"outputServer"`
+	RunMethod         string              `yaml:"runMethod"`
+	Version           string              `yaml:"version"`
+	ContainerRuntime  string              `yaml:"containerRuntime"`
+}
+
+// OutputServerAnswers holds the output server responses to the install wizard.
+type OutputServerAnswers struct {
+	Address  string `yaml:"address"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// loadAnswers builds the answers for a non-interactive install. Answers are read from
+// the answers file if one is given, and then overridden by any flags or environment
+// variables that are set.
+func loadAnswers(c *cli.Context) (*Answers, error) {
+	answers := &Answers{}
+
+	if path := c.String("answers-file"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read answers file: %w", err)
+		}
+
+		// Be strict about unknown keys, a typo shouldn't silently fall back to a default.
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+
+		if err := decoder.Decode(answers); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to parse answers file: %w", err)
+		}
+	}
+
+	overrides := map[string]*string{
+		"network":                &answers.Network,
+		"beacon-node-address":    &answers.BeaconNodeAddress,
+		"output-server-address":  &answers.OutputServer.Address,
+		"output-server-username": &answers.OutputServer.Username,
+		"output-server-password": &answers.OutputServer.Password,
+		"run-method":             &answers.RunMethod,
+		"version":                &answers.Version,
+		"container-runtime":      &answers.ContainerRuntime,
+	}
+
+	for name, answer := range overrides {
+		if c.IsSet(name) {
+			*answer = c.String(name)
+		}
+	}
+
+	return answers, nil
+}
+
+// applyAnswers validates the answers, in the same way the install wizard does, and
+// writes them to the sidecar config.
+func applyAnswers(sidecarCfg sidecar.ConfigManager, answers *Answers) error {
+	var (
+		current     = sidecarCfg.Get()
+		network     = current.NetworkName
+		runMethod   = current.RunMethod
+		version     = current.Version
+		beacon      = current.BeaconNodeAddress
+		address     string
+		credentials string
+	)
+
+	if current.OutputServer != nil {
+		address = current.OutputServer.Address
+		credentials = current.OutputServer.Credentials
+	}
+
+	if answers.Network != "" {
+		parsed, err := parseNetwork(answers.Network)
+		if err != nil {
+			return err
+		}
+
+		network = parsed
+	}
+
+	if answers.RunMethod != "" {
+		parsed, err := parseRunMethod(answers.RunMethod)
+		if err != nil {
+			return err
+		}
+
+		runMethod = parsed
+	}
+
+	if answers.Version != "" {
+		version = answers.Version
+	}
+
+	var containerRuntime installer.ContainerRuntime
+
+	if answers.ContainerRuntime != "" {
+		parsed, err := parseContainerRuntime(answers.ContainerRuntime)
+		if err != nil {
+			return err
+		}
+
+		containerRuntime = parsed
+	}
+
+	if answers.BeaconNodeAddress != "" {
+		beacon = answers.BeaconNodeAddress
+	}
+
+	if err := validate.ValidateBeaconNodeAddress(beacon); err != nil {
+		return err
+	}
+
+	if answers.OutputServer.Address != "" {
+		// Credentials for one server type mean nothing to the other.
+		if validate.IsEthPandaOpsServer(address) != validate.IsEthPandaOpsServer(answers.OutputServer.Address) {
+			credentials = ""
+		}
+
+		address = answers.OutputServer.Address
+	}
+
+	if err := validate.ValidateOutputServerAddress(address); err != nil {
+		return err
+	}
+
+	username, password := answers.OutputServer.Username, answers.OutputServer.Password
+	if username != "" || password != "" || credentials == "" {
+		if err := validate.ValidateOutputServerCredentials(username, password, validate.IsEthPandaOpsServer(address)); err != nil {
+			return err
+		}
+
+		credentials = validate.EncodeCredentials(username, password)
+	}
+
+	if err := sidecarCfg.Update(func(cfg *config.Config) {
+		cfg.NetworkName = network
+		cfg.RunMethod = runMethod
+		cfg.Version = version
+		cfg.BeaconNodeAddress = beacon
+		cfg.OutputServer = &config.OutputServer{
+			Address:     address,
+			Credentials: credentials,
+		}
+	}); err != nil {
+		return err
+	}
+
+	if answers.ContainerRuntime == "" {
+		return nil
+	}
+
+	return saveContainerRuntime(sidecarCfg, containerRuntime)
+}
+
+// parseNetwork parses a network name, either short (eg: "mainnet") or in full (eg: "NETWORK_NAME_MAINNET").
+func parseNetwork(name string) (config.NetworkName, error) {
+	name = strings.ToUpper(name)
+
+	value, ok := config.NetworkName_value[name]
+	if !ok {
+		value, ok = config.NetworkName_value[fmt.Sprintf("NETWORK_NAME_%s", name)]
+	}
+
+	if !ok || value == int32(config.NetworkName_NETWORK_NAME_UNSPECIFIED) {
+		return config.NetworkName_NETWORK_NAME_UNSPECIFIED, fmt.Errorf("invalid network: %s", strings.ToLower(name))
+	}
+
+	return config.NetworkName(value), nil
+}
+
+// parseRunMethod parses a run method, either short (eg: "docker") or in full (eg: "RUN_METHOD_DOCKER").
+func parseRunMethod(name string) (config.RunMethod, error) {
+	name = strings.ToUpper(name)
+
+	value, ok := config.RunMethod_value[name]
+	if !ok {
+		value, ok = config.RunMethod_value[fmt.Sprintf("RUN_METHOD_%s", name)]
+	}
+
+	if !ok || value == int32(config.RunMethod_RUN_METHOD_UNSPECIFIED) {
+		return config.RunMethod_RUN_METHOD_UNSPECIFIED, fmt.Errorf("invalid run method: %s", strings.ToLower(name))
+	}
+
+	return config.RunMethod(value), nil
+}
+
+// parseContainerRuntime parses a container runtime, where auto means whichever is found.
+func parseContainerRuntime(name string) (installer.ContainerRuntime, error) {
+	if strings.ToLower(name) == "auto" {
+		return "", nil
+	}
+
+	return installer.ParseContainerRuntime(strings.ToLower(name))
+}
+
+// saveContainerRuntime records the container runtime in the installer state, as
+// config.yaml has no room for it.
+func saveContainerRuntime(sidecarCfg sidecar.ConfigManager, runtime installer.ContainerRuntime) error {
+	state, err := installer.LoadState(filepath.Dir(sidecarCfg.GetConfigPath()))
+	if err != nil {
+		return err
+	}
+
+	state.ContainerRuntime = runtime
+
+	return state.Save()
+}
+
+// saveReleaseSource records the release source in the installer state, where it's
+// looked up releases in from then on.
+func saveReleaseSource(sidecarCfg sidecar.ConfigManager, location string) error {
+	source, err := service.ParseReleaseSource(location)
+	if err != nil {
+		return err
+	}
+
+	state, err := installer.LoadState(filepath.Dir(sidecarCfg.GetConfigPath()))
+	if err != nil {
+		return err
+	}
+
+	state.ReleaseSource = source
+
+	return state.Save()
+}
@@ -0,0 +1,322 @@
+package install
+
+import (
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/mock"
+	"github.com/ethpandaops/contributoor-installer/internal/validate"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestLoadAnswers(t *testing.T) {
+	answersFile := filepath.Join(t.TempDir(), "answers.yaml")
+	require.NoError(t, os.WriteFile(answersFile, []byte(`
+network: holesky
+beaconNodeAddress: http://localhost:5052
+outputServer:
+  address: https://xatu.example.com
+  username: user
+  password: pass
+runMethod: binary
+`), 0600))
+
+	typoFile := filepath.Join(t.TempDir(), "answers.yaml")
+	require.NoError(t, os.WriteFile(typoFile, []byte("netwrok: holesky\n"), 0600))
+
+	tests := []struct {
+		name          string
+		flags         map[string]string
+		expected      *Answers
+		expectedError string
+	}{
+		{
+			name: "flags only",
+			flags: map[string]string{
+				"network":             "sepolia",
+				"beacon-node-address": "http://beacon:5052",
+				"version":             "1.0.0",
+				"container-runtime":   "podman",
+			},
+			expected: &Answers{
+				Network:           "sepolia",
+				BeaconNodeAddress: "http://beacon:5052",
+				Version:           "1.0.0",
+				ContainerRuntime:  "podman",
+			},
+		},
+		{
+			name: "flags override answers file",
+			flags: map[string]string{
+				"answers-file":           answersFile,
+				"network":                "mainnet",
+				"output-server-password": "secret",
+			},
+			expected: &Answers{
+				Network:           "mainnet",
+				BeaconNodeAddress: "http://localhost:5052",
+				OutputServer: OutputServerAnswers{
+					Address:  "https://xatu.example.com",
+					Username: "user",
+					Password: "secret",
+				},
+				RunMethod: "binary",
+			},
+		},
+		{
+			name:          "missing answers file",
+			flags:         map[string]string{"answers-file": "/path/that/doesnt/exist.yaml"},
+			expectedError: "failed to read answers file",
+		},
+		{
+			name:          "unknown keys in answers file",
+			flags:         map[string]string{"answers-file": typoFile},
+			expectedError: "failed to parse answers file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := flag.NewFlagSet("test", flag.ContinueOnError)
+			for _, name := range []string{
+				"answers-file", "network", "beacon-node-address", "output-server-address",
+				"output-server-username", "output-server-password", "run-method", "version",
+				"container-runtime",
+			} {
+				set.String(name, "", "")
+			}
+
+			for name, value := range tt.flags {
+				require.NoError(t, set.Set(name, value))
+			}
+
+			answers, err := loadAnswers(cli.NewContext(nil, set, nil))
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, answers)
+		})
+	}
+}
+
+func TestApplyAnswers(t *testing.T) {
+	beacon := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer beacon.Close()
+
+	const production = "https://xatu.primary.production.platform.ethpandaops.io"
+
+	tests := []struct {
+		name          string
+		current       *config.Config
+		answers       *Answers
+		expected      *config.Config
+		expectedError string
+	}{
+		{
+			name: "writes all answers",
+			current: &config.Config{
+				Version:      "latest",
+				RunMethod:    config.RunMethod_RUN_METHOD_DOCKER,
+				NetworkName:  config.NetworkName_NETWORK_NAME_MAINNET,
+				OutputServer: &config.OutputServer{},
+			},
+			answers: &Answers{
+				Network:           "holesky",
+				BeaconNodeAddress: beacon.URL,
+				OutputServer: OutputServerAnswers{
+					Address:  production,
+					Username: "user",
+					Password: "pass",
+				},
+				RunMethod: "RUN_METHOD_SYSTEMD",
+				Version:   "1.0.0",
+			},
+			expected: &config.Config{
+				Version:           "1.0.0",
+				RunMethod:         config.RunMethod_RUN_METHOD_SYSTEMD,
+				NetworkName:       config.NetworkName_NETWORK_NAME_HOLESKY,
+				BeaconNodeAddress: beacon.URL,
+				OutputServer: &config.OutputServer{
+					Address:     production,
+					Credentials: validate.EncodeCredentials("user", "pass"),
+				},
+			},
+		},
+		{
+			name: "keeps existing values for empty answers",
+			current: &config.Config{
+				Version:           "1.0.0",
+				RunMethod:         config.RunMethod_RUN_METHOD_BINARY,
+				NetworkName:       config.NetworkName_NETWORK_NAME_SEPOLIA,
+				BeaconNodeAddress: beacon.URL,
+				OutputServer: &config.OutputServer{
+					Address:     production,
+					Credentials: validate.EncodeCredentials("user", "pass"),
+				},
+			},
+			answers: &Answers{},
+			expected: &config.Config{
+				Version:           "1.0.0",
+				RunMethod:         config.RunMethod_RUN_METHOD_BINARY,
+				NetworkName:       config.NetworkName_NETWORK_NAME_SEPOLIA,
+				BeaconNodeAddress: beacon.URL,
+				OutputServer: &config.OutputServer{
+					Address:     production,
+					Credentials: validate.EncodeCredentials("user", "pass"),
+				},
+			},
+		},
+		{
+			name:          "invalid network",
+			current:       &config.Config{},
+			answers:       &Answers{Network: "goerli"},
+			expectedError: "invalid network: goerli",
+		},
+		{
+			name:          "invalid run method",
+			current:       &config.Config{},
+			answers:       &Answers{RunMethod: "kubernetes"},
+			expectedError: "invalid run method: kubernetes",
+		},
+		{
+			name:          "invalid container runtime",
+			current:       &config.Config{},
+			answers:       &Answers{ContainerRuntime: "containerd"},
+			expectedError: `invalid container runtime "containerd"`,
+		},
+		{
+			name:          "invalid beacon node address",
+			current:       &config.Config{},
+			answers:       &Answers{BeaconNodeAddress: "localhost:5052"},
+			expectedError: "beacon node address must start with http:// or https://",
+		},
+		{
+			name:    "invalid output server address",
+			current: &config.Config{},
+			answers: &Answers{
+				BeaconNodeAddress: beacon.URL,
+				OutputServer:      OutputServerAnswers{Address: "xatu.example.com"},
+			},
+			expectedError: "server address must start with http:// or https://",
+		},
+		{
+			name:    "missing credentials for ethPandaOps server",
+			current: &config.Config{},
+			answers: &Answers{
+				BeaconNodeAddress: beacon.URL,
+				OutputServer:      OutputServerAnswers{Address: production},
+			},
+			expectedError: "username and password are required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			var updated *config.Config
+
+			mockConfig := mock.NewMockConfigManager(ctrl)
+			mockConfig.EXPECT().Get().Return(tt.current).AnyTimes()
+
+			if tt.expectedError == "" {
+				mockConfig.EXPECT().Update(gomock.Any()).DoAndReturn(func(fn func(*config.Config)) error {
+					updated = &config.Config{}
+					fn(updated)
+
+					return nil
+				})
+			}
+
+			err := applyAnswers(mockConfig, tt.answers)
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, proto.Equal(tt.expected, updated), "expected %v, got %v", tt.expected, updated)
+		})
+	}
+}
+
+func TestApplyAnswers_ContainerRuntime(t *testing.T) {
+	beacon := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer beacon.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir := t.TempDir()
+
+	mockConfig := mock.NewMockConfigManager(ctrl)
+	mockConfig.EXPECT().Get().Return(&config.Config{
+		BeaconNodeAddress: beacon.URL,
+		OutputServer: &config.OutputServer{
+			Address:     "https://xatu.example.com",
+			Credentials: validate.EncodeCredentials("user", "pass"),
+		},
+	}).AnyTimes()
+	mockConfig.EXPECT().GetConfigPath().Return(filepath.Join(dir, "config.yaml")).AnyTimes()
+	mockConfig.EXPECT().Update(gomock.Any()).Return(nil).Times(2)
+
+	require.NoError(t, applyAnswers(mockConfig, &Answers{ContainerRuntime: "Podman"}))
+
+	state, err := installer.LoadState(dir)
+	require.NoError(t, err)
+	assert.Equal(t, installer.ContainerRuntimePodman, state.ContainerRuntime)
+
+	// Auto goes back to whichever is found.
+	require.NoError(t, applyAnswers(mockConfig, &Answers{ContainerRuntime: "auto"}))
+
+	state, err = installer.LoadState(dir)
+	require.NoError(t, err)
+	assert.Empty(t, state.ContainerRuntime)
+}
+
+func TestSaveReleaseSource(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir := t.TempDir()
+
+	mockConfig := mock.NewMockConfigManager(ctrl)
+	mockConfig.EXPECT().GetConfigPath().Return(filepath.Join(dir, "config.yaml")).AnyTimes()
+
+	require.NoError(t, saveReleaseSource(mockConfig, "https://mirror.example.com/contributoor"))
+
+	state, err := installer.LoadState(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "https://mirror.example.com/contributoor", state.ReleaseSource)
+
+	assert.ErrorContains(t, saveReleaseSource(mockConfig, "https://"), "invalid release mirror")
+
+	// GitHub is the default, so it isn't recorded.
+	require.NoError(t, saveReleaseSource(mockConfig, "github"))
+
+	state, err = installer.LoadState(dir)
+	require.NoError(t, err)
+	assert.Empty(t, state.ReleaseSource)
+}
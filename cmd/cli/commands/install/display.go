@@ -129,14 +129,21 @@ func (d *InstallDisplay) setPage(page *tui.Page) {
 
 // OnComplete is called when the install wizard is complete.
 func (d *InstallDisplay) OnComplete() error {
-	cfg := d.sidecarCfg.Get()
+	printSummary(d.sidecarCfg)
+
+	return nil
+}
+
+// printSummary prints the installed config, and how to manage contributoor from here.
+func printSummary(sidecarCfg sidecar.ConfigManager) {
+	cfg := sidecarCfg.Get()
 
 	fmt.Printf("%sContributoor Status%s\n", tui.TerminalColorLightBlue, tui.TerminalColorReset)
 	fmt.Printf("%-20s: %s\n", "Version", cfg.Version)
 	fmt.Printf("%-20s: %s\n", "Run Method", cfg.RunMethod)
 	fmt.Printf("%-20s: %s\n", "Network", cfg.NetworkName)
 	fmt.Printf("%-20s: %s\n", "Beacon Node", cfg.BeaconNodeAddress)
-	fmt.Printf("%-20s: %s\n", "Config Path", d.sidecarCfg.GetConfigPath())
+	fmt.Printf("%-20s: %s\n", "Config Path", sidecarCfg.GetConfigPath())
 
 	if cfg.OutputServer != nil {
 		fmt.Printf("%-20s: %s\n", "Output Server", cfg.OutputServer.Address)
@@ -145,6 +152,4 @@ func (d *InstallDisplay) OnComplete() error {
 	fmt.Printf("\n%sInstallation complete%s\n", tui.TerminalColorGreen, tui.TerminalColorReset)
 	fmt.Printf("You can now manage contributoor using the following command(s):\n")
 	fmt.Printf("    contributoor [start|stop|status|update|config]\n")
-
-	return nil
 }
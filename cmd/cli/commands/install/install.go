@@ -2,10 +2,14 @@ package install
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"github.com/ethpandaops/contributoor-installer/cmd/cli/options"
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/service"
 	"github.com/ethpandaops/contributoor-installer/internal/sidecar"
 	"github.com/ethpandaops/contributoor-installer/internal/tui"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
 	"github.com/rivo/tview"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
@@ -26,24 +30,98 @@ func RegisterCommands(app *cli.App, opts *options.CommandOpts) {
 				return fmt.Errorf("error loading config: %w", err)
 			}
 
-			return installContributoor(c, log, sidecarCfg)
+			// Releases are looked up to resolve the version, so the release source is
+			// saved before anything else.
+			if c.IsSet("release-source") {
+				if err := saveReleaseSource(sidecarCfg, c.String("release-source")); err != nil {
+					return cli.NewExitError(fmt.Sprintf("%s%v%s", tui.TerminalColorRed, err, tui.TerminalColorReset), exitCodeInvalidAnswers)
+				}
+			}
+
+			githubService, err := service.NewGitHubService(log, opts.InstallerConfig(), filepath.Dir(sidecarCfg.GetConfigPath()))
+			if err != nil {
+				return fmt.Errorf("error creating github service: %w", err)
+			}
+
+			return installContributoor(c, log, sidecarCfg, githubService)
 		},
 		Flags: []cli.Flag{
 			cli.StringFlag{
-				Name:  "version, v",
-				Usage: "The contributoor version to install",
-				Value: "latest",
+				Name:   "version, v",
+				Usage:  "The contributoor version to install",
+				Value:  "latest",
+				EnvVar: "CONTRIBUTOOR_VERSION",
+			},
+			cli.StringFlag{
+				Name:   "run-method, r",
+				Usage:  "The method to run contributoor",
+				Value:  sidecar.RunMethodDocker,
+				EnvVar: "CONTRIBUTOOR_RUN_METHOD",
+			},
+			cli.StringFlag{
+				Name:   "container-runtime",
+				Usage:  "The container runtime the docker run method uses (docker, podman or auto)",
+				EnvVar: "CONTRIBUTOOR_CONTAINER_RUNTIME",
+			},
+			cli.StringFlag{
+				Name:   "release-source",
+				Usage:  "Where releases are looked up and downloaded from (github, a mirror URL, or a local directory or bundle)",
+				EnvVar: "CONTRIBUTOOR_RELEASE_SOURCE",
+			},
+			cli.BoolFlag{
+				Name:   "non-interactive",
+				Usage:  "Install without the wizard, using flags, environment variables or an answers file",
+				EnvVar: "CONTRIBUTOOR_NON_INTERACTIVE",
+			},
+			cli.StringFlag{
+				Name:   "answers-file",
+				Usage:  "YAML `file` of answers for a non-interactive install",
+				EnvVar: "CONTRIBUTOOR_ANSWERS_FILE",
+			},
+			cli.StringFlag{
+				Name:   "network",
+				Usage:  "The network to contribute to (mainnet, holesky, sepolia)",
+				EnvVar: "CONTRIBUTOOR_NETWORK",
 			},
 			cli.StringFlag{
-				Name:  "run-method, r",
-				Usage: "The method to run contributoor",
-				Value: sidecar.RunMethodDocker,
+				Name:   "beacon-node-address",
+				Usage:  "The address of your beacon node",
+				EnvVar: "CONTRIBUTOOR_BEACON_NODE_ADDRESS",
+			},
+			cli.StringFlag{
+				Name:   "output-server-address",
+				Usage:  "The address of the output server",
+				EnvVar: "CONTRIBUTOOR_OUTPUT_SERVER_ADDRESS",
+			},
+			cli.StringFlag{
+				Name:   "output-server-username",
+				Usage:  "The username for the output server",
+				EnvVar: "CONTRIBUTOOR_OUTPUT_SERVER_USERNAME",
+			},
+			cli.StringFlag{
+				Name:   "output-server-password",
+				Usage:  "The password for the output server",
+				EnvVar: "CONTRIBUTOOR_OUTPUT_SERVER_PASSWORD",
 			},
 		},
 	})
 }
 
-func installContributoor(c *cli.Context, log *logrus.Logger, sidecarCfg sidecar.ConfigManager) error {
+func installContributoor(c *cli.Context, log *logrus.Logger, sidecarCfg sidecar.ConfigManager, github service.GitHubService) error {
+	if c.Bool("non-interactive") || c.String("answers-file") != "" {
+		return installNonInteractive(c, sidecarCfg, github)
+	}
+
+	// The version, run method and container runtime are decided before the wizard, eg:
+	// by install.sh.
+	if err := applyInstallFlags(c, sidecarCfg); err != nil {
+		return cli.NewExitError(fmt.Sprintf("%s%v%s", tui.TerminalColorRed, err, tui.TerminalColorReset), exitCodeInvalidAnswers)
+	}
+
+	if err := resolveVersion(sidecarCfg, github); err != nil {
+		return fmt.Errorf("%s%w%s", tui.TerminalColorRed, err, tui.TerminalColorReset)
+	}
+
 	var (
 		app     = tview.NewApplication()
 		display = NewInstallDisplay(log, app, sidecarCfg)
@@ -65,3 +143,85 @@ func installContributoor(c *cli.Context, log *logrus.Logger, sidecarCfg sidecar.
 
 	return nil
 }
+
+// installNonInteractive installs contributoor from flags, environment variables and
+// an answers file, without launching the wizard.
+func installNonInteractive(c *cli.Context, sidecarCfg sidecar.ConfigManager, github service.GitHubService) error {
+	answers, err := loadAnswers(c)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("%s%v%s", tui.TerminalColorRed, err, tui.TerminalColorReset), exitCodeInvalidAnswers)
+	}
+
+	if err := applyAnswers(sidecarCfg, answers); err != nil {
+		return cli.NewExitError(fmt.Sprintf("%sinvalid answers: %v%s", tui.TerminalColorRed, err, tui.TerminalColorReset), exitCodeInvalidAnswers)
+	}
+
+	if err := resolveVersion(sidecarCfg, github); err != nil {
+		return fmt.Errorf("%s%w%s", tui.TerminalColorRed, err, tui.TerminalColorReset)
+	}
+
+	printSummary(sidecarCfg)
+
+	return nil
+}
+
+// applyInstallFlags applies the version, run method and container runtime flags, if set.
+func applyInstallFlags(c *cli.Context, sidecarCfg sidecar.ConfigManager) error {
+	if c.IsSet("container-runtime") {
+		runtime, err := parseContainerRuntime(c.String("container-runtime"))
+		if err != nil {
+			return err
+		}
+
+		if err := saveContainerRuntime(sidecarCfg, runtime); err != nil {
+			return err
+		}
+	}
+
+	if !c.IsSet("version") && !c.IsSet("run-method") {
+		return nil
+	}
+
+	var (
+		cfg       = sidecarCfg.Get()
+		version   = cfg.Version
+		runMethod = cfg.RunMethod
+	)
+
+	if c.IsSet("version") {
+		version = c.String("version")
+	}
+
+	if c.IsSet("run-method") {
+		parsed, err := parseRunMethod(c.String("run-method"))
+		if err != nil {
+			return err
+		}
+
+		runMethod = parsed
+	}
+
+	return sidecarCfg.Update(func(cfg *config.Config) {
+		cfg.Version = version
+		cfg.RunMethod = runMethod
+	})
+}
+
+// resolveVersion resolves the version to install to a concrete one, eg: latest to the
+// newest release on the channel in the installer state, so that what's installed doesn't
+// move under it. Updates follow the channel instead.
+func resolveVersion(sidecarCfg sidecar.ConfigManager, github service.GitHubService) error {
+	state, err := installer.LoadState(filepath.Dir(sidecarCfg.GetConfigPath()))
+	if err != nil {
+		return fmt.Errorf("failed to load installer state: %w", err)
+	}
+
+	version, err := service.ResolveVersion(github, sidecarCfg.Get().Version, state.GetChannel())
+	if err != nil {
+		return err
+	}
+
+	return sidecarCfg.Update(func(cfg *config.Config) {
+		cfg.Version = version
+	})
+}
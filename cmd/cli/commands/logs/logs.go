@@ -0,0 +1,154 @@
+package logs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/ethpandaops/contributoor-installer/cmd/cli/options"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+func RegisterCommands(app *cli.App, opts *options.CommandOpts) {
+	app.Commands = append(app.Commands, cli.Command{
+		Name:      opts.Name(),
+		Aliases:   opts.Aliases(),
+		Usage:     "Show Contributoor logs",
+		UsageText: "contributoor logs [options]",
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "follow, f",
+				Usage: "Keep streaming new logs",
+			},
+			cli.IntFlag{
+				Name:  "tail, n",
+				Usage: "Number of `lines` to show from the end of the logs, or 0 for all",
+				Value: 100,
+			},
+			cli.StringFlag{
+				Name:  "since",
+				Usage: "Only show logs since a `time`, either relative (eg: 30m) or a timestamp (eg: 2025-01-02T15:04:05Z)",
+			},
+			cli.StringFlag{
+				Name:  "level, l",
+				Usage: "Only show logs at or above a `level` (eg: warn)",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			var (
+				log          = opts.Logger()
+				installerCfg = opts.InstallerConfig()
+			)
+
+			sidecarCfg, err := sidecar.NewConfigService(log, c.GlobalString("config-path"))
+			if err != nil {
+				return fmt.Errorf("error loading config: %w", err)
+			}
+
+			dockerSidecar, err := sidecar.NewDockerSidecar(log, sidecarCfg, installerCfg)
+			if err != nil {
+				return fmt.Errorf("error creating docker sidecar service: %w", err)
+			}
+
+			systemdSidecar, err := sidecar.NewSystemdSidecar(log, sidecarCfg, installerCfg)
+			if err != nil {
+				return fmt.Errorf("error creating systemd sidecar service: %w", err)
+			}
+
+			binarySidecar, err := sidecar.NewBinarySidecar(log, sidecarCfg, installerCfg)
+			if err != nil {
+				return fmt.Errorf("error creating binary sidecar service: %w", err)
+			}
+
+			return showLogs(c, log, os.Stdout, sidecarCfg, dockerSidecar, systemdSidecar, binarySidecar)
+		},
+	})
+}
+
+func showLogs(
+	c *cli.Context,
+	log *logrus.Logger,
+	w io.Writer,
+	sidecarCfg sidecar.ConfigManager,
+	docker sidecar.DockerSidecar,
+	systemd sidecar.SystemdSidecar,
+	binary sidecar.BinarySidecar,
+) error {
+	var (
+		sidecarRunner sidecar.SidecarRunner
+		cfg           = sidecarCfg.Get()
+	)
+
+	switch cfg.RunMethod {
+	case config.RunMethod_RUN_METHOD_DOCKER:
+		sidecarRunner = docker
+	case config.RunMethod_RUN_METHOD_SYSTEMD:
+		sidecarRunner = systemd
+	case config.RunMethod_RUN_METHOD_BINARY:
+		sidecarRunner = binary
+	default:
+		return fmt.Errorf("invalid sidecar run method: %s", cfg.RunMethod)
+	}
+
+	since, err := parseSince(c.String("since"), time.Now())
+	if err != nil {
+		return err
+	}
+
+	if c.Int("tail") < 0 {
+		return fmt.Errorf("invalid --tail value %d: must be 0 or more", c.Int("tail"))
+	}
+
+	logOpts := runner.LogOptions{
+		Follow: c.Bool("follow"),
+		Tail:   c.Int("tail"),
+		Since:  since,
+	}
+
+	if c.String("level") == "" {
+		return sidecarRunner.Logs(context.Background(), logOpts, w)
+	}
+
+	level, err := logrus.ParseLevel(c.String("level"))
+	if err != nil {
+		return fmt.Errorf("invalid --level value: %w", err)
+	}
+
+	// The tail applies before filtering, so fewer lines may be shown than asked for.
+	filter := sidecar.NewLevelFilter(w, level)
+
+	if err := sidecarRunner.Logs(context.Background(), logOpts, filter); err != nil {
+		return err
+	}
+
+	return filter.Flush()
+}
+
+// parseSince parses the --since flag, which is either a duration before now, or a
+// timestamp. An empty value means no limit.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateTime, time.DateOnly} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf(
+		"invalid --since value %q: expected a duration (eg: 30m) or timestamp (eg: 2025-01-02T15:04:05Z)",
+		value,
+	)
+}
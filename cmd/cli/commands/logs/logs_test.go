@@ -0,0 +1,274 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ethpandaops/contributoor-installer/cmd/cli/options"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/mock"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+	"go.uber.org/mock/gomock"
+)
+
+func TestShowLogs(t *testing.T) {
+	const output = `time="2025-01-02T15:04:05Z" level=debug msg="Polling beacon node"
+time="2025-01-02T15:04:06Z" level=error msg="Failed to connect"
+goroutine 1 [running]:
+time="2025-01-02T15:04:07Z" level=info msg="Connected"
+`
+
+	tests := []struct {
+		name           string
+		runMethod      config.RunMethod
+		flags          map[string]string
+		setupMocks     func(*mock.MockDockerSidecar, *mock.MockSystemdSidecar, *mock.MockBinarySidecar)
+		expectedOutput string
+		expectedError  string
+	}{
+		{
+			name:      "docker - passes options through",
+			runMethod: config.RunMethod_RUN_METHOD_DOCKER,
+			flags:     map[string]string{"follow": "true", "tail": "10"},
+			setupMocks: func(d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar) {
+				d.EXPECT().Logs(gomock.Any(), runner.LogOptions{Follow: true, Tail: 10}, gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:      "systemd - shows all logs",
+			runMethod: config.RunMethod_RUN_METHOD_SYSTEMD,
+			setupMocks: func(d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar) {
+				s.EXPECT().Logs(gomock.Any(), runner.LogOptions{Tail: 100}, gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ runner.LogOptions, w io.Writer) error {
+						_, err := io.WriteString(w, output)
+
+						return err
+					},
+				)
+			},
+			expectedOutput: output,
+		},
+		{
+			name:      "binary - filters by level",
+			runMethod: config.RunMethod_RUN_METHOD_BINARY,
+			flags:     map[string]string{"level": "warn"},
+			setupMocks: func(d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar) {
+				b.EXPECT().Logs(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ runner.LogOptions, w io.Writer) error {
+						_, err := io.WriteString(w, output)
+
+						return err
+					},
+				)
+			},
+			expectedOutput: "time=\"2025-01-02T15:04:06Z\" level=error msg=\"Failed to connect\"\ngoroutine 1 [running]:\n",
+		},
+		{
+			name:      "binary - runner fails",
+			runMethod: config.RunMethod_RUN_METHOD_BINARY,
+			setupMocks: func(d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar) {
+				b.EXPECT().Logs(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("boom"))
+			},
+			expectedError: "boom",
+		},
+		{
+			name:          "invalid since",
+			runMethod:     config.RunMethod_RUN_METHOD_DOCKER,
+			flags:         map[string]string{"since": "yesterday"},
+			setupMocks:    func(d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar) {},
+			expectedError: "invalid --since value \"yesterday\"",
+		},
+		{
+			name:          "invalid level",
+			runMethod:     config.RunMethod_RUN_METHOD_DOCKER,
+			flags:         map[string]string{"level": "loud"},
+			setupMocks:    func(d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar) {},
+			expectedError: "invalid --level value",
+		},
+		{
+			name:          "invalid tail",
+			runMethod:     config.RunMethod_RUN_METHOD_DOCKER,
+			flags:         map[string]string{"tail": "-1"},
+			setupMocks:    func(d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar) {},
+			expectedError: "invalid --tail value -1",
+		},
+		{
+			name:          "invalid run method",
+			runMethod:     config.RunMethod_RUN_METHOD_UNSPECIFIED,
+			setupMocks:    func(d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar) {},
+			expectedError: "invalid sidecar run method",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockConfig := mock.NewMockConfigManager(ctrl)
+			mockConfig.EXPECT().Get().Return(&config.Config{RunMethod: tt.runMethod}).AnyTimes()
+
+			mockDocker := mock.NewMockDockerSidecar(ctrl)
+			mockSystemd := mock.NewMockSystemdSidecar(ctrl)
+			mockBinary := mock.NewMockBinarySidecar(ctrl)
+
+			tt.setupMocks(mockDocker, mockSystemd, mockBinary)
+
+			set := flag.NewFlagSet("test", flag.ContinueOnError)
+			set.Bool("follow", false, "")
+			set.Int("tail", 100, "")
+			set.String("since", "", "")
+			set.String("level", "", "")
+
+			for name, value := range tt.flags {
+				require.NoError(t, set.Set(name, value))
+			}
+
+			var out bytes.Buffer
+
+			err := showLogs(cli.NewContext(nil, set, nil), logrus.New(), &out, mockConfig, mockDocker, mockSystemd, mockBinary)
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedOutput, out.String())
+		})
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Time
+	}{
+		{
+			name:     "empty",
+			value:    "",
+			expected: time.Time{},
+		},
+		{
+			name:     "duration",
+			value:    "90m",
+			expected: now.Add(-90 * time.Minute),
+		},
+		{
+			name:     "timestamp",
+			value:    "2025-01-01T12:00:00Z",
+			expected: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "date",
+			value:    "2025-01-01",
+			expected: time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			since, err := parseSince(tt.value, now)
+			require.NoError(t, err)
+			assert.True(t, tt.expected.Equal(since), "expected %s, got %s", tt.expected, since)
+		})
+	}
+}
+
+func TestRegisterCommands(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name          string
+		configPath    string
+		expectedError string
+	}{
+		{
+			name:       "successfully registers command",
+			configPath: "testdata/valid", // "testdata" is an ancillary dir provided by go-test.
+		},
+		{
+			name:          "fails when config service fails",
+			configPath:    "/invalid/path/that/doesnt/exist",
+			expectedError: "error loading config",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create CLI app, with the config flag.
+			app := cli.NewApp()
+			app.Flags = []cli.Flag{
+				cli.StringFlag{
+					Name: "config-path",
+				},
+			}
+
+			// Ensure we set the config path flag.
+			globalSet := flag.NewFlagSet("test", flag.ContinueOnError)
+			globalSet.String("config-path", "", "")
+			err := globalSet.Set("config-path", tt.configPath)
+			require.NoError(t, err)
+
+			// Create the cmd context.
+			globalCtx := cli.NewContext(app, globalSet, nil)
+			app.Metadata = map[string]interface{}{
+				"flagContext": globalCtx,
+			}
+
+			// Now test!
+			RegisterCommands(
+				app,
+				options.NewCommandOpts(
+					options.WithName("logs"),
+					options.WithLogger(logrus.New()),
+					options.WithAliases([]string{"l"}),
+				),
+			)
+
+			if tt.expectedError != "" {
+				// Ensure the command registration succeeded.
+				assert.NoError(t, err)
+
+				// Assert that the action execution fails as expected.
+				cmd := app.Commands[0]
+				ctx := cli.NewContext(app, nil, globalCtx)
+
+				// Assert that the action is the func we expect, mainly because the linter is having a fit otherwise.
+				action, ok := cmd.Action.(func(*cli.Context) error)
+				require.True(t, ok, "expected action to be func(*cli.Context) error")
+
+				// Execute the action and assert the error.
+				actionErr := action(ctx)
+				assert.Error(t, actionErr)
+				assert.ErrorContains(t, actionErr, tt.expectedError)
+			} else {
+				// Ensure the command registration succeeded.
+				assert.NoError(t, err)
+				assert.Len(t, app.Commands, 1)
+
+				// Ensure the command is registered as expected by dumping the command.
+				cmd := app.Commands[0]
+				assert.Equal(t, "logs", cmd.Name)
+				assert.Equal(t, []string{"l"}, cmd.Aliases)
+				assert.Equal(t, "Show Contributoor logs", cmd.Usage)
+				assert.Equal(t, "contributoor logs [options]", cmd.UsageText)
+				assert.NotNil(t, cmd.Action)
+			}
+		})
+	}
+}
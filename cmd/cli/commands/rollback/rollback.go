@@ -0,0 +1,149 @@
+package rollback
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/ethpandaops/contributoor-installer/cmd/cli/options"
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar"
+	"github.com/ethpandaops/contributoor-installer/internal/tui"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+func RegisterCommands(app *cli.App, opts *options.CommandOpts) {
+	app.Commands = append(app.Commands, cli.Command{
+		Name:      opts.Name(),
+		Aliases:   opts.Aliases(),
+		Usage:     "Roll back Contributoor to the previous version",
+		UsageText: "contributoor rollback [options]",
+		Action: func(c *cli.Context) error {
+			var (
+				log          = opts.Logger()
+				installerCfg = opts.InstallerConfig()
+			)
+
+			sidecarCfg, err := sidecar.NewConfigService(log, c.GlobalString("config-path"))
+			if err != nil {
+				return fmt.Errorf("error loading config: %w", err)
+			}
+
+			dockerSidecar, err := sidecar.NewDockerSidecar(log, sidecarCfg, installerCfg)
+			if err != nil {
+				return fmt.Errorf("error creating docker sidecar service: %w", err)
+			}
+
+			systemdSidecar, err := sidecar.NewSystemdSidecar(log, sidecarCfg, installerCfg)
+			if err != nil {
+				return fmt.Errorf("error creating systemd sidecar service: %w", err)
+			}
+
+			binarySidecar, err := sidecar.NewBinarySidecar(log, sidecarCfg, installerCfg)
+			if err != nil {
+				return fmt.Errorf("error creating binary sidecar service: %w", err)
+			}
+
+			state, err := installer.LoadState(filepath.Dir(sidecarCfg.GetConfigPath()))
+			if err != nil {
+				return fmt.Errorf("error loading installer state: %w", err)
+			}
+
+			return rollbackContributoor(c, log, sidecarCfg, state, dockerSidecar, systemdSidecar, binarySidecar)
+		},
+	})
+}
+
+func rollbackContributoor(
+	c *cli.Context,
+	log *logrus.Logger,
+	sidecarCfg sidecar.ConfigManager,
+	state *installer.State,
+	docker sidecar.DockerSidecar,
+	systemd sidecar.SystemdSidecar,
+	binary sidecar.BinarySidecar,
+) error {
+	var (
+		runner sidecar.SidecarRunner
+		cfg    = sidecarCfg.Get()
+	)
+
+	fmt.Printf("%sRolling back Contributoor%s\n", tui.TerminalColorLightBlue, tui.TerminalColorReset)
+
+	// Determine which runner to use
+	switch cfg.RunMethod {
+	case config.RunMethod_RUN_METHOD_DOCKER:
+		runner = docker
+	case config.RunMethod_RUN_METHOD_SYSTEMD:
+		runner = systemd
+	case config.RunMethod_RUN_METHOD_BINARY:
+		runner = binary
+	default:
+		return fmt.Errorf("invalid sidecar run method: %s", cfg.RunMethod)
+	}
+
+	previousVersion := state.PreviousVersion()
+	if previousVersion == "" {
+		fmt.Printf("%sNo previous version to roll back to%s\n", tui.TerminalColorYellow, tui.TerminalColorReset)
+
+		return nil
+	}
+
+	fmt.Printf("%-20s: %s\n", "Current Version", cfg.Version)
+	fmt.Printf("%-20s: %s\n", "Rollback Version", previousVersion)
+
+	// Check if running
+	running, err := runner.IsRunning()
+	if err != nil {
+		log.Errorf("could not check sidecar status: %v", err)
+
+		return err
+	}
+
+	// Stop if running, the previous version can't be swapped in underneath it.
+	if running {
+		if err := runner.Stop(); err != nil {
+			return fmt.Errorf("failed to stop service: %w", err)
+		}
+	}
+
+	if err := runner.Rollback(previousVersion); err != nil {
+		// Leave things as we found them.
+		if running {
+			if serr := runner.Start(); serr != nil {
+				log.Errorf("could not restart sidecar: %v", serr)
+			}
+		}
+
+		return fmt.Errorf("failed to roll back service: %w", err)
+	}
+
+	if err := sidecarCfg.Update(func(cfg *config.Config) {
+		cfg.Version = previousVersion
+	}); err != nil {
+		return fmt.Errorf("failed to update sidecar config version: %w", err)
+	}
+
+	if err := sidecarCfg.Save(); err != nil {
+		return fmt.Errorf("could not save updated sidecar config: %w", err)
+	}
+
+	// The version we rolled back to is now current, so it's no longer a rollback target.
+	state.PopVersion()
+
+	if err := state.Save(); err != nil {
+		return fmt.Errorf("could not save installer state: %w", err)
+	}
+
+	fmt.Printf("%sContributoor rolled back successfully to version %s%s\n", tui.TerminalColorGreen, previousVersion, tui.TerminalColorReset)
+
+	// If it was running, start it again for them.
+	if running {
+		if err := runner.Start(); err != nil {
+			return fmt.Errorf("failed to start service: %w", err)
+		}
+	}
+
+	return nil
+}
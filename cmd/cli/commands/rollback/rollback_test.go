@@ -0,0 +1,219 @@
+package rollback
+
+import (
+	"errors"
+	"flag"
+	"testing"
+
+	"github.com/ethpandaops/contributoor-installer/cmd/cli/options"
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/mock"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRollbackContributoor(t *testing.T) {
+	tests := []struct {
+		name             string
+		runMethod        config.RunMethod
+		previousVersions []string
+		setupMocks       func(*mock.MockConfigManager, *mock.MockDockerSidecar, *mock.MockSystemdSidecar, *mock.MockBinarySidecar)
+		expectedError    string
+		expectedVersions []string
+	}{
+		{
+			name:             "docker - rolls back running service",
+			runMethod:        config.RunMethod_RUN_METHOD_DOCKER,
+			previousVersions: []string{"v0.9.0", "v1.0.0"},
+			setupMocks: func(cfg *mock.MockConfigManager, d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar) {
+				d.EXPECT().IsRunning().Return(true, nil)
+				d.EXPECT().Stop().Return(nil)
+				d.EXPECT().Rollback("v1.0.0").Return(nil)
+				cfg.EXPECT().Update(gomock.Any()).Return(nil)
+				cfg.EXPECT().Save().Return(nil)
+				d.EXPECT().Start().Return(nil)
+			},
+			expectedVersions: []string{"v0.9.0"},
+		},
+		{
+			name:             "binary - rolls back stopped service",
+			runMethod:        config.RunMethod_RUN_METHOD_BINARY,
+			previousVersions: []string{"v1.0.0"},
+			setupMocks: func(cfg *mock.MockConfigManager, d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar) {
+				b.EXPECT().IsRunning().Return(false, nil)
+				b.EXPECT().Rollback("v1.0.0").Return(nil)
+				cfg.EXPECT().Update(gomock.Any()).Return(nil)
+				cfg.EXPECT().Save().Return(nil)
+			},
+			expectedVersions: []string{},
+		},
+		{
+			name:             "systemd - rollback fails",
+			runMethod:        config.RunMethod_RUN_METHOD_SYSTEMD,
+			previousVersions: []string{"v1.0.0"},
+			setupMocks: func(cfg *mock.MockConfigManager, d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar) {
+				s.EXPECT().IsRunning().Return(true, nil)
+				s.EXPECT().Stop().Return(nil)
+				s.EXPECT().Rollback("v1.0.0").Return(errors.New("version v1.0.0 is not installed"))
+
+				// The service is restarted on the current version, and config is left alone.
+				s.EXPECT().Start().Return(nil)
+			},
+			expectedError:    "failed to roll back service",
+			expectedVersions: []string{"v1.0.0"},
+		},
+		{
+			name:      "no previous version",
+			runMethod: config.RunMethod_RUN_METHOD_BINARY,
+			setupMocks: func(cfg *mock.MockConfigManager, d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar) {
+				// Nothing should be touched.
+			},
+		},
+		{
+			name:             "invalid run method",
+			runMethod:        config.RunMethod_RUN_METHOD_UNSPECIFIED,
+			previousVersions: []string{"v1.0.0"},
+			setupMocks: func(cfg *mock.MockConfigManager, d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar) {
+			},
+			expectedError:    "invalid sidecar run method",
+			expectedVersions: []string{"v1.0.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockConfig := mock.NewMockConfigManager(ctrl)
+			mockConfig.EXPECT().Get().Return(&config.Config{
+				RunMethod: tt.runMethod,
+				Version:   "v1.1.0",
+			}).AnyTimes()
+
+			mockDocker := mock.NewMockDockerSidecar(ctrl)
+			mockSystemd := mock.NewMockSystemdSidecar(ctrl)
+			mockBinary := mock.NewMockBinarySidecar(ctrl)
+
+			tt.setupMocks(mockConfig, mockDocker, mockSystemd, mockBinary)
+
+			dir := t.TempDir()
+
+			state, err := installer.LoadState(dir)
+			require.NoError(t, err)
+
+			state.PreviousVersions = tt.previousVersions
+			require.NoError(t, state.Save())
+
+			err = rollbackContributoor(
+				cli.NewContext(nil, nil, nil),
+				logrus.New(),
+				mockConfig,
+				state,
+				mockDocker,
+				mockSystemd,
+				mockBinary,
+			)
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			// Ensure the state on disk reflects what we expect.
+			saved, err := installer.LoadState(dir)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.expectedVersions, saved.PreviousVersions)
+		})
+	}
+}
+
+func TestRegisterCommands(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name          string
+		configPath    string
+		expectedError string
+	}{
+		{
+			name:       "successfully registers command",
+			configPath: "testdata/valid", // "testdata" is an ancillary dir provided by go-test.
+		},
+		{
+			name:          "fails when config service fails",
+			configPath:    "/invalid/path/that/doesnt/exist",
+			expectedError: "error loading config",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create CLI app, with the config flag.
+			app := cli.NewApp()
+			app.Flags = []cli.Flag{
+				cli.StringFlag{
+					Name: "config-path",
+				},
+			}
+
+			// Ensure we set the config path flag.
+			globalSet := flag.NewFlagSet("test", flag.ContinueOnError)
+			globalSet.String("config-path", "", "")
+			err := globalSet.Set("config-path", tt.configPath)
+			require.NoError(t, err)
+
+			// Create the cmd context.
+			globalCtx := cli.NewContext(app, globalSet, nil)
+			app.Metadata = map[string]interface{}{
+				"flagContext": globalCtx,
+			}
+
+			// Now test!
+			RegisterCommands(
+				app,
+				options.NewCommandOpts(
+					options.WithName("rollback"),
+					options.WithLogger(logrus.New()),
+					options.WithAliases([]string{"rb"}),
+				),
+			)
+
+			if tt.expectedError != "" {
+				// Ensure the command registration succeeded.
+				assert.NoError(t, err)
+
+				// Assert that the action execution fails as expected.
+				cmd := app.Commands[0]
+				ctx := cli.NewContext(app, nil, globalCtx)
+
+				// Assert that the action is the func we expect, mainly because the linter is having a fit otherwise.
+				action, ok := cmd.Action.(func(*cli.Context) error)
+				require.True(t, ok, "expected action to be func(*cli.Context) error")
+
+				// Execute the action and assert the error.
+				actionErr := action(ctx)
+				assert.Error(t, actionErr)
+				assert.ErrorContains(t, actionErr, tt.expectedError)
+			} else {
+				// Ensure the command registration succeeded.
+				assert.NoError(t, err)
+				assert.Len(t, app.Commands, 1)
+
+				// Ensure the command is registered as expected by dumping the command.
+				cmd := app.Commands[0]
+				assert.Equal(t, "rollback", cmd.Name)
+				assert.Equal(t, []string{"rb"}, cmd.Aliases)
+				assert.Equal(t, "Roll back Contributoor to the previous version", cmd.Usage)
+				assert.Equal(t, "contributoor rollback [options]", cmd.UsageText)
+				assert.NotNil(t, cmd.Action)
+			}
+		})
+	}
+}
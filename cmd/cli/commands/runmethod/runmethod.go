@@ -0,0 +1,95 @@
+package runmethod
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ethpandaops/contributoor-installer/cmd/cli/options"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar"
+	"github.com/ethpandaops/contributoor-installer/internal/tui"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/urfave/cli"
+)
+
+func RegisterCommands(app *cli.App, opts *options.CommandOpts) {
+	app.Commands = append(app.Commands, cli.Command{
+		Name:      opts.Name(),
+		Aliases:   opts.Aliases(),
+		Usage:     "Switch Contributoor to another run method, moving the running service over",
+		UsageText: "contributoor migrate-run-method <docker|systemd|binary>",
+		Action: func(c *cli.Context) error {
+			var (
+				log          = opts.Logger()
+				installerCfg = opts.InstallerConfig()
+			)
+
+			if c.NArg() != 1 {
+				return cli.NewExitError("expected exactly one argument: <run method>", 1)
+			}
+
+			runMethod, err := parseRunMethod(c.Args().Get(0))
+			if err != nil {
+				return cli.NewExitError(err.Error(), 1)
+			}
+
+			sidecarCfg, err := sidecar.NewConfigService(log, c.GlobalString("config-path"))
+			if err != nil {
+				return fmt.Errorf("error loading config: %w", err)
+			}
+
+			dockerSidecar, err := sidecar.NewDockerSidecar(log, sidecarCfg, installerCfg)
+			if err != nil {
+				return fmt.Errorf("error creating docker sidecar service: %w", err)
+			}
+
+			systemdSidecar, err := sidecar.NewSystemdSidecar(log, sidecarCfg, installerCfg)
+			if err != nil {
+				return fmt.Errorf("error creating systemd sidecar service: %w", err)
+			}
+
+			binarySidecar, err := sidecar.NewBinarySidecar(log, sidecarCfg, installerCfg)
+			if err != nil {
+				return fmt.Errorf("error creating binary sidecar service: %w", err)
+			}
+
+			return migrateRunMethod(c.App.Writer, sidecarCfg, &sidecar.Runners{
+				Docker:  dockerSidecar,
+				Systemd: systemdSidecar,
+				Binary:  binarySidecar,
+			}, runMethod)
+		},
+	})
+}
+
+// migrateRunMethod moves contributoor from its configured run method to another.
+func migrateRunMethod(w io.Writer, sidecarCfg sidecar.ConfigManager, runners *sidecar.Runners, to config.RunMethod) error {
+	from := sidecarCfg.Get().RunMethod
+
+	if from == to {
+		fmt.Fprintf(w, "%sAlready using the %s run method%s\n", tui.TerminalColorYellow, strings.ToLower(to.DisplayName()), tui.TerminalColorReset)
+
+		return nil
+	}
+
+	fmt.Fprintf(w, "%sMigrating Contributoor from %s to %s%s\n",
+		tui.TerminalColorLightBlue, strings.ToLower(from.DisplayName()), strings.ToLower(to.DisplayName()), tui.TerminalColorReset)
+
+	return sidecar.SwitchRunMethod(w, sidecarCfg, runners, from, to)
+}
+
+// parseRunMethod parses a run method, either short (eg: "docker") or in full (eg: "RUN_METHOD_DOCKER").
+func parseRunMethod(name string) (config.RunMethod, error) {
+	name = strings.ToUpper(name)
+
+	value, ok := config.RunMethod_value[name]
+	if !ok {
+		value, ok = config.RunMethod_value[fmt.Sprintf("RUN_METHOD_%s", name)]
+	}
+
+	if !ok || value == int32(config.RunMethod_RUN_METHOD_UNSPECIFIED) {
+		return config.RunMethod_RUN_METHOD_UNSPECIFIED, fmt.Errorf("invalid run method: %s", strings.ToLower(name))
+	}
+
+	return config.RunMethod(value), nil
+}
@@ -0,0 +1,203 @@
+package runmethod
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+
+	"github.com/ethpandaops/contributoor-installer/cmd/cli/options"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/mock"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMigrateRunMethod(t *testing.T) {
+	tests := []struct {
+		name           string
+		from, to       config.RunMethod
+		setupMocks     func(*mock.MockConfigManager, *mock.MockDockerSidecar, *mock.MockSystemdSidecar, *mock.MockBinarySidecar)
+		expectedOutput string
+	}{
+		{
+			name: "docker to binary",
+			from: config.RunMethod_RUN_METHOD_DOCKER,
+			to:   config.RunMethod_RUN_METHOD_BINARY,
+			setupMocks: func(cfg *mock.MockConfigManager, d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar) {
+				d.EXPECT().IsRunning().Return(true, nil)
+				b.EXPECT().Details().Return(&runner.Details{}, nil)
+				b.EXPECT().Update().Return(nil)
+				d.EXPECT().Stop().Return(nil)
+				cfg.EXPECT().Update(gomock.Any()).Return(nil)
+				b.EXPECT().Start().Return(nil)
+				b.EXPECT().IsRunning().Return(true, nil)
+				d.EXPECT().Down().Return(nil)
+			},
+			expectedOutput: "Switched from docker to binary",
+		},
+		{
+			name: "already using the run method",
+			from: config.RunMethod_RUN_METHOD_SYSTEMD,
+			to:   config.RunMethod_RUN_METHOD_SYSTEMD,
+			setupMocks: func(cfg *mock.MockConfigManager, d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar) {
+				// Nothing should be touched.
+			},
+			expectedOutput: "Already using the systemd run method",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockConfig := mock.NewMockConfigManager(ctrl)
+			mockConfig.EXPECT().Get().Return(&config.Config{RunMethod: tt.from}).AnyTimes()
+
+			mockDocker := mock.NewMockDockerSidecar(ctrl)
+			mockSystemd := mock.NewMockSystemdSidecar(ctrl)
+			mockBinary := mock.NewMockBinarySidecar(ctrl)
+
+			tt.setupMocks(mockConfig, mockDocker, mockSystemd, mockBinary)
+
+			var out bytes.Buffer
+
+			err := migrateRunMethod(&out, mockConfig, &sidecar.Runners{
+				Docker:  mockDocker,
+				Systemd: mockSystemd,
+				Binary:  mockBinary,
+			}, tt.to)
+
+			require.NoError(t, err)
+			assert.Contains(t, out.String(), tt.expectedOutput)
+		})
+	}
+}
+
+func TestParseRunMethod(t *testing.T) {
+	tests := []struct {
+		input         string
+		expected      config.RunMethod
+		expectedError string
+	}{
+		{input: "docker", expected: config.RunMethod_RUN_METHOD_DOCKER},
+		{input: "Systemd", expected: config.RunMethod_RUN_METHOD_SYSTEMD},
+		{input: "RUN_METHOD_BINARY", expected: config.RunMethod_RUN_METHOD_BINARY},
+		{input: "unspecified", expectedError: "invalid run method: unspecified"},
+		{input: "podman", expectedError: "invalid run method: podman"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			method, err := parseRunMethod(tt.input)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, method)
+		})
+	}
+}
+
+func TestRegisterCommands(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name          string
+		configPath    string
+		args          []string
+		expectedError string
+	}{
+		{
+			name:       "successfully registers command",
+			configPath: "testdata/valid", // "testdata" is an ancillary dir provided by go-test.
+		},
+		{
+			name:          "fails without a run method",
+			configPath:    "testdata/valid",
+			expectedError: "expected exactly one argument: <run method>",
+		},
+		{
+			name:          "fails when config service fails",
+			configPath:    "/invalid/path/that/doesnt/exist",
+			args:          []string{"docker"},
+			expectedError: "error loading config",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create CLI app, with the config flag.
+			app := cli.NewApp()
+			app.Flags = []cli.Flag{
+				cli.StringFlag{
+					Name: "config-path",
+				},
+			}
+
+			// Ensure we set the config path flag.
+			globalSet := flag.NewFlagSet("test", flag.ContinueOnError)
+			globalSet.String("config-path", "", "")
+			err := globalSet.Set("config-path", tt.configPath)
+			require.NoError(t, err)
+
+			// Create the cmd context.
+			globalCtx := cli.NewContext(app, globalSet, nil)
+			app.Metadata = map[string]interface{}{
+				"flagContext": globalCtx,
+			}
+
+			// Now test!
+			RegisterCommands(
+				app,
+				options.NewCommandOpts(
+					options.WithName("migrate-run-method"),
+					options.WithLogger(logrus.New()),
+				),
+			)
+
+			if tt.expectedError != "" {
+				// Ensure the command registration succeeded.
+				assert.NoError(t, err)
+
+				// Assert that the action execution fails as expected.
+				cmd := app.Commands[0]
+
+				set := flag.NewFlagSet("migrate-run-method", flag.ContinueOnError)
+				require.NoError(t, set.Parse(tt.args))
+
+				ctx := cli.NewContext(app, set, globalCtx)
+
+				// Assert that the action is the func we expect, mainly because the linter is having a fit otherwise.
+				action, ok := cmd.Action.(func(*cli.Context) error)
+				require.True(t, ok, "expected action to be func(*cli.Context) error")
+
+				// Execute the action and assert the error.
+				actionErr := action(ctx)
+				assert.Error(t, actionErr)
+				assert.ErrorContains(t, actionErr, tt.expectedError)
+			} else {
+				// Ensure the command registration succeeded.
+				assert.NoError(t, err)
+				assert.Len(t, app.Commands, 1)
+
+				// Ensure the command is registered as expected by dumping the command.
+				cmd := app.Commands[0]
+				assert.Equal(t, "migrate-run-method", cmd.Name)
+				assert.Equal(t, "Switch Contributoor to another run method, moving the running service over", cmd.Usage)
+				assert.Equal(t, "contributoor migrate-run-method <docker|systemd|binary>", cmd.UsageText)
+				assert.NotNil(t, cmd.Action)
+			}
+		})
+	}
+}
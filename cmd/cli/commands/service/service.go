@@ -0,0 +1,153 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ethpandaops/contributoor-installer/cmd/cli/options"
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+	"github.com/ethpandaops/contributoor-installer/internal/tui"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/urfave/cli"
+)
+
+func RegisterCommands(app *cli.App, opts *options.CommandOpts) {
+	// newSystemdSidecar loads the config, and creates the sidecar managing its unit.
+	newSystemdSidecar := func(c *cli.Context) (sidecar.ConfigManager, sidecar.SystemdSidecar, error) {
+		log := opts.Logger()
+
+		sidecarCfg, err := sidecar.NewConfigService(log, c.GlobalString("config-path"))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error loading config: %w", err)
+		}
+
+		systemdSidecar, err := sidecar.NewSystemdSidecar(log, sidecarCfg, opts.InstallerConfig())
+		if err != nil {
+			return nil, nil, fmt.Errorf("error creating systemd sidecar service: %w", err)
+		}
+
+		return sidecarCfg, systemdSidecar, nil
+	}
+
+	app.Commands = append(app.Commands, cli.Command{
+		Name:      opts.Name(),
+		Aliases:   opts.Aliases(),
+		Usage:     "Manage the systemd unit file",
+		UsageText: "contributoor service [command]",
+		Subcommands: []cli.Command{
+			{
+				Name:      "diff",
+				Usage:     "Show how the installed unit file differs from the one generated from the config",
+				UsageText: "contributoor service diff",
+				Action: func(c *cli.Context) error {
+					sidecarCfg, systemdSidecar, err := newSystemdSidecar(c)
+					if err != nil {
+						return err
+					}
+
+					return diffUnit(c.App.Writer, sidecarCfg, systemdSidecar)
+				},
+			},
+			{
+				Name:      "install",
+				Aliases:   []string{"update"},
+				Usage:     "Install the unit file generated from the config, or update it if it differs",
+				UsageText: "contributoor service install [--scope system|user]",
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "scope",
+						Usage: "Install a system-wide unit, or a user unit that doesn't need root. Defaults to the current scope",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					sidecarCfg, systemdSidecar, err := newSystemdSidecar(c)
+					if err != nil {
+						return err
+					}
+
+					return installUnit(c.App.Writer, sidecarCfg, systemdSidecar, c.String("scope"))
+				},
+			},
+		},
+	})
+}
+
+// diffUnit prints a diff from the installed unit file to the generated one.
+func diffUnit(w io.Writer, sidecarCfg sidecar.ConfigManager, systemd sidecar.SystemdSidecar) error {
+	if err := checkRunMethod(sidecarCfg); err != nil {
+		return err
+	}
+
+	diff, err := systemd.UnitDiff()
+	if err != nil {
+		return err
+	}
+
+	if diff == "" {
+		fmt.Fprintln(w, "The unit file is up to date")
+
+		return nil
+	}
+
+	fmt.Fprint(w, diff)
+
+	return nil
+}
+
+// installUnit installs or updates the unit file, and explains what happened. If a
+// scope is given, the unit is switched to it first.
+func installUnit(w io.Writer, sidecarCfg sidecar.ConfigManager, systemd sidecar.SystemdSidecar, scope string) error {
+	if err := checkRunMethod(sidecarCfg); err != nil {
+		return err
+	}
+
+	if scope != "" {
+		if err := switchScope(w, sidecarCfg, systemd, scope); err != nil {
+			return err
+		}
+	}
+
+	change, err := systemd.InstallUnit()
+	if err != nil {
+		return err
+	}
+
+	switch change {
+	case runner.UnitCreated:
+		fmt.Fprintf(w, "%sInstalled the systemd unit, start it with 'contributoor start'%s\n", tui.TerminalColorGreen, tui.TerminalColorReset)
+	case runner.UnitUpdated:
+		fmt.Fprintf(w, "%sUpdated the systemd unit%s\n", tui.TerminalColorGreen, tui.TerminalColorReset)
+
+		// systemd keeps running the service as it was started, until it's restarted.
+		if running, err := systemd.IsRunning(); err == nil && running {
+			fmt.Fprintf(w, "For these changes to take effect, you must restart the service:\n")
+			fmt.Fprintf(w, "    contributoor restart\n")
+		}
+	default:
+		fmt.Fprintln(w, "The unit file is up to date")
+	}
+
+	return nil
+}
+
+// switchScope moves the unit to the given scope, removing the unit of the old scope.
+func switchScope(w io.Writer, sidecarCfg sidecar.ConfigManager, systemd sidecar.SystemdSidecar, scope string) error {
+	newScope, err := installer.ParseSystemdScope(scope)
+	if err != nil {
+		return err
+	}
+
+	return sidecar.SwitchScope(w, sidecarCfg, systemd, newScope)
+}
+
+// checkRunMethod checks the config uses systemd, the only run method with a unit file.
+func checkRunMethod(sidecarCfg sidecar.ConfigManager) error {
+	if cfg := sidecarCfg.Get(); cfg.RunMethod != config.RunMethod_RUN_METHOD_SYSTEMD {
+		return fmt.Errorf("the unit file is only used by the systemd run method, not %s", strings.ToLower(cfg.RunMethod.DisplayName()))
+	}
+
+	return nil
+}
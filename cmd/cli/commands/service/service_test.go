@@ -0,0 +1,351 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/contributoor-installer/cmd/cli/options"
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/mock"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDiffUnit(t *testing.T) {
+	const diff = `--- /etc/systemd/system/contributoor.service
++++ /etc/systemd/system/contributoor.service (generated)
+@@ -20,2 +20,3 @@
++MemoryMax=2G
+`
+
+	tests := []struct {
+		name           string
+		runMethod      config.RunMethod
+		setupMocks     func(*mock.MockSystemdSidecar)
+		expectedOutput string
+		expectedError  string
+	}{
+		{
+			name:      "prints the diff",
+			runMethod: config.RunMethod_RUN_METHOD_SYSTEMD,
+			setupMocks: func(s *mock.MockSystemdSidecar) {
+				s.EXPECT().UnitDiff().Return(diff, nil)
+			},
+			expectedOutput: diff,
+		},
+		{
+			name:      "up to date",
+			runMethod: config.RunMethod_RUN_METHOD_SYSTEMD,
+			setupMocks: func(s *mock.MockSystemdSidecar) {
+				s.EXPECT().UnitDiff().Return("", nil)
+			},
+			expectedOutput: "The unit file is up to date\n",
+		},
+		{
+			name:      "diff fails",
+			runMethod: config.RunMethod_RUN_METHOD_SYSTEMD,
+			setupMocks: func(s *mock.MockSystemdSidecar) {
+				s.EXPECT().UnitDiff().Return("", errors.New("failed to read unit file"))
+			},
+			expectedError: "failed to read unit file",
+		},
+		{
+			name:          "other run methods",
+			runMethod:     config.RunMethod_RUN_METHOD_DOCKER,
+			expectedError: "the unit file is only used by the systemd run method, not docker",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockConfig := mock.NewMockConfigManager(ctrl)
+			mockConfig.EXPECT().Get().Return(&config.Config{RunMethod: tt.runMethod}).AnyTimes()
+
+			mockSystemd := mock.NewMockSystemdSidecar(ctrl)
+			if tt.setupMocks != nil {
+				tt.setupMocks(mockSystemd)
+			}
+
+			var out bytes.Buffer
+
+			err := diffUnit(&out, mockConfig, mockSystemd)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedOutput, out.String())
+		})
+	}
+}
+
+func TestInstallUnit(t *testing.T) {
+	tests := []struct {
+		name           string
+		runMethod      config.RunMethod
+		stateScope     installer.SystemdScope
+		scope          string
+		setupMocks     func(*mock.MockSystemdSidecar)
+		expectedOutput string
+		expectedScope  installer.SystemdScope
+		expectedError  string
+	}{
+		{
+			name:      "created",
+			runMethod: config.RunMethod_RUN_METHOD_SYSTEMD,
+			setupMocks: func(s *mock.MockSystemdSidecar) {
+				s.EXPECT().InstallUnit().Return(runner.UnitCreated, nil)
+			},
+			expectedOutput: "Installed the systemd unit",
+		},
+		{
+			name:      "updated while running",
+			runMethod: config.RunMethod_RUN_METHOD_SYSTEMD,
+			setupMocks: func(s *mock.MockSystemdSidecar) {
+				s.EXPECT().InstallUnit().Return(runner.UnitUpdated, nil)
+				s.EXPECT().IsRunning().Return(true, nil)
+			},
+			expectedOutput: "contributoor restart",
+		},
+		{
+			name:      "updated while stopped",
+			runMethod: config.RunMethod_RUN_METHOD_SYSTEMD,
+			setupMocks: func(s *mock.MockSystemdSidecar) {
+				s.EXPECT().InstallUnit().Return(runner.UnitUpdated, nil)
+				s.EXPECT().IsRunning().Return(false, nil)
+			},
+			expectedOutput: "Updated the systemd unit",
+		},
+		{
+			name:      "unchanged",
+			runMethod: config.RunMethod_RUN_METHOD_SYSTEMD,
+			setupMocks: func(s *mock.MockSystemdSidecar) {
+				s.EXPECT().InstallUnit().Return(runner.UnitUnchanged, nil)
+			},
+			expectedOutput: "The unit file is up to date",
+		},
+		{
+			name:      "install fails",
+			runMethod: config.RunMethod_RUN_METHOD_SYSTEMD,
+			setupMocks: func(s *mock.MockSystemdSidecar) {
+				s.EXPECT().InstallUnit().Return(runner.UnitChange(""), errors.New("not authorized to reload contributoor.service"))
+			},
+			expectedError: "not authorized to reload contributoor.service",
+		},
+		{
+			name:      "switches to a user unit",
+			runMethod: config.RunMethod_RUN_METHOD_SYSTEMD,
+			scope:     "user",
+			setupMocks: func(s *mock.MockSystemdSidecar) {
+				gomock.InOrder(
+					s.EXPECT().IsRunning().Return(false, nil),
+					s.EXPECT().UninstallUnit().Return(nil),
+					s.EXPECT().InstallUnit().Return(runner.UnitCreated, nil),
+				)
+			},
+			expectedOutput: "Switched from a system unit to a user unit, the system unit was removed",
+			expectedScope:  installer.SystemdScopeUser,
+		},
+		{
+			name:       "already the scope",
+			runMethod:  config.RunMethod_RUN_METHOD_SYSTEMD,
+			stateScope: installer.SystemdScopeUser,
+			scope:      "user",
+			setupMocks: func(s *mock.MockSystemdSidecar) {
+				s.EXPECT().InstallUnit().Return(runner.UnitUnchanged, nil)
+			},
+			expectedOutput: "The unit file is up to date",
+			expectedScope:  installer.SystemdScopeUser,
+		},
+		{
+			name:      "switching while running",
+			runMethod: config.RunMethod_RUN_METHOD_SYSTEMD,
+			scope:     "user",
+			setupMocks: func(s *mock.MockSystemdSidecar) {
+				gomock.InOrder(
+					s.EXPECT().IsRunning().Return(true, nil),
+					s.EXPECT().Stop().Return(nil),
+					s.EXPECT().UninstallUnit().Return(nil),
+					s.EXPECT().InstallUnit().Return(runner.UnitCreated, nil),
+				)
+			},
+			expectedOutput: "Stopping the system unit",
+			expectedScope:  installer.SystemdScopeUser,
+		},
+		{
+			name:      "old unit can't be removed",
+			runMethod: config.RunMethod_RUN_METHOD_SYSTEMD,
+			scope:     "user",
+			setupMocks: func(s *mock.MockSystemdSidecar) {
+				s.EXPECT().IsRunning().Return(false, nil)
+				s.EXPECT().UninstallUnit().Return(errors.New("permission denied"))
+			},
+			expectedError: "failed to remove the system unit, so it's kept: permission denied",
+		},
+		{
+			name:          "invalid scope",
+			runMethod:     config.RunMethod_RUN_METHOD_SYSTEMD,
+			scope:         "global",
+			expectedError: `invalid systemd scope "global", expected system or user`,
+		},
+		{
+			name:          "other run methods",
+			runMethod:     config.RunMethod_RUN_METHOD_BINARY,
+			expectedError: "the unit file is only used by the systemd run method",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			configDir := t.TempDir()
+
+			if tt.stateScope != "" {
+				state, err := installer.LoadState(configDir)
+				require.NoError(t, err)
+
+				state.SystemdScope = tt.stateScope
+				require.NoError(t, state.Save())
+			}
+
+			mockConfig := mock.NewMockConfigManager(ctrl)
+			mockConfig.EXPECT().Get().Return(&config.Config{RunMethod: tt.runMethod}).AnyTimes()
+			mockConfig.EXPECT().GetConfigPath().Return(filepath.Join(configDir, "config.yaml")).AnyTimes()
+
+			mockSystemd := mock.NewMockSystemdSidecar(ctrl)
+			if tt.setupMocks != nil {
+				tt.setupMocks(mockSystemd)
+			}
+
+			var out bytes.Buffer
+
+			err := installUnit(&out, mockConfig, mockSystemd, tt.scope)
+
+			state, serr := installer.LoadState(configDir)
+			require.NoError(t, serr)
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+
+				// The scope is left alone.
+				assert.Equal(t, installer.SystemdScopeSystem, state.GetSystemdScope())
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Contains(t, out.String(), tt.expectedOutput)
+
+			if tt.expectedScope != "" {
+				assert.Equal(t, tt.expectedScope, state.GetSystemdScope())
+			}
+
+			if tt.name == "updated while stopped" {
+				assert.NotContains(t, out.String(), "contributoor restart")
+			}
+		})
+	}
+}
+
+func TestRegisterCommands(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name          string
+		configPath    string
+		expectedError string
+	}{
+		{
+			name:       "successfully registers command",
+			configPath: "testdata/valid", // "testdata" is an ancillary dir provided by go-test.
+		},
+		{
+			name:          "fails when config service fails",
+			configPath:    "/invalid/path/that/doesnt/exist",
+			expectedError: "error loading config",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create CLI app, with the config flag.
+			app := cli.NewApp()
+			app.Flags = []cli.Flag{
+				cli.StringFlag{
+					Name: "config-path",
+				},
+			}
+
+			// Ensure we set the config path flag.
+			globalSet := flag.NewFlagSet("test", flag.ContinueOnError)
+			globalSet.String("config-path", "", "")
+			err := globalSet.Set("config-path", tt.configPath)
+			require.NoError(t, err)
+
+			// Create the cmd context.
+			globalCtx := cli.NewContext(app, globalSet, nil)
+			app.Metadata = map[string]interface{}{
+				"flagContext": globalCtx,
+			}
+
+			// Now test!
+			RegisterCommands(
+				app,
+				options.NewCommandOpts(
+					options.WithName("service"),
+					options.WithLogger(logrus.New()),
+				),
+			)
+
+			if tt.expectedError != "" {
+				// Ensure the command registration succeeded.
+				assert.NoError(t, err)
+
+				// Assert that each subcommand's action fails as expected.
+				for _, cmd := range app.Commands[0].Subcommands {
+					ctx := cli.NewContext(app, nil, globalCtx)
+
+					// Assert that the action is the func we expect, mainly because the linter is having a fit otherwise.
+					action, ok := cmd.Action.(func(*cli.Context) error)
+					require.True(t, ok, "expected action to be func(*cli.Context) error")
+
+					// Execute the action and assert the error.
+					actionErr := action(ctx)
+					assert.Error(t, actionErr)
+					assert.ErrorContains(t, actionErr, tt.expectedError)
+				}
+			} else {
+				// Ensure the command registration succeeded.
+				assert.NoError(t, err)
+				assert.Len(t, app.Commands, 1)
+
+				// Ensure the command is registered as expected by dumping the command.
+				cmd := app.Commands[0]
+				assert.Equal(t, "service", cmd.Name)
+				assert.Equal(t, "Manage the systemd unit file", cmd.Usage)
+				assert.Equal(t, "contributoor service [command]", cmd.UsageText)
+				require.Len(t, cmd.Subcommands, 2)
+				assert.Equal(t, "diff", cmd.Subcommands[0].Name)
+				assert.Equal(t, "install", cmd.Subcommands[1].Name)
+				assert.Equal(t, []string{"update"}, cmd.Subcommands[1].Aliases)
+			}
+		})
+	}
+}
@@ -1,23 +1,98 @@
 package status
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/ethpandaops/contributoor-installer/cmd/cli/options"
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/semver"
 	"github.com/ethpandaops/contributoor-installer/internal/service"
 	"github.com/ethpandaops/contributoor-installer/internal/sidecar"
 	"github.com/ethpandaops/contributoor-installer/internal/tui"
 	"github.com/ethpandaops/contributoor/pkg/config/v1"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
+	"gopkg.in/yaml.v3"
 )
 
+// Exit codes, so scripts can tell the state of contributoor without parsing output.
+// Any other failure exits with 1.
+const (
+	exitCodeRunning      = 0
+	exitCodeStopped      = 3
+	exitCodeNotInstalled = 4
+)
+
+// States reported by the status command.
+const (
+	stateRunning      = "running"
+	stateStopped      = "stopped"
+	stateNotInstalled = "not_installed"
+)
+
+// Output formats supported by the status command.
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+// statusSchemaVersion is bumped whenever a field in Status is removed or changes meaning.
+// New fields may be added without bumping it.
+const statusSchemaVersion = 1
+
+// Status is the machine readable output of the status command.
+type Status struct {
+	SchemaVersion   int           `json:"schemaVersion" yaml:"schemaVersion"`
+	State           string        `json:"state" yaml:"state"`
+	Running         bool          `json:"running" yaml:"running"`
+	Version         string        `json:"version" yaml:"version"`
+	LatestVersion   string        `json:"latestVersion" yaml:"latestVersion"`
+	UpdateAvailable bool          `json:"updateAvailable" yaml:"updateAvailable"`
+	Channel         string        `json:"channel,omitempty" yaml:"channel,omitempty"`
+	RunMethod       string        `json:"runMethod" yaml:"runMethod"`
+	Network         string        `json:"network" yaml:"network"`
+	BeaconNode      string        `json:"beaconNode" yaml:"beaconNode"`
+	OutputServer    string        `json:"outputServer" yaml:"outputServer"`
+	ConfigPath      string        `json:"configPath" yaml:"configPath"`
+	Details         StatusDetails `json:"details" yaml:"details"`
+}
+
+// StatusDetails holds run method specific details. Fields that don't apply to the run
+// method in use are omitted.
+type StatusDetails struct {
+	PID              int    `json:"pid,omitempty" yaml:"pid,omitempty"`
+	Restarts         int    `json:"restarts,omitempty" yaml:"restarts,omitempty"`
+	LastExitCode     *int   `json:"lastExitCode,omitempty" yaml:"lastExitCode,omitempty"`
+	ContainerID      string `json:"containerId,omitempty" yaml:"containerId,omitempty"`
+	ContainerHealth  string `json:"containerHealth,omitempty" yaml:"containerHealth,omitempty"`
+	ImageDigest      string `json:"imageDigest,omitempty" yaml:"imageDigest,omitempty"`
+	PinnedDigest     string `json:"pinnedDigest,omitempty" yaml:"pinnedDigest,omitempty"`
+	ContainerRuntime string `json:"containerRuntime,omitempty" yaml:"containerRuntime,omitempty"`
+	UnitScope        string `json:"unitScope,omitempty" yaml:"unitScope,omitempty"`
+	UnitState        string `json:"unitState,omitempty" yaml:"unitState,omitempty"`
+	UnitSubState     string `json:"unitSubState,omitempty" yaml:"unitSubState,omitempty"`
+}
+
 func RegisterCommands(app *cli.App, opts *options.CommandOpts) {
 	app.Commands = append(app.Commands, cli.Command{
 		Name:      opts.Name(),
 		Aliases:   opts.Aliases(),
 		Usage:     "Show Contributoor status",
 		UsageText: "contributoor status [options]",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "output, o",
+				Usage: "Output `format` (text, json or yaml)",
+				Value: outputText,
+			},
+		},
 		Action: func(c *cli.Context) error {
 			var (
 				log          = opts.Logger()
@@ -26,6 +101,13 @@ func RegisterCommands(app *cli.App, opts *options.CommandOpts) {
 
 			sidecarCfg, err := sidecar.NewConfigService(log, c.GlobalString("config-path"))
 			if err != nil {
+				if errors.Is(err, sidecar.ErrConfigNotFound) {
+					return printStatus(os.Stdout, &Status{
+						SchemaVersion: statusSchemaVersion,
+						State:         stateNotInstalled,
+					}, c.String("output"))
+				}
+
 				return fmt.Errorf("error loading config: %w", err)
 			}
 
@@ -44,7 +126,7 @@ func RegisterCommands(app *cli.App, opts *options.CommandOpts) {
 				return fmt.Errorf("error creating binary sidecar service: %w", err)
 			}
 
-			githubService, err := service.NewGitHubService(log, installerCfg)
+			githubService, err := service.NewGitHubService(log, installerCfg, filepath.Dir(sidecarCfg.GetConfigPath()))
 			if err != nil {
 				return fmt.Errorf("error creating github service: %w", err)
 			}
@@ -63,6 +145,23 @@ func showStatus(
 	binary sidecar.BinarySidecar,
 	github service.GitHubService,
 ) error {
+	status, err := buildStatus(log, sidecarCfg, docker, systemd, binary, github)
+	if err != nil {
+		return err
+	}
+
+	return printStatus(os.Stdout, status, c.String("output"))
+}
+
+// buildStatus gathers the status of contributoor, for the configured run method.
+func buildStatus(
+	log *logrus.Logger,
+	sidecarCfg sidecar.ConfigManager,
+	docker sidecar.DockerSidecar,
+	systemd sidecar.SystemdSidecar,
+	binary sidecar.BinarySidecar,
+	github service.GitHubService,
+) (*Status, error) {
 	var (
 		runner sidecar.SidecarRunner
 		cfg    = sidecarCfg.Get()
@@ -77,50 +176,212 @@ func showStatus(
 	case config.RunMethod_RUN_METHOD_BINARY:
 		runner = binary
 	default:
-		return fmt.Errorf("invalid sidecar run method: %s", cfg.RunMethod)
+		return nil, fmt.Errorf("invalid sidecar run method: %s", cfg.RunMethod)
 	}
 
 	// Check if running.
 	running, err := runner.IsRunning()
 	if err != nil {
-		return fmt.Errorf("failed to check status: %w", err)
+		return nil, fmt.Errorf("failed to check status: %w", err)
 	}
 
-	// Check if there's a newer version available.
-	var latestVersionLine string
+	status := &Status{
+		SchemaVersion: statusSchemaVersion,
+		State:         stateStopped,
+		Running:       running,
+		Version:       cfg.Version,
+		RunMethod:     strings.ToLower(cfg.RunMethod.DisplayName()),
+		Network:       strings.ToLower(strings.TrimPrefix(cfg.NetworkName.String(), "NETWORK_NAME_")),
+		BeaconNode:    cfg.BeaconNodeAddress,
+		ConfigPath:    sidecarCfg.GetConfigPath(),
+	}
 
-	latestVersion, err := github.GetLatestVersion()
-	if err == nil && cfg.Version != latestVersion {
-		latestVersionLine = fmt.Sprintf("%-20s: %s%s%s", "Latest Version", tui.TerminalColorYellow, latestVersion, tui.TerminalColorReset)
+	if running {
+		status.State = stateRunning
 	}
 
-	// Print status information.
-	fmt.Printf("%sContributoor Status%s\n", tui.TerminalColorLightBlue, tui.TerminalColorReset)
-	fmt.Printf("%-20s: %s\n", "Version", cfg.Version)
+	if cfg.OutputServer != nil {
+		status.OutputServer = cfg.OutputServer.Address
+	}
 
-	if latestVersionLine != "" {
-		fmt.Printf("%s\n", latestVersionLine)
+	// The channel is a nice to have too, the latest version is looked up on stable
+	// without it.
+	channel := installer.ChannelStable
+
+	if state, serr := installer.LoadState(filepath.Dir(status.ConfigPath)); serr == nil {
+		channel = state.GetChannel()
+		status.Channel = string(channel)
+	} else {
+		log.Warnf("Failed to load installer state: %v", serr)
 	}
 
-	fmt.Printf("%-20s: %s\n", "Run Method", cfg.RunMethod)
-	fmt.Printf("%-20s: %s\n", "Network", cfg.NetworkName)
-	fmt.Printf("%-20s: %s\n", "Beacon Node", cfg.BeaconNodeAddress)
-	fmt.Printf("%-20s: %s\n", "Config Path", sidecarCfg.GetConfigPath())
+	// Check if there's a newer version available on the channel. One that's yet to be
+	// resolved, eg: latest, can't be compared, it's resolved by the next update.
+	if latestVersion, err := github.GetLatestVersion(channel); err == nil {
+		status.LatestVersion = latestVersion
+		status.UpdateAvailable = !service.IsUnresolved(cfg.Version) && isNewer(latestVersion, cfg.Version)
+	}
 
-	if cfg.OutputServer != nil {
-		fmt.Printf("%-20s: %s\n", "Output Server", cfg.OutputServer.Address)
+	// Details are a nice to have, don't fail the whole status without them.
+	details, err := runner.Details()
+	if err != nil {
+		log.Warnf("Failed to get %s details: %v", status.RunMethod, err)
+
+		return status, nil
+	}
+
+	if !details.Installed && !running {
+		status.State = stateNotInstalled
+	}
+
+	status.Details = StatusDetails{
+		PID:              details.PID,
+		Restarts:         details.Restarts,
+		LastExitCode:     details.LastExitCode,
+		ContainerID:      details.ContainerID,
+		ContainerHealth:  details.ContainerHealth,
+		ImageDigest:      details.ImageDigest,
+		PinnedDigest:     details.PinnedDigest,
+		ContainerRuntime: details.ContainerRuntime,
+		UnitScope:        details.UnitScope,
+		UnitState:        details.UnitState,
+		UnitSubState:     details.UnitSubState,
+	}
+
+	return status, nil
+}
+
+// printStatus writes the status in the given format. The returned error carries the exit
+// code for the state, so it's returned even when printing succeeds.
+func printStatus(w io.Writer, status *Status, output string) error {
+	switch output {
+	case outputJSON:
+		data, err := json.MarshalIndent(status, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal status: %w", err)
+		}
+
+		fmt.Fprintf(w, "%s\n", data)
+	case outputYAML:
+		data, err := yaml.Marshal(status)
+		if err != nil {
+			return fmt.Errorf("failed to marshal status: %w", err)
+		}
+
+		fmt.Fprintf(w, "%s", data)
+	case outputText, "":
+		printStatusText(w, status)
+	default:
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+
+	switch status.State {
+	case stateRunning:
+		return nil
+	case stateStopped:
+		return cli.NewExitError("", exitCodeStopped)
+	default:
+		return cli.NewExitError("", exitCodeNotInstalled)
+	}
+}
+
+// printStatusText writes the status for humans.
+func printStatusText(w io.Writer, status *Status) {
+	fmt.Fprintf(w, "%sContributoor Status%s\n", tui.TerminalColorLightBlue, tui.TerminalColorReset)
+
+	if status.State == stateNotInstalled && status.Version == "" {
+		fmt.Fprintf(w, "%-20s: %sNot installed%s\n", "Status", tui.TerminalColorRed, tui.TerminalColorReset)
+
+		return
+	}
+
+	if status.Version == service.VersionLatest {
+		fmt.Fprintf(w, "%-20s: %s %s(unresolved, 'contributoor update' resolves it)%s\n", "Version", status.Version, tui.TerminalColorYellow, tui.TerminalColorReset)
+	} else {
+		fmt.Fprintf(w, "%-20s: %s\n", "Version", status.Version)
+	}
+
+	if status.UpdateAvailable {
+		fmt.Fprintf(w, "%-20s: %s%s%s\n", "Latest Version", tui.TerminalColorYellow, status.LatestVersion, tui.TerminalColorReset)
+	}
+
+	if status.Channel != "" {
+		fmt.Fprintf(w, "%-20s: %s\n", "Channel", status.Channel)
+	}
+
+	fmt.Fprintf(w, "%-20s: %s\n", "Run Method", status.RunMethod)
+	fmt.Fprintf(w, "%-20s: %s\n", "Network", status.Network)
+	fmt.Fprintf(w, "%-20s: %s\n", "Beacon Node", status.BeaconNode)
+	fmt.Fprintf(w, "%-20s: %s\n", "Config Path", status.ConfigPath)
+
+	if status.OutputServer != "" {
+		fmt.Fprintf(w, "%-20s: %s\n", "Output Server", status.OutputServer)
+	}
+
+	if status.Details.PID != 0 {
+		fmt.Fprintf(w, "%-20s: %d\n", "PID", status.Details.PID)
+	}
+
+	if status.Details.Restarts != 0 {
+		fmt.Fprintf(w, "%-20s: %d\n", "Restarts", status.Details.Restarts)
+	}
+
+	if status.Details.LastExitCode != nil {
+		fmt.Fprintf(w, "%-20s: %d\n", "Last Exit Code", *status.Details.LastExitCode)
+	}
+
+	if status.Details.ContainerID != "" {
+		fmt.Fprintf(w, "%-20s: %s\n", "Container ID", status.Details.ContainerID)
+	}
+
+	if status.Details.ContainerHealth != "" {
+		fmt.Fprintf(w, "%-20s: %s\n", "Container Health", status.Details.ContainerHealth)
+	}
+
+	if status.Details.ImageDigest != "" {
+		fmt.Fprintf(w, "%-20s: %s\n", "Image Digest", status.Details.ImageDigest)
+	}
+
+	if status.Details.PinnedDigest != "" {
+		fmt.Fprintf(w, "%-20s: %s\n", "Pinned Digest", status.Details.PinnedDigest)
+	}
+
+	if status.Details.ContainerRuntime != "" {
+		fmt.Fprintf(w, "%-20s: %s\n", "Container Runtime", status.Details.ContainerRuntime)
+	}
+
+	if status.Details.UnitScope != "" {
+		fmt.Fprintf(w, "%-20s: %s\n", "Unit Scope", status.Details.UnitScope)
+	}
+
+	if status.Details.UnitState != "" && status.Details.UnitSubState != "" {
+		fmt.Fprintf(w, "%-20s: %s (%s)\n", "Unit State", status.Details.UnitState, status.Details.UnitSubState)
+	} else if status.Details.UnitState != "" {
+		fmt.Fprintf(w, "%-20s: %s\n", "Unit State", status.Details.UnitState)
 	}
 
 	// Print running status with color
 	statusColor := tui.TerminalColorRed
 	statusText := "Stopped"
 
-	if running {
+	switch status.State {
+	case stateRunning:
 		statusColor = tui.TerminalColorGreen
 		statusText = "Running"
+	case stateNotInstalled:
+		statusText = "Not installed"
 	}
 
-	fmt.Printf("%-20s: %s%s%s\n", "Status", statusColor, statusText, tui.TerminalColorReset)
+	fmt.Fprintf(w, "%-20s: %s%s%s\n", "Status", statusColor, statusText, tui.TerminalColorReset)
+}
+
+// isNewer returns whether version orders after current, eg: 1.1.0 after 1.1.0-rc.1.
+// Versions that aren't semver are newer whenever they differ.
+func isNewer(version, current string) bool {
+	c, err := semver.Compare(version, current)
+	if err != nil {
+		return version != current
+	}
 
-	return nil
+	return c > 0
 }
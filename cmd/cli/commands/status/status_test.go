@@ -1,18 +1,47 @@
 package status
 
 import (
+	"bytes"
+	"flag"
 	"fmt"
+	"path/filepath"
 	"testing"
 
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
 	servicemock "github.com/ethpandaops/contributoor-installer/internal/service/mock"
 	"github.com/ethpandaops/contributoor-installer/internal/sidecar/mock"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
 	"github.com/ethpandaops/contributoor/pkg/config/v1"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/urfave/cli"
 	"go.uber.org/mock/gomock"
 )
 
+func newContext(t *testing.T, output string) *cli.Context {
+	t.Helper()
+
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	set.String("output", output, "")
+
+	return cli.NewContext(nil, set, nil)
+}
+
+func assertExitCode(t *testing.T, err error, expected int) {
+	t.Helper()
+
+	if expected == exitCodeRunning {
+		assert.NoError(t, err)
+
+		return
+	}
+
+	var exitErr cli.ExitCoder
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, expected, exitErr.ExitCode())
+}
+
 func TestShowStatus(t *testing.T) {
 	t.Run("shows status for running docker sidecar", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
@@ -34,6 +63,7 @@ func TestShowStatus(t *testing.T) {
 		// Create mock docker sidecar that's running
 		mockDocker := mock.NewMockDockerSidecar(ctrl)
 		mockDocker.EXPECT().IsRunning().Return(true, nil)
+		mockDocker.EXPECT().Details().Return(&runner.Details{Installed: true, ContainerID: "abc123"}, nil)
 
 		// Create mock binary sidecar (shouldn't be used)
 		mockBinary := mock.NewMockBinarySidecar(ctrl)
@@ -43,10 +73,10 @@ func TestShowStatus(t *testing.T) {
 
 		// Create mock GitHub service
 		mockGithub := servicemock.NewMockGitHubService(ctrl)
-		mockGithub.EXPECT().GetLatestVersion().Return("1.0.1", nil)
+		mockGithub.EXPECT().GetLatestVersion(installer.ChannelStable).Return("1.0.1", nil)
 
 		err := showStatus(
-			cli.NewContext(nil, nil, nil),
+			newContext(t, "text"),
 			logrus.New(),
 			mockConfig,
 			mockDocker,
@@ -78,16 +108,17 @@ func TestShowStatus(t *testing.T) {
 		// Create mock binary sidecar that's stopped
 		mockBinary := mock.NewMockBinarySidecar(ctrl)
 		mockBinary.EXPECT().IsRunning().Return(false, nil)
+		mockBinary.EXPECT().Details().Return(&runner.Details{Installed: true}, nil)
 
 		// Create mock systemd sidecar (shouldn't be used)
 		mockSystemd := mock.NewMockSystemdSidecar(ctrl)
 
 		// Create mock GitHub service with same version (shouldn't show update)
 		mockGithub := servicemock.NewMockGitHubService(ctrl)
-		mockGithub.EXPECT().GetLatestVersion().Return("1.0.0", nil)
+		mockGithub.EXPECT().GetLatestVersion(installer.ChannelStable).Return("1.0.0", nil)
 
 		err := showStatus(
-			cli.NewContext(nil, nil, nil),
+			newContext(t, "text"),
 			logrus.New(),
 			mockConfig,
 			mockDocker,
@@ -96,7 +127,7 @@ func TestShowStatus(t *testing.T) {
 			mockGithub,
 		)
 
-		assert.NoError(t, err)
+		assertExitCode(t, err, exitCodeStopped)
 	})
 
 	t.Run("handles github service error gracefully", func(t *testing.T) {
@@ -113,14 +144,15 @@ func TestShowStatus(t *testing.T) {
 
 		mockDocker := mock.NewMockDockerSidecar(ctrl)
 		mockDocker.EXPECT().IsRunning().Return(true, nil)
+		mockDocker.EXPECT().Details().Return(&runner.Details{Installed: true}, nil)
 		mockSystemd := mock.NewMockSystemdSidecar(ctrl)
 
 		// Create mock GitHub service that returns an error
 		mockGithub := servicemock.NewMockGitHubService(ctrl)
-		mockGithub.EXPECT().GetLatestVersion().Return("", fmt.Errorf("github error"))
+		mockGithub.EXPECT().GetLatestVersion(installer.ChannelStable).Return("", fmt.Errorf("github error"))
 
 		err := showStatus(
-			cli.NewContext(nil, nil, nil),
+			newContext(t, "text"),
 			logrus.New(),
 			mockConfig,
 			mockDocker,
@@ -143,7 +175,7 @@ func TestShowStatus(t *testing.T) {
 		})
 
 		err := showStatus(
-			cli.NewContext(nil, nil, nil),
+			newContext(t, "text"),
 			logrus.New(),
 			mockConfig,
 			nil,
@@ -156,3 +188,286 @@ func TestShowStatus(t *testing.T) {
 		assert.Contains(t, err.Error(), "invalid sidecar run method")
 	})
 }
+
+func TestBuildStatus(t *testing.T) {
+	cfg := &config.Config{
+		Version:           "1.0.0",
+		RunMethod:         config.RunMethod_RUN_METHOD_SYSTEMD,
+		NetworkName:       config.NetworkName_NETWORK_NAME_HOLESKY,
+		BeaconNodeAddress: "http://localhost:5052",
+		OutputServer: &config.OutputServer{
+			Address: "https://output.server",
+		},
+	}
+
+	tests := []struct {
+		name       string
+		setupMocks func(*mock.MockSystemdSidecar)
+		expected   *Status
+	}{
+		{
+			name: "running",
+			setupMocks: func(s *mock.MockSystemdSidecar) {
+				s.EXPECT().IsRunning().Return(true, nil)
+				s.EXPECT().Details().Return(&runner.Details{
+					Installed:    true,
+					PID:          1234,
+					UnitScope:    "user",
+					UnitState:    "active",
+					UnitSubState: "running",
+				}, nil)
+			},
+			expected: &Status{
+				SchemaVersion:   statusSchemaVersion,
+				State:           stateRunning,
+				Running:         true,
+				Version:         "1.0.0",
+				LatestVersion:   "1.0.1",
+				UpdateAvailable: true,
+				Channel:         "stable",
+				RunMethod:       "systemd",
+				Network:         "holesky",
+				BeaconNode:      "http://localhost:5052",
+				OutputServer:    "https://output.server",
+				ConfigPath:      "/path/to/config.yaml",
+				Details:         StatusDetails{PID: 1234, UnitScope: "user", UnitState: "active", UnitSubState: "running"},
+			},
+		},
+		{
+			name: "not installed",
+			setupMocks: func(s *mock.MockSystemdSidecar) {
+				s.EXPECT().IsRunning().Return(false, nil)
+				s.EXPECT().Details().Return(&runner.Details{}, nil)
+			},
+			expected: &Status{
+				SchemaVersion:   statusSchemaVersion,
+				State:           stateNotInstalled,
+				Version:         "1.0.0",
+				LatestVersion:   "1.0.1",
+				UpdateAvailable: true,
+				Channel:         "stable",
+				RunMethod:       "systemd",
+				Network:         "holesky",
+				BeaconNode:      "http://localhost:5052",
+				OutputServer:    "https://output.server",
+				ConfigPath:      "/path/to/config.yaml",
+			},
+		},
+		{
+			name: "details unavailable",
+			setupMocks: func(s *mock.MockSystemdSidecar) {
+				s.EXPECT().IsRunning().Return(false, nil)
+				s.EXPECT().Details().Return(nil, fmt.Errorf("boom"))
+			},
+			expected: &Status{
+				SchemaVersion:   statusSchemaVersion,
+				State:           stateStopped,
+				Version:         "1.0.0",
+				LatestVersion:   "1.0.1",
+				UpdateAvailable: true,
+				Channel:         "stable",
+				RunMethod:       "systemd",
+				Network:         "holesky",
+				BeaconNode:      "http://localhost:5052",
+				OutputServer:    "https://output.server",
+				ConfigPath:      "/path/to/config.yaml",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockConfig := mock.NewMockConfigManager(ctrl)
+			mockConfig.EXPECT().Get().Return(cfg).AnyTimes()
+			mockConfig.EXPECT().GetConfigPath().Return("/path/to/config.yaml")
+
+			mockSystemd := mock.NewMockSystemdSidecar(ctrl)
+			tt.setupMocks(mockSystemd)
+
+			mockGithub := servicemock.NewMockGitHubService(ctrl)
+			mockGithub.EXPECT().GetLatestVersion(installer.ChannelStable).Return("1.0.1", nil)
+
+			status, err := buildStatus(
+				logrus.New(),
+				mockConfig,
+				mock.NewMockDockerSidecar(ctrl),
+				mockSystemd,
+				mock.NewMockBinarySidecar(ctrl),
+				mockGithub,
+			)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, status)
+		})
+	}
+}
+
+func TestBuildStatus_UnresolvedVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir := t.TempDir()
+
+	state, err := installer.LoadState(dir)
+	require.NoError(t, err)
+
+	state.Channel = installer.ChannelPinned
+	require.NoError(t, state.Save())
+
+	mockConfig := mock.NewMockConfigManager(ctrl)
+	mockConfig.EXPECT().Get().Return(&config.Config{
+		Version:     "latest",
+		RunMethod:   config.RunMethod_RUN_METHOD_DOCKER,
+		NetworkName: config.NetworkName_NETWORK_NAME_MAINNET,
+	}).AnyTimes()
+	mockConfig.EXPECT().GetConfigPath().Return(filepath.Join(dir, "config.yaml"))
+
+	mockDocker := mock.NewMockDockerSidecar(ctrl)
+	mockDocker.EXPECT().IsRunning().Return(true, nil)
+	mockDocker.EXPECT().Details().Return(&runner.Details{Installed: true}, nil)
+
+	mockGithub := servicemock.NewMockGitHubService(ctrl)
+	mockGithub.EXPECT().GetLatestVersion(installer.ChannelPinned).Return("1.0.1", nil)
+
+	status, err := buildStatus(logrus.New(), mockConfig, mockDocker, mock.NewMockSystemdSidecar(ctrl), mock.NewMockBinarySidecar(ctrl), mockGithub)
+	require.NoError(t, err)
+
+	// Latest can't be compared with a release, it's resolved by the next update.
+	assert.Equal(t, "1.0.1", status.LatestVersion)
+	assert.False(t, status.UpdateAvailable)
+	assert.Equal(t, "pinned", status.Channel)
+
+	var out bytes.Buffer
+
+	require.NoError(t, printStatus(&out, status, outputText))
+	assert.Contains(t, out.String(), "(unresolved, 'contributoor update' resolves it)")
+	assert.Contains(t, out.String(), fmt.Sprintf("%-20s: %s\n", "Channel", "pinned"))
+}
+
+func TestPrintStatus(t *testing.T) {
+	status := &Status{
+		SchemaVersion: statusSchemaVersion,
+		State:         stateRunning,
+		Running:       true,
+		Version:       "1.0.0",
+		LatestVersion: "1.0.0",
+		RunMethod:     "docker",
+		Network:       "mainnet",
+		BeaconNode:    "http://localhost:5052",
+		ConfigPath:    "/path/to/config.yaml",
+		Details:       StatusDetails{ContainerID: "abc123"},
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+
+		require.NoError(t, printStatus(&out, status, outputJSON))
+		assert.JSONEq(t, `{
+			"schemaVersion": 1,
+			"state": "running",
+			"running": true,
+			"version": "1.0.0",
+			"latestVersion": "1.0.0",
+			"updateAvailable": false,
+			"runMethod": "docker",
+			"network": "mainnet",
+			"beaconNode": "http://localhost:5052",
+			"outputServer": "",
+			"configPath": "/path/to/config.yaml",
+			"details": {"containerId": "abc123"}
+		}`, out.String())
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		var out bytes.Buffer
+
+		require.NoError(t, printStatus(&out, status, outputYAML))
+		assert.YAMLEq(t, `
+schemaVersion: 1
+state: running
+running: true
+version: 1.0.0
+latestVersion: 1.0.0
+updateAvailable: false
+runMethod: docker
+network: mainnet
+beaconNode: http://localhost:5052
+outputServer: ""
+configPath: /path/to/config.yaml
+details:
+  containerId: abc123
+`, out.String())
+	})
+
+	t.Run("text with supervisor details", func(t *testing.T) {
+		var (
+			out      bytes.Buffer
+			exitCode = 1
+		)
+
+		binaryStatus := *status
+		binaryStatus.RunMethod = "binary"
+		binaryStatus.Details = StatusDetails{PID: 1234, Restarts: 2, LastExitCode: &exitCode}
+
+		require.NoError(t, printStatus(&out, &binaryStatus, outputText))
+		assert.Contains(t, out.String(), fmt.Sprintf("%-20s: %d\n", "Restarts", 2))
+		assert.Contains(t, out.String(), fmt.Sprintf("%-20s: %d\n", "Last Exit Code", 1))
+	})
+
+	t.Run("text with container details", func(t *testing.T) {
+		var out bytes.Buffer
+
+		dockerStatus := *status
+		dockerStatus.Details = StatusDetails{
+			ContainerID:      "abc123",
+			ContainerHealth:  "healthy",
+			ImageDigest:      "sha256:4b2c",
+			PinnedDigest:     "sha256:4b2c",
+			ContainerRuntime: "podman 5.2.2 (rootless)",
+		}
+
+		require.NoError(t, printStatus(&out, &dockerStatus, outputText))
+		assert.Contains(t, out.String(), fmt.Sprintf("%-20s: %s\n", "Container Health", "healthy"))
+		assert.Contains(t, out.String(), fmt.Sprintf("%-20s: %s\n", "Image Digest", "sha256:4b2c"))
+		assert.Contains(t, out.String(), fmt.Sprintf("%-20s: %s\n", "Pinned Digest", "sha256:4b2c"))
+		assert.Contains(t, out.String(), fmt.Sprintf("%-20s: %s\n", "Container Runtime", "podman 5.2.2 (rootless)"))
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		assert.ErrorContains(t, printStatus(&bytes.Buffer{}, status, "xml"), "unsupported output format: xml")
+	})
+
+	t.Run("exit codes", func(t *testing.T) {
+		for state, code := range map[string]int{
+			stateRunning:      exitCodeRunning,
+			stateStopped:      exitCodeStopped,
+			stateNotInstalled: exitCodeNotInstalled,
+		} {
+			assertExitCode(t, printStatus(&bytes.Buffer{}, &Status{State: state}, outputJSON), code)
+		}
+	})
+}
+
+func TestIsNewer(t *testing.T) {
+	tests := []struct {
+		version  string
+		current  string
+		expected bool
+	}{
+		{version: "1.0.1", current: "1.0.0", expected: true},
+		{version: "1.0.0", current: "1.0.0", expected: false},
+		{version: "1.1.0", current: "1.1.0-rc.1", expected: true},
+		{version: "1.1.0-rc.1", current: "1.1.0-rc.2", expected: false},
+		{version: "1.0.0", current: "v1.0.0", expected: false},
+		{version: "1.0.0", current: "custom", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version+" "+tt.current, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isNewer(tt.version, tt.current))
+		})
+	}
+}
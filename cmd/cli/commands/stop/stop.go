@@ -17,6 +17,16 @@ func RegisterCommands(app *cli.App, opts *options.CommandOpts) {
 		Aliases:   opts.Aliases(),
 		Usage:     "Stop Contributoor",
 		UsageText: "contributoor stop [options]",
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "down",
+				Usage: "Remove the container once stopped, keeping its image (docker only)",
+			},
+			cli.BoolFlag{
+				Name:  "purge",
+				Usage: "Remove the container, its volumes and the images once stopped (docker only)",
+			},
+		},
 		Action: func(c *cli.Context) error {
 			var (
 				log          = opts.Logger()
@@ -75,6 +85,19 @@ func stopContributoor(
 		return fmt.Errorf("invalid sidecar run method: %s", cfg.RunMethod)
 	}
 
+	// Removing the container applies whether or not it's running.
+	if c.Bool("down") || c.Bool("purge") {
+		if cfg.RunMethod == config.RunMethod_RUN_METHOD_DOCKER {
+			if c.Bool("purge") {
+				return docker.Purge()
+			}
+
+			return docker.Down()
+		}
+
+		fmt.Printf("%s--down and --purge only apply to the docker run method, stopping as usual%s\n", tui.TerminalColorYellow, tui.TerminalColorReset)
+	}
+
 	// Check if running before attempting to stop.
 	running, err := runner.IsRunning()
 	if err != nil {
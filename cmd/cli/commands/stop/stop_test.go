@@ -22,6 +22,7 @@ func TestStopContributoor(t *testing.T) {
 	tests := []struct {
 		name          string
 		runMethod     config.RunMethod
+		args          []string
 		setupMocks    func(*mock.MockConfigManager, *mock.MockDockerSidecar, *mock.MockBinarySidecar)
 		expectedError string
 	}{
@@ -59,6 +60,41 @@ func TestStopContributoor(t *testing.T) {
 			},
 			expectedError: "stop failed",
 		},
+		{
+			name:      "docker - down removes the stopped container",
+			runMethod: config.RunMethod_RUN_METHOD_DOCKER,
+			args:      []string{"--down"},
+			setupMocks: func(cfg *mock.MockConfigManager, d *mock.MockDockerSidecar, b *mock.MockBinarySidecar) {
+				cfg.EXPECT().Get().Return(&config.Config{
+					RunMethod: config.RunMethod_RUN_METHOD_DOCKER,
+				}).Times(1)
+				d.EXPECT().Down().Return(nil)
+			},
+		},
+		{
+			name:      "docker - purge",
+			runMethod: config.RunMethod_RUN_METHOD_DOCKER,
+			args:      []string{"--down", "--purge"},
+			setupMocks: func(cfg *mock.MockConfigManager, d *mock.MockDockerSidecar, b *mock.MockBinarySidecar) {
+				cfg.EXPECT().Get().Return(&config.Config{
+					RunMethod: config.RunMethod_RUN_METHOD_DOCKER,
+				}).Times(1)
+				d.EXPECT().Purge().Return(errors.New("image is in use"))
+			},
+			expectedError: "image is in use",
+		},
+		{
+			name:      "binary - purge just stops",
+			runMethod: config.RunMethod_RUN_METHOD_BINARY,
+			args:      []string{"--purge"},
+			setupMocks: func(cfg *mock.MockConfigManager, d *mock.MockDockerSidecar, b *mock.MockBinarySidecar) {
+				cfg.EXPECT().Get().Return(&config.Config{
+					RunMethod: config.RunMethod_RUN_METHOD_BINARY,
+				}).Times(1)
+				b.EXPECT().IsRunning().Return(true, nil)
+				b.EXPECT().Stop().Return(nil)
+			},
+		},
 		{
 			name:      "binary - stops running service successfully",
 			runMethod: config.RunMethod_RUN_METHOD_BINARY,
@@ -101,8 +137,13 @@ func TestStopContributoor(t *testing.T) {
 
 			tt.setupMocks(mockConfig, mockDocker, mockBinary)
 
+			set := flag.NewFlagSet("stop", flag.ContinueOnError)
+			set.Bool("down", false, "")
+			set.Bool("purge", false, "")
+			require.NoError(t, set.Parse(tt.args))
+
 			app := cli.NewApp()
-			ctx := cli.NewContext(app, nil, nil)
+			ctx := cli.NewContext(app, set, nil)
 
 			err := stopContributoor(ctx, logrus.New(), mockConfig, mockDocker, mockSystemd, mockBinary)
 
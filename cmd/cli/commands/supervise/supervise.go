@@ -0,0 +1,66 @@
+package supervise
+
+import (
+	"context"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"github.com/ethpandaops/contributoor-installer/cmd/cli/options"
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar"
+	"github.com/ethpandaops/contributoor-installer/internal/supervisor"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli"
+)
+
+func RegisterCommands(app *cli.App, opts *options.CommandOpts) {
+	app.Commands = append(app.Commands, cli.Command{
+		Name:      opts.Name(),
+		Aliases:   opts.Aliases(),
+		Usage:     "Run the Contributoor binary in the foreground, restarting it if it exits",
+		UsageText: "contributoor supervise [options]",
+		Action: func(c *cli.Context) error {
+			var (
+				log          = opts.Logger()
+				installerCfg = opts.InstallerConfig()
+			)
+
+			sidecarCfg, err := sidecar.NewConfigService(log, c.GlobalString("config-path"))
+			if err != nil {
+				return fmt.Errorf("error loading config: %w", err)
+			}
+
+			// The supervisor's output ends up in a log file, rather than a terminal.
+			log.SetFormatter(&logrus.TextFormatter{DisableColors: true, FullTimestamp: true})
+
+			// Replace the CLI's own signal handling, which exits immediately, so sentry is
+			// stopped along with the supervisor.
+			signal.Reset(syscall.SIGINT, syscall.SIGTERM)
+
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			return supervise(ctx, log, sidecarCfg, installerCfg)
+		},
+	})
+}
+
+func supervise(
+	ctx context.Context,
+	log *logrus.Logger,
+	sidecarCfg sidecar.ConfigManager,
+	installerCfg *installer.Config,
+) error {
+	if cfg := sidecarCfg.Get(); cfg.RunMethod != config.RunMethod_RUN_METHOD_BINARY {
+		return fmt.Errorf("supervise is only used by the binary run method, not %s", cfg.RunMethod.DisplayName())
+	}
+
+	supervisorCfg, err := sidecar.SupervisorConfig(sidecarCfg, installerCfg)
+	if err != nil {
+		return err
+	}
+
+	return supervisor.New(log, supervisorCfg).Run(ctx)
+}
@@ -0,0 +1,154 @@
+package supervise
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethpandaops/contributoor-installer/cmd/cli/options"
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/mock"
+	"github.com/ethpandaops/contributoor-installer/internal/supervisor"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSupervise(t *testing.T) {
+	t.Run("supervises the binary until cancelled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		dir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "bin"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "bin", "sentry"), []byte("#!/bin/sh\nexec sleep 60\n"), 0700))
+
+		mockConfig := mock.NewMockConfigManager(ctrl)
+		mockConfig.EXPECT().Get().Return(&config.Config{
+			ContributoorDirectory: dir,
+			RunMethod:             config.RunMethod_RUN_METHOD_BINARY,
+		}).AnyTimes()
+		mockConfig.EXPECT().GetConfigPath().Return(filepath.Join(dir, "config.yaml")).AnyTimes()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+
+		go func() {
+			done <- supervise(ctx, logrus.New(), mockConfig, installer.NewConfig())
+		}()
+
+		socketPath := filepath.Join(dir, "supervisor.sock")
+
+		require.Eventually(t, func() bool {
+			status, err := supervisor.GetStatus(socketPath)
+
+			return err == nil && status.PID != 0
+		}, 5*time.Second, 10*time.Millisecond)
+
+		cancel()
+		require.NoError(t, <-done)
+		assert.NoFileExists(t, socketPath)
+	})
+
+	t.Run("fails for other run methods", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		mockConfig := mock.NewMockConfigManager(ctrl)
+		mockConfig.EXPECT().Get().Return(&config.Config{
+			RunMethod: config.RunMethod_RUN_METHOD_DOCKER,
+		}).AnyTimes()
+
+		err := supervise(context.Background(), logrus.New(), mockConfig, installer.NewConfig())
+		assert.ErrorContains(t, err, "supervise is only used by the binary run method")
+	})
+}
+
+func TestRegisterCommands(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name          string
+		configPath    string
+		expectedError string
+	}{
+		{
+			name:       "successfully registers command",
+			configPath: "testdata/valid", // "testdata" is an ancillary dir provided by go-test.
+		},
+		{
+			name:          "fails when config service fails",
+			configPath:    "/invalid/path/that/doesnt/exist",
+			expectedError: "error loading config",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create CLI app, with the config flag.
+			app := cli.NewApp()
+			app.Flags = []cli.Flag{
+				cli.StringFlag{
+					Name: "config-path",
+				},
+			}
+
+			// Ensure we set the config path flag.
+			globalSet := flag.NewFlagSet("test", flag.ContinueOnError)
+			globalSet.String("config-path", "", "")
+			err := globalSet.Set("config-path", tt.configPath)
+			require.NoError(t, err)
+
+			// Create the cmd context.
+			globalCtx := cli.NewContext(app, globalSet, nil)
+			app.Metadata = map[string]interface{}{
+				"flagContext": globalCtx,
+			}
+
+			// Now test!
+			RegisterCommands(
+				app,
+				options.NewCommandOpts(
+					options.WithName("supervise"),
+					options.WithLogger(logrus.New()),
+				),
+			)
+
+			if tt.expectedError != "" {
+				// Ensure the command registration succeeded.
+				assert.NoError(t, err)
+
+				// Assert that the action execution fails as expected.
+				cmd := app.Commands[0]
+				ctx := cli.NewContext(app, nil, globalCtx)
+
+				// Assert that the action is the func we expect, mainly because the linter is having a fit otherwise.
+				action, ok := cmd.Action.(func(*cli.Context) error)
+				require.True(t, ok, "expected action to be func(*cli.Context) error")
+
+				// Execute the action and assert the error.
+				actionErr := action(ctx)
+				assert.Error(t, actionErr)
+				assert.ErrorContains(t, actionErr, tt.expectedError)
+			} else {
+				// Ensure the command registration succeeded.
+				assert.NoError(t, err)
+				assert.Len(t, app.Commands, 1)
+
+				// Ensure the command is registered as expected by dumping the command.
+				cmd := app.Commands[0]
+				assert.Equal(t, "supervise", cmd.Name)
+				assert.Equal(t, "Run the Contributoor binary in the foreground, restarting it if it exits", cmd.Usage)
+				assert.Equal(t, "contributoor supervise [options]", cmd.UsageText)
+				assert.NotNil(t, cmd.Action)
+			}
+		})
+	}
+}
@@ -1,9 +1,14 @@
 package update
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
+	"slices"
 
 	"github.com/ethpandaops/contributoor-installer/cmd/cli/options"
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/semver"
 	"github.com/ethpandaops/contributoor-installer/internal/service"
 	"github.com/ethpandaops/contributoor-installer/internal/sidecar"
 	"github.com/ethpandaops/contributoor-installer/internal/tui"
@@ -24,6 +29,18 @@ func RegisterCommands(app *cli.App, opts *options.CommandOpts) {
 				Usage: "The contributoor version to update to",
 				Value: "latest",
 			},
+			cli.StringFlag{
+				Name:  "channel",
+				Usage: "The release channel to follow from now on: stable, beta, nightly or pinned",
+			},
+			cli.DurationFlag{
+				Name:  "health-check-timeout",
+				Usage: "How long to watch Contributoor after updating before it's considered healthy, 0 to disable",
+			},
+			cli.StringFlag{
+				Name:  "health-check-url",
+				Usage: "Sentry endpoint (eg: its metrics endpoint) that must respond during the health check",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			var (
@@ -51,12 +68,31 @@ func RegisterCommands(app *cli.App, opts *options.CommandOpts) {
 				return fmt.Errorf("error creating binary sidecar service: %w", err)
 			}
 
-			githubService, err := service.NewGitHubService(log, installerCfg)
+			githubService, err := service.NewGitHubService(log, installerCfg, filepath.Dir(sidecarCfg.GetConfigPath()))
 			if err != nil {
 				return fmt.Errorf("error creating github service: %w", err)
 			}
 
-			return updateContributoor(c, log, sidecarCfg, dockerSidecar, systemdSidecar, binarySidecar, githubService)
+			state, err := installer.LoadState(filepath.Dir(sidecarCfg.GetConfigPath()))
+			if err != nil {
+				return fmt.Errorf("error loading installer state: %w", err)
+			}
+
+			healthCheck := sidecar.HealthCheck{
+				Timeout:  installerCfg.HealthCheckTimeout,
+				Interval: installerCfg.HealthCheckInterval,
+				URL:      installerCfg.HealthCheckURL,
+			}
+
+			if c.IsSet("health-check-timeout") {
+				healthCheck.Timeout = c.Duration("health-check-timeout")
+			}
+
+			if c.IsSet("health-check-url") {
+				healthCheck.URL = c.String("health-check-url")
+			}
+
+			return updateContributoor(c, log, installerCfg, sidecarCfg, state, healthCheck, dockerSidecar, systemdSidecar, binarySidecar, githubService)
 		},
 	})
 }
@@ -64,7 +100,10 @@ func RegisterCommands(app *cli.App, opts *options.CommandOpts) {
 func updateContributoor(
 	c *cli.Context,
 	log *logrus.Logger,
+	installerCfg *installer.Config,
 	sidecarCfg sidecar.ConfigManager,
+	state *installer.State,
+	healthCheck sidecar.HealthCheck,
 	docker sidecar.DockerSidecar,
 	systemd sidecar.SystemdSidecar,
 	binary sidecar.BinarySidecar,
@@ -72,9 +111,11 @@ func updateContributoor(
 ) error {
 	var (
 		success        bool
+		configRestored bool
 		targetVersion  string
 		cfg            = sidecarCfg.Get()
 		currentVersion = cfg.Version
+		prevVersions   = slices.Clone(state.PreviousVersions)
 	)
 
 	fmt.Printf("%sUpdating Contributoor Version%s\n", tui.TerminalColorLightBlue, tui.TerminalColorReset)
@@ -82,14 +123,22 @@ func updateContributoor(
 
 	defer func() {
 		if !success {
-			if err := rollbackVersion(sidecarCfg, currentVersion); err != nil {
-				log.Error(err)
+			if !configRestored {
+				if err := rollbackVersion(sidecarCfg, currentVersion); err != nil {
+					log.Error(err)
+				}
+			}
+
+			if !slices.Equal(state.PreviousVersions, prevVersions) {
+				if err := restorePreviousVersions(state, prevVersions); err != nil {
+					log.Error(err)
+				}
 			}
 		}
 	}()
 
 	// Determine target version.
-	targetVersion, err := determineTargetVersion(c, github)
+	targetVersion, err := determineTargetVersion(c, state, github)
 	if err != nil || targetVersion == "" {
 		// Flag as success, there's nothing to update on rollback if we fail to determine the target version.
 		success = true
@@ -109,24 +158,118 @@ func updateContributoor(
 		return nil
 	}
 
+	// A channel never moves backwards, eg: from an rc to the stable release before it,
+	// only an explicit version does.
+	if !c.IsSet("version") && isOlder(targetVersion, currentVersion) {
+		// Flag as success, there's nothing to update.
+		success = true
+
+		fmt.Printf(
+			"%sContributoor is on version %s, which is newer than the %s channel. It's updated once the channel moves past it%s\n",
+			tui.TerminalColorYellow,
+			currentVersion,
+			state.GetChannel(),
+			tui.TerminalColorReset,
+		)
+
+		return nil
+	}
+
 	// Update config version.
 	if uerr := updateConfigVersion(sidecarCfg, targetVersion); uerr != nil {
 		return uerr
 	}
 
+	// Record the version we're moving away from, so it can be rolled back to later.
+	if serr := recordPreviousVersion(state, currentVersion, installerCfg.VersionRetention); serr != nil {
+		return serr
+	}
+
 	// Refresh our config state, given it was updated above.
 	cfg = sidecarCfg.Get()
 
-	// Update the sidecar.
-	success, err = updateSidecar(log, cfg, docker, systemd, binary)
-	if err != nil {
+	// Update the sidecar. A sidecar that doesn't start on the new version has failed
+	// its health check before it began.
+	success, started, herr := updateSidecar(log, cfg, docker, systemd, binary)
+	if herr != nil && !errors.Is(herr, errStartFailed) {
+		return herr
+	}
+
+	runner := sidecarRunner(cfg, docker, systemd, binary)
+
+	if herr == nil {
+		// Only a sidecar we've started on the new version can be health checked.
+		if !success || !started || !healthCheck.Enabled() {
+			return nil
+		}
+
+		fmt.Printf("\n%sWatching Contributoor for %s to ensure it stays healthy%s\n", tui.TerminalColorLightBlue, healthCheck.Timeout, tui.TerminalColorReset)
+
+		herr = sidecar.WaitHealthy(runner, healthCheck)
+		if herr == nil {
+			fmt.Printf("%sContributoor is healthy on version %s%s\n", tui.TerminalColorGreen, targetVersion, tui.TerminalColorReset)
+
+			return nil
+		}
+	}
+
+	// The new version is bad, put the previous version back.
+	success = false
+
+	fmt.Printf("%sContributoor failed its health check on version %s: %v%s\n", tui.TerminalColorRed, targetVersion, herr, tui.TerminalColorReset)
+	fmt.Printf("%sRolling back to version %s%s\n", tui.TerminalColorYellow, currentVersion, tui.TerminalColorReset)
+
+	configRestored = true
+
+	if rerr := restoreSidecar(sidecarCfg, runner, currentVersion); rerr != nil {
+		return fmt.Errorf("version %s failed its health check (%w), and rolling back to %s failed: %w", targetVersion, herr, currentVersion, rerr)
+	}
+
+	fmt.Printf("%sContributoor rolled back and restarted on version %s%s\n", tui.TerminalColorGreen, currentVersion, tui.TerminalColorReset)
+
+	return fmt.Errorf("version %s failed its health check and was rolled back: %w", targetVersion, herr)
+}
+
+// errStartFailed is returned when the sidecar was updated, but didn't start on the new
+// version.
+var errStartFailed = errors.New("failed to start sidecar")
+
+func sidecarRunner(cfg *config.Config, docker sidecar.DockerSidecar, systemd sidecar.SystemdSidecar, binary sidecar.BinarySidecar) sidecar.SidecarRunner {
+	switch cfg.RunMethod {
+	case config.RunMethod_RUN_METHOD_DOCKER:
+		return docker
+	case config.RunMethod_RUN_METHOD_SYSTEMD:
+		return systemd
+	default:
+		return binary
+	}
+}
+
+// restoreSidecar stops the sidecar, switches it back to the given version and starts it again.
+func restoreSidecar(sidecarCfg sidecar.ConfigManager, runner sidecar.SidecarRunner, version string) error {
+	if running, _ := runner.IsRunning(); running {
+		if err := runner.Stop(); err != nil {
+			return fmt.Errorf("failed to stop sidecar: %w", err)
+		}
+	}
+
+	if err := runner.Rollback(version); err != nil {
+		return fmt.Errorf("failed to roll back sidecar: %w", err)
+	}
+
+	// The config version must be restored before starting, as runners start whichever version it holds.
+	if err := rollbackVersion(sidecarCfg, version); err != nil {
 		return err
 	}
 
+	if err := runner.Start(); err != nil {
+		return fmt.Errorf("failed to start sidecar: %w", err)
+	}
+
 	return nil
 }
 
-func updateSidecar(log *logrus.Logger, cfg *config.Config, docker sidecar.DockerSidecar, systemd sidecar.SystemdSidecar, binary sidecar.BinarySidecar) (bool, error) {
+func updateSidecar(log *logrus.Logger, cfg *config.Config, docker sidecar.DockerSidecar, systemd sidecar.SystemdSidecar, binary sidecar.BinarySidecar) (bool, bool, error) {
 	switch cfg.RunMethod {
 	case config.RunMethod_RUN_METHOD_DOCKER:
 		return updateDocker(log, cfg, docker)
@@ -135,30 +278,30 @@ func updateSidecar(log *logrus.Logger, cfg *config.Config, docker sidecar.Docker
 	case config.RunMethod_RUN_METHOD_BINARY:
 		return updateBinary(log, cfg, binary)
 	default:
-		return false, fmt.Errorf("invalid sidecar run method: %s", cfg.RunMethod)
+		return false, false, fmt.Errorf("invalid sidecar run method: %s", cfg.RunMethod)
 	}
 }
 
-func updateSystemd(log *logrus.Logger, cfg *config.Config, systemd sidecar.SystemdSidecar) (bool, error) {
+func updateSystemd(log *logrus.Logger, cfg *config.Config, systemd sidecar.SystemdSidecar) (bool, bool, error) {
 	// Check if sidecar is currently running.
 	running, err := systemd.IsRunning()
 	if err != nil {
 		log.Errorf("could not check sidecar status: %v", err)
 
-		return false, err
+		return false, false, err
 	}
 
 	// If the sidecar is running, we need to stop it before we can update the binary.
 	if running {
 		if err := systemd.Stop(); err != nil {
-			return false, fmt.Errorf("failed to stop sidecar: %w", err)
+			return false, false, fmt.Errorf("failed to stop sidecar: %w", err)
 		}
 	}
 
 	if err := systemd.Update(); err != nil {
 		log.Errorf("could not update sidecar: %v", err)
 
-		return false, err
+		return false, false, err
 	}
 
 	fmt.Printf("%sContributoor updated successfully to version %s%s\n", tui.TerminalColorGreen, cfg.Version, tui.TerminalColorReset)
@@ -166,20 +309,20 @@ func updateSystemd(log *logrus.Logger, cfg *config.Config, systemd sidecar.Syste
 	// If it was running, start it again for them.
 	if running {
 		if err := systemd.Start(); err != nil {
-			return true, fmt.Errorf("failed to start sidecar: %w", err)
+			return true, false, fmt.Errorf("%w: %w", errStartFailed, err)
 		}
 	}
 
-	return true, nil
+	return true, running, nil
 }
 
-func updateBinary(log *logrus.Logger, cfg *config.Config, binary sidecar.BinarySidecar) (bool, error) {
+func updateBinary(log *logrus.Logger, cfg *config.Config, binary sidecar.BinarySidecar) (bool, bool, error) {
 	// Check if sidecar is currently running.
 	running, err := binary.IsRunning()
 	if err != nil {
 		log.Errorf("could not check sidecar status: %v", err)
 
-		return false, err
+		return false, false, err
 	}
 
 	// If the sidecar is running, we need to stop it before we can update the binary.
@@ -188,19 +331,19 @@ func updateBinary(log *logrus.Logger, cfg *config.Config, binary sidecar.BinaryS
 
 		if tui.Confirm("Contributoor is running. In order to update, it must be stopped. Would you like to stop it?") {
 			if err := binary.Stop(); err != nil {
-				return false, fmt.Errorf("failed to stop sidecar: %w", err)
+				return false, false, fmt.Errorf("failed to stop sidecar: %w", err)
 			}
 		} else {
 			fmt.Printf("%sUpdate process was cancelled%s\n", tui.TerminalColorRed, tui.TerminalColorReset)
 
-			return false, nil
+			return false, false, nil
 		}
 	}
 
 	if err := binary.Update(); err != nil {
 		log.Errorf("could not update sidecar: %v", err)
 
-		return false, err
+		return false, false, err
 	}
 
 	fmt.Printf("%sContributoor updated successfully to version %s%s\n", tui.TerminalColorGreen, cfg.Version, tui.TerminalColorReset)
@@ -208,18 +351,18 @@ func updateBinary(log *logrus.Logger, cfg *config.Config, binary sidecar.BinaryS
 	// If it was running, start it again for them.
 	if running {
 		if err := binary.Start(); err != nil {
-			return true, fmt.Errorf("failed to start sidecar: %w", err)
+			return true, false, fmt.Errorf("%w: %w", errStartFailed, err)
 		}
 	}
 
-	return true, nil
+	return true, running, nil
 }
 
-func updateDocker(log *logrus.Logger, cfg *config.Config, docker sidecar.DockerSidecar) (bool, error) {
+func updateDocker(log *logrus.Logger, cfg *config.Config, docker sidecar.DockerSidecar) (bool, bool, error) {
 	if err := docker.Update(); err != nil {
 		log.Errorf("could not update service: %v", err)
 
-		return false, err
+		return false, false, err
 	}
 
 	fmt.Printf("%sContributoor updated successfully to version %s%s\n", tui.TerminalColorGreen, cfg.Version, tui.TerminalColorReset)
@@ -229,7 +372,7 @@ func updateDocker(log *logrus.Logger, cfg *config.Config, docker sidecar.DockerS
 	if err != nil {
 		log.Errorf("could not check sidecar status: %v", err)
 
-		return true, err
+		return true, false, err
 	}
 
 	fmt.Printf("\n")
@@ -238,28 +381,38 @@ func updateDocker(log *logrus.Logger, cfg *config.Config, docker sidecar.DockerS
 	if running {
 		if tui.Confirm("Contributoor is running. Would you like to restart it with the new version?") {
 			if err := docker.Stop(); err != nil {
-				return true, fmt.Errorf("failed to stop sidecar: %w", err)
+				return true, false, fmt.Errorf("failed to stop sidecar: %w", err)
 			}
 
 			if err := docker.Start(); err != nil {
-				return true, fmt.Errorf("failed to start sidecar: %w", err)
+				return true, false, fmt.Errorf("%w: %w", errStartFailed, err)
 			}
-		} else {
-			fmt.Printf("%sContributoor will continue running with the previous version until next restart%s\n", tui.TerminalColorYellow, tui.TerminalColorReset)
+
+			return true, true, nil
 		}
+
+		fmt.Printf("%sContributoor will continue running with the previous version until next restart%s\n", tui.TerminalColorYellow, tui.TerminalColorReset)
 	} else {
 		if tui.Confirm("Contributoor is not running. Would you like to start it?") {
 			if err := docker.Start(); err != nil {
-				return true, fmt.Errorf("failed to start service: %w", err)
+				return true, false, fmt.Errorf("%w: %w", errStartFailed, err)
 			}
+
+			return true, true, nil
 		}
 	}
 
-	return true, nil
+	return true, false, nil
 }
 
-func determineTargetVersion(c *cli.Context, github service.GitHubService) (string, error) {
-	if c.IsSet("version") {
+func determineTargetVersion(c *cli.Context, state *installer.State, github service.GitHubService) (string, error) {
+	if c.IsSet("channel") {
+		if err := switchChannel(state, c.String("channel")); err != nil {
+			return "", err
+		}
+	}
+
+	if c.IsSet("version") && !service.IsUnresolved(c.String("version")) {
 		version := c.String("version")
 
 		exists, err := github.VersionExists(version)
@@ -281,7 +434,19 @@ func determineTargetVersion(c *cli.Context, github service.GitHubService) (strin
 		return version, nil
 	}
 
-	version, err := github.GetLatestVersion()
+	// The pinned channel only moves when it's told where to.
+	if !c.IsSet("version") && state.GetChannel() == installer.ChannelPinned {
+		fmt.Printf(
+			"%sContributoor is on the %s channel. Use 'contributoor update --version' to move to another version%s\n",
+			tui.TerminalColorYellow,
+			installer.ChannelPinned,
+			tui.TerminalColorReset,
+		)
+
+		return "", nil
+	}
+
+	version, err := github.GetLatestVersion(state.GetChannel())
 	if err != nil {
 		return "", fmt.Errorf("failed to get latest version: %w", err)
 	}
@@ -289,6 +454,36 @@ func determineTargetVersion(c *cli.Context, github service.GitHubService) (strin
 	return version, nil
 }
 
+// switchChannel saves the channel to follow in the installer state.
+func switchChannel(state *installer.State, value string) error {
+	channel, err := installer.ParseChannel(value)
+	if err != nil {
+		return err
+	}
+
+	if channel == state.GetChannel() {
+		return nil
+	}
+
+	state.Channel = channel
+
+	if err := state.Save(); err != nil {
+		return fmt.Errorf("could not save installer state: %w", err)
+	}
+
+	fmt.Printf("%-20s: %s\n", "Channel", channel)
+
+	return nil
+}
+
+// isOlder returns whether version orders before current. Versions that aren't semver,
+// eg: latest, are never older.
+func isOlder(version, current string) bool {
+	c, err := semver.Compare(version, current)
+
+	return err == nil && c < 0
+}
+
 func updateConfigVersion(sidecarCfg sidecar.ConfigManager, version string) error {
 	if err := sidecarCfg.Update(func(cfg *config.Config) {
 		cfg.Version = version
@@ -317,6 +512,26 @@ func rollbackVersion(sidecarCfg sidecar.ConfigManager, version string) error {
 	return nil
 }
 
+func recordPreviousVersion(state *installer.State, version string, retention int) error {
+	state.PushVersion(version, retention)
+
+	if err := state.Save(); err != nil {
+		return fmt.Errorf("could not save installer state: %w", err)
+	}
+
+	return nil
+}
+
+func restorePreviousVersions(state *installer.State, versions []string) error {
+	state.PreviousVersions = versions
+
+	if err := state.Save(); err != nil {
+		return fmt.Errorf("failed to save installer state after version rollback: %w", err)
+	}
+
+	return nil
+}
+
 func printUpdateStatus(isVersionSet bool, version string) {
 	if isVersionSet {
 		fmt.Printf(
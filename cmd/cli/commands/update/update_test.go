@@ -4,9 +4,12 @@ import (
 	"errors"
 	"flag"
 	"testing"
+	"time"
 
 	"github.com/ethpandaops/contributoor-installer/cmd/cli/options"
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
 	smock "github.com/ethpandaops/contributoor-installer/internal/service/mock"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar"
 	"github.com/ethpandaops/contributoor-installer/internal/sidecar/mock"
 	"github.com/ethpandaops/contributoor-installer/internal/tui"
 	"github.com/ethpandaops/contributoor/pkg/config/v1"
@@ -33,12 +36,17 @@ func TestUpdateContributoor(t *testing.T) {
 	confirmResponse = true
 
 	tests := []struct {
-		name          string
-		runMethod     config.RunMethod
-		version       string
-		confirmPrompt bool
-		setupMocks    func(*mock.MockConfigManager, *mock.MockDockerSidecar, *mock.MockSystemdSidecar, *mock.MockBinarySidecar, *smock.MockGitHubService)
-		expectedError string
+		name             string
+		runMethod        config.RunMethod
+		version          string
+		channel          installer.Channel
+		channelFlag      string
+		confirmPrompt    bool
+		setupMocks       func(*mock.MockConfigManager, *mock.MockDockerSidecar, *mock.MockSystemdSidecar, *mock.MockBinarySidecar, *smock.MockGitHubService)
+		healthCheck      sidecar.HealthCheck
+		expectedError    string
+		expectedPrevious string
+		expectedChannel  installer.Channel
 	}{
 		{
 			name:          "docker - updates service successfully",
@@ -49,7 +57,7 @@ func TestUpdateContributoor(t *testing.T) {
 					RunMethod: config.RunMethod_RUN_METHOD_DOCKER,
 					Version:   "v1.0.0",
 				}).Times(2)
-				g.EXPECT().GetLatestVersion().Return("v1.1.0", nil)
+				g.EXPECT().GetLatestVersion(installer.ChannelStable).Return("v1.1.0", nil)
 
 				// Expect a call to update, which in-turn updates + saves the config.
 				d.EXPECT().Update().Return(nil)
@@ -63,6 +71,7 @@ func TestUpdateContributoor(t *testing.T) {
 				d.EXPECT().Stop().Return(nil)
 				d.EXPECT().Start().Return(nil)
 			},
+			expectedPrevious: "v1.0.0",
 		},
 		{
 			name:      "docker - already at latest version",
@@ -72,7 +81,7 @@ func TestUpdateContributoor(t *testing.T) {
 					RunMethod: config.RunMethod_RUN_METHOD_DOCKER,
 					Version:   "v1.0.0",
 				}).Times(1)
-				g.EXPECT().GetLatestVersion().Return("v1.0.0", nil)
+				g.EXPECT().GetLatestVersion(installer.ChannelStable).Return("v1.0.0", nil)
 			},
 		},
 		{
@@ -83,7 +92,7 @@ func TestUpdateContributoor(t *testing.T) {
 					RunMethod: config.RunMethod_RUN_METHOD_DOCKER,
 					Version:   "v1.0.0",
 				}).Times(2)
-				g.EXPECT().GetLatestVersion().Return("v1.1.0", nil)
+				g.EXPECT().GetLatestVersion(installer.ChannelStable).Return("v1.1.0", nil)
 
 				// Expect a call to update, which in-turn updates + saves the config.
 				d.EXPECT().Update().Return(errors.New("update failed"))
@@ -120,6 +129,7 @@ func TestUpdateContributoor(t *testing.T) {
 				d.EXPECT().Stop().Return(nil)
 				d.EXPECT().Start().Return(nil)
 			},
+			expectedPrevious: "v1.0.0",
 		},
 		{
 			name:      "specific version - does not exist",
@@ -133,6 +143,61 @@ func TestUpdateContributoor(t *testing.T) {
 				g.EXPECT().VersionExists("v999.0.0").Return(false, nil)
 			},
 		},
+		{
+			name:      "pinned channel - stays on the installed version",
+			runMethod: config.RunMethod_RUN_METHOD_DOCKER,
+			channel:   installer.ChannelPinned,
+			setupMocks: func(cfg *mock.MockConfigManager, d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar, g *smock.MockGitHubService) {
+				cfg.EXPECT().Get().Return(&config.Config{
+					RunMethod: config.RunMethod_RUN_METHOD_DOCKER,
+					Version:   "v1.0.0",
+				}).Times(1)
+			},
+		},
+		{
+			name:          "pinned channel - moves to a given version",
+			version:       "v1.1.0",
+			channel:       installer.ChannelPinned,
+			confirmPrompt: true,
+			runMethod:     config.RunMethod_RUN_METHOD_DOCKER,
+			setupMocks: func(cfg *mock.MockConfigManager, d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar, g *smock.MockGitHubService) {
+				cfg.EXPECT().Get().Return(&config.Config{
+					RunMethod: config.RunMethod_RUN_METHOD_DOCKER,
+					Version:   "v1.0.0",
+				}).Times(2)
+				g.EXPECT().VersionExists("v1.1.0").Return(true, nil)
+
+				d.EXPECT().Update().Return(nil)
+				cfg.EXPECT().Update(gomock.Any()).Return(nil)
+				cfg.EXPECT().Save().Return(nil)
+
+				d.EXPECT().IsRunning().Return(true, nil)
+				d.EXPECT().Stop().Return(nil)
+				d.EXPECT().Start().Return(nil)
+			},
+			expectedPrevious: "v1.0.0",
+		},
+		{
+			name:          "latest version - resolved to the newest release",
+			version:       "latest",
+			confirmPrompt: true,
+			runMethod:     config.RunMethod_RUN_METHOD_DOCKER,
+			setupMocks: func(cfg *mock.MockConfigManager, d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar, g *smock.MockGitHubService) {
+				cfg.EXPECT().Get().Return(&config.Config{
+					RunMethod: config.RunMethod_RUN_METHOD_DOCKER,
+					Version:   "latest",
+				}).Times(2)
+				g.EXPECT().GetLatestVersion(installer.ChannelStable).Return("v1.1.0", nil)
+
+				d.EXPECT().Update().Return(nil)
+				cfg.EXPECT().Update(gomock.Any()).Return(nil)
+				cfg.EXPECT().Save().Return(nil)
+
+				d.EXPECT().IsRunning().Return(false, nil)
+				d.EXPECT().Start().Return(nil)
+			},
+			expectedPrevious: "latest",
+		},
 		{
 			name:          "binary - updates service successfully",
 			runMethod:     config.RunMethod_RUN_METHOD_BINARY,
@@ -142,7 +207,7 @@ func TestUpdateContributoor(t *testing.T) {
 					RunMethod: config.RunMethod_RUN_METHOD_BINARY,
 					Version:   "v1.0.0",
 				}).Times(2)
-				g.EXPECT().GetLatestVersion().Return("v1.1.0", nil)
+				g.EXPECT().GetLatestVersion(installer.ChannelStable).Return("v1.1.0", nil)
 
 				// Expect a call to update, which in-turn updates + saves the config.
 				b.EXPECT().Update().Return(nil)
@@ -156,6 +221,7 @@ func TestUpdateContributoor(t *testing.T) {
 				b.EXPECT().Stop().Return(nil)
 				b.EXPECT().Start().Return(nil)
 			},
+			expectedPrevious: "v1.0.0",
 		},
 		{
 			name:      "binary - already at latest version",
@@ -165,7 +231,7 @@ func TestUpdateContributoor(t *testing.T) {
 					RunMethod: config.RunMethod_RUN_METHOD_BINARY,
 					Version:   "v1.0.0",
 				}).Times(1)
-				g.EXPECT().GetLatestVersion().Return("v1.0.0", nil)
+				g.EXPECT().GetLatestVersion(installer.ChannelStable).Return("v1.0.0", nil)
 			},
 		},
 		{
@@ -177,7 +243,7 @@ func TestUpdateContributoor(t *testing.T) {
 					RunMethod: config.RunMethod_RUN_METHOD_BINARY,
 					Version:   "v1.0.0",
 				}).Times(2)
-				g.EXPECT().GetLatestVersion().Return("v1.1.0", nil)
+				g.EXPECT().GetLatestVersion(installer.ChannelStable).Return("v1.1.0", nil)
 
 				// Expect check if service is running.
 				b.EXPECT().IsRunning().Return(false, nil)
@@ -193,6 +259,159 @@ func TestUpdateContributoor(t *testing.T) {
 			},
 			expectedError: "update failed",
 		},
+		{
+			name:          "binary - passes health check",
+			runMethod:     config.RunMethod_RUN_METHOD_BINARY,
+			confirmPrompt: true,
+			setupMocks: func(cfg *mock.MockConfigManager, d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar, g *smock.MockGitHubService) {
+				cfg.EXPECT().Get().Return(&config.Config{
+					RunMethod: config.RunMethod_RUN_METHOD_BINARY,
+					Version:   "v1.0.0",
+				}).Times(2)
+				g.EXPECT().GetLatestVersion(installer.ChannelStable).Return("v1.1.0", nil)
+
+				b.EXPECT().IsRunning().Return(true, nil)
+				b.EXPECT().Stop().Return(nil)
+				b.EXPECT().Update().Return(nil)
+				cfg.EXPECT().Update(gomock.Any()).Return(nil)
+				cfg.EXPECT().Save().Return(nil)
+				b.EXPECT().Start().Return(nil)
+
+				// The health check expects the service to stay up.
+				b.EXPECT().IsRunning().Return(true, nil).MinTimes(1)
+			},
+			healthCheck:      sidecar.HealthCheck{Timeout: 20 * time.Millisecond, Interval: 5 * time.Millisecond},
+			expectedPrevious: "v1.0.0",
+		},
+		{
+			name:          "systemd - fails health check and rolls back",
+			runMethod:     config.RunMethod_RUN_METHOD_SYSTEMD,
+			confirmPrompt: true,
+			setupMocks: func(cfg *mock.MockConfigManager, d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar, g *smock.MockGitHubService) {
+				cfg.EXPECT().Get().Return(&config.Config{
+					RunMethod: config.RunMethod_RUN_METHOD_SYSTEMD,
+					Version:   "v1.0.0",
+				}).Times(2)
+				g.EXPECT().GetLatestVersion(installer.ChannelStable).Return("v1.1.0", nil)
+
+				s.EXPECT().IsRunning().Return(true, nil)
+				s.EXPECT().Stop().Return(nil)
+				s.EXPECT().Update().Return(nil)
+				cfg.EXPECT().Update(gomock.Any()).Return(nil)
+				cfg.EXPECT().Save().Return(nil)
+				s.EXPECT().Start().Return(nil)
+
+				// The new version crashes during the health check.
+				s.EXPECT().IsRunning().Return(false, nil).Times(2)
+
+				// Expect the previous version to be restored and started.
+				s.EXPECT().Rollback("v1.0.0").Return(nil)
+				cfg.EXPECT().Update(gomock.Any()).Return(nil)
+				cfg.EXPECT().Save().Return(nil)
+				s.EXPECT().Start().Return(nil)
+			},
+			healthCheck:   sidecar.HealthCheck{Timeout: 20 * time.Millisecond, Interval: 5 * time.Millisecond},
+			expectedError: "version v1.1.0 failed its health check and was rolled back",
+		},
+		{
+			name:          "binary - fails to start and rolls back",
+			runMethod:     config.RunMethod_RUN_METHOD_BINARY,
+			confirmPrompt: true,
+			setupMocks: func(cfg *mock.MockConfigManager, d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar, g *smock.MockGitHubService) {
+				cfg.EXPECT().Get().Return(&config.Config{
+					RunMethod: config.RunMethod_RUN_METHOD_BINARY,
+					Version:   "v1.0.0",
+				}).Times(2)
+				g.EXPECT().GetLatestVersion(installer.ChannelStable).Return("v1.1.0", nil)
+
+				b.EXPECT().IsRunning().Return(true, nil)
+				b.EXPECT().Stop().Return(nil)
+				b.EXPECT().Update().Return(nil)
+				cfg.EXPECT().Update(gomock.Any()).Return(nil)
+				cfg.EXPECT().Save().Return(nil)
+
+				// The new version doesn't start.
+				b.EXPECT().Start().Return(errors.New("exec format error"))
+
+				// Expect the previous version to be restored and started.
+				b.EXPECT().IsRunning().Return(false, nil)
+				b.EXPECT().Rollback("v1.0.0").Return(nil)
+				cfg.EXPECT().Update(gomock.Any()).Return(nil)
+				cfg.EXPECT().Save().Return(nil)
+				b.EXPECT().Start().Return(nil)
+			},
+			expectedError: "version v1.1.0 failed its health check and was rolled back: failed to start sidecar: exec format error",
+		},
+		{
+			name:          "docker - health check skipped when not restarted",
+			runMethod:     config.RunMethod_RUN_METHOD_DOCKER,
+			confirmPrompt: false,
+			setupMocks: func(cfg *mock.MockConfigManager, d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar, g *smock.MockGitHubService) {
+				cfg.EXPECT().Get().Return(&config.Config{
+					RunMethod: config.RunMethod_RUN_METHOD_DOCKER,
+					Version:   "v1.0.0",
+				}).Times(2)
+				g.EXPECT().GetLatestVersion(installer.ChannelStable).Return("v1.1.0", nil)
+
+				d.EXPECT().Update().Return(nil)
+				cfg.EXPECT().Update(gomock.Any()).Return(nil)
+				cfg.EXPECT().Save().Return(nil)
+
+				// Declining the restart leaves the previous version running, which isn't ours to check.
+				d.EXPECT().IsRunning().Return(true, nil)
+			},
+			healthCheck:      sidecar.HealthCheck{Timeout: 20 * time.Millisecond, Interval: 5 * time.Millisecond},
+			expectedPrevious: "v1.0.0",
+		},
+		{
+			name:          "channel flag - switches to beta",
+			runMethod:     config.RunMethod_RUN_METHOD_DOCKER,
+			channelFlag:   "beta",
+			confirmPrompt: true,
+			setupMocks: func(cfg *mock.MockConfigManager, d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar, g *smock.MockGitHubService) {
+				cfg.EXPECT().Get().Return(&config.Config{
+					RunMethod: config.RunMethod_RUN_METHOD_DOCKER,
+					Version:   "v1.0.0",
+				}).Times(2)
+				g.EXPECT().GetLatestVersion(installer.ChannelBeta).Return("v1.1.0-rc.1", nil)
+
+				d.EXPECT().Update().Return(nil)
+				cfg.EXPECT().Update(gomock.Any()).Return(nil)
+				cfg.EXPECT().Save().Return(nil)
+
+				d.EXPECT().IsRunning().Return(true, nil)
+				d.EXPECT().Stop().Return(nil)
+				d.EXPECT().Start().Return(nil)
+			},
+			expectedPrevious: "v1.0.0",
+			expectedChannel:  installer.ChannelBeta,
+		},
+		{
+			name:        "channel flag - invalid",
+			runMethod:   config.RunMethod_RUN_METHOD_DOCKER,
+			channelFlag: "rc",
+			setupMocks: func(cfg *mock.MockConfigManager, d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar, g *smock.MockGitHubService) {
+				cfg.EXPECT().Get().Return(&config.Config{
+					RunMethod: config.RunMethod_RUN_METHOD_DOCKER,
+					Version:   "v1.0.0",
+				}).Times(1)
+			},
+			expectedError: `invalid channel "rc"`,
+		},
+		{
+			name:        "channel flag - stays on a newer pre-release",
+			runMethod:   config.RunMethod_RUN_METHOD_DOCKER,
+			channel:     installer.ChannelBeta,
+			channelFlag: "stable",
+			setupMocks: func(cfg *mock.MockConfigManager, d *mock.MockDockerSidecar, s *mock.MockSystemdSidecar, b *mock.MockBinarySidecar, g *smock.MockGitHubService) {
+				cfg.EXPECT().Get().Return(&config.Config{
+					RunMethod: config.RunMethod_RUN_METHOD_DOCKER,
+					Version:   "v1.1.0-rc.1",
+				}).Times(1)
+				g.EXPECT().GetLatestVersion(installer.ChannelStable).Return("v1.0.0", nil)
+			},
+			expectedChannel: installer.ChannelStable,
+		},
 	}
 
 	for _, tt := range tests {
@@ -215,21 +434,39 @@ func TestUpdateContributoor(t *testing.T) {
 			}
 			set := flag.NewFlagSet("test", 0)
 			set.String("version", "", "")
+			set.String("channel", "", "")
 			if tt.version != "" {
 				err := set.Set("version", tt.version)
 				require.NoError(t, err)
 			}
+			if tt.channelFlag != "" {
+				err := set.Set("channel", tt.channelFlag)
+				require.NoError(t, err)
+			}
 			context := cli.NewContext(app, set, nil)
 
-			err := updateContributoor(context, logrus.New(), mockConfig, mockDocker, mockSystemd, mockBinary, mockGithub)
+			state, err := installer.LoadState(t.TempDir())
+			require.NoError(t, err)
+
+			state.Channel = tt.channel
+
+			err = updateContributoor(context, logrus.New(), installer.NewConfig(), mockConfig, state, tt.healthCheck, mockDocker, mockSystemd, mockBinary, mockGithub)
 
 			if tt.expectedError != "" {
 				assert.ErrorContains(t, err, tt.expectedError)
 
+				// A failed update must not be recorded as a version to roll back to.
+				assert.Empty(t, state.PreviousVersions)
+
 				return
 			}
 
 			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedPrevious, state.PreviousVersion())
+
+			if tt.expectedChannel != "" {
+				assert.Equal(t, tt.expectedChannel, state.GetChannel())
+			}
 		})
 	}
 }
@@ -8,15 +8,22 @@ import (
 	"syscall"
 
 	"github.com/ethpandaops/contributoor-installer/cmd/cli/commands/config"
+	"github.com/ethpandaops/contributoor-installer/cmd/cli/commands/container"
 	"github.com/ethpandaops/contributoor-installer/cmd/cli/commands/install"
+	"github.com/ethpandaops/contributoor-installer/cmd/cli/commands/logs"
 	"github.com/ethpandaops/contributoor-installer/cmd/cli/commands/restart"
+	"github.com/ethpandaops/contributoor-installer/cmd/cli/commands/rollback"
+	"github.com/ethpandaops/contributoor-installer/cmd/cli/commands/runmethod"
+	"github.com/ethpandaops/contributoor-installer/cmd/cli/commands/service"
 	"github.com/ethpandaops/contributoor-installer/cmd/cli/commands/start"
 	"github.com/ethpandaops/contributoor-installer/cmd/cli/commands/status"
 	"github.com/ethpandaops/contributoor-installer/cmd/cli/commands/stop"
+	"github.com/ethpandaops/contributoor-installer/cmd/cli/commands/supervise"
 	"github.com/ethpandaops/contributoor-installer/cmd/cli/commands/update"
 	"github.com/ethpandaops/contributoor-installer/cmd/cli/options"
 	"github.com/ethpandaops/contributoor-installer/internal/installer"
 	"github.com/ethpandaops/contributoor-installer/internal/tui"
+	"github.com/mitchellh/go-homedir"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 )
@@ -36,8 +43,7 @@ func main() {
 		DisableColors: false,
 	})
 
-	// Set up log rotation for CLI logs.
-	// TODO(@matty): Move this to install.sh?
+	// Set up the CLI logs directory.
 	logDir := filepath.Join(os.Getenv("HOME"), ".contributoor", "logs")
 	if err := os.MkdirAll(logDir, 0755); err != nil {
 		fmt.Printf("Failed to create log directory: %v\n", err)
@@ -67,6 +73,24 @@ func main() {
 		},
 	}
 
+	// Rotate any logs that are due, with the policy in the installer state, which is
+	// only known once the flags are parsed.
+	app.Before = func(c *cli.Context) error {
+		policy := installerCfg.LogRotation
+
+		if configDir, err := homedir.Expand(c.GlobalString("config-path")); err != nil {
+			log.Warnf("Failed to expand config path: %v", err)
+		} else if policy, err = installerCfg.LogRotationPolicy(configDir); err != nil {
+			log.Warnf("Failed to load the log rotation policy, using the default: %v", err)
+		}
+
+		if err := policy.RotateDir(logDir); err != nil {
+			log.Warnf("Failed to rotate logs: %v", err)
+		}
+
+		return nil
+	}
+
 	install.RegisterCommands(app, options.NewCommandOpts(
 		options.WithName("install"),
 		options.WithLogger(log),
@@ -96,15 +120,52 @@ func main() {
 		options.WithInstallerConfig(installerCfg),
 	))
 
+	logs.RegisterCommands(app, options.NewCommandOpts(
+		options.WithName("logs"),
+		options.WithLogger(log),
+		options.WithInstallerConfig(installerCfg),
+	))
+
+	supervise.RegisterCommands(app, options.NewCommandOpts(
+		options.WithName("supervise"),
+		options.WithLogger(log),
+		options.WithInstallerConfig(installerCfg),
+	))
+
 	update.RegisterCommands(app, options.NewCommandOpts(
 		options.WithName("update"),
 		options.WithLogger(log),
 		options.WithInstallerConfig(installerCfg),
 	))
 
+	rollback.RegisterCommands(app, options.NewCommandOpts(
+		options.WithName("rollback"),
+		options.WithLogger(log),
+		options.WithInstallerConfig(installerCfg),
+	))
+
+	service.RegisterCommands(app, options.NewCommandOpts(
+		options.WithName("service"),
+		options.WithLogger(log),
+		options.WithInstallerConfig(installerCfg),
+	))
+
+	runmethod.RegisterCommands(app, options.NewCommandOpts(
+		options.WithName("migrate-run-method"),
+		options.WithLogger(log),
+		options.WithInstallerConfig(installerCfg),
+	))
+
 	config.RegisterCommands(app, options.NewCommandOpts(
 		options.WithName("config"),
 		options.WithLogger(log),
+		options.WithInstallerConfig(installerCfg),
+	))
+
+	container.RegisterCommands(app, options.NewCommandOpts(
+		options.WithName("container"),
+		options.WithLogger(log),
+		options.WithInstallerConfig(installerCfg),
 	))
 
 	// Handle normal exit.
@@ -112,11 +173,15 @@ func main() {
 		return nil
 	}
 
-	fmt.Println("")
+	// Padding goes to stderr, so that output from commands like `config get` can be
+	// consumed by scripts.
+	fmt.Fprintln(os.Stderr, "")
 
 	if err := app.Run(os.Args); err != nil {
 		log.Error(err)
+		fmt.Fprintln(os.Stderr, "")
+		os.Exit(1)
 	}
 
-	fmt.Println("")
+	fmt.Fprintln(os.Stderr, "")
 }
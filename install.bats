@@ -295,7 +295,6 @@ EOF
     # Mock tar extraction
     function tar() {
         touch "$CONTRIBUTOOR_BIN/contributoor"
-        touch "$CONTRIBUTOOR_BIN/docker-compose.yml"
         return 0
     }
     
@@ -305,7 +304,6 @@ EOF
     
     [ "$status" -eq 0 ]
     [ -f "$CONTRIBUTOOR_BIN/contributoor" ]
-    [ -f "$CONTRIBUTOOR_BIN/docker-compose.yml" ]
 }
 
 @test "setup_installer fails on checksum mismatch" {
@@ -376,11 +374,26 @@ EOF
     }
     
     export -f docker
+    export CONTAINER_RUNTIME="docker"
     
     run setup_docker_contributoor
     [ "$status" -eq 0 ]
 }
 
+@test "setup_docker_contributoor pulls a qualified image with podman" {
+    # Mock podman commands
+    function podman() {
+        echo "podman $*" >> "$TEST_DIR/podman.log"
+    }
+    
+    export -f podman
+    export CONTAINER_RUNTIME="podman"
+    
+    run setup_docker_contributoor
+    [ "$status" -eq 0 ]
+    grep -q "podman pull docker.io/ethpandaops/contributoor:" "$TEST_DIR/podman.log"
+}
+
 @test "setup_binary_contributoor downloads and verifies binary checksum" {
     mkdir -p "$CONTRIBUTOOR_BIN"
     
@@ -505,23 +518,27 @@ EOF
     echo "$output" | grep -q "Failed to extract contributoor binary"
 }
 
-@test "check_docker fails when docker not installed" {
+@test "check_container_runtime fails when docker not installed" {
+    export CONTAINER_RUNTIME="docker"
+
     # Mock command to simulate docker not being installed
     function command() {
         case "$2" in
             "docker") return 1 ;;
-            *) command "$@" ;;
+            *) builtin command "$@" ;;
         esac
     }
     export -f command
 
-    run check_docker 2>/dev/null
+    run check_container_runtime 2>/dev/null
     [ "$status" -eq 1 ]
     echo "$output" | grep -F -q "**ERROR**"
     echo "$output" | grep -q "Docker is not installed. Please install Docker first: https://docs.docker.com/get-docker/"
 }
 
-@test "check_docker fails when docker daemon not running" {
+@test "check_container_runtime fails when docker daemon not running" {
+    export CONTAINER_RUNTIME="docker"
+
     # Mock docker info to simulate daemon not running
     function docker() {
         case "$1" in
@@ -531,109 +548,143 @@ EOF
     }
     export -f docker
 
-    run check_docker 2>/dev/null
+    run check_container_runtime 2>/dev/null
     [ "$status" -eq 1 ]
     echo "$output" | grep -F -q "**ERROR**"
     echo "$output" | grep -q "Docker daemon is not running. Please start Docker and try again."
 }
 
-@test "check_docker fails when docker compose not available" {
-    # Mock docker and command to simulate compose missing
+@test "check_container_runtime prefers docker when everything available" {
+    # Mock successful docker environment
     function docker() {
         case "$1" in
-            "compose")
-                return 1
-                ;;
-            "info") return 0 ;;  # Need docker daemon to appear running
+            "info") return 0 ;;
             *) return 0 ;;
         esac
     }
-    function docker-compose() {
-        return 1
-    }
-    export -f docker docker-compose
+    export -f docker
 
-    run check_docker 2>/dev/null
-    [ "$status" -eq 1 ]
-    echo "$output" | grep -F -q "**ERROR**"
-    echo "$output" | grep -q "Docker Compose is not installed. Please install Docker Compose: https://docs.docker.com/compose/install/"
+    check_container_runtime
+    [ "$CONTAINER_RUNTIME" = "docker" ]
 }
 
-@test "check_docker succeeds when everything available" {
-    # Mock successful docker environment
-    function docker() {
-        case "$1" in
-            "info") return 0 ;;
-            "compose") return 0 ;;
-            *) return 0 ;;
+@test "check_container_runtime falls back to rootless podman" {
+    # Mock command to simulate only podman being installed
+    function command() {
+        case "$2" in
+            "docker") return 1 ;;
+            "podman") return 0 ;;
+            *) builtin command "$@" ;;
         esac
     }
-    export -f docker
+    function detect_platform() {
+        echo "linux"
+    }
+    function id() {
+        echo "1000"
+    }
+    function systemctl() {
+        echo "systemctl $*" >> "$TEST_DIR/systemctl.log"
+    }
+    export -f command detect_platform id systemctl
 
-    run check_docker
-    [ "$status" -eq 0 ]
-    [ -z "$output" ]
+    check_container_runtime
+    [ "$CONTAINER_RUNTIME" = "podman" ]
+    grep -q "systemctl --user enable --now podman.socket" "$TEST_DIR/systemctl.log"
+}
+
+@test "check_container_runtime fails for unknown runtimes" {
+    export CONTAINER_RUNTIME="containerd"
+
+    run check_container_runtime 2>/dev/null
+    [ "$status" -eq 1 ]
+    echo "$output" | grep -q "Unsupported container runtime: containerd"
 }
 
-@test "[linux] setup_systemd_contributoor creates service file correctly" {
+@test "[linux] setup_systemd_contributoor installs the unit through the installer" {
     # Set platform for test
     function detect_platform() {
         echo "linux"
     }
     export -f detect_platform
 
-    # Mock sudo to capture commands
+    # Mock id so we aren't root, and sudo to run commands as they are
+    function id() {
+        echo "1000"
+    }
+    export -f id
+
     function sudo() {
         case "$1" in
-            "tee")
-                # Capture service file content
-                cat > "$TEST_DIR/contributoor.service"
-                ;;
-            *) return 0 ;;
+            "-p"|"chown") return 0 ;;
+            *) "$@" ;;
         esac
     }
     export -f sudo
 
+    # Mock the installer binary to capture its arguments
+    mkdir -p "$CONTRIBUTOOR_BIN"
+    cat > "$CONTRIBUTOOR_BIN/contributoor" << EOF
+#!/bin/sh
+echo "\$@" > "$TEST_DIR/contributoor.args"
+EOF
+    chmod +x "$CONTRIBUTOOR_BIN/contributoor"
+
     run setup_systemd_contributoor
 
     # Check status
     [ "$status" -eq 0 ]
 
-    # Verify service file was created and has correct content
-    [ -f "$TEST_DIR/contributoor.service" ]
-    grep -q "Description=Contributoor Service" "$TEST_DIR/contributoor.service"
-    grep -q "User=$USER" "$TEST_DIR/contributoor.service"
-    grep -q "ExecStart=$CONTRIBUTOOR_BIN/sentry" "$TEST_DIR/contributoor.service"
+    # The unit is generated by the installer, rather than written here
+    grep -q -- "--config-path $CONTRIBUTOOR_PATH service install --scope system" "$TEST_DIR/contributoor.args"
 }
 
-@test "[linux] setup_systemd_contributoor removes any existing systemd service before installing" {
+@test "[linux] setup_systemd_contributoor installs a user unit without sudo" {
     # Set platform for test
     function detect_platform() {
         echo "linux"
     }
     export -f detect_platform
 
-    # Mock sudo and systemctl
     function sudo() {
-        case "$1" in
-            "systemctl")
-                case "$2" in
-                    "list-unit-files") echo "contributoor.service enabled" ;;
-                    *) return 0 ;;
-                esac
-                ;;
-            *) return 0 ;;
-        esac
+        echo "sudo called"
+        return 1
     }
     export -f sudo
 
+    # Mock the installer binary to capture its arguments
+    mkdir -p "$CONTRIBUTOOR_BIN"
+    cat > "$CONTRIBUTOOR_BIN/contributoor" << EOF
+#!/bin/sh
+echo "\$@" > "$TEST_DIR/contributoor.args"
+EOF
+    chmod +x "$CONTRIBUTOOR_BIN/contributoor"
+
+    SYSTEMD_SCOPE=user
     run setup_systemd_contributoor
 
     # Check status
     [ "$status" -eq 0 ]
+    grep -q -- "service install --scope user" "$TEST_DIR/contributoor.args"
+    ! echo "$output" | grep -q "sudo called"
+}
 
-    # Verify it detected and handled existing service
-    echo "$output" | grep -q "Stopped and disabled existing systemd service"
+@test "[linux] setup_systemd_contributoor fails when the unit can't be installed" {
+    # Set platform for test
+    function detect_platform() {
+        echo "linux"
+    }
+    export -f detect_platform
+
+    mkdir -p "$CONTRIBUTOOR_BIN"
+    printf '#!/bin/sh\nexit 1\n' > "$CONTRIBUTOOR_BIN/contributoor"
+    chmod +x "$CONTRIBUTOOR_BIN/contributoor"
+
+    SYSTEMD_SCOPE=user
+    run setup_systemd_contributoor
+
+    [ "$status" -eq 1 ]
+    echo "$output" | grep -q "Failed to install the systemd service"
 }
 
 @test "[linux] check_systemd validates systemd availability" {
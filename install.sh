@@ -23,6 +23,8 @@ TOTAL_STEPS="8"
 CONTRIBUTOOR_PATH=${CONTRIBUTOOR_PATH:-"$HOME/.contributoor"}
 CONTRIBUTOOR_BIN="$CONTRIBUTOOR_PATH/bin"
 VERSION="latest"
+CONTAINER_RUNTIME=${CONTAINER_RUNTIME:-} # docker or podman, detected if unset
+SYSTEMD_SCOPE=${SYSTEMD_SCOPE:-system} # system, or user for a unit that doesn't need root
 
 ###############################################################################
 # UI Functions
@@ -193,23 +195,21 @@ setup_installer() {
     success "Extracted archive"
     
     chmod +x "$CONTRIBUTOOR_BIN/contributoor"
-    [ -f "$CONTRIBUTOOR_BIN/docker-compose.yml" ] && {
-        chmod 644 "$CONTRIBUTOOR_BIN/docker-compose.yml"
-        chmod 755 "$CONTRIBUTOOR_BIN"
-    } || fail "docker-compose.yml not found after extraction"
+    chmod 755 "$CONTRIBUTOOR_BIN"
     
     success "Set installer permissions: $CONTRIBUTOOR_BIN/contributoor"
     rm -f "$temp_archive"
 }
 
 setup_docker_contributoor() {
-    docker system prune -f >/dev/null 2>&1 || true
+    # Podman has no daemon to clean up after, and needs the registry spelled out.
+    [ "$CONTAINER_RUNTIME" = "docker" ] && { docker system prune -f >/dev/null 2>&1 || true; }
 
-    docker pull "ethpandaops/contributoor:${VERSION}" >/dev/null 2>&1 &
+    "$CONTAINER_RUNTIME" pull "docker.io/ethpandaops/contributoor:${VERSION}" >/dev/null 2>&1 &
     spinner $!
     wait $!
-    [ $? -ne 0 ] && fail "Failed to pull docker image"
-    success "Pulled docker image: ethpandaops/contributoor:${VERSION}"
+    [ $? -ne 0 ] && fail "Failed to pull $CONTAINER_RUNTIME image"
+    success "Pulled $CONTAINER_RUNTIME image: ethpandaops/contributoor:${VERSION}"
 }
 
 setup_binary_contributoor() {
@@ -354,8 +354,19 @@ EOF
     success "Service configured for manual start"
 }
 
-# Setup Linux systemd service
+# Setup Linux systemd service. The unit file is generated by the installer binary from
+# the config, so this runs once the config is written.
 setup_linux_systemd() {
+    local contributoor="$CONTRIBUTOOR_BIN/contributoor"
+
+    # User units, and system units when we're already root, are installed as we are.
+    if [ "$SYSTEMD_SCOPE" = "user" ] || [ "$(id -u)" -eq 0 ]; then
+        "$contributoor" --config-path "$CONTRIBUTOOR_PATH" service install --scope "$SYSTEMD_SCOPE" || fail "Failed to install the systemd service"
+        success "Created systemd service ($SYSTEMD_SCOPE)"
+        success "Service configured for manual start"
+        return
+    fi
+
     # Warn about sudo requirement
     warn "Setting up systemd service requires sudo access. "
 
@@ -364,88 +375,66 @@ setup_linux_systemd() {
         fail "sudo access is required to setup systemd service. Installation aborted."
     fi
 
-    # Stop and disable existing service if it exists
-    if sudo systemctl list-unit-files | grep -q "contributoor.service"; then
-        sudo systemctl stop contributoor.service
-        sudo systemctl disable contributoor.service >/dev/null 2>&1
-        
-        # Remove existing service file
-        sudo rm -f "/etc/systemd/system/contributoor.service"
-        
-        # Remove any leftover runtime files
-        sudo rm -rf "/etc/systemd/system/contributoor.service.d"
-        sudo rm -f "/etc/systemd/system/contributoor.service.wants"
-        sudo rm -f "/etc/systemd/system/multi-user.target.wants/contributoor.service"
-        
-        # Reload systemd to recognize the removal
-        sudo systemctl daemon-reload
-
-        success "Stopped and disabled existing systemd service"
-    fi
-
-    # Create systemd directory
-    sudo mkdir -p "/etc/systemd/system"
+    sudo "$contributoor" --config-path "$CONTRIBUTOOR_PATH" service install --scope system || fail "Failed to install the systemd service"
 
-    # Create the service file
-    sudo tee "/etc/systemd/system/contributoor.service" >/dev/null << EOF
-[Unit]
-Description=Contributoor Service
-After=network-online.target
-Wants=network-online.target
-StartLimitIntervalSec=0
-
-[Service]
-Type=simple
-User=$USER
-Group=$USER
-ExecStart=$CONTRIBUTOOR_BIN/sentry --config $CONTRIBUTOOR_PATH/config.yaml
-WorkingDirectory=$CONTRIBUTOOR_PATH
-Restart=always
-RestartSec=5
-
-# Environment setup
-Environment=HOME=$HOME
-Environment=USER=$USER
-Environment=PATH=/usr/local/bin:/usr/bin:/bin
-
-# Hardening
-NoNewPrivileges=true
-ProtectSystem=full
-ProtectHome=read-only
-PrivateTmp=true
-
-[Install]
-WantedBy=multi-user.target
-EOF
-
-    # Set permissions
-    sudo chmod 644 "/etc/systemd/system/contributoor.service"
-
-    # Reload systemd
-    sudo systemctl daemon-reload
-
-    # Enable but don't start the service
-    sudo systemctl enable contributoor.service >/dev/null 2>&1
+    # Anything written to the contributoor directory while installing it stays ours.
+    sudo chown -R "$(id -u):$(id -g)" "$CONTRIBUTOOR_PATH"
 
     success "Created systemd service: /etc/systemd/system/contributoor.service"
     success "Service configured for manual start"
 }
 
-# Check if docker is installed and running
-check_docker() {
-    # Check if docker command exists
-    if ! command -v docker >/dev/null 2>&1; then
-        fail "Docker is not installed. Please install Docker first: https://docs.docker.com/get-docker/"
+# Check if docker or podman is installed and running, and set CONTAINER_RUNTIME to it.
+# Docker is preferred when both are installed, unless CONTAINER_RUNTIME is already set.
+check_container_runtime() {
+    if [ -z "$CONTAINER_RUNTIME" ]; then
+        if command -v docker >/dev/null 2>&1 && docker info >/dev/null 2>&1; then
+            CONTAINER_RUNTIME="docker"
+        elif command -v podman >/dev/null 2>&1; then
+            CONTAINER_RUNTIME="podman"
+        elif command -v docker >/dev/null 2>&1; then
+            fail "Docker daemon is not running. Please start Docker and try again."
+        else
+            fail "Neither Docker nor Podman is installed. Please install one first: https://docs.docker.com/get-docker/ or https://podman.io/docs/installation"
+        fi
     fi
 
-    # Check if docker daemon is running
-    if ! docker info >/dev/null 2>&1; then
-        fail "Docker daemon is not running. Please start Docker and try again."
+    case "$CONTAINER_RUNTIME" in
+        docker)
+            if ! command -v docker >/dev/null 2>&1; then
+                fail "Docker is not installed. Please install Docker first: https://docs.docker.com/get-docker/"
+            fi
+
+            if ! docker info >/dev/null 2>&1; then
+                fail "Docker daemon is not running. Please start Docker and try again."
+            fi
+            ;;
+        podman)
+            if ! command -v podman >/dev/null 2>&1; then
+                fail "Podman is not installed. Please install Podman first: https://podman.io/docs/installation"
+            fi
+
+            check_podman_socket
+            ;;
+        *)
+            fail "Unsupported container runtime: $CONTAINER_RUNTIME (expected docker or podman)"
+            ;;
+    esac
+}
+
+# Enable the socket podman serves its docker compatible API on, which contributoor talks
+# to. It's the user's own socket when podman runs rootless.
+check_podman_socket() {
+    if [ "$(detect_platform)" = "darwin" ]; then
+        # The podman machine serves the API on macOS.
+        podman machine inspect >/dev/null 2>&1 || fail "No podman machine found. Create one with 'podman machine init' and try again."
+        return
     fi
 
-    # Check if docker compose is available (either as plugin or standalone)
-    if ! (docker compose version >/dev/null 2>&1 || docker-compose version >/dev/null 2>&1); then
-        fail "Docker Compose is not installed. Please install Docker Compose: https://docs.docker.com/compose/install/"
+    if [ "$(id -u)" -eq 0 ]; then
+        systemctl enable --now podman.socket >/dev/null 2>&1 || fail "Failed to enable podman.socket"
+    else
+        systemctl --user enable --now podman.socket >/dev/null 2>&1 || fail "Failed to enable the podman.socket user unit"
     fi
 }
 
@@ -644,9 +633,9 @@ main() {
                     printf "Selected: "
                     if [ "$selected" = 1 ]; then
                         INSTALL_MODE="RUN_METHOD_DOCKER"
-                        # Check docker is available before proceeding
-                        check_docker
-                        printf "${COLOR_GREEN}docker${COLOR_RESET}"
+                        # Check docker or podman is available before proceeding
+                        check_container_runtime
+                        printf "${COLOR_GREEN}docker${COLOR_RESET} (${CONTAINER_RUNTIME})"
                     elif [ "$selected" = 2 ]; then
                         INSTALL_MODE="RUN_METHOD_SYSTEMD"
                         # Check systemd is available
@@ -688,11 +677,8 @@ main() {
         setup_binary_contributoor
     fi
     
-    # Setup systemd service if needed
-    [ "$INSTALL_MODE" = "RUN_METHOD_SYSTEMD" ] && setup_systemd_contributoor
-
-    # Docker cleanup if needed
-    if [ "$INSTALL_MODE" = "RUN_METHOD_DOCKER" ] && command -v docker >/dev/null 2>&1; then
+    # Pull the image if needed
+    if [ "$INSTALL_MODE" = "RUN_METHOD_DOCKER" ] && [ -n "$CONTAINER_RUNTIME" ]; then
         setup_docker_contributoor
     fi
 
@@ -702,9 +688,16 @@ main() {
     update_config_file "$config_file"
     success "Updated config: $config_file"
 
+    # Setup systemd service if needed, from the config
+    [ "$INSTALL_MODE" = "RUN_METHOD_SYSTEMD" ] && setup_systemd_contributoor
+
     # Run installer
     progress 8 "Run install wizard"
-    "$CONTRIBUTOOR_BIN/contributoor" --config-path "$CONTRIBUTOOR_PATH" install --version "$VERSION" --run-method "$INSTALL_MODE"
+    if [ -n "$CONTAINER_RUNTIME" ]; then
+        "$CONTRIBUTOOR_BIN/contributoor" --config-path "$CONTRIBUTOOR_PATH" install --version "$VERSION" --run-method "$INSTALL_MODE" --container-runtime "$CONTAINER_RUNTIME"
+    else
+        "$CONTRIBUTOOR_BIN/contributoor" --config-path "$CONTRIBUTOOR_PATH" install --version "$VERSION" --run-method "$INSTALL_MODE"
+    fi
 }
 
 # Execute main installation
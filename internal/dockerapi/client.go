@@ -0,0 +1,199 @@
+// Package dockerapi is a small client for the parts of the Docker Engine API the
+// installer uses. It talks to docker, or to podman's docker compatible API.
+package dockerapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// APIVersion is the Engine API version requested. It's old enough for podman's
+// compatible API, and every docker release we support.
+const APIVersion = "1.41"
+
+// DefaultDockerHost is docker's socket.
+const DefaultDockerHost = "unix:///var/run/docker.sock"
+
+// Error is an error response from the API.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// IsNotFound reports whether err is the API saying the object doesn't exist.
+func IsNotFound(err error) bool {
+	var apiErr *Error
+
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// Client talks to the Engine API.
+type Client struct {
+	host    string
+	baseURL string
+	http    *http.Client
+}
+
+// NewClient returns a client for the API at host, eg: unix:///var/run/docker.sock or
+// tcp://127.0.0.1:2375.
+func NewClient(host string) (*Client, error) {
+	return NewEndpointClient(&Endpoint{Host: host})
+}
+
+// NewEndpointClient returns a client for the API at the endpoint. With a TLS config, a
+// tcp:// host is reached over https, as docker does.
+func NewEndpointClient(endpoint *Endpoint) (*Client, error) {
+	var (
+		host      = endpoint.Host
+		tlsConfig = endpoint.TLS
+	)
+
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %q: %w", host, err)
+	}
+
+	c := &Client{
+		host: host,
+		http: &http.Client{},
+	}
+
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		c.baseURL = "http://docker"
+		c.http.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}
+	case "tcp", "http":
+		c.baseURL = "http://" + u.Host
+
+		if tlsConfig != nil && u.Scheme == "tcp" {
+			c.baseURL = "https://" + u.Host
+			c.http.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+		}
+	case "https":
+		c.baseURL = "https://" + u.Host
+
+		if tlsConfig != nil {
+			c.http.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported docker host %q, expected unix://, tcp:// or https://", host)
+	}
+
+	return c, nil
+}
+
+// Host returns the address of the API.
+func (c *Client) Host() string {
+	return c.host
+}
+
+// Ping checks the API is reachable.
+func (c *Client) Ping(ctx context.Context) error {
+	resp, err := c.do(ctx, http.MethodGet, "/_ping", nil, nil)
+	if err != nil {
+		return err
+	}
+
+	return resp.Body.Close()
+}
+
+// do sends a request to the API, returning an *Error for error responses. The caller
+// must close the response body.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+
+		reader = bytes.NewReader(data)
+	}
+
+	u := fmt.Sprintf("%s/v%s%s", c.baseURL, APIVersion, path)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the docker API at %s, set DOCKER_HOST if it's served elsewhere: %w", c.host, err)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+
+		return nil, readError(resp)
+	}
+
+	return resp, nil
+}
+
+// doJSON sends a request to the API, and decodes the response into out, unless it's nil.
+func (c *Client) doJSON(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	resp, err := c.do(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+// readError turns an error response into an *Error.
+func readError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	var body struct {
+		Message string `json:"message"`
+	}
+
+	message := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &body) == nil && body.Message != "" {
+		message = body.Message
+	}
+
+	if message == "" {
+		message = resp.Status
+	}
+
+	return &Error{StatusCode: resp.StatusCode, Message: message}
+}
@@ -0,0 +1,190 @@
+package dockerapi_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethpandaops/contributoor-installer/internal/dockerapi"
+	"github.com/ethpandaops/contributoor-installer/internal/dockerapi/dockerapitest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newClient(t *testing.T) (*dockerapi.Client, *dockerapitest.Server) {
+	t.Helper()
+
+	server := dockerapitest.NewServer(t)
+
+	client, err := dockerapi.NewClient(server.Host())
+	require.NoError(t, err)
+
+	return client, server
+}
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		host          string
+		expectedError string
+	}{
+		{host: "unix:///var/run/docker.sock"},
+		{host: "tcp://127.0.0.1:2375"},
+		{host: "https://docker.example.com:2376"},
+		{host: "ssh://user@host", expectedError: `unsupported docker host "ssh://user@host"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			client, err := dockerapi.NewClient(tt.host)
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.host, client.Host())
+		})
+	}
+}
+
+func TestClient_Version(t *testing.T) {
+	client, server := newClient(t)
+
+	version, err := client.Version(context.Background())
+	require.NoError(t, err)
+	assert.False(t, version.IsPodman())
+
+	info, err := client.Info(context.Background())
+	require.NoError(t, err)
+	assert.False(t, info.Rootless())
+
+	server.Lock()
+	server.Podman, server.Rootless = true, true
+	server.Unlock()
+
+	version, err = client.Version(context.Background())
+	require.NoError(t, err)
+	assert.True(t, version.IsPodman())
+	assert.Equal(t, "5.2.2", version.Version)
+
+	info, err = client.Info(context.Background())
+	require.NoError(t, err)
+	assert.True(t, info.Rootless())
+}
+
+func TestClient_Ping(t *testing.T) {
+	client, _ := newClient(t)
+	require.NoError(t, client.Ping(context.Background()))
+
+	// Nothing's listening.
+	client, err := dockerapi.NewClient("unix://" + filepath.Join(t.TempDir(), "docker.sock"))
+	require.NoError(t, err)
+	assert.ErrorContains(t, client.Ping(context.Background()), "failed to connect to the docker API")
+}
+
+func TestClient_ImagePull(t *testing.T) {
+	client, server := newClient(t)
+	server.AddImage("ethpandaops/contributoor:1.0.0", "sha256:4b2c")
+
+	var progress []dockerapi.PullProgress
+
+	require.NoError(t, client.ImagePull(context.Background(), "ethpandaops/contributoor:1.0.0", func(p dockerapi.PullProgress) {
+		progress = append(progress, p)
+	}))
+
+	require.NotEmpty(t, progress)
+	assert.Equal(t, "Downloading", progress[2].Status)
+	assert.Equal(t, int64(1024), progress[2].Current)
+	assert.Equal(t, int64(2*1024*1024), progress[2].Total)
+
+	image, err := client.ImageInspect(context.Background(), "ethpandaops/contributoor:1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:4b2c", image.Digest("ethpandaops/contributoor"))
+	assert.Equal(t, "sha256:4b2c", image.Digest("docker.io/ethpandaops/contributoor"))
+	assert.Empty(t, image.Digest("ethpandaops/other"))
+
+	// The error comes part way through the stream.
+	err = client.ImagePull(context.Background(), "ethpandaops/contributoor:9.9.9", nil)
+	assert.EqualError(t, err, "failed to pull ethpandaops/contributoor:9.9.9: manifest for ethpandaops/contributoor:9.9.9 not found: manifest unknown")
+
+	_, err = client.ImageInspect(context.Background(), "ethpandaops/contributoor:9.9.9")
+	assert.True(t, dockerapi.IsNotFound(err))
+}
+
+func TestClient_Containers(t *testing.T) {
+	var (
+		ctx            = context.Background()
+		client, server = newClient(t)
+	)
+
+	server.AddImage("busybox:latest", "sha256:9ae9")
+	require.NoError(t, client.ImagePull(ctx, "busybox", nil))
+
+	id, err := client.ContainerCreate(ctx, "sentry", &dockerapi.ContainerSpec{
+		Image:  "busybox:latest",
+		Labels: map[string]string{"app": "sentry"},
+	})
+	require.NoError(t, err)
+
+	// Names are unique.
+	_, err = client.ContainerCreate(ctx, "sentry", &dockerapi.ContainerSpec{Image: "busybox:latest"})
+
+	var apiErr *dockerapi.Error
+
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+	assert.Contains(t, apiErr.Message, "is already in use")
+
+	containers, err := client.ContainerList(ctx, map[string]string{"app": "sentry"}, false)
+	require.NoError(t, err)
+	assert.Empty(t, containers, "created containers aren't running")
+
+	containers, err = client.ContainerList(ctx, map[string]string{"app": "sentry"}, true)
+	require.NoError(t, err)
+	require.Len(t, containers, 1)
+	assert.Equal(t, id, containers[0].ID)
+
+	require.NoError(t, client.ContainerStart(ctx, id))
+
+	container, err := client.ContainerInspect(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "running", container.State.Status)
+	assert.Empty(t, container.Health())
+
+	require.NoError(t, client.ContainerStop(ctx, id, 10*time.Second))
+
+	// Stopping a stopped container is fine.
+	require.NoError(t, client.ContainerStop(ctx, id, 10*time.Second))
+
+	require.NoError(t, client.ContainerRemove(ctx, id, true))
+
+	_, err = client.ContainerInspect(ctx, id)
+	assert.True(t, dockerapi.IsNotFound(err))
+}
+
+func TestClient_ContainerLogs(t *testing.T) {
+	var (
+		ctx            = context.Background()
+		client, server = newClient(t)
+	)
+
+	server.AddImage("busybox:latest", "sha256:9ae9")
+	require.NoError(t, client.ImagePull(ctx, "busybox:latest", nil))
+
+	id, err := client.ContainerCreate(ctx, "sentry", &dockerapi.ContainerSpec{Image: "busybox:latest"})
+	require.NoError(t, err)
+
+	server.Lock()
+	server.Logs[id] = "level=info msg=started\nlevel=warn msg=\"slow beacon node\"\n"
+	server.Unlock()
+
+	var out bytes.Buffer
+
+	require.NoError(t, client.ContainerLogs(ctx, id, dockerapi.LogsOptions{Tail: 10}, &out))
+	assert.Equal(t, "level=info msg=started\nlevel=warn msg=\"slow beacon node\"\n", out.String())
+}
@@ -0,0 +1,226 @@
+package dockerapi
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ContainerSpec is what a container is created from.
+type ContainerSpec struct {
+	Image      string            `json:"Image"`
+	Cmd        []string          `json:"Cmd,omitempty"`
+	Env        []string          `json:"Env,omitempty"`
+	Labels     map[string]string `json:"Labels,omitempty"`
+	HostConfig HostConfig        `json:"HostConfig"`
+}
+
+// HostConfig is how a container is run on the host.
+type HostConfig struct {
+	Binds         []string      `json:"Binds,omitempty"`
+	RestartPolicy RestartPolicy `json:"RestartPolicy"`
+	NetworkMode   string        `json:"NetworkMode,omitempty"`
+	NanoCPUs      int64         `json:"NanoCpus,omitempty"`
+	Memory        int64         `json:"Memory,omitempty"`
+	LogConfig     *LogConfig    `json:"LogConfig,omitempty"`
+}
+
+// LogConfig is the logging driver a container's output goes to, eg: journald.
+type LogConfig struct {
+	Type   string            `json:"Type"`
+	Config map[string]string `json:"Config,omitempty"`
+}
+
+// RestartPolicy says when a container is restarted, eg: always or unless-stopped.
+type RestartPolicy struct {
+	Name string `json:"Name"`
+}
+
+// ContainerSummary is a container, as listed.
+type ContainerSummary struct {
+	ID     string            `json:"Id"`
+	Image  string            `json:"Image"`
+	State  string            `json:"State"`
+	Labels map[string]string `json:"Labels"`
+}
+
+// Container is a container, as inspected.
+type Container struct {
+	ID           string `json:"Id"`
+	Name         string `json:"Name"`
+	Image        string `json:"Image"`
+	RestartCount int    `json:"RestartCount"`
+	State        struct {
+		Status   string `json:"Status"`
+		Running  bool   `json:"Running"`
+		ExitCode int    `json:"ExitCode"`
+		Health   *struct {
+			Status string `json:"Status"`
+		} `json:"Health"`
+	} `json:"State"`
+	Config struct {
+		Image  string            `json:"Image"`
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+	HostConfig HostConfig `json:"HostConfig"`
+}
+
+// Health returns the container's health, eg: healthy, or empty if it has no healthcheck.
+func (c *Container) Health() string {
+	if c.State.Health == nil {
+		return ""
+	}
+
+	return c.State.Health.Status
+}
+
+// LogsOptions controls which logs are returned.
+type LogsOptions struct {
+	Follow bool
+	Tail   int
+	Since  time.Time
+}
+
+// ContainerList lists the containers with all the given labels. Stopped containers are
+// included too, with all.
+func (c *Client) ContainerList(ctx context.Context, labels map[string]string, all bool) ([]ContainerSummary, error) {
+	filter := make([]string, 0, len(labels))
+	for k, v := range labels {
+		filter = append(filter, k+"="+v)
+	}
+
+	filters, err := json.Marshal(map[string][]string{"label": filter})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode filters: %w", err)
+	}
+
+	query := url.Values{"filters": {string(filters)}}
+	if all {
+		query.Set("all", "1")
+	}
+
+	var containers []ContainerSummary
+	if err := c.doJSON(ctx, http.MethodGet, "/containers/json", query, nil, &containers); err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	return containers, nil
+}
+
+// ContainerCreate creates a container with the given name, returning its ID.
+func (c *Client) ContainerCreate(ctx context.Context, name string, spec *ContainerSpec) (string, error) {
+	var created struct {
+		ID string `json:"Id"`
+	}
+
+	if err := c.doJSON(ctx, http.MethodPost, "/containers/create", url.Values{"name": {name}}, spec, &created); err != nil {
+		return "", fmt.Errorf("failed to create container: %w", err)
+	}
+
+	return created.ID, nil
+}
+
+// ContainerInspect returns the container with the given ID or name.
+func (c *Client) ContainerInspect(ctx context.Context, id string) (*Container, error) {
+	var container Container
+	if err := c.doJSON(ctx, http.MethodGet, "/containers/"+url.PathEscape(id)+"/json", nil, nil, &container); err != nil {
+		return nil, fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	return &container, nil
+}
+
+// ContainerStart starts a container. Starting a running container isn't an error.
+func (c *Client) ContainerStart(ctx context.Context, id string) error {
+	if err := c.doJSON(ctx, http.MethodPost, "/containers/"+url.PathEscape(id)+"/start", nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to start container: %w", err)
+	}
+
+	return nil
+}
+
+// ContainerStop stops a container, killing it if it hasn't exited within timeout.
+// Stopping a stopped container isn't an error.
+func (c *Client) ContainerStop(ctx context.Context, id string, timeout time.Duration) error {
+	query := url.Values{"t": {strconv.Itoa(int(timeout.Seconds()))}}
+
+	if err := c.doJSON(ctx, http.MethodPost, "/containers/"+url.PathEscape(id)+"/stop", query, nil, nil); err != nil {
+		return fmt.Errorf("failed to stop container: %w", err)
+	}
+
+	return nil
+}
+
+// ContainerRemove removes a container, along with its anonymous volumes with volumes.
+func (c *Client) ContainerRemove(ctx context.Context, id string, volumes bool) error {
+	query := url.Values{"force": {"1"}}
+	if volumes {
+		query.Set("v", "1")
+	}
+
+	if err := c.doJSON(ctx, http.MethodDelete, "/containers/"+url.PathEscape(id), query, nil, nil); err != nil {
+		return fmt.Errorf("failed to remove container: %w", err)
+	}
+
+	return nil
+}
+
+// ContainerLogs writes a container's stdout and stderr to w. With opts.Follow, it keeps
+// writing new logs until ctx is cancelled, or the container stops. The container must
+// not have a TTY.
+func (c *Client) ContainerLogs(ctx context.Context, id string, opts LogsOptions, w io.Writer) error {
+	query := url.Values{"stdout": {"1"}, "stderr": {"1"}}
+
+	if opts.Follow {
+		query.Set("follow", "1")
+	}
+
+	if opts.Tail > 0 {
+		query.Set("tail", strconv.Itoa(opts.Tail))
+	}
+
+	if !opts.Since.IsZero() {
+		query.Set("since", strconv.FormatInt(opts.Since.Unix(), 10))
+	}
+
+	resp, err := c.do(ctx, http.MethodGet, "/containers/"+url.PathEscape(id)+"/logs", query, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get container logs: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if err := demux(resp.Body, w); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to read container logs: %w", err)
+	}
+
+	return nil
+}
+
+// demux copies the payload of a multiplexed stream to w. Each frame has an 8 byte
+// header: the stream, 3 bytes of padding and the big endian payload size.
+func demux(r io.Reader, w io.Writer) error {
+	header := make([]byte, 8)
+
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+
+			return err
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+
+		if _, err := io.CopyN(w, r, size); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,418 @@
+// Package dockerapitest provides a fake of the Docker Engine API, for tests.
+package dockerapitest
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethpandaops/contributoor-installer/internal/dockerapi"
+)
+
+// Server is a fake of the Docker Engine API, keeping containers and images in memory.
+// Its fields may be changed by tests, while holding Lock.
+type Server struct {
+	*httptest.Server
+	sync.Mutex
+
+	// Registry holds the images that can be pulled, by reference, eg: busybox:latest.
+	Registry map[string]*dockerapi.Image
+
+	// Images holds the pulled images, by reference.
+	Images map[string]*dockerapi.Image
+
+	// Containers holds the containers, by ID.
+	Containers map[string]*dockerapi.Container
+
+	// Specs holds what each container was created from, by ID.
+	Specs map[string]*dockerapi.ContainerSpec
+
+	// Logs holds each container's output, by ID.
+	Logs map[string]string
+
+	// Requests are the requests made, eg: POST /containers/create.
+	Requests []string
+
+	// Podman makes the API report itself as podman's, rather than docker's.
+	Podman bool
+
+	// Rootless makes the API report the engine runs without root.
+	Rootless bool
+
+	nextID int
+}
+
+// NewServer starts a fake API, which is closed when the test ends.
+func NewServer(t *testing.T) *Server {
+	t.Helper()
+
+	s := &Server{
+		Registry:   make(map[string]*dockerapi.Image),
+		Images:     make(map[string]*dockerapi.Image),
+		Containers: make(map[string]*dockerapi.Container),
+		Specs:      make(map[string]*dockerapi.ContainerSpec),
+		Logs:       make(map[string]string),
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// Host returns the address to reach the API on, eg: for DOCKER_HOST.
+func (s *Server) Host() string {
+	return "tcp://" + strings.TrimPrefix(s.URL, "http://")
+}
+
+// AddImage adds an image that can be pulled, eg: busybox:latest, with the given digest.
+func (s *Server) AddImage(ref, digest string) {
+	s.Lock()
+	defer s.Unlock()
+
+	repository, _, _ := strings.Cut(ref, "@")
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository = repository[:i]
+	}
+
+	image := &dockerapi.Image{
+		ID:          "sha256:" + strings.Repeat(fmt.Sprintf("%x", len(s.Registry)+1), 64)[:64],
+		RepoTags:    []string{ref},
+		RepoDigests: []string{repository + "@" + digest},
+	}
+
+	// Like a registry, the image can be pulled by its digest, even once the tag moves on.
+	s.Registry[ref] = image
+	s.Registry[repository+"@"+digest] = image
+}
+
+// AddContainer adds a container, eg: one left behind by docker compose.
+func (s *Server) AddContainer(container *dockerapi.Container) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.Containers[container.ID] = container
+}
+
+// Container returns the container with the given ID or name, or nil.
+func (s *Server) Container(id string) *dockerapi.Container {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.container(id)
+}
+
+func (s *Server) container(id string) *dockerapi.Container {
+	if c, ok := s.Containers[id]; ok {
+		return c
+	}
+
+	for _, c := range s.Containers {
+		if c.Name == "/"+id {
+			return c
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v"+dockerapi.APIVersion)
+	s.Requests = append(s.Requests, r.Method+" "+path)
+
+	switch {
+	case path == "/_ping":
+		fmt.Fprint(w, "OK")
+	case r.Method == http.MethodGet && path == "/version":
+		component := dockerapi.Component{Name: "Engine", Version: "27.3.1"}
+		if s.Podman {
+			component = dockerapi.Component{Name: "Podman Engine", Version: "5.2.2"}
+		}
+
+		writeJSON(w, dockerapi.Version{Version: component.Version, APIVersion: "1.41", Components: []dockerapi.Component{component}})
+	case r.Method == http.MethodGet && path == "/info":
+		info := dockerapi.Info{SecurityOptions: []string{"name=seccomp,profile=default"}}
+		if s.Rootless {
+			info.SecurityOptions = append(info.SecurityOptions, "name=rootless")
+		}
+
+		writeJSON(w, info)
+	case r.Method == http.MethodPost && path == "/images/create":
+		s.pull(w, r)
+	case r.Method == http.MethodGet && path == "/images/json":
+		s.listImages(w, r)
+	case r.Method == http.MethodDelete && strings.HasPrefix(path, "/images/"):
+		s.removeImage(w, strings.TrimPrefix(path, "/images/"))
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/images/") && strings.HasSuffix(path, "/json"):
+		ref := strings.TrimSuffix(strings.TrimPrefix(path, "/images/"), "/json")
+
+		if image := s.image(ref); image != nil {
+			writeJSON(w, image)
+		} else {
+			writeError(w, http.StatusNotFound, "No such image: "+ref)
+		}
+	case r.Method == http.MethodGet && path == "/containers/json":
+		s.list(w, r)
+	case r.Method == http.MethodPost && path == "/containers/create":
+		s.create(w, r)
+	case strings.HasPrefix(path, "/containers/"):
+		s.handleContainer(w, r, strings.TrimPrefix(path, "/containers/"))
+	default:
+		writeError(w, http.StatusNotFound, "page not found")
+	}
+}
+
+func (s *Server) handleContainer(w http.ResponseWriter, r *http.Request, path string) {
+	id, action, _ := strings.Cut(path, "/")
+
+	c := s.container(id)
+	if c == nil {
+		writeError(w, http.StatusNotFound, "No such container: "+id)
+
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && action == "json":
+		writeJSON(w, c)
+	case r.Method == http.MethodPost && action == "start":
+		c.State.Status, c.State.Running = "running", true
+
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPost && action == "stop":
+		if !c.State.Running {
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		c.State.Status, c.State.Running, c.State.ExitCode = "exited", false, 0
+
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete && action == "":
+		delete(s.Containers, c.ID)
+
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && action == "logs":
+		w.Header().Set("Content-Type", "application/vnd.docker.multiplexed-stream")
+
+		for _, line := range strings.SplitAfter(s.Logs[c.ID], "\n") {
+			if line == "" {
+				continue
+			}
+
+			header := make([]byte, 8)
+			header[0] = 1
+			binary.BigEndian.PutUint32(header[4:], uint32(len(line))) //nolint:gosec // Test logs are small.
+
+			_, _ = w.Write(header)
+			_, _ = w.Write([]byte(line))
+		}
+	default:
+		writeError(w, http.StatusNotFound, "page not found")
+	}
+}
+
+// pull streams the progress of pulling an image from the registry, as the API does.
+func (s *Server) pull(w http.ResponseWriter, r *http.Request) {
+	ref := r.URL.Query().Get("fromImage") + ":" + r.URL.Query().Get("tag")
+	if strings.HasPrefix(r.URL.Query().Get("tag"), "sha256:") {
+		ref = r.URL.Query().Get("fromImage") + "@" + r.URL.Query().Get("tag")
+	}
+
+	encoder := json.NewEncoder(w)
+
+	image, ok := s.Registry[ref]
+	if !ok {
+		_ = encoder.Encode(map[string]string{"error": "manifest for " + ref + " not found: manifest unknown"})
+
+		return
+	}
+
+	layer := image.ID[7:19]
+
+	for _, msg := range []map[string]interface{}{
+		{"status": "Pulling from " + ref},
+		{"status": "Pulling fs layer", "id": layer},
+		{"status": "Downloading", "id": layer, "progressDetail": map[string]int{"current": 1024, "total": 2 * 1024 * 1024}},
+		{"status": "Downloading", "id": layer, "progressDetail": map[string]int{"current": 2048, "total": 2 * 1024 * 1024}},
+		{"status": "Pull complete", "id": layer},
+		{"status": "Digest: " + image.RepoDigests[0][strings.Index(image.RepoDigests[0], "@")+1:]},
+		{"status": "Status: Downloaded newer image for " + ref},
+	} {
+		_ = encoder.Encode(msg)
+	}
+
+	s.Images[ref] = image
+}
+
+func (s *Server) image(ref string) *dockerapi.Image {
+	if image, ok := s.Images[ref]; ok {
+		return image
+	}
+
+	// Images are found by ID, or by digest, whichever tag they were pulled by.
+	for _, image := range s.Images {
+		if image.ID == ref || slices.Contains(image.RepoDigests, ref) {
+			return image
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) listImages(w http.ResponseWriter, r *http.Request) {
+	var filters struct {
+		Reference []string `json:"reference"`
+	}
+
+	if f := r.URL.Query().Get("filters"); f != "" {
+		if err := json.Unmarshal([]byte(f), &filters); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+
+			return
+		}
+	}
+
+	var (
+		images = make([]*dockerapi.Image, 0)
+		seen   = make(map[string]bool)
+	)
+
+	for ref, image := range s.Images {
+		matches := len(filters.Reference) == 0
+
+		for _, repository := range filters.Reference {
+			if ref == repository || strings.HasPrefix(ref, repository+":") || strings.HasPrefix(ref, repository+"@") {
+				matches = true
+			}
+		}
+
+		if matches && !seen[image.ID] {
+			seen[image.ID] = true
+			images = append(images, image)
+		}
+	}
+
+	writeJSON(w, images)
+}
+
+func (s *Server) removeImage(w http.ResponseWriter, ref string) {
+	image := s.image(ref)
+	if image == nil {
+		writeError(w, http.StatusNotFound, "No such image: "+ref)
+
+		return
+	}
+
+	for r, i := range s.Images {
+		if i.ID == image.ID {
+			delete(s.Images, r)
+		}
+	}
+
+	writeJSON(w, []map[string]string{{"Deleted": image.ID}})
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	var filters struct {
+		Label []string `json:"label"`
+	}
+
+	if f := r.URL.Query().Get("filters"); f != "" {
+		if err := json.Unmarshal([]byte(f), &filters); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+
+			return
+		}
+	}
+
+	containers := make([]dockerapi.ContainerSummary, 0)
+
+	for _, c := range s.Containers {
+		if !c.State.Running && r.URL.Query().Get("all") == "" {
+			continue
+		}
+
+		matches := true
+
+		for _, label := range filters.Label {
+			k, v, _ := strings.Cut(label, "=")
+			if c.Config.Labels[k] != v {
+				matches = false
+			}
+		}
+
+		if matches {
+			containers = append(containers, dockerapi.ContainerSummary{
+				ID:     c.ID,
+				Image:  c.Config.Image,
+				State:  c.State.Status,
+				Labels: c.Config.Labels,
+			})
+		}
+	}
+
+	writeJSON(w, containers)
+}
+
+func (s *Server) create(w http.ResponseWriter, r *http.Request) {
+	var spec dockerapi.ContainerSpec
+	if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	if name != "" && s.container(name) != nil {
+		writeError(w, http.StatusConflict, fmt.Sprintf("Conflict. The container name %q is already in use", "/"+name))
+
+		return
+	}
+
+	image := s.image(spec.Image)
+	if image == nil {
+		writeError(w, http.StatusNotFound, "No such image: "+spec.Image)
+
+		return
+	}
+
+	s.nextID++
+
+	c := &dockerapi.Container{
+		ID:    fmt.Sprintf("%064x", s.nextID),
+		Name:  "/" + name,
+		Image: image.ID,
+	}
+	c.State.Status = "created"
+	c.Config.Image = spec.Image
+	c.Config.Labels = spec.Labels
+	c.HostConfig = spec.HostConfig
+
+	s.Containers[c.ID] = c
+	s.Specs[c.ID] = &spec
+
+	w.WriteHeader(http.StatusCreated)
+	writeJSON(w, map[string]string{"Id": c.ID})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
@@ -0,0 +1,183 @@
+package dockerapi
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// defaultContext is docker's context when none is chosen, which uses DOCKER_HOST or
+// docker's socket.
+const defaultContext = "default"
+
+// Endpoint is where the API is served, and how to reach it.
+type Endpoint struct {
+	// Host is the address of the API, eg: unix:///var/run/docker.sock.
+	Host string
+
+	// TLS is the config to connect over TLS with, or nil to connect without it.
+	TLS *tls.Config
+}
+
+// EnvTLSConfig returns the TLS config docker's CLI takes from the environment, or nil if
+// TLS isn't used. DOCKER_TLS_VERIFY turns TLS on, verifying the daemon against ca.pem in
+// DOCKER_CERT_PATH (~/.docker by default), and DOCKER_TLS turns it on without verifying.
+// The client's certificate is cert.pem and key.pem, if they're in DOCKER_CERT_PATH too.
+func EnvTLSConfig() (*tls.Config, error) {
+	verify := os.Getenv("DOCKER_TLS_VERIFY") != ""
+	if !verify && os.Getenv("DOCKER_TLS") == "" {
+		//nolint:nilnil // No TLS isn't an error.
+		return nil, nil
+	}
+
+	certPath := os.Getenv("DOCKER_CERT_PATH")
+	if certPath == "" {
+		dir, err := configDir()
+		if err != nil {
+			return nil, err
+		}
+
+		certPath = dir
+	}
+
+	return loadTLSConfig(certPath, !verify)
+}
+
+// ContextEndpoint returns the endpoint of docker's current context, DOCKER_CONTEXT or
+// else the one set in docker's config.json, eg: by Docker Desktop, colima or orbstack.
+// It's nil for the default context, or if there's no docker config at all.
+func ContextEndpoint() (*Endpoint, error) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+
+	name := os.Getenv("DOCKER_CONTEXT")
+	if name == "" {
+		if name, err = currentContext(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	if name == "" || name == defaultContext {
+		//nolint:nilnil // The default context has no endpoint of its own.
+		return nil, nil
+	}
+
+	// Contexts are stored by the digest of their name.
+	digest := sha256.Sum256([]byte(name))
+	id := hex.EncodeToString(digest[:])
+
+	data, err := os.ReadFile(filepath.Join(dir, "contexts", "meta", id, "meta.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read docker context %q: %w", name, err)
+	}
+
+	var meta struct {
+		Endpoints map[string]struct {
+			Host          string `json:"Host"`
+			SkipTLSVerify bool   `json:"SkipTLSVerify"`
+		} `json:"Endpoints"`
+	}
+
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse docker context %q: %w", name, err)
+	}
+
+	docker := meta.Endpoints["docker"]
+	if docker.Host == "" {
+		return nil, fmt.Errorf("docker context %q has no docker endpoint", name)
+	}
+
+	endpoint := &Endpoint{Host: docker.Host}
+
+	// A context's certificates are kept apart from its metadata, when it has any.
+	tlsDir := filepath.Join(dir, "contexts", "tls", id, "docker")
+	if _, err := os.Stat(tlsDir); err == nil {
+		if endpoint.TLS, err = loadTLSConfig(tlsDir, docker.SkipTLSVerify); err != nil {
+			return nil, fmt.Errorf("failed to load TLS config of docker context %q: %w", name, err)
+		}
+	}
+
+	return endpoint, nil
+}
+
+// configDir returns docker's config directory, DOCKER_CONFIG or ~/.docker.
+func configDir() (string, error) {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir, nil
+	}
+
+	dir, err := homedir.Expand("~/.docker")
+	if err != nil {
+		return "", fmt.Errorf("failed to find docker's config directory: %w", err)
+	}
+
+	return dir, nil
+}
+
+// currentContext returns the context set in docker's config.json, if any.
+func currentContext(dir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to read docker config: %w", err)
+	}
+
+	var cfg struct {
+		CurrentContext string `json:"currentContext"`
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return "", fmt.Errorf("failed to parse docker config: %w", err)
+	}
+
+	return cfg.CurrentContext, nil
+}
+
+// loadTLSConfig loads a TLS config from the ca.pem, cert.pem and key.pem in dir, as
+// docker lays them out. Without ca.pem, the system's roots are trusted.
+func loadTLSConfig(dir string, skipVerify bool) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		//nolint:gosec // Only when asked to, as docker's CLI does.
+		InsecureSkipVerify: skipVerify,
+	}
+
+	ca, err := os.ReadFile(filepath.Join(dir, "ca.pem"))
+
+	switch {
+	case err == nil:
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in %s", filepath.Join(dir, "ca.pem"))
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if _, err := os.Stat(certFile); errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+
+	cfg.Certificates = []tls.Certificate{cert}
+
+	return cfg, nil
+}
@@ -0,0 +1,176 @@
+package dockerapi_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethpandaops/contributoor-installer/internal/dockerapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeContext writes a docker context, as docker context create does.
+func writeContext(t *testing.T, dir, name, meta string) string {
+	t.Helper()
+
+	digest := sha256.Sum256([]byte(name))
+	id := hex.EncodeToString(digest[:])
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "contexts", "meta", id), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "contexts", "meta", id, "meta.json"), []byte(meta), 0600))
+
+	return id
+}
+
+func TestContextEndpoint(t *testing.T) {
+	tests := []struct {
+		name           string
+		config         string
+		contextEnv     string
+		meta           string
+		expectedHost   string
+		expectedNoHost bool
+		expectedError  string
+	}{
+		{
+			name:         "current context",
+			config:       `{"currentContext":"desktop-linux"}`,
+			meta:         `{"Name":"desktop-linux","Endpoints":{"docker":{"Host":"unix:///Users/ethereum/.docker/run/docker.sock"}}}`,
+			expectedHost: "unix:///Users/ethereum/.docker/run/docker.sock",
+		},
+		{
+			name:         "DOCKER_CONTEXT overrides the config",
+			config:       `{"currentContext":"default"}`,
+			contextEnv:   "desktop-linux",
+			meta:         `{"Name":"desktop-linux","Endpoints":{"docker":{"Host":"unix:///Users/ethereum/.docker/run/docker.sock"}}}`,
+			expectedHost: "unix:///Users/ethereum/.docker/run/docker.sock",
+		},
+		{
+			name:           "default context",
+			config:         `{"currentContext":"default"}`,
+			expectedNoHost: true,
+		},
+		{
+			name:           "no docker config",
+			expectedNoHost: true,
+		},
+		{
+			name:          "missing context",
+			config:        `{"currentContext":"orbstack"}`,
+			expectedError: `failed to read docker context "orbstack"`,
+		},
+		{
+			name:          "context without a docker endpoint",
+			config:        `{"currentContext":"desktop-linux"}`,
+			meta:          `{"Name":"desktop-linux","Endpoints":{}}`,
+			expectedError: `docker context "desktop-linux" has no docker endpoint`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			t.Setenv("DOCKER_CONFIG", dir)
+			t.Setenv("DOCKER_CONTEXT", tt.contextEnv)
+
+			if tt.config != "" {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "config.json"), []byte(tt.config), 0600))
+			}
+
+			if tt.meta != "" {
+				writeContext(t, dir, "desktop-linux", tt.meta)
+			}
+
+			endpoint, err := dockerapi.ContextEndpoint()
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			if tt.expectedNoHost {
+				assert.Nil(t, endpoint)
+
+				return
+			}
+
+			require.NotNil(t, endpoint)
+			assert.Equal(t, tt.expectedHost, endpoint.Host)
+			assert.Nil(t, endpoint.TLS)
+		})
+	}
+}
+
+func TestEndpoint_TLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "OK")
+	}))
+	t.Cleanup(server.Close)
+
+	var (
+		host   = "tcp://" + strings.TrimPrefix(server.URL, "https://")
+		caCert = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	)
+
+	t.Run("environment", func(t *testing.T) {
+		certPath := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(certPath, "ca.pem"), caCert, 0600))
+
+		t.Setenv("DOCKER_TLS", "")
+		t.Setenv("DOCKER_TLS_VERIFY", "")
+		t.Setenv("DOCKER_CERT_PATH", certPath)
+
+		tlsConfig, err := dockerapi.EnvTLSConfig()
+		require.NoError(t, err)
+		assert.Nil(t, tlsConfig)
+
+		t.Setenv("DOCKER_TLS_VERIFY", "1")
+
+		tlsConfig, err = dockerapi.EnvTLSConfig()
+		require.NoError(t, err)
+		require.NotNil(t, tlsConfig)
+
+		client, err := dockerapi.NewEndpointClient(&dockerapi.Endpoint{Host: host, TLS: tlsConfig})
+		require.NoError(t, err)
+		require.NoError(t, client.Ping(context.Background()))
+
+		// Without TLS, the daemon refuses us.
+		client, err = dockerapi.NewClient(host)
+		require.NoError(t, err)
+		assert.Error(t, client.Ping(context.Background()))
+	})
+
+	t.Run("context", func(t *testing.T) {
+		dir := t.TempDir()
+
+		t.Setenv("DOCKER_CONFIG", dir)
+		t.Setenv("DOCKER_CONTEXT", "remote")
+
+		id := writeContext(t, dir, "remote", fmt.Sprintf(`{"Name":"remote","Endpoints":{"docker":{"Host":%q}}}`, host))
+
+		tlsDir := filepath.Join(dir, "contexts", "tls", id, "docker")
+		require.NoError(t, os.MkdirAll(tlsDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(tlsDir, "ca.pem"), caCert, 0600))
+
+		endpoint, err := dockerapi.ContextEndpoint()
+		require.NoError(t, err)
+		require.NotNil(t, endpoint.TLS)
+
+		client, err := dockerapi.NewEndpointClient(endpoint)
+		require.NoError(t, err)
+		require.NoError(t, client.Ping(context.Background()))
+	})
+}
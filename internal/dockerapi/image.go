@@ -0,0 +1,154 @@
+package dockerapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Image is an image, as inspected.
+type Image struct {
+	ID          string   `json:"Id"`
+	RepoTags    []string `json:"RepoTags"`
+	RepoDigests []string `json:"RepoDigests"`
+}
+
+// Digest returns the image's content digest in the given repository, eg: sha256:abc...,
+// or empty if it hasn't come from a registry.
+func (i *Image) Digest(repository string) string {
+	for _, repoDigest := range i.RepoDigests {
+		name, digest, ok := strings.Cut(repoDigest, "@")
+		if ok && normalizeRepository(name) == normalizeRepository(repository) {
+			return digest
+		}
+	}
+
+	return ""
+}
+
+// normalizeRepository strips the docker.io prefixes podman keeps, and docker doesn't.
+func normalizeRepository(name string) string {
+	name = strings.TrimPrefix(name, "docker.io/")
+
+	return strings.TrimPrefix(name, "library/")
+}
+
+// PullProgress is a message streamed while an image is pulled, eg: a layer downloading.
+type PullProgress struct {
+	// ID is the layer, if the message is about one.
+	ID string
+
+	// Status is what's happening, eg: Downloading or Pull complete.
+	Status string
+
+	// Current and Total are the bytes done and to do, if known.
+	Current int64
+	Total   int64
+}
+
+// pullMessage is a message in the stream returned by /images/create.
+type pullMessage struct {
+	ID             string `json:"id"`
+	Status         string `json:"status"`
+	ProgressDetail struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+	Error string `json:"error"`
+}
+
+// ImagePull pulls an image, eg: ethpandaops/contributoor:1.0.0, calling progress with
+// each message the API streams about it.
+func (c *Client) ImagePull(ctx context.Context, ref string, progress func(PullProgress)) error {
+	image, tag := splitReference(ref)
+
+	resp, err := c.do(ctx, http.MethodPost, "/images/create", url.Values{"fromImage": {image}, "tag": {tag}}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to pull %s: %w", ref, err)
+	}
+
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+
+	for {
+		var msg pullMessage
+		if err := decoder.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return fmt.Errorf("failed to read progress pulling %s: %w", ref, err)
+		}
+
+		// Errors after the pull has started, eg: the tag not existing, are in the stream.
+		if msg.Error != "" {
+			return fmt.Errorf("failed to pull %s: %s", ref, msg.Error)
+		}
+
+		if progress != nil {
+			progress(PullProgress{
+				ID:      msg.ID,
+				Status:  msg.Status,
+				Current: msg.ProgressDetail.Current,
+				Total:   msg.ProgressDetail.Total,
+			})
+		}
+	}
+}
+
+// ImageInspect returns the image with the given reference or ID.
+func (c *Client) ImageInspect(ctx context.Context, ref string) (*Image, error) {
+	var image Image
+	if err := c.doJSON(ctx, http.MethodGet, "/images/"+ref+"/json", nil, nil, &image); err != nil {
+		return nil, fmt.Errorf("failed to inspect image %s: %w", ref, err)
+	}
+
+	return &image, nil
+}
+
+// ImageList lists the images in the given repository, eg: ethpandaops/contributoor,
+// whatever their tag.
+func (c *Client) ImageList(ctx context.Context, repository string) ([]Image, error) {
+	filters, err := json.Marshal(map[string][]string{"reference": {repository}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode filters: %w", err)
+	}
+
+	var images []Image
+	if err := c.doJSON(ctx, http.MethodGet, "/images/json", url.Values{"filters": {string(filters)}}, nil, &images); err != nil {
+		return nil, fmt.Errorf("failed to list images: %w", err)
+	}
+
+	return images, nil
+}
+
+// ImageRemove removes an image by reference or ID, along with all its tags. An image
+// used by a container can't be removed.
+func (c *Client) ImageRemove(ctx context.Context, ref string) error {
+	if err := c.doJSON(ctx, http.MethodDelete, "/images/"+ref, url.Values{"force": {"1"}}, nil, nil); err != nil {
+		return fmt.Errorf("failed to remove image %s: %w", ref, err)
+	}
+
+	return nil
+}
+
+// splitReference splits an image reference into the image and its tag or digest. The
+// tag defaults to latest.
+func splitReference(ref string) (string, string) {
+	if image, digest, ok := strings.Cut(ref, "@"); ok {
+		return image, digest
+	}
+
+	// A colon after the last slash is a tag, rather than a registry's port.
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		return ref[:i], ref[i+1:]
+	}
+
+	return ref, "latest"
+}
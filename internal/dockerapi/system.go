@@ -0,0 +1,69 @@
+package dockerapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Version is the engine's version, as reported by /version.
+type Version struct {
+	Version    string      `json:"Version"`
+	APIVersion string      `json:"ApiVersion"`
+	Components []Component `json:"Components"`
+}
+
+// Component is a part of the engine, eg: Engine for docker, or Podman Engine.
+type Component struct {
+	Name    string `json:"Name"`
+	Version string `json:"Version"`
+}
+
+// IsPodman reports whether the API is podman's docker compatible one.
+func (v *Version) IsPodman() bool {
+	for _, c := range v.Components {
+		if strings.HasPrefix(c.Name, "Podman") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Info is system wide information about the engine, as reported by /info.
+type Info struct {
+	SecurityOptions []string `json:"SecurityOptions"`
+}
+
+// Rootless reports whether the engine runs without root, as rootless docker or podman
+// run by a user do.
+func (i *Info) Rootless() bool {
+	for _, opt := range i.SecurityOptions {
+		if opt == "name=rootless" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Version returns the engine's version.
+func (c *Client) Version(ctx context.Context) (*Version, error) {
+	var version Version
+	if err := c.doJSON(ctx, http.MethodGet, "/version", nil, nil, &version); err != nil {
+		return nil, fmt.Errorf("failed to get engine version: %w", err)
+	}
+
+	return &version, nil
+}
+
+// Info returns system wide information about the engine.
+func (c *Client) Info(ctx context.Context) (*Info, error) {
+	var info Info
+	if err := c.doJSON(ctx, http.MethodGet, "/info", nil, nil, &info); err != nil {
+		return nil, fmt.Errorf("failed to get engine info: %w", err)
+	}
+
+	return &info, nil
+}
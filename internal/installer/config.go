@@ -1,6 +1,11 @@
 package installer
 
-import "github.com/sirupsen/logrus"
+import (
+	"time"
+
+	"github.com/ethpandaops/contributoor-installer/internal/logrotate"
+	"github.com/sirupsen/logrus"
+)
 
 // Config holds installer-specific configuration that isn't exposed to the sidecar.
 type Config struct {
@@ -14,14 +19,87 @@ type Config struct {
 	GithubOrg string
 	// GithubRepo is the repository name of the sidecar repository.
 	GithubRepo string
+	// VersionRetention is the number of previous versions kept around for rollback.
+	VersionRetention int
+	// HealthCheckTimeout is how long the sidecar is watched for after an update before
+	// it's considered healthy. Zero disables the health check.
+	HealthCheckTimeout time.Duration
+	// HealthCheckInterval is how often the sidecar is polled during the health check.
+	HealthCheckInterval time.Duration
+	// HealthCheckURL is an optional sentry endpoint that must respond during the health check.
+	HealthCheckURL string
+	// StopGracePeriod is how long the sidecar has to exit after being asked to stop,
+	// before it's killed.
+	StopGracePeriod time.Duration
+	// LogRotation is the default policy the sidecar's log files, and the installer's own,
+	// are rotated with. It can be overridden in the installer state, see LogRotationPolicy.
+	LogRotation logrotate.Policy
+	// SystemdUnit controls the unit file generated for the systemd run method.
+	SystemdUnit SystemdUnitConfig
+}
+
+// SystemdUnitConfig controls the unit file generated for the systemd run method. See
+// systemd.service(5), systemd.resource-control(5) and systemd.exec(5) for the directives.
+type SystemdUnitConfig struct {
+	// Path is where the unit file is installed.
+	Path string
+	// Restart is the restart policy, eg: always or on-failure.
+	Restart string
+	// RestartSec is how long systemd waits before restarting the sidecar.
+	RestartSec time.Duration
+	// MemoryMax caps the sidecar's memory, eg: 2G. Empty means no limit.
+	MemoryMax string
+	// CPUQuota caps the sidecar's CPU time, eg: 200%. Empty means no limit.
+	CPUQuota string
+	// LimitNOFILE caps the number of files the sidecar can open. Zero keeps systemd's default.
+	LimitNOFILE int
+	// NoNewPrivileges stops the sidecar gaining privileges, eg: through setuid binaries.
+	NoNewPrivileges bool
+	// ProtectSystem mounts system directories read-only, eg: full or strict. Empty disables it.
+	ProtectSystem string
+	// ProtectHome restricts access to home directories, eg: read-only. Empty disables it.
+	ProtectHome string
+	// PrivateTmp gives the sidecar its own /tmp.
+	PrivateTmp bool
+}
+
+// LogRotationPolicy returns the policy log files are rotated with, which is LogRotation
+// with any settings in the installer state, in configDir, applied over it. On error,
+// LogRotation is returned as it is.
+func (c *Config) LogRotationPolicy(configDir string) (logrotate.Policy, error) {
+	state, err := LoadState(configDir)
+	if err != nil {
+		return c.LogRotation, err
+	}
+
+	return state.LogRotation.Policy(c.LogRotation)
 }
 
 // NewConfig returns the default installer configuration.
 func NewConfig() *Config {
 	return &Config{
-		LogLevel:    logrus.InfoLevel.String(),
-		DockerImage: "ethpandaops/contributoor",
-		GithubOrg:   "ethpandaops",
-		GithubRepo:  "contributoor",
+		LogLevel:            logrus.InfoLevel.String(),
+		DockerImage:         "ethpandaops/contributoor",
+		GithubOrg:           "ethpandaops",
+		GithubRepo:          "contributoor",
+		VersionRetention:    2,
+		HealthCheckTimeout:  30 * time.Second,
+		HealthCheckInterval: 2 * time.Second,
+		StopGracePeriod:     30 * time.Second,
+		LogRotation: logrotate.Policy{
+			MaxSize:   100 * 1024 * 1024,
+			MaxAge:    7 * 24 * time.Hour,
+			Retention: 5,
+			Compress:  true,
+		},
+		SystemdUnit: SystemdUnitConfig{
+			Path:            "/etc/systemd/system/contributoor.service",
+			Restart:         "always",
+			RestartSec:      5 * time.Second,
+			NoNewPrivileges: true,
+			ProtectSystem:   "full",
+			ProtectHome:     "read-only",
+			PrivateTmp:      true,
+		},
 	}
 }
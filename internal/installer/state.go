@@ -0,0 +1,456 @@
+package installer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethpandaops/contributoor-installer/internal/logrotate"
+	"gopkg.in/yaml.v3"
+)
+
+// StateFile is the name of the file installer state is persisted to, alongside config.yaml.
+const StateFile = "state.yaml"
+
+// SystemdScope is the scope of the systemd unit used by the systemd run method.
+type SystemdScope string
+
+const (
+	// SystemdScopeSystem is a system-wide unit, which needs root to manage. It's the default.
+	SystemdScopeSystem SystemdScope = "system"
+
+	// SystemdScopeUser is a unit run by the user's own service manager, without root.
+	SystemdScopeUser SystemdScope = "user"
+)
+
+// ParseSystemdScope parses a systemd scope, eg: user.
+func ParseSystemdScope(scope string) (SystemdScope, error) {
+	switch SystemdScope(scope) {
+	case SystemdScopeSystem, SystemdScopeUser:
+		return SystemdScope(scope), nil
+	default:
+		return "", fmt.Errorf("invalid systemd scope %q, expected %s or %s", scope, SystemdScopeSystem, SystemdScopeUser)
+	}
+}
+
+// PullPolicy says when the docker run method pulls the image before starting it.
+type PullPolicy string
+
+const (
+	// PullPolicyAlways pulls on every start, by the digest the version is pinned to.
+	PullPolicyAlways PullPolicy = "always"
+
+	// PullPolicyMissing only pulls if the image isn't available locally. It's the default.
+	PullPolicyMissing PullPolicy = "missing"
+
+	// PullPolicyNever never pulls on start, failing if the image isn't available locally.
+	// Images are still pulled by an update.
+	PullPolicyNever PullPolicy = "never"
+)
+
+// PullPolicies are the valid pull policies, default first.
+var PullPolicies = []PullPolicy{PullPolicyMissing, PullPolicyAlways, PullPolicyNever}
+
+// ParsePullPolicy parses a pull policy, eg: never.
+func ParsePullPolicy(policy string) (PullPolicy, error) {
+	switch PullPolicy(policy) {
+	case PullPolicyAlways, PullPolicyMissing, PullPolicyNever:
+		return PullPolicy(policy), nil
+	default:
+		return "", fmt.Errorf("invalid pull policy %q, expected %s, %s or %s", policy, PullPolicyAlways, PullPolicyMissing, PullPolicyNever)
+	}
+}
+
+// Channel says which version an update moves to, when it's not given one.
+type Channel string
+
+const (
+	// ChannelStable follows the newest stable release. It's the default.
+	ChannelStable Channel = "stable"
+
+	// ChannelBeta follows the newest release or beta and rc pre-release, eg: 1.2.0-rc.1.
+	ChannelBeta Channel = "beta"
+
+	// ChannelNightly follows the newest release or pre-release of any kind.
+	ChannelNightly Channel = "nightly"
+
+	// ChannelPinned stays on the installed version, until update is given another.
+	ChannelPinned Channel = "pinned"
+)
+
+// Channels are the valid channels, default first.
+var Channels = []Channel{ChannelStable, ChannelBeta, ChannelNightly, ChannelPinned}
+
+// ParseChannel parses a channel, eg: beta.
+func ParseChannel(channel string) (Channel, error) {
+	switch Channel(channel) {
+	case ChannelStable, ChannelBeta, ChannelNightly, ChannelPinned:
+		return Channel(channel), nil
+	default:
+		return "", fmt.Errorf("invalid channel %q, expected %s, %s, %s or %s", channel, ChannelStable, ChannelBeta, ChannelNightly, ChannelPinned)
+	}
+}
+
+// ContainerRuntime is the engine the docker run method runs the container under.
+type ContainerRuntime string
+
+const (
+	// ContainerRuntimeDocker is docker's engine.
+	ContainerRuntimeDocker ContainerRuntime = "docker"
+
+	// ContainerRuntimePodman is podman, through its docker compatible API.
+	ContainerRuntimePodman ContainerRuntime = "podman"
+)
+
+// ParseContainerRuntime parses a container runtime, eg: podman.
+func ParseContainerRuntime(runtime string) (ContainerRuntime, error) {
+	switch ContainerRuntime(runtime) {
+	case ContainerRuntimeDocker, ContainerRuntimePodman:
+		return ContainerRuntime(runtime), nil
+	default:
+		return "", fmt.Errorf("invalid container runtime %q, expected %s or %s", runtime, ContainerRuntimeDocker, ContainerRuntimePodman)
+	}
+}
+
+// ContainerSettings customise the container the docker run method creates, beyond the
+// image, command and config mount it always has.
+type ContainerSettings struct {
+	// Network is a network mode, eg: host, or the name of a network to join, eg: the
+	// beacon node's. Empty means the runtime's default bridge.
+	Network string `yaml:"network,omitempty"`
+
+	// CPUs limits how many CPUs the container can use, eg: 1.5. Zero is unlimited.
+	CPUs float64 `yaml:"cpus,omitempty"`
+
+	// Memory limits the memory the container can use, eg: 2g. Empty is unlimited.
+	Memory string `yaml:"memory,omitempty"`
+
+	// Volumes are extra mounts, eg: /data/jwt:/jwt:ro.
+	Volumes []string `yaml:"volumes,omitempty"`
+
+	// Env are extra environment variables, eg: TZ=UTC.
+	Env []string `yaml:"env,omitempty"`
+
+	// LogDriver is the logging driver, eg: journald. Empty is the runtime's default.
+	LogDriver string `yaml:"logDriver,omitempty"`
+
+	// LogOptions are options for the logging driver, eg: max-size: 10m.
+	LogOptions map[string]string `yaml:"logOptions,omitempty"`
+}
+
+// Validate checks the settings can be applied to a container.
+func (c *ContainerSettings) Validate() error {
+	if strings.ContainsAny(c.Network, " \t") {
+		return fmt.Errorf("invalid network %q", c.Network)
+	}
+
+	if c.CPUs < 0 {
+		return fmt.Errorf("invalid cpus %v, expected a positive number", c.CPUs)
+	}
+
+	if _, err := ParseMemory(c.Memory); err != nil {
+		return err
+	}
+
+	for _, volume := range c.Volumes {
+		if parts := strings.Split(volume, ":"); len(parts) < 2 || len(parts) > 3 || parts[0] == "" || !strings.HasPrefix(parts[1], "/") {
+			return fmt.Errorf("invalid volume %q, expected source:/target or source:/target:options", volume)
+		}
+	}
+
+	for _, env := range c.Env {
+		if key, _, ok := strings.Cut(env, "="); !ok || key == "" {
+			return fmt.Errorf("invalid environment variable %q, expected KEY=value", env)
+		}
+	}
+
+	if len(c.LogOptions) > 0 && c.LogDriver == "" {
+		return errors.New("log options need a log driver")
+	}
+
+	return nil
+}
+
+// ParseMemory parses a memory size in bytes, or with a b, k, m or g suffix, eg: 512m.
+// An empty size is zero, meaning unlimited.
+func ParseMemory(size string) (int64, error) {
+	if size == "" {
+		return 0, nil
+	}
+
+	var (
+		number     = strings.ToLower(size)
+		multiplier = int64(1)
+	)
+
+	switch number[len(number)-1] {
+	case 'b':
+		number = number[:len(number)-1]
+	case 'k':
+		number, multiplier = number[:len(number)-1], 1<<10
+	case 'm':
+		number, multiplier = number[:len(number)-1], 1<<20
+	case 'g':
+		number, multiplier = number[:len(number)-1], 1<<30
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("invalid memory %q, expected eg: 512m or 2g", size)
+	}
+
+	return int64(value * float64(multiplier)), nil
+}
+
+// LogRotationSettings override the policy the binary run method's log files, and the
+// installer's own, are rotated with. Unset settings keep the installer's defaults.
+type LogRotationSettings struct {
+	// MaxSize is the size a log file may grow to before it's rotated, eg: 100m. 0
+	// disables size based rotation.
+	MaxSize string `yaml:"maxSize,omitempty"`
+
+	// MaxAge is how long a log file is written to before it's rotated, eg: 168h. 0
+	// disables age based rotation.
+	MaxAge string `yaml:"maxAge,omitempty"`
+
+	// Retention is the number of rotated files kept for each log file. 0 keeps them all.
+	Retention *int `yaml:"retention,omitempty"`
+
+	// Compress gzips rotated files.
+	Compress *bool `yaml:"compress,omitempty"`
+}
+
+// Policy returns defaults with the settings applied over it.
+func (l *LogRotationSettings) Policy(defaults logrotate.Policy) (logrotate.Policy, error) {
+	policy := defaults
+
+	if l.MaxSize != "" {
+		size, err := parseLogSize(l.MaxSize)
+		if err != nil {
+			return defaults, err
+		}
+
+		policy.MaxSize = size
+	}
+
+	if l.MaxAge != "" {
+		age, err := time.ParseDuration(l.MaxAge)
+		if err != nil || age < 0 {
+			return defaults, fmt.Errorf("invalid log max age %q, expected eg: 168h, or 0 to disable", l.MaxAge)
+		}
+
+		policy.MaxAge = age
+	}
+
+	if l.Retention != nil {
+		if *l.Retention < 0 {
+			return defaults, fmt.Errorf("invalid log retention %d, expected a positive number, or 0 to keep them all", *l.Retention)
+		}
+
+		policy.Retention = *l.Retention
+	}
+
+	if l.Compress != nil {
+		policy.Compress = *l.Compress
+	}
+
+	return policy, nil
+}
+
+// parseLogSize parses the size a log file may grow to, as ParseMemory does, or 0.
+func parseLogSize(size string) (int64, error) {
+	if size == "0" {
+		return 0, nil
+	}
+
+	bytes, err := ParseMemory(size)
+	if err != nil {
+		return 0, fmt.Errorf("invalid log max size %q, expected eg: 100m, or 0 to disable", size)
+	}
+
+	return bytes, nil
+}
+
+// State holds what must survive between invocations, but has no place in the sidecar
+// config. Some of it is managed by the installer alone, eg: the previous versions and
+// image digests. The rest are user-facing settings, the log rotation, pull policy,
+// container runtime, channel, container settings, release source and cosign public key,
+// which 'contributoor config' gets, sets and unsets as though they were in the config.
+type State struct {
+	// PreviousVersions is the list of known-good versions we've moved away from,
+	// oldest first. It's used to roll back a bad update.
+	PreviousVersions []string `yaml:"previousVersions,omitempty"`
+
+	// ConfigSchemaVersion is the schema version config.yaml has been migrated to. It's
+	// kept here, as the config itself has no room for it.
+	ConfigSchemaVersion int `yaml:"configSchemaVersion,omitempty"`
+
+	// SystemdScope is the scope of the systemd unit, chosen alongside the systemd run
+	// method. It's kept here, as the config has no room for it either.
+	SystemdScope SystemdScope `yaml:"systemdScope,omitempty"`
+
+	// PullPolicy says when the docker run method pulls the image before starting it.
+	PullPolicy PullPolicy `yaml:"pullPolicy,omitempty"`
+
+	// ContainerRuntime is the engine the docker run method uses. Empty means whichever
+	// is found, preferring docker.
+	ContainerRuntime ContainerRuntime `yaml:"containerRuntime,omitempty"`
+
+	// Channel says which version an update moves to, when it's not given one.
+	Channel Channel `yaml:"channel,omitempty"`
+
+	// Container customises the container the docker run method creates.
+	Container ContainerSettings `yaml:"container,omitempty"`
+
+	// LogRotation overrides how log files are rotated.
+	LogRotation LogRotationSettings `yaml:"logRotation,omitempty"`
+
+	// ImageDigests pins each version's image to the digest its tag resolved to, so a
+	// version always runs the same image, even once its tag has moved, eg: latest.
+	ImageDigests map[string]string `yaml:"imageDigests,omitempty"`
+
+	// ReleaseSource is where releases are looked up and downloaded from: empty for
+	// GitHub, the URL of a mirror, or the absolute path of a local directory or bundle.
+	ReleaseSource string `yaml:"releaseSource,omitempty"`
+
+	// CosignPublicKey is the path to a cosign public key. When set, an image's signature
+	// must verify against it before the image is pinned.
+	CosignPublicKey string `yaml:"cosignPublicKey,omitempty"`
+
+	path string
+}
+
+// LoadState loads installer state from the given directory. A missing state file isn't
+// an error, it just means nothing has been recorded yet.
+func LoadState(dir string) (*State, error) {
+	state := &State{
+		path: filepath.Join(dir, StateFile),
+	}
+
+	data, err := os.ReadFile(state.path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read installer state: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse installer state: %w", err)
+	}
+
+	return state, nil
+}
+
+// Save atomically persists the state to disk.
+func (s *State) Save() error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal installer state: %w", err)
+	}
+
+	tmpPath := fmt.Sprintf("%s.tmp", s.path)
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write installer state: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		os.Remove(tmpPath)
+
+		return fmt.Errorf("failed to save installer state: %w", err)
+	}
+
+	return nil
+}
+
+// GetSystemdScope returns the scope of the systemd unit, defaulting to system-wide.
+func (s *State) GetSystemdScope() SystemdScope {
+	if s.SystemdScope == "" {
+		return SystemdScopeSystem
+	}
+
+	return s.SystemdScope
+}
+
+// GetPullPolicy returns the pull policy, defaulting to only pulling missing images.
+func (s *State) GetPullPolicy() PullPolicy {
+	if s.PullPolicy == "" {
+		return PullPolicyMissing
+	}
+
+	return s.PullPolicy
+}
+
+// GetChannel returns the channel updates follow, defaulting to stable.
+func (s *State) GetChannel() Channel {
+	if s.Channel == "" {
+		return ChannelStable
+	}
+
+	return s.Channel
+}
+
+// PushVersion records version as the most recent known-good version, keeping at most
+// retention versions. Re-pushing a version moves it to the top.
+func (s *State) PushVersion(version string, retention int) {
+	versions := make([]string, 0, len(s.PreviousVersions)+1)
+
+	for _, v := range s.PreviousVersions {
+		if v != version {
+			versions = append(versions, v)
+		}
+	}
+
+	versions = append(versions, version)
+
+	if retention > 0 && len(versions) > retention {
+		versions = versions[len(versions)-retention:]
+	}
+
+	s.PreviousVersions = versions
+}
+
+// PreviousVersion returns the most recent known-good version, or an empty string if
+// there isn't one.
+func (s *State) PreviousVersion() string {
+	if len(s.PreviousVersions) == 0 {
+		return ""
+	}
+
+	return s.PreviousVersions[len(s.PreviousVersions)-1]
+}
+
+// PopVersion removes and returns the most recent known-good version.
+func (s *State) PopVersion() string {
+	version := s.PreviousVersion()
+	if version != "" {
+		s.PreviousVersions = s.PreviousVersions[:len(s.PreviousVersions)-1]
+	}
+
+	return version
+}
+
+// ImageDigest returns the digest the version's image is pinned to, or an empty string
+// if it isn't pinned.
+func (s *State) ImageDigest(version string) string {
+	return s.ImageDigests[version]
+}
+
+// PinImage pins the version's image to digest. Pins for versions other than it, and
+// those kept for rollback, are forgotten.
+func (s *State) PinImage(version, digest string) {
+	digests := map[string]string{version: digest}
+
+	for _, v := range s.PreviousVersions {
+		if d, ok := s.ImageDigests[v]; ok && v != version {
+			digests[v] = d
+		}
+	}
+
+	s.ImageDigests = digests
+}
@@ -0,0 +1,366 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethpandaops/contributoor-installer/internal/logrotate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestState_PushVersion(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  []string
+		version   string
+		retention int
+		expected  []string
+	}{
+		{
+			name:      "appends to empty state",
+			version:   "v1.0.0",
+			retention: 2,
+			expected:  []string{"v1.0.0"},
+		},
+		{
+			name:      "trims to retention",
+			existing:  []string{"v0.9.0", "v1.0.0"},
+			version:   "v1.1.0",
+			retention: 2,
+			expected:  []string{"v1.0.0", "v1.1.0"},
+		},
+		{
+			name:      "moves existing version to the top",
+			existing:  []string{"v1.0.0", "v1.1.0"},
+			version:   "v1.0.0",
+			retention: 2,
+			expected:  []string{"v1.1.0", "v1.0.0"},
+		},
+		{
+			name:      "zero retention keeps everything",
+			existing:  []string{"v0.9.0", "v1.0.0"},
+			version:   "v1.1.0",
+			retention: 0,
+			expected:  []string{"v0.9.0", "v1.0.0", "v1.1.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &State{PreviousVersions: tt.existing}
+			state.PushVersion(tt.version, tt.retention)
+			assert.Equal(t, tt.expected, state.PreviousVersions)
+		})
+	}
+}
+
+func TestState_PopVersion(t *testing.T) {
+	state := &State{PreviousVersions: []string{"v1.0.0", "v1.1.0"}}
+
+	assert.Equal(t, "v1.1.0", state.PopVersion())
+	assert.Equal(t, "v1.0.0", state.PreviousVersion())
+	assert.Equal(t, "v1.0.0", state.PopVersion())
+	assert.Equal(t, "", state.PopVersion())
+	assert.Equal(t, "", state.PreviousVersion())
+}
+
+func TestState_PinImage(t *testing.T) {
+	state := &State{PreviousVersions: []string{"v1.0.0"}}
+
+	assert.Equal(t, "", state.ImageDigest("v1.0.0"))
+
+	state.PinImage("v1.0.0", "sha256:1000")
+	state.PinImage("v1.1.0", "sha256:1100")
+	assert.Equal(t, "sha256:1000", state.ImageDigest("v1.0.0"))
+	assert.Equal(t, "sha256:1100", state.ImageDigest("v1.1.0"))
+
+	// Repinning moves the pin.
+	state.PinImage("v1.1.0", "sha256:1101")
+	assert.Equal(t, "sha256:1101", state.ImageDigest("v1.1.0"))
+
+	// Pins for versions that can't be rolled back to are forgotten.
+	state.PreviousVersions = []string{"v1.1.0"}
+	state.PinImage("v1.2.0", "sha256:1200")
+	assert.Equal(t, map[string]string{"v1.1.0": "sha256:1101", "v1.2.0": "sha256:1200"}, state.ImageDigests)
+}
+
+func TestLoadState(t *testing.T) {
+	t.Run("missing file returns empty state", func(t *testing.T) {
+		state, err := LoadState(t.TempDir())
+		require.NoError(t, err)
+		assert.Empty(t, state.PreviousVersions)
+	})
+
+	t.Run("round trips through disk", func(t *testing.T) {
+		dir := t.TempDir()
+
+		state, err := LoadState(dir)
+		require.NoError(t, err)
+
+		state.PushVersion("v1.0.0", 2)
+		require.NoError(t, state.Save())
+
+		loaded, err := LoadState(dir)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"v1.0.0"}, loaded.PreviousVersions)
+		assert.NoFileExists(t, filepath.Join(dir, StateFile+".tmp"))
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, StateFile), []byte("previousVersions: {"), 0600))
+
+		_, err := LoadState(dir)
+		assert.ErrorContains(t, err, "failed to parse installer state")
+	})
+}
+
+func TestState_SystemdScope(t *testing.T) {
+	dir := t.TempDir()
+
+	state, err := LoadState(dir)
+	require.NoError(t, err)
+	assert.Equal(t, SystemdScopeSystem, state.GetSystemdScope())
+
+	state.SystemdScope = SystemdScopeUser
+	require.NoError(t, state.Save())
+
+	loaded, err := LoadState(dir)
+	require.NoError(t, err)
+	assert.Equal(t, SystemdScopeUser, loaded.GetSystemdScope())
+
+	scope, err := ParseSystemdScope("user")
+	require.NoError(t, err)
+	assert.Equal(t, SystemdScopeUser, scope)
+
+	_, err = ParseSystemdScope("global")
+	assert.EqualError(t, err, `invalid systemd scope "global", expected system or user`)
+}
+
+func TestState_Channel(t *testing.T) {
+	dir := t.TempDir()
+
+	state, err := LoadState(dir)
+	require.NoError(t, err)
+	assert.Equal(t, ChannelStable, state.GetChannel())
+
+	state.Channel = ChannelPinned
+	require.NoError(t, state.Save())
+
+	loaded, err := LoadState(dir)
+	require.NoError(t, err)
+	assert.Equal(t, ChannelPinned, loaded.GetChannel())
+
+	channel, err := ParseChannel("beta")
+	require.NoError(t, err)
+	assert.Equal(t, ChannelBeta, channel)
+
+	_, err = ParseChannel("rc")
+	assert.EqualError(t, err, `invalid channel "rc", expected stable, beta, nightly or pinned`)
+}
+
+func TestState_PullPolicy(t *testing.T) {
+	dir := t.TempDir()
+
+	state, err := LoadState(dir)
+	require.NoError(t, err)
+	assert.Equal(t, PullPolicyMissing, state.GetPullPolicy())
+
+	state.PullPolicy = PullPolicyNever
+	require.NoError(t, state.Save())
+
+	loaded, err := LoadState(dir)
+	require.NoError(t, err)
+	assert.Equal(t, PullPolicyNever, loaded.GetPullPolicy())
+
+	policy, err := ParsePullPolicy("always")
+	require.NoError(t, err)
+	assert.Equal(t, PullPolicyAlways, policy)
+
+	_, err = ParsePullPolicy("sometimes")
+	assert.EqualError(t, err, `invalid pull policy "sometimes", expected always, missing or never`)
+}
+
+func TestLogRotationSettings_Policy(t *testing.T) {
+	var (
+		defaults  = NewConfig().LogRotation
+		retention = 2
+		compress  = false
+	)
+
+	tests := []struct {
+		name          string
+		settings      LogRotationSettings
+		expected      logrotate.Policy
+		expectedError string
+	}{
+		{
+			name:     "unset",
+			expected: defaults,
+		},
+		{
+			name:     "overridden",
+			settings: LogRotationSettings{MaxSize: "10m", MaxAge: "24h", Retention: &retention, Compress: &compress},
+			expected: logrotate.Policy{MaxSize: 10 << 20, MaxAge: 24 * time.Hour, Retention: 2},
+		},
+		{
+			name:     "disabled",
+			settings: LogRotationSettings{MaxSize: "0", MaxAge: "0"},
+			expected: logrotate.Policy{Retention: defaults.Retention, Compress: defaults.Compress},
+		},
+		{
+			name:          "invalid max size",
+			settings:      LogRotationSettings{MaxSize: "huge"},
+			expectedError: `invalid log max size "huge", expected eg: 100m, or 0 to disable`,
+		},
+		{
+			name:          "invalid max age",
+			settings:      LogRotationSettings{MaxAge: "7d"},
+			expectedError: `invalid log max age "7d", expected eg: 168h, or 0 to disable`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := tt.settings.Policy(defaults)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				assert.Equal(t, defaults, policy)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, policy)
+		})
+	}
+}
+
+func TestConfig_LogRotationPolicy(t *testing.T) {
+	var (
+		dir = t.TempDir()
+		cfg = NewConfig()
+	)
+
+	policy, err := cfg.LogRotationPolicy(dir)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.LogRotation, policy)
+
+	state, err := LoadState(dir)
+	require.NoError(t, err)
+
+	state.LogRotation.MaxSize = "1g"
+	require.NoError(t, state.Save())
+
+	policy, err = cfg.LogRotationPolicy(dir)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1<<30), policy.MaxSize)
+	assert.Equal(t, cfg.LogRotation.MaxAge, policy.MaxAge)
+}
+
+func TestParseContainerRuntime(t *testing.T) {
+	runtime, err := ParseContainerRuntime("podman")
+	require.NoError(t, err)
+	assert.Equal(t, ContainerRuntimePodman, runtime)
+
+	_, err = ParseContainerRuntime("containerd")
+	assert.EqualError(t, err, `invalid container runtime "containerd", expected docker or podman`)
+}
+
+func TestContainerSettings_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		settings      ContainerSettings
+		expectedError string
+	}{
+		{
+			name: "valid",
+			settings: ContainerSettings{
+				Network:    "eth-docker_default",
+				CPUs:       1.5,
+				Memory:     "2g",
+				Volumes:    []string{"/data/jwt:/jwt:ro", "sentry-data:/data"},
+				Env:        []string{"TZ=UTC", "EMPTY="},
+				LogDriver:  "json-file",
+				LogOptions: map[string]string{"max-size": "10m"},
+			},
+		},
+		{
+			name: "empty",
+		},
+		{
+			name:          "negative cpus",
+			settings:      ContainerSettings{CPUs: -1},
+			expectedError: "invalid cpus -1, expected a positive number",
+		},
+		{
+			name:          "invalid memory",
+			settings:      ContainerSettings{Memory: "lots"},
+			expectedError: `invalid memory "lots", expected eg: 512m or 2g`,
+		},
+		{
+			name:          "relative volume target",
+			settings:      ContainerSettings{Volumes: []string{"/data:data"}},
+			expectedError: `invalid volume "/data:data", expected source:/target or source:/target:options`,
+		},
+		{
+			name:          "volume without a target",
+			settings:      ContainerSettings{Volumes: []string{"/data"}},
+			expectedError: `invalid volume "/data"`,
+		},
+		{
+			name:          "env without a value",
+			settings:      ContainerSettings{Env: []string{"TZ"}},
+			expectedError: `invalid environment variable "TZ", expected KEY=value`,
+		},
+		{
+			name:          "log options without a driver",
+			settings:      ContainerSettings{LogOptions: map[string]string{"max-size": "10m"}},
+			expectedError: "log options need a log driver",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.settings.Validate()
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestParseMemory(t *testing.T) {
+	tests := []struct {
+		size     string
+		expected int64
+	}{
+		{size: "", expected: 0},
+		{size: "1024", expected: 1024},
+		{size: "512b", expected: 512},
+		{size: "64k", expected: 64 << 10},
+		{size: "512m", expected: 512 << 20},
+		{size: "2G", expected: 2 << 30},
+		{size: "1.5g", expected: 3 << 29},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			bytes, err := ParseMemory(tt.size)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, bytes)
+		})
+	}
+
+	_, err := ParseMemory("0m")
+	assert.Error(t, err)
+}
@@ -0,0 +1,296 @@
+// Package logrotate rotates log files that are written to by processes we don't
+// control, such as sentry's stdout and stderr. As those processes hold their log files
+// open, files are rotated by copying and then truncating them in place, rather than
+// being renamed. Anything written between the copy and the truncate is lost, which is
+// kept small by rotating often: whenever the CLI runs, and periodically from the
+// supervisor of the binary run method.
+package logrotate
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// timestampFormat is the format of the timestamp in rotated file names.
+const timestampFormat = "20060102T150405Z"
+
+var (
+	// rotatedPattern matches the name of a rotated log file, eg: service-20250102T150405Z.log.gz.
+	rotatedPattern = regexp.MustCompile(`^(.+)-(\d{8}T\d{6}Z)\.log(\.gz)?$`)
+
+	// lineTimePattern matches an RFC3339 timestamp, as found at the start of most log lines.
+	lineTimePattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`)
+)
+
+// Policy describes when log files are rotated, and how many rotated files are kept.
+type Policy struct {
+	// MaxSize is the size, in bytes, a log file may grow to before it's rotated. Zero
+	// disables size based rotation.
+	MaxSize int64
+
+	// MaxAge is how long a log file is written to before it's rotated. It's measured
+	// from the last rotation, or the first line of the file if it's never been rotated.
+	// Zero disables age based rotation.
+	MaxAge time.Duration
+
+	// Retention is the number of rotated files kept for each log file. Zero keeps them all.
+	Retention int
+
+	// Compress gzips rotated files.
+	Compress bool
+}
+
+// RotateDir rotates each of the *.log files in dir that are due, skipping those that
+// are themselves rotated files.
+func (p Policy) RotateDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	if err != nil {
+		return fmt.Errorf("failed to list log files: %w", err)
+	}
+
+	for _, path := range paths {
+		if rotatedPattern.MatchString(filepath.Base(path)) {
+			continue
+		}
+
+		if _, err := p.Rotate(path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Rotate rotates the log file at path if it's due, and prunes any rotated files beyond
+// the retention. It returns whether the file was rotated. A missing file isn't an error.
+func (p Policy) Rotate(path string) (bool, error) {
+	return p.rotate(path, time.Now().UTC())
+}
+
+func (p Policy) rotate(path string, now time.Time) (bool, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	if info.Size() == 0 {
+		return false, nil
+	}
+
+	rotated, err := listRotated(path)
+	if err != nil {
+		return false, err
+	}
+
+	due, err := p.due(path, info, rotated, now)
+	if err != nil || !due {
+		return false, err
+	}
+
+	if err := p.copyTruncate(path, rotatedPath(path, now, p.Compress)); err != nil {
+		return false, err
+	}
+
+	if err := p.prune(path); err != nil {
+		return true, err
+	}
+
+	return true, nil
+}
+
+// due reports whether the log file should be rotated.
+func (p Policy) due(path string, info os.FileInfo, rotated []rotatedFile, now time.Time) (bool, error) {
+	if p.MaxSize > 0 && info.Size() >= p.MaxSize {
+		return true, nil
+	}
+
+	if p.MaxAge <= 0 {
+		return false, nil
+	}
+
+	var started time.Time
+
+	if len(rotated) > 0 {
+		started = rotated[0].time
+	} else {
+		first, err := firstLineTime(path)
+		if err != nil {
+			return false, err
+		}
+
+		started = first
+	}
+
+	return !started.IsZero() && now.Sub(started) >= p.MaxAge, nil
+}
+
+// copyTruncate copies the log file to dst, compressing it if required, and then
+// truncates it. The copy is written to a temporary file first, so an interrupted
+// rotation never leaves a partial file behind.
+func (p Policy) copyTruncate(path, dst string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer src.Close()
+
+	tmpPath := fmt.Sprintf("%s.tmp", dst)
+
+	if err := writeCopy(src, tmpPath, p.Compress); err != nil {
+		os.Remove(tmpPath)
+
+		return err
+	}
+
+	if err := os.Rename(tmpPath, dst); err != nil {
+		os.Remove(tmpPath)
+
+		return fmt.Errorf("failed to save rotated log file: %w", err)
+	}
+
+	if err := os.Truncate(path, 0); err != nil {
+		return fmt.Errorf("failed to truncate log file: %w", err)
+	}
+
+	return nil
+}
+
+func writeCopy(src io.Reader, path string, compress bool) error {
+	dst, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create rotated log file: %w", err)
+	}
+	defer dst.Close()
+
+	var w io.Writer = dst
+
+	if compress {
+		gz := gzip.NewWriter(dst)
+		defer gz.Close()
+
+		w = gz
+	}
+
+	if _, err := io.Copy(w, src); err != nil {
+		return fmt.Errorf("failed to copy log file: %w", err)
+	}
+
+	if gz, ok := w.(*gzip.Writer); ok {
+		if err := gz.Close(); err != nil {
+			return fmt.Errorf("failed to compress log file: %w", err)
+		}
+	}
+
+	return dst.Close()
+}
+
+// prune removes the oldest rotated files, beyond the retention.
+func (p Policy) prune(path string) error {
+	if p.Retention <= 0 {
+		return nil
+	}
+
+	rotated, err := listRotated(path)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range rotated[min(p.Retention, len(rotated)):] {
+		if err := os.Remove(file.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove old log file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// rotatedFile is a previously rotated copy of a log file.
+type rotatedFile struct {
+	path string
+	time time.Time
+}
+
+// listRotated returns the rotated copies of the log file at path, newest first.
+func listRotated(path string) ([]rotatedFile, error) {
+	var (
+		dir  = filepath.Dir(path)
+		stem = strings.TrimSuffix(filepath.Base(path), ".log")
+	)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list log files: %w", err)
+	}
+
+	var rotated []rotatedFile
+
+	for _, entry := range entries {
+		match := rotatedPattern.FindStringSubmatch(entry.Name())
+		if match == nil || match[1] != stem {
+			continue
+		}
+
+		t, err := time.Parse(timestampFormat, match[2])
+		if err != nil {
+			continue
+		}
+
+		rotated = append(rotated, rotatedFile{path: filepath.Join(dir, entry.Name()), time: t})
+	}
+
+	sort.Slice(rotated, func(i, j int) bool {
+		return rotated[i].time.After(rotated[j].time)
+	})
+
+	return rotated, nil
+}
+
+// rotatedPath returns the path a log file is rotated to, eg: service.log is rotated to
+// service-20250102T150405Z.log.gz.
+func rotatedPath(path string, now time.Time, compress bool) string {
+	name := fmt.Sprintf("%s-%s.log", strings.TrimSuffix(filepath.Base(path), ".log"), now.UTC().Format(timestampFormat))
+	if compress {
+		name += ".gz"
+	}
+
+	return filepath.Join(filepath.Dir(path), name)
+}
+
+// firstLineTime returns the timestamp of the first line of the file, or the zero time if
+// it doesn't have one.
+func firstLineTime(path string) (time.Time, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	line, err := bufio.NewReader(io.LimitReader(file, 4096)).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return time.Time{}, fmt.Errorf("failed to read log file: %w", err)
+	}
+
+	match := lineTimePattern.FindString(line)
+	if match == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, match)
+	if err != nil {
+		return time.Time{}, nil
+	}
+
+	return t, nil
+}
@@ -0,0 +1,233 @@
+package logrotate
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_Rotate(t *testing.T) {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		policy          Policy
+		content         string
+		existing        []string
+		expectedRotated bool
+		expectedFiles   []string
+	}{
+		{
+			name:          "under size",
+			policy:        Policy{MaxSize: 1024},
+			content:       "small\n",
+			expectedFiles: []string{"service.log"},
+		},
+		{
+			name:            "over size",
+			policy:          Policy{MaxSize: 4},
+			content:         "too big\n",
+			expectedRotated: true,
+			expectedFiles:   []string{"service-20250110T120000Z.log", "service.log"},
+		},
+		{
+			name:            "over size, compressed",
+			policy:          Policy{MaxSize: 4, Compress: true},
+			content:         "too big\n",
+			expectedRotated: true,
+			expectedFiles:   []string{"service-20250110T120000Z.log.gz", "service.log"},
+		},
+		{
+			name:            "first line older than max age",
+			policy:          Policy{MaxAge: 24 * time.Hour},
+			content:         "time=\"2025-01-08T12:00:00Z\" level=info msg=old\n",
+			expectedRotated: true,
+			expectedFiles:   []string{"service-20250110T120000Z.log", "service.log"},
+		},
+		{
+			name:          "first line newer than max age",
+			policy:        Policy{MaxAge: 24 * time.Hour},
+			content:       "{\"level\":\"info\",\"time\":\"2025-01-10T11:00:00+00:00\"}\n",
+			expectedFiles: []string{"service.log"},
+		},
+		{
+			name:          "first line without a time",
+			policy:        Policy{MaxAge: 24 * time.Hour},
+			content:       "no time here\n",
+			expectedFiles: []string{"service.log"},
+		},
+		{
+			name:            "last rotation older than max age",
+			policy:          Policy{MaxAge: 24 * time.Hour},
+			content:         "time=\"2025-01-10T11:00:00Z\" level=info msg=new\n",
+			existing:        []string{"service-20250108T120000Z.log"},
+			expectedRotated: true,
+			expectedFiles:   []string{"service-20250108T120000Z.log", "service-20250110T120000Z.log", "service.log"},
+		},
+		{
+			name:          "last rotation newer than max age",
+			policy:        Policy{MaxAge: 24 * time.Hour},
+			content:       "time=\"2025-01-01T00:00:00Z\" level=info msg=old\n",
+			existing:      []string{"service-20250110T000000Z.log.gz"},
+			expectedFiles: []string{"service-20250110T000000Z.log.gz", "service.log"},
+		},
+		{
+			name:    "prunes beyond retention",
+			policy:  Policy{MaxSize: 4, Retention: 2},
+			content: "too big\n",
+			existing: []string{
+				"service-20250107T120000Z.log.gz",
+				"service-20250108T120000Z.log",
+				"service-20250109T120000Z.log.gz",
+				"debug-20250101T120000Z.log.gz",
+			},
+			expectedRotated: true,
+			expectedFiles: []string{
+				"debug-20250101T120000Z.log.gz",
+				"service-20250109T120000Z.log.gz",
+				"service-20250110T120000Z.log",
+				"service.log",
+			},
+		},
+		{
+			name:          "empty file",
+			policy:        Policy{MaxSize: 1, MaxAge: time.Nanosecond},
+			expectedFiles: []string{"service.log"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "service.log")
+
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0600))
+
+			for _, name := range tt.existing {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0600))
+			}
+
+			rotated, err := tt.policy.rotate(path, now)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedRotated, rotated)
+			assert.Equal(t, tt.expectedFiles, listDir(t, dir))
+
+			// The log file is emptied in place, and its contents moved to the rotated file.
+			current, err := os.ReadFile(path)
+			require.NoError(t, err)
+
+			if !tt.expectedRotated {
+				assert.Equal(t, tt.content, string(current))
+
+				return
+			}
+
+			assert.Empty(t, current)
+			assert.Equal(t, tt.content, readRotated(t, rotatedPath(path, now, tt.policy.Compress)))
+		})
+	}
+}
+
+func TestPolicy_Rotate_KeepsWriterOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "debug.log")
+
+	// Mimic the sentry process, which holds its log file open in append mode.
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+
+	defer file.Close()
+
+	_, err = file.WriteString("before\n")
+	require.NoError(t, err)
+
+	rotated, err := Policy{MaxSize: 1}.Rotate(path)
+	require.NoError(t, err)
+	assert.True(t, rotated)
+
+	_, err = file.WriteString("after\n")
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "after\n", string(content))
+}
+
+func TestPolicy_RotateDir(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, content := range map[string]string{
+		"debug.log":                    "too big\n",
+		"service.log":                  "",
+		"cli-20250101T000000Z.log":     "already rotated\n",
+		"notes.txt":                    "not a log\n",
+		"service-20250101T000000Z.log": "",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+	}
+
+	require.NoError(t, Policy{MaxSize: 4}.RotateDir(dir))
+
+	files := listDir(t, dir)
+	assert.Len(t, files, 6)
+	assert.Contains(t, files, "cli-20250101T000000Z.log")
+	assert.Contains(t, files, "notes.txt")
+
+	// Only debug.log was due.
+	rotated, err := listRotated(filepath.Join(dir, "debug.log"))
+	require.NoError(t, err)
+	assert.Len(t, rotated, 1)
+}
+
+func TestPolicy_Rotate_Missing(t *testing.T) {
+	rotated, err := Policy{MaxSize: 1}.Rotate(filepath.Join(t.TempDir(), "missing.log"))
+	require.NoError(t, err)
+	assert.False(t, rotated)
+}
+
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func readRotated(t *testing.T, path string) string {
+	t.Helper()
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+
+	defer file.Close()
+
+	var r io.Reader = file
+
+	if filepath.Ext(path) == ".gz" {
+		gz, err := gzip.NewReader(file)
+		require.NoError(t, err)
+
+		defer gz.Close()
+
+		r = gz
+	}
+
+	content, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	return string(content)
+}
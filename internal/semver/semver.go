@@ -0,0 +1,208 @@
+// Package semver parses and orders versions, as described by https://semver.org.
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed semantic version, eg: 1.2.3-rc.1+build.5.
+type Version struct {
+	Major, Minor, Patch int
+
+	// Prerelease holds the dot separated pre-release identifiers, eg: rc and 1.
+	Prerelease []string
+
+	// Build is the build metadata, which plays no part in ordering.
+	Build string
+}
+
+// Parse parses a version, with or without a v prefix, eg: v1.2.3-rc.1.
+func Parse(version string) (*Version, error) {
+	rest := strings.TrimPrefix(version, "v")
+
+	v := &Version{}
+
+	rest, v.Build, _ = strings.Cut(rest, "+")
+
+	core, prerelease, hasPrerelease := strings.Cut(rest, "-")
+
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid version %q, expected major.minor.patch", version)
+	}
+
+	numbers := []*int{&v.Major, &v.Minor, &v.Patch}
+
+	for i, part := range parts {
+		n, err := parseNumber(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q: %w", version, err)
+		}
+
+		*numbers[i] = n
+	}
+
+	if hasPrerelease {
+		v.Prerelease = strings.Split(prerelease, ".")
+
+		for _, id := range v.Prerelease {
+			if !validIdentifier(id) {
+				return nil, fmt.Errorf("invalid version %q: invalid pre-release identifier %q", version, id)
+			}
+
+			if isNumeric(id) {
+				if _, err := parseNumber(id); err != nil {
+					return nil, fmt.Errorf("invalid version %q: %w", version, err)
+				}
+			}
+		}
+	}
+
+	if strings.Contains(version, "+") {
+		for _, id := range strings.Split(v.Build, ".") {
+			if !validIdentifier(id) {
+				return nil, fmt.Errorf("invalid version %q: invalid build identifier %q", version, id)
+			}
+		}
+	}
+
+	return v, nil
+}
+
+// IsPrerelease returns whether the version is a pre-release, eg: 1.2.3-rc.1.
+func (v *Version) IsPrerelease() bool {
+	return len(v.Prerelease) > 0
+}
+
+// String returns the version without a v prefix.
+func (v *Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+
+	if v.IsPrerelease() {
+		s += "-" + strings.Join(v.Prerelease, ".")
+	}
+
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+
+	return s
+}
+
+// Compare returns -1, 0 or 1 as v orders before, alongside or after other. A pre-release
+// orders before its release, and build metadata is ignored.
+func (v *Version) Compare(other *Version) int {
+	for _, pair := range [][2]int{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		if c := compareInts(pair[0], pair[1]); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case !v.IsPrerelease() && !other.IsPrerelease():
+		return 0
+	case !v.IsPrerelease():
+		return 1
+	case !other.IsPrerelease():
+		return -1
+	}
+
+	for i := 0; i < len(v.Prerelease) && i < len(other.Prerelease); i++ {
+		if c := compareIdentifiers(v.Prerelease[i], other.Prerelease[i]); c != 0 {
+			return c
+		}
+	}
+
+	// A larger set of identifiers orders after a smaller one it starts with.
+	return compareInts(len(v.Prerelease), len(other.Prerelease))
+}
+
+// Compare parses and compares two versions, as Version.Compare does.
+func Compare(a, b string) (int, error) {
+	va, err := Parse(a)
+	if err != nil {
+		return 0, err
+	}
+
+	vb, err := Parse(b)
+	if err != nil {
+		return 0, err
+	}
+
+	return va.Compare(vb), nil
+}
+
+// compareIdentifiers compares pre-release identifiers. Numeric ones compare numerically,
+// and order before alphanumeric ones, which compare in ASCII order.
+func compareIdentifiers(a, b string) int {
+	aNumeric, bNumeric := isNumeric(a), isNumeric(b)
+
+	switch {
+	case aNumeric && bNumeric:
+		an, _ := strconv.Atoi(a)
+		bn, _ := strconv.Atoi(b)
+
+		return compareInts(an, bn)
+	case aNumeric:
+		return -1
+	case bNumeric:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// parseNumber parses a numeric part, which can't have leading zeroes.
+func parseNumber(s string) (int, error) {
+	if !isNumeric(s) {
+		return 0, fmt.Errorf("%q isn't a number", s)
+	}
+
+	if len(s) > 1 && s[0] == '0' {
+		return 0, fmt.Errorf("%q has a leading zero", s)
+	}
+
+	return strconv.Atoi(s)
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validIdentifier returns whether s is a non-empty run of [0-9A-Za-z-].
+func validIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && r != '-' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,87 @@
+package semver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		version       string
+		expected      *Version
+		expectedError string
+	}{
+		{version: "1.2.3", expected: &Version{Major: 1, Minor: 2, Patch: 3}},
+		{version: "v0.0.6", expected: &Version{Patch: 6}},
+		{version: "1.2.3-rc.1", expected: &Version{Major: 1, Minor: 2, Patch: 3, Prerelease: []string{"rc", "1"}}},
+		{version: "1.2.3-nightly.20241201+sha.abc123", expected: &Version{Major: 1, Minor: 2, Patch: 3, Prerelease: []string{"nightly", "20241201"}, Build: "sha.abc123"}},
+		{version: "1.2.3+build", expected: &Version{Major: 1, Minor: 2, Patch: 3, Build: "build"}},
+		{version: "1.2.3-x-y", expected: &Version{Major: 1, Minor: 2, Patch: 3, Prerelease: []string{"x-y"}}},
+		{version: "latest", expectedError: "expected major.minor.patch"},
+		{version: "1.2", expectedError: "expected major.minor.patch"},
+		{version: "1.02.3", expectedError: "has a leading zero"},
+		{version: "1.2.3-rc.01", expectedError: "has a leading zero"},
+		{version: "1.2.3-rc..1", expectedError: `invalid pre-release identifier ""`},
+		{version: "1.2.3+", expectedError: `invalid build identifier ""`},
+		{version: "1.2.x", expectedError: `"x" isn't a number`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			v, err := Parse(tt.version)
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, v)
+			assert.Equal(t, tt.version[len(tt.version)-len(v.String()):], v.String())
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// In ascending order, as given by semver.org.
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"2.0.0",
+	}
+
+	for i := range ordered {
+		for j := range ordered {
+			c, err := Compare(ordered[i], ordered[j])
+			require.NoError(t, err)
+
+			switch {
+			case i < j:
+				assert.Equal(t, -1, c, "%s < %s", ordered[i], ordered[j])
+			case i > j:
+				assert.Equal(t, 1, c, "%s > %s", ordered[i], ordered[j])
+			default:
+				assert.Equal(t, 0, c, "%s = %s", ordered[i], ordered[j])
+			}
+		}
+	}
+
+	// Build metadata and the v prefix don't matter.
+	c, err := Compare("v1.0.0+abc", "1.0.0+def")
+	require.NoError(t, err)
+	assert.Equal(t, 0, c)
+
+	_, err = Compare("1.0.0", "latest")
+	assert.Error(t, err)
+}
@@ -1,181 +1,123 @@
 package service
 
 import (
-	"encoding/json"
-	"errors"
 	"fmt"
-	"net/http"
-	"net/url"
-	"strconv"
 	"strings"
-	"time"
 
 	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/semver"
 	"github.com/sirupsen/logrus"
 )
 
-var (
-	githubAPIHost     = "api.github.com"
-	validateGitHubURL = func(owner, repo string) (*url.URL, error) {
-		if owner == "" || repo == "" {
-			return nil, errors.New("owner and repo cannot be empty")
-		}
-
-		if strings.ContainsAny(owner+repo, "/?#[]@!$&'()*+,;=") {
-			return nil, errors.New("invalid owner or repo name")
-		}
-
-		urlStr := fmt.Sprintf("https://%s/repos/%s/%s/releases", githubAPIHost, owner, repo)
-
-		u, err := url.Parse(urlStr)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse URL: %w", err)
-		}
-
-		if u.Host != githubAPIHost {
-			return nil, fmt.Errorf("invalid GitHub API host: %s", u.Host)
-		}
-
-		return u, nil
-	}
-)
-
 //go:generate mockgen -package mock -destination mock/github.mock.go github.com/ethpandaops/contributoor-installer/internal/service GitHubService
 
-// GitHubService defines the interface for GitHub operations.
+// GitHubService defines the interface for looking up releases. They're looked up on
+// GitHub, unless the installer config chooses another release source.
 type GitHubService interface {
-	// GetLatestVersion returns the latest version tag (e.g., "0.0.1") from GitHub releases.
-	GetLatestVersion() (string, error)
+	// GetLatestVersion returns the newest version (e.g., "0.0.1") on the given channel
+	// from the releases.
+	GetLatestVersion(channel installer.Channel) (string, error)
 
-	// VersionExists checks if a specific version exists in the GitHub releases.
+	// VersionExists checks if a specific version exists in the releases.
 	VersionExists(version string) (bool, error)
 }
 
-// GitHubRelease is a struct that represents a GitHub release.
+// GitHubRelease is a struct that represents a GitHub release. Mirrors and bundles list
+// their releases in the same format.
 type GitHubRelease struct {
-	TagName string `json:"tag_name"` //nolint:tagliatelle // Upstream response doesnt camelCase.
+	TagName    string `json:"tag_name"` //nolint:tagliatelle // Upstream response doesnt camelCase.
+	Prerelease bool   `json:"prerelease"`
+	Draft      bool   `json:"draft"`
 }
 
-// githubService is a basic service for interacting with the GitHub API.
+// OnChannel returns whether the release, with its parsed version, is followed by the
+// channel. Drafts are on none, and stable, like pinned, only has releases that are
+// neither flagged nor versioned as pre-releases. Beta adds the beta and rc pre-releases,
+// along with anything flagged as a pre-release without a pre-release version. Nightly
+// has everything else.
+func (r *GitHubRelease) OnChannel(channel installer.Channel, version *semver.Version) bool {
+	if r.Draft {
+		return false
+	}
+
+	switch channel {
+	case installer.ChannelNightly:
+		return true
+	case installer.ChannelBeta:
+		if !version.IsPrerelease() {
+			return true
+		}
+
+		kind := strings.ToLower(version.Prerelease[0])
+
+		return strings.HasPrefix(kind, "beta") || strings.HasPrefix(kind, "rc")
+	default:
+		return !r.Prerelease && !version.IsPrerelease()
+	}
+}
+
+// githubService is a basic service for looking up releases in a release source.
 type githubService struct {
-	log          *logrus.Logger
-	client       *http.Client
-	githubURL    *url.URL
-	installerCfg *installer.Config
+	source ReleaseSource
+
+	// fetched are the releases, once they've been fetched.
+	fetched []GitHubRelease
 }
 
-// NewGitHubService creates a new GitHubService.
-func NewGitHubService(log *logrus.Logger, installerCfg *installer.Config) (GitHubService, error) {
-	githubURL, err := validateGitHubURL(installerCfg.GithubOrg, installerCfg.GithubRepo)
+// NewGitHubService creates a new GitHubService, looking releases up in the release
+// source chosen in the installer state, in configDir.
+func NewGitHubService(log *logrus.Logger, installerCfg *installer.Config, configDir string) (GitHubService, error) {
+	source, err := NewReleaseSource(log, installerCfg, configDir)
 	if err != nil {
-		return nil, fmt.Errorf("invalid github url: %w", err)
+		return nil, err
 	}
 
 	return &githubService{
-		log:          log,
-		installerCfg: installerCfg,
-		githubURL:    githubURL,
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		source: source,
 	}, nil
 }
 
-// GetLatestVersion returns the latest version tag (e.g., "0.0.1") from GitHub releases.
-func (s *githubService) GetLatestVersion() (string, error) {
-	resp, err := s.client.Get(s.githubURL.String())
+// GetLatestVersion returns the newest version (e.g., "0.0.1") on the given channel
+// from the releases.
+func (s *githubService) GetLatestVersion(channel installer.Channel) (string, error) {
+	releases, err := s.releases()
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch releases: %w", err)
-	}
-
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
-	}
-
-	var releases []GitHubRelease
-	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
-		return "", fmt.Errorf("failed to parse releases response: %w", err)
+		return "", err
 	}
 
-	// Find highest version tag
 	var (
-		latestVersion string
-		latestParts   []int
+		latestTag     string
+		latestVersion *semver.Version
 	)
 
-	// Iterate over releases and find the highest semver version.
+	// Iterate over releases and find the highest semver version on the channel. Tags
+	// that aren't semver are skipped.
 	for _, release := range releases {
-		if release.TagName == "" {
-			continue
-		}
-
-		parts := strings.Split(strings.TrimPrefix(release.TagName, "v"), ".")
-		if len(parts) != 3 {
+		version, err := semver.Parse(release.TagName)
+		if err != nil || !release.OnChannel(channel, version) {
 			continue
 		}
 
-		// Convert versions from GH to ints for comparison.
-		var versionParts []int
-
-		for _, part := range parts {
-			num, err := strconv.Atoi(part)
-			if err != nil {
-				continue
-			}
-
-			versionParts = append(versionParts, num)
-		}
-
-		if len(versionParts) == 3 {
-			// If we don't have a latest version, set it.
-			if latestVersion == "" {
-				latestVersion = release.TagName
-				latestParts = versionParts
-
-				continue
-			}
-
-			// Now we compare the version parts to find the highest version.
-			for i := 0; i < 3; i++ {
-				if versionParts[i] > latestParts[i] {
-					latestVersion = release.TagName
-					latestParts = versionParts
-
-					break
-				} else if versionParts[i] < latestParts[i] {
-					break
-				}
-			}
+		if latestVersion == nil || version.Compare(latestVersion) > 0 {
+			latestTag = release.TagName
+			latestVersion = version
 		}
 	}
 
 	// Something's cooked if we don't have a latest version.
-	if latestVersion == "" {
-		return "", fmt.Errorf("no valid version tags found")
+	if latestVersion == nil {
+		return "", fmt.Errorf("no valid version tags found on the %s channel", channel)
 	}
 
 	// Return the version without the 'v' prefix.
-	return strings.TrimPrefix(latestVersion, "v"), nil
+	return strings.TrimPrefix(latestTag, "v"), nil
 }
 
-// VersionExists checks if a specific version exists in the GitHub releases.
+// VersionExists checks if a specific version exists in the releases.
 func (s *githubService) VersionExists(version string) (bool, error) {
-	resp, err := s.client.Get(s.githubURL.String())
+	releases, err := s.releases()
 	if err != nil {
-		return false, fmt.Errorf("failed to fetch releases: %w", err)
-	}
-
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return false, fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
-	}
-
-	var releases []GitHubRelease
-	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
-		return false, fmt.Errorf("failed to parse releases response: %w", err)
+		return false, err
 	}
 
 	// Add 'v' prefix if not present
@@ -184,12 +126,28 @@ func (s *githubService) VersionExists(version string) (bool, error) {
 		searchVersion = "v" + searchVersion
 	}
 
-	// Look for exact match
+	// Look for exact match. Drafts aren't published, so have nothing to download.
 	for _, release := range releases {
-		if release.TagName == searchVersion {
+		if release.TagName == searchVersion && !release.Draft {
 			return true, nil
 		}
 	}
 
 	return false, nil
 }
+
+// releases returns every release, fetched once per run.
+func (s *githubService) releases() ([]GitHubRelease, error) {
+	if s.fetched != nil {
+		return s.fetched, nil
+	}
+
+	releases, err := s.source.Releases()
+	if err != nil {
+		return nil, err
+	}
+
+	s.fetched = releases
+
+	return releases, nil
+}
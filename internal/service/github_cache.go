@@ -0,0 +1,115 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// releaseCacheFile is the file the GitHub release pages are cached in.
+const releaseCacheFile = "github-releases.json"
+
+// releaseCache is an on-disk cache of the release pages fetched from GitHub. Pages are
+// revalidated with their ETag, which doesn't count towards the rate limit when they're
+// unchanged, and are used as they are when GitHub can't be reached.
+type releaseCache struct {
+	path string
+
+	// Pages are the release pages, keyed by their URL.
+	Pages map[string]*cachedPage `json:"pages"`
+
+	// RateLimitReset is when an exhausted rate limit resets. GitHub isn't asked again
+	// until then.
+	RateLimitReset time.Time `json:"rateLimitReset"`
+}
+
+// cachedPage is a page of releases, and what's needed to revalidate and follow it.
+type cachedPage struct {
+	ETag     string          `json:"etag"`
+	Next     string          `json:"next,omitempty"`
+	Releases []GitHubRelease `json:"releases"`
+}
+
+// loadReleaseCache loads the release cache from dir. A missing cache is empty, and an
+// empty dir gives a cache that's never saved.
+func loadReleaseCache(dir string) (*releaseCache, error) {
+	cache := &releaseCache{
+		Pages: make(map[string]*cachedPage),
+	}
+
+	if dir == "" {
+		return cache, nil
+	}
+
+	cache.path = filepath.Join(dir, releaseCacheFile)
+
+	data, err := os.ReadFile(cache.path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+
+	if err != nil {
+		return cache, fmt.Errorf("failed to read release cache: %w", err)
+	}
+
+	if err := json.Unmarshal(data, cache); err != nil {
+		return cache, fmt.Errorf("failed to parse release cache: %w", err)
+	}
+
+	if cache.Pages == nil {
+		cache.Pages = make(map[string]*cachedPage)
+	}
+
+	return cache, nil
+}
+
+// save atomically persists the cache to disk.
+func (c *releaseCache) save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create release cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal release cache: %w", err)
+	}
+
+	tmpPath := fmt.Sprintf("%s.tmp", c.path)
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write release cache: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, c.path); err != nil {
+		os.Remove(tmpPath)
+
+		return fmt.Errorf("failed to save release cache: %w", err)
+	}
+
+	return nil
+}
+
+// releases returns the cached releases, following the pages from firstURL. It returns
+// false unless every page is cached.
+func (c *releaseCache) releases(firstURL string) ([]GitHubRelease, bool) {
+	var releases []GitHubRelease
+
+	pageURL := firstURL
+
+	for i := 0; pageURL != "" && i < maxReleasePages; i++ {
+		page, ok := c.Pages[pageURL]
+		if !ok {
+			return nil, false
+		}
+
+		releases = append(releases, page.Releases...)
+		pageURL = page.Next
+	}
+
+	return releases, true
+}
@@ -0,0 +1,283 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// GitHubTokenEnv is the environment variable an optional GitHub token is read from.
+	// Anonymous requests share a limit of 60 an hour per IP, which is easily exhausted
+	// behind a shared NAT.
+	GitHubTokenEnv = "GITHUB_TOKEN"
+
+	// releasesPerPage is the most releases GitHub returns in a page.
+	releasesPerPage = 100
+
+	// maxReleasePages caps how many pages of releases are followed.
+	maxReleasePages = 10
+)
+
+var (
+	// githubDownloadURL is the base URL release assets are downloaded from.
+	githubDownloadURL = "https://github.com"
+
+	githubAPIHost     = "api.github.com"
+	validateGitHubURL = func(owner, repo string) (*url.URL, error) {
+		if owner == "" || repo == "" {
+			return nil, errors.New("owner and repo cannot be empty")
+		}
+
+		if strings.ContainsAny(owner+repo, "/?#[]@!$&'()*+,;=") {
+			return nil, errors.New("invalid owner or repo name")
+		}
+
+		urlStr := fmt.Sprintf("https://%s/repos/%s/%s/releases", githubAPIHost, owner, repo)
+
+		u, err := url.Parse(urlStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse URL: %w", err)
+		}
+
+		if u.Host != githubAPIHost {
+			return nil, fmt.Errorf("invalid GitHub API host: %s", u.Host)
+		}
+
+		return u, nil
+	}
+)
+
+// githubSource is a ReleaseSource for the releases of a GitHub repository.
+type githubSource struct {
+	log          *logrus.Logger
+	client       *http.Client
+	githubURL    *url.URL
+	installerCfg *installer.Config
+	token        string
+
+	// cacheDir is where the releases are cached, so they're revalidated rather than
+	// refetched, and used when GitHub can't be reached. Empty disables the cache.
+	cacheDir string
+}
+
+// newGitHubSource creates a ReleaseSource for the repository in the installer config,
+// caching its releases in cacheDir.
+func newGitHubSource(log *logrus.Logger, installerCfg *installer.Config, cacheDir string) (ReleaseSource, error) {
+	githubURL, err := validateGitHubURL(installerCfg.GithubOrg, installerCfg.GithubRepo)
+	if err != nil {
+		return nil, fmt.Errorf("invalid github url: %w", err)
+	}
+
+	return &githubSource{
+		log:          log,
+		installerCfg: installerCfg,
+		githubURL:    githubURL,
+		token:        os.Getenv(GitHubTokenEnv),
+		cacheDir:     cacheDir,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}, nil
+}
+
+// Releases returns every release. When GitHub can't be reached, or its rate limit is
+// exhausted, the cached releases are used instead.
+func (s *githubSource) Releases() ([]GitHubRelease, error) {
+	cache, err := loadReleaseCache(s.cacheDir)
+	if err != nil {
+		s.log.Warnf("Ignoring the GitHub release cache: %v", err)
+	}
+
+	releases, err := s.fetchReleases(cache)
+
+	if serr := cache.save(); serr != nil {
+		s.log.Warnf("Failed to save the GitHub release cache: %v", serr)
+	}
+
+	if err != nil {
+		cached, ok := cache.releases(s.firstPageURL())
+		if !ok {
+			return nil, err
+		}
+
+		s.log.Warnf("Using cached releases, as they couldn't be fetched: %v", err)
+
+		releases = cached
+	}
+
+	return releases, nil
+}
+
+// Download writes the named asset of the release version to w.
+func (s *githubSource) Download(version, asset string, w io.Writer) error {
+	return download(fmt.Sprintf(
+		"%s/%s/%s/releases/download/v%s/%s",
+		githubDownloadURL,
+		s.installerCfg.GithubOrg,
+		s.installerCfg.GithubRepo,
+		version,
+		asset,
+	), w)
+}
+
+// fetchReleases fetches every page of releases from GitHub, revalidating those that
+// are cached, and leaving only the pages it saw in the cache.
+func (s *githubSource) fetchReleases(cache *releaseCache) ([]GitHubRelease, error) {
+	if time.Now().Before(cache.RateLimitReset) {
+		return nil, rateLimitError(cache.RateLimitReset)
+	}
+
+	var (
+		releases []GitHubRelease
+		pages    = make(map[string]*cachedPage)
+		pageURL  = s.firstPageURL()
+	)
+
+	for i := 0; pageURL != "" && i < maxReleasePages; i++ {
+		page, err := s.fetchPage(cache, pageURL)
+		if err != nil {
+			return nil, err
+		}
+
+		pages[pageURL] = page
+		releases = append(releases, page.Releases...)
+		pageURL = page.Next
+	}
+
+	cache.Pages = pages
+
+	return releases, nil
+}
+
+// fetchPage fetches a page of releases, or revalidates it if it's cached.
+func (s *githubSource) fetchPage(cache *releaseCache, pageURL string) (*cachedPage, error) {
+	req, err := http.NewRequest(http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	cached := cache.Pages[pageURL]
+	if cached != nil && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch releases: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	// Once the rate limit is exhausted, GitHub isn't asked again until it resets.
+	reset, limited := rateLimitReset(resp)
+	if limited {
+		cache.RateLimitReset = reset
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		return cached, nil
+	case resp.StatusCode == http.StatusOK:
+	case limited && (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests):
+		return nil, rateLimitError(reset)
+	default:
+		return nil, fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+	}
+
+	var releases []GitHubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("failed to parse releases response: %w", err)
+	}
+
+	page := &cachedPage{
+		ETag:     resp.Header.Get("ETag"),
+		Next:     s.nextPageURL(resp.Header.Get("Link")),
+		Releases: releases,
+	}
+
+	cache.Pages[pageURL] = page
+
+	return page, nil
+}
+
+// firstPageURL returns the URL of the first page of releases.
+func (s *githubSource) firstPageURL() string {
+	u := *s.githubURL
+	u.RawQuery = url.Values{"per_page": {strconv.Itoa(releasesPerPage)}}.Encode()
+
+	return u.String()
+}
+
+// nextPageURL returns the URL of the next page from a Link header, eg:
+// <https://api.github.com/repositories/1/releases?page=2>; rel="next". Links off the API
+// host are ignored, so the token isn't sent anywhere else.
+func (s *githubSource) nextPageURL(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		target, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+
+		next, err := url.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+		if err != nil || next.Scheme != s.githubURL.Scheme || next.Host != s.githubURL.Host {
+			s.log.Warnf("Ignoring the next page of releases at %q", target)
+
+			return ""
+		}
+
+		return next.String()
+	}
+
+	return ""
+}
+
+// rateLimitReset returns when the rate limit resets, if it's exhausted. That's either
+// after the Retry-After of a secondary rate limit, or at the X-RateLimit-Reset of the
+// primary one, once X-RateLimit-Remaining hits zero.
+func rateLimitReset(resp *http.Response) (time.Time, bool) {
+	if after := resp.Header.Get("Retry-After"); after != "" &&
+		(resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) {
+		seconds, err := strconv.Atoi(after)
+		if err != nil {
+			seconds = 60
+		}
+
+		return time.Now().Add(time.Duration(seconds) * time.Second), true
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return time.Time{}, false
+	}
+
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return time.Now().Add(time.Minute), true
+	}
+
+	return time.Unix(reset, 0), true
+}
+
+func rateLimitError(reset time.Time) error {
+	return fmt.Errorf(
+		"GitHub API rate limit exceeded until %s, set %s for a higher limit",
+		reset.Local().Format(time.DateTime),
+		GitHubTokenEnv,
+	)
+}
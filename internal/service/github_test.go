@@ -5,17 +5,24 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/ethpandaops/contributoor-installer/internal/installer"
 	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGitHubService_GetLatestVersion(t *testing.T) {
 	tests := []struct {
 		name       string
 		releases   string
+		channel    installer.Channel
 		wantErr    bool
 		wantResult string
 	}{
@@ -27,6 +34,7 @@ func TestGitHubService_GetLatestVersion(t *testing.T) {
 				{"tag_name": "v0.0.2"},
 				{"tag_name": "v1.0.0"}
 			]`,
+			channel:    installer.ChannelStable,
 			wantErr:    false,
 			wantResult: "1.2.3",
 		},
@@ -36,12 +44,88 @@ func TestGitHubService_GetLatestVersion(t *testing.T) {
 				{"tag_name": "invalid"},
 				{"tag_name": "v0.0.1"}
 			]`,
+			channel:    installer.ChannelStable,
 			wantErr:    false,
 			wantResult: "0.0.1",
 		},
 		{
 			name:       "empty releases",
 			releases:   `[]`,
+			channel:    installer.ChannelStable,
+			wantErr:    true,
+			wantResult: "",
+		},
+		{
+			name: "stable skips pre-releases and drafts",
+			releases: `[
+				{"tag_name": "v1.0.0"},
+				{"tag_name": "v1.1.0-rc.1", "prerelease": true},
+				{"tag_name": "v1.1.0", "prerelease": true},
+				{"tag_name": "v1.2.0", "draft": true}
+			]`,
+			channel:    installer.ChannelStable,
+			wantErr:    false,
+			wantResult: "1.0.0",
+		},
+		{
+			name: "pinned follows stable",
+			releases: `[
+				{"tag_name": "v1.0.0"},
+				{"tag_name": "v1.1.0-rc.1", "prerelease": true}
+			]`,
+			channel:    installer.ChannelPinned,
+			wantErr:    false,
+			wantResult: "1.0.0",
+		},
+		{
+			name: "beta picks the highest rc",
+			releases: `[
+				{"tag_name": "v1.0.0"},
+				{"tag_name": "v1.1.0-rc.2", "prerelease": true},
+				{"tag_name": "v1.1.0-rc.10", "prerelease": true},
+				{"tag_name": "v1.1.0-nightly.20241201", "prerelease": true},
+				{"tag_name": "v1.2.0-rc.1", "draft": true}
+			]`,
+			channel:    installer.ChannelBeta,
+			wantErr:    false,
+			wantResult: "1.1.0-rc.10",
+		},
+		{
+			name: "beta prefers the release over its rc",
+			releases: `[
+				{"tag_name": "v1.1.0-beta.1", "prerelease": true},
+				{"tag_name": "v1.1.0"}
+			]`,
+			channel:    installer.ChannelBeta,
+			wantErr:    false,
+			wantResult: "1.1.0",
+		},
+		{
+			name: "nightly keeps build metadata",
+			releases: `[
+				{"tag_name": "v1.1.0-rc.1", "prerelease": true},
+				{"tag_name": "v1.1.0-nightly.20241201+sha.abc123", "prerelease": true}
+			]`,
+			channel:    installer.ChannelNightly,
+			wantErr:    false,
+			wantResult: "1.1.0-rc.1",
+		},
+		{
+			name: "nightly takes the newest nightly",
+			releases: `[
+				{"tag_name": "v1.0.0"},
+				{"tag_name": "v1.1.0-nightly.20241201+sha.abc123", "prerelease": true}
+			]`,
+			channel:    installer.ChannelNightly,
+			wantErr:    false,
+			wantResult: "1.1.0-nightly.20241201+sha.abc123",
+		},
+		{
+			name: "no release on the channel",
+			releases: `[
+				{"tag_name": "v1.1.0-rc.1", "prerelease": true}
+			]`,
+			channel:    installer.ChannelStable,
 			wantErr:    true,
 			wantResult: "",
 		},
@@ -72,14 +156,14 @@ func TestGitHubService_GetLatestVersion(t *testing.T) {
 			}
 			defer func() { validateGitHubURL = validate }()
 
-			svc, err := NewGitHubService(logrus.New(), installer.NewConfig())
+			svc, err := NewGitHubService(logrus.New(), installer.NewConfig(), t.TempDir())
 			if err != nil {
 				t.Errorf("NewGitHubService() error = %v", err)
 
 				return
 			}
 
-			got, err := svc.GetLatestVersion()
+			got, err := svc.GetLatestVersion(tt.channel)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetLatestVersion() error = %v, wantErr %v", err, tt.wantErr)
 
@@ -121,6 +205,16 @@ func TestGitHubService_VersionExists(t *testing.T) {
 			wantErr:    false,
 			wantExists: false,
 		},
+		{
+			name:    "draft",
+			version: "1.1.0",
+			releases: `[
+				{"tag_name": "v1.0.0"},
+				{"tag_name": "v1.1.0", "draft": true}
+			]`,
+			wantErr:    false,
+			wantExists: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -148,7 +242,7 @@ func TestGitHubService_VersionExists(t *testing.T) {
 			}
 			defer func() { validateGitHubURL = validate }()
 
-			svc, err := NewGitHubService(logrus.New(), installer.NewConfig())
+			svc, err := NewGitHubService(logrus.New(), installer.NewConfig(), t.TempDir())
 			if err != nil {
 				t.Errorf("NewGitHubService() error = %v", err)
 
@@ -212,3 +306,225 @@ func TestValidateGitHubURL(t *testing.T) {
 		})
 	}
 }
+
+// newTestGitHubService returns a GitHubService for the config directory configDir, whose
+// requests go to server.
+func newTestGitHubService(t *testing.T, configDir string, server *httptest.Server) GitHubService {
+	t.Helper()
+
+	validate := validateGitHubURL
+	validateGitHubURL = func(owner, repo string) (*url.URL, error) {
+		return url.Parse(fmt.Sprintf("%s/repos/%s/%s/releases", server.URL, owner, repo))
+	}
+
+	t.Cleanup(func() { validateGitHubURL = validate })
+
+	svc, err := NewGitHubService(logrus.New(), installer.NewConfig(), configDir)
+	require.NoError(t, err)
+
+	return svc
+}
+
+// newTestServer returns a test server, closed once the test's done.
+func newTestServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestGitHubService_Pagination(t *testing.T) {
+	var requests atomic.Int32
+
+	svc := newTestGitHubService(t, t.TempDir(), newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		assert.Equal(t, "100", r.URL.Query().Get("per_page"))
+
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s?per_page=100&page=2>; rel="next", <http://%s%s?per_page=100&page=2>; rel="last"`, r.Host, r.URL.Path, r.Host, r.URL.Path))
+			fmt.Fprint(w, `[{"tag_name": "v1.0.0"}]`)
+		case "2":
+			fmt.Fprint(w, `[{"tag_name": "v0.9.0"}, {"tag_name": "v1.1.0"}]`)
+		default:
+			t.Errorf("unexpected page %q", r.URL.Query().Get("page"))
+		}
+	}))
+
+	exists, err := svc.VersionExists("0.9.0")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	latest, err := svc.GetLatestVersion(installer.ChannelStable)
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", latest)
+
+	// Releases are fetched once per run.
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestGitHubService_NextPageOffHost(t *testing.T) {
+	svc := newTestGitHubService(t, t.TempDir(), newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Link", `<https://example.com/releases?page=2>; rel="next"`)
+		fmt.Fprint(w, `[{"tag_name": "v1.0.0"}]`)
+	}))
+
+	latest, err := svc.GetLatestVersion(installer.ChannelStable)
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", latest)
+}
+
+func TestGitHubService_Token(t *testing.T) {
+	t.Setenv(GitHubTokenEnv, "secret")
+
+	svc := newTestGitHubService(t, t.TempDir(), newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		fmt.Fprint(w, `[{"tag_name": "v1.0.0"}]`)
+	}))
+
+	_, err := svc.GetLatestVersion(installer.ChannelStable)
+	require.NoError(t, err)
+
+	t.Setenv(GitHubTokenEnv, "")
+
+	svc = newTestGitHubService(t, t.TempDir(), newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Authorization"))
+		fmt.Fprint(w, `[{"tag_name": "v1.0.0"}]`)
+	}))
+
+	_, err = svc.GetLatestVersion(installer.ChannelStable)
+	require.NoError(t, err)
+}
+
+func TestGitHubService_Cache(t *testing.T) {
+	var (
+		dir      = t.TempDir()
+		modified atomic.Int32
+	)
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		modified.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `[{"tag_name": "v1.0.0"}]`)
+	})
+
+	// The first run fetches the releases, and the next revalidates them.
+	for range 2 {
+		latest, err := newTestGitHubService(t, dir, server).GetLatestVersion(installer.ChannelStable)
+		require.NoError(t, err)
+		assert.Equal(t, "1.0.0", latest)
+	}
+
+	assert.Equal(t, int32(1), modified.Load())
+	assert.FileExists(t, filepath.Join(dir, releaseCacheDir, releaseCacheFile))
+
+	// Once GitHub can't be reached, the cached releases are used.
+	server.Close()
+
+	exists, err := newTestGitHubService(t, dir, server).VersionExists("1.0.0")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// Unless there aren't any.
+	_, err = newTestGitHubService(t, t.TempDir(), server).VersionExists("1.0.0")
+	assert.ErrorContains(t, err, "failed to fetch releases")
+}
+
+func TestGitHubService_RateLimit(t *testing.T) {
+	var (
+		dir      = t.TempDir()
+		limited  atomic.Bool
+		requests atomic.Int32
+		reset    = time.Now().Add(time.Hour).Unix()
+	)
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		if limited.Load() {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset, 10))
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		fmt.Fprint(w, `[{"tag_name": "v1.0.0"}]`)
+	})
+
+	_, err := newTestGitHubService(t, dir, server).GetLatestVersion(installer.ChannelStable)
+	require.NoError(t, err)
+
+	// Without anything cached, the limit is an error.
+	limited.Store(true)
+
+	_, err = newTestGitHubService(t, t.TempDir(), server).GetLatestVersion(installer.ChannelStable)
+	assert.ErrorContains(t, err, "GitHub API rate limit exceeded until")
+
+	// With the releases cached, they're used instead, and GitHub isn't asked again
+	// until the limit resets.
+	requests.Store(0)
+
+	for range 2 {
+		latest, err := newTestGitHubService(t, dir, server).GetLatestVersion(installer.ChannelStable)
+		require.NoError(t, err)
+		assert.Equal(t, "1.0.0", latest)
+	}
+
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestRateLimitReset(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          int
+		headers         map[string]string
+		expectedLimited bool
+		expectedReset   time.Duration
+	}{
+		{
+			name:    "remaining",
+			status:  http.StatusOK,
+			headers: map[string]string{"X-RateLimit-Remaining": "59", "X-RateLimit-Reset": "1700000000"},
+		},
+		{
+			name:            "exhausted",
+			status:          http.StatusOK,
+			headers:         map[string]string{"X-RateLimit-Remaining": "0"},
+			expectedLimited: true,
+			expectedReset:   time.Minute,
+		},
+		{
+			name:            "secondary limit",
+			status:          http.StatusTooManyRequests,
+			headers:         map[string]string{"Retry-After": "30"},
+			expectedLimited: true,
+			expectedReset:   30 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			for k, v := range tt.headers {
+				resp.Header.Set(k, v)
+			}
+
+			reset, limited := rateLimitReset(resp)
+			assert.Equal(t, tt.expectedLimited, limited)
+
+			if tt.expectedLimited {
+				assert.WithinDuration(t, time.Now().Add(tt.expectedReset), reset, 5*time.Second)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// localSource is a ReleaseSource for releases copied onto the host, for those without
+// a way out to GitHub or a mirror. They're either laid out in a directory, or bundled
+// up in a .tar.gz of one.
+type localSource struct {
+	path   string
+	bundle bool
+}
+
+// newLocalSource creates a ReleaseSource for the directory or bundle at location.
+func newLocalSource(location string) (ReleaseSource, error) {
+	expanded, err := homedir.Expand(location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand release source path: %w", err)
+	}
+
+	expanded, err = filepath.Abs(expanded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve release source path: %w", err)
+	}
+
+	return &localSource{
+		path:   expanded,
+		bundle: strings.HasSuffix(expanded, ".tar.gz") || strings.HasSuffix(expanded, ".tgz"),
+	}, nil
+}
+
+// Releases returns every release in the index.
+func (s *localSource) Releases() ([]GitHubRelease, error) {
+	var releases []GitHubRelease
+
+	err := s.open(releaseIndexFile, func(r io.Reader) error {
+		var err error
+
+		releases, err = parseReleaseIndex(r)
+
+		return err
+	})
+
+	return releases, err
+}
+
+// Download writes the named asset of the release version to w.
+func (s *localSource) Download(version, asset string, w io.Writer) error {
+	return s.open(releaseAssetPath(version, asset), func(r io.Reader) error {
+		if _, err := io.Copy(w, r); err != nil {
+			return fmt.Errorf("failed to read %s: %w", asset, err)
+		}
+
+		return nil
+	})
+}
+
+// open calls fn with the contents of the file at name, relative to the root of the
+// directory or bundle.
+func (s *localSource) open(name string, fn func(io.Reader) error) error {
+	if !filepath.IsLocal(name) {
+		return fmt.Errorf("invalid release file %q", name)
+	}
+
+	if s.bundle {
+		return s.openBundled(name, fn)
+	}
+
+	f, err := os.Open(filepath.Join(s.path, filepath.FromSlash(name)))
+	if err != nil {
+		return fmt.Errorf("failed to open release file: %w", err)
+	}
+
+	defer f.Close()
+
+	return fn(f)
+}
+
+// openBundled calls fn with the contents of the bundle's entry at name.
+func (s *localSource) openBundled(name string, fn func(io.Reader) error) error {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to open release bundle: %w", err)
+	}
+
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to read release bundle: %w", err)
+	}
+
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("%s not found in release bundle %s", name, s.path)
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to read release bundle: %w", err)
+		}
+
+		if header.Typeflag == tar.TypeReg && path.Clean(header.Name) == name {
+			return fn(tr)
+		}
+	}
+}
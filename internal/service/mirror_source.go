@@ -0,0 +1,55 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// mirrorSource is a ReleaseSource for releases mirrored over http(s), eg: in an S3
+// bucket or an Artifactory repository.
+type mirrorSource struct {
+	client  *http.Client
+	baseURL string
+}
+
+// newMirrorSource creates a ReleaseSource for the mirror at location.
+func newMirrorSource(location string) (ReleaseSource, error) {
+	u, err := url.Parse(location)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid release mirror %q", location)
+	}
+
+	return &mirrorSource{
+		baseURL: strings.TrimSuffix(u.String(), "/"),
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}, nil
+}
+
+// Releases returns every release in the mirror's index.
+func (s *mirrorSource) Releases() ([]GitHubRelease, error) {
+	indexURL := s.baseURL + "/" + releaseIndexFile
+
+	resp, err := s.client.Get(indexURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch releases: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("release mirror returned status %d for %s", resp.StatusCode, indexURL)
+	}
+
+	return parseReleaseIndex(resp.Body)
+}
+
+// Download writes the named asset of the release version to w.
+func (s *mirrorSource) Download(version, asset string, w io.Writer) error {
+	return download(s.baseURL+"/"+releaseAssetPath(version, asset), w)
+}
@@ -12,6 +12,7 @@ package mock
 import (
 	reflect "reflect"
 
+	installer "github.com/ethpandaops/contributoor-installer/internal/installer"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -39,18 +40,18 @@ func (m *MockGitHubService) EXPECT() *MockGitHubServiceMockRecorder {
 }
 
 // GetLatestVersion mocks base method.
-func (m *MockGitHubService) GetLatestVersion() (string, error) {
+func (m *MockGitHubService) GetLatestVersion(arg0 installer.Channel) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetLatestVersion")
+	ret := m.ctrl.Call(m, "GetLatestVersion", arg0)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetLatestVersion indicates an expected call of GetLatestVersion.
-func (mr *MockGitHubServiceMockRecorder) GetLatestVersion() *gomock.Call {
+func (mr *MockGitHubServiceMockRecorder) GetLatestVersion(arg0 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestVersion", reflect.TypeOf((*MockGitHubService)(nil).GetLatestVersion))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestVersion", reflect.TypeOf((*MockGitHubService)(nil).GetLatestVersion), arg0)
 }
 
 // VersionExists mocks base method.
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/sirupsen/logrus"
+)
+
+// ReleaseSourceGitHub chooses GitHub as the release source. It's the default.
+const ReleaseSourceGitHub = "github"
+
+// releaseCacheDir is the directory, in the config directory, that GitHub release lookups
+// are cached in.
+const releaseCacheDir = "cache"
+
+// releaseIndexFile lists the releases of a mirror or local release source, in the
+// format of the GitHub releases API.
+const releaseIndexFile = "index.json"
+
+// ReleaseSource is where releases are looked up and downloaded from.
+type ReleaseSource interface {
+	// Releases returns every release.
+	Releases() ([]GitHubRelease, error)
+
+	// Download writes the named asset of the release version (e.g., "0.0.1") to w.
+	Download(version, asset string, w io.Writer) error
+}
+
+// NewReleaseSource creates the ReleaseSource chosen in the installer state, in configDir.
+// That's GitHub by default, a mirror for an http(s) URL, and a local directory or bundle
+// for anything else. Mirrors, directories and bundles share a layout:
+//
+//	index.json                                     the releases, as GitHub lists them
+//	v0.0.1/contributoor_0.0.1_checksums.txt        the assets of each release
+//	v0.0.1/contributoor_0.0.1_linux_x86_64.tar.gz
+func NewReleaseSource(log *logrus.Logger, installerCfg *installer.Config, configDir string) (ReleaseSource, error) {
+	state, err := installer.LoadState(configDir)
+	if err != nil {
+		return nil, err
+	}
+
+	switch location := state.ReleaseSource; {
+	case location == "" || location == ReleaseSourceGitHub:
+		return newGitHubSource(log, installerCfg, filepath.Join(configDir, releaseCacheDir))
+	case isMirror(location):
+		return newMirrorSource(location)
+	default:
+		return newLocalSource(location)
+	}
+}
+
+// ParseReleaseSource validates a release source, returning it as it's kept in the
+// installer state. GitHub is kept as empty, and local paths are made absolute, so that
+// they're found from wherever the CLI runs.
+func ParseReleaseSource(location string) (string, error) {
+	switch {
+	case location == "" || location == ReleaseSourceGitHub:
+		return "", nil
+	case isMirror(location):
+		if _, err := newMirrorSource(location); err != nil {
+			return "", err
+		}
+
+		return location, nil
+	default:
+		source, err := newLocalSource(location)
+		if err != nil {
+			return "", err
+		}
+
+		return source.(*localSource).path, nil
+	}
+}
+
+// isMirror reports whether the release source at location is a mirror.
+func isMirror(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// releaseAssetPath returns the path of the named asset of the release version, relative
+// to the root of a mirror or local release source.
+func releaseAssetPath(version, asset string) string {
+	return path.Join("v"+version, asset)
+}
+
+// parseReleaseIndex parses the index of a mirror or local release source.
+func parseReleaseIndex(r io.Reader) ([]GitHubRelease, error) {
+	var releases []GitHubRelease
+	if err := json.NewDecoder(r).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", releaseIndexFile, err)
+	}
+
+	return releases, nil
+}
+
+// download streams the body of the given url into w.
+func download(url string, w io.Writer) error {
+	//nolint:gosec // controlled url.
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testReleaseFiles are the files of a mirror or local release source.
+var testReleaseFiles = map[string]string{
+	"index.json": `[
+		{"tag_name": "v1.0.0"},
+		{"tag_name": "v1.1.0-rc.1", "prerelease": true}
+	]`,
+	"v1.0.0/contributoor_1.0.0_checksums.txt":       "checksums",
+	"v1.0.0/contributoor_1.0.0_linux_x86_64.tar.gz": "archive",
+}
+
+func TestNewReleaseSource(t *testing.T) {
+	tests := []struct {
+		location      string
+		expected      ReleaseSource
+		expectedError string
+	}{
+		{location: "", expected: &githubSource{}},
+		{location: "github", expected: &githubSource{}},
+		{location: "https://mirror.example.com/contributoor/", expected: &mirrorSource{baseURL: "https://mirror.example.com/contributoor"}},
+		{location: "https://", expectedError: `invalid release mirror "https://"`},
+		{location: "/srv/contributoor", expected: &localSource{path: "/srv/contributoor"}},
+		{location: "/srv/contributoor.tar.gz", expected: &localSource{path: "/srv/contributoor.tar.gz", bundle: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			dir := testConfigDir(t, tt.location)
+
+			source, err := NewReleaseSource(logrus.New(), installer.NewConfig(), dir)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.IsType(t, tt.expected, source)
+
+			switch expected := tt.expected.(type) {
+			case *githubSource:
+				// Releases are cached alongside the config.
+				assert.Equal(t, filepath.Join(dir, "cache"), source.(*githubSource).cacheDir)
+			case *mirrorSource:
+				assert.Equal(t, expected.baseURL, source.(*mirrorSource).baseURL)
+			case *localSource:
+				assert.Equal(t, expected, source)
+			}
+		})
+	}
+}
+
+func TestGitHubSource_Download(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ethpandaops/contributoor/releases/download/v1.0.0/contributoor_1.0.0_checksums.txt" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		fmt.Fprint(w, "checksums")
+	})
+
+	downloadURL := githubDownloadURL
+	githubDownloadURL = server.URL
+
+	t.Cleanup(func() { githubDownloadURL = downloadURL })
+
+	source, err := NewReleaseSource(logrus.New(), installer.NewConfig(), t.TempDir())
+	require.NoError(t, err)
+
+	assertDownloads(t, source)
+}
+
+func TestMirrorSource(t *testing.T) {
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		content, ok := testReleaseFiles[strings.TrimPrefix(r.URL.Path, "/contributoor/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		fmt.Fprint(w, content)
+	})
+
+	assertReleaseSource(t, testConfigDir(t, server.URL+"/contributoor/"))
+
+	// A mirror without an index has no releases.
+	source, err := NewReleaseSource(logrus.New(), installer.NewConfig(), testConfigDir(t, server.URL+"/contributoor/missing"))
+	require.NoError(t, err)
+
+	_, err = source.Releases()
+	assert.ErrorContains(t, err, "release mirror returned status 404")
+}
+
+func TestLocalSource(t *testing.T) {
+	t.Run("directory", func(t *testing.T) {
+		dir := t.TempDir()
+
+		for name, content := range testReleaseFiles {
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+		}
+
+		assertReleaseSource(t, testConfigDir(t, dir))
+	})
+
+	t.Run("bundle", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+
+		for name, content := range testReleaseFiles {
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: "./" + name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+			_, err := tw.Write([]byte(content))
+			require.NoError(t, err)
+		}
+
+		require.NoError(t, tw.Close())
+		require.NoError(t, gz.Close())
+
+		bundle := filepath.Join(t.TempDir(), "contributoor.tar.gz")
+		require.NoError(t, os.WriteFile(bundle, buf.Bytes(), 0600))
+
+		assertReleaseSource(t, testConfigDir(t, bundle))
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		dir := testConfigDir(t, filepath.Join(t.TempDir(), "missing.tar.gz"))
+
+		source, err := NewReleaseSource(logrus.New(), installer.NewConfig(), dir)
+		require.NoError(t, err)
+
+		_, err = source.Releases()
+		assert.ErrorContains(t, err, "failed to open release bundle")
+	})
+
+	t.Run("escaping the directory", func(t *testing.T) {
+		source, err := NewReleaseSource(logrus.New(), installer.NewConfig(), testConfigDir(t, t.TempDir()))
+		require.NoError(t, err)
+
+		err = source.Download("1.0.0/../..", "passwd", &bytes.Buffer{})
+		assert.ErrorContains(t, err, "invalid release file")
+	})
+}
+
+func TestParseReleaseSource(t *testing.T) {
+	// Relative paths are taken from the working directory.
+	relative, err := filepath.Abs("releases")
+	require.NoError(t, err)
+
+	tests := []struct {
+		location      string
+		expected      string
+		expectedError string
+	}{
+		{location: "", expected: ""},
+		{location: "github", expected: ""},
+		{location: "https://mirror.example.com/contributoor", expected: "https://mirror.example.com/contributoor"},
+		{location: "https://", expectedError: `invalid release mirror "https://"`},
+		{location: "/srv/contributoor.tar.gz", expected: "/srv/contributoor.tar.gz"},
+		{location: "releases", expected: relative},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			source, err := ParseReleaseSource(tt.location)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, source)
+		})
+	}
+}
+
+// testConfigDir returns a config directory whose installer state chooses the release
+// source at location.
+func testConfigDir(t *testing.T, location string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	state, err := installer.LoadState(dir)
+	require.NoError(t, err)
+
+	state.ReleaseSource = location
+	require.NoError(t, state.Save())
+
+	return dir
+}
+
+// assertReleaseSource asserts the release source chosen in configDir serves
+// testReleaseFiles, both directly and through a GitHubService.
+func assertReleaseSource(t *testing.T, configDir string) {
+	t.Helper()
+
+	source, err := NewReleaseSource(logrus.New(), installer.NewConfig(), configDir)
+	require.NoError(t, err)
+
+	releases, err := source.Releases()
+	require.NoError(t, err)
+	assert.Equal(t, []GitHubRelease{{TagName: "v1.0.0"}, {TagName: "v1.1.0-rc.1", Prerelease: true}}, releases)
+
+	assertDownloads(t, source)
+
+	var buf bytes.Buffer
+
+	require.NoError(t, source.Download("1.0.0", "contributoor_1.0.0_linux_x86_64.tar.gz", &buf))
+	assert.Equal(t, "archive", buf.String())
+
+	svc, err := NewGitHubService(logrus.New(), installer.NewConfig(), configDir)
+	require.NoError(t, err)
+
+	latest, err := svc.GetLatestVersion(installer.ChannelBeta)
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0-rc.1", latest)
+
+	exists, err := svc.VersionExists("1.0.0")
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+// assertDownloads asserts the source downloads the checksums of 1.0.0, and fails on
+// assets it doesn't have.
+func assertDownloads(t *testing.T, source ReleaseSource) {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	require.NoError(t, source.Download("1.0.0", "contributoor_1.0.0_checksums.txt", &buf))
+	assert.Equal(t, "checksums", buf.String())
+
+	assert.Error(t, source.Download("9.9.9", "contributoor_9.9.9_checksums.txt", &bytes.Buffer{}))
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+)
+
+// VersionLatest stands for whichever release is newest. It's what the config defaults
+// to, but it's resolved to a concrete version before anything is installed, as there's
+// no binary release called latest, and the image's latest tag moves.
+const VersionLatest = "latest"
+
+// IsUnresolved returns whether version has yet to be resolved to a concrete version.
+func IsUnresolved(version string) bool {
+	return version == "" || version == VersionLatest
+}
+
+// ResolveVersion resolves version to a concrete version, eg: latest to 0.0.6, taking the
+// newest on the channel. Concrete versions are checked to exist, and returned as is.
+func ResolveVersion(github GitHubService, version string, channel installer.Channel) (string, error) {
+	if IsUnresolved(version) {
+		latest, err := github.GetLatestVersion(channel)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve the latest version: %w", err)
+		}
+
+		return latest, nil
+	}
+
+	exists, err := github.VersionExists(version)
+	if err != nil {
+		return "", fmt.Errorf("failed to check version %s: %w", version, err)
+	}
+
+	if !exists {
+		return "", fmt.Errorf("version %s not found", version)
+	}
+
+	return version, nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGitHub is a GitHubService with a fixed set of releases.
+type fakeGitHub struct {
+	latest   map[installer.Channel]string
+	versions []string
+	err      error
+}
+
+func (f *fakeGitHub) GetLatestVersion(channel installer.Channel) (string, error) {
+	return f.latest[channel], f.err
+}
+
+func (f *fakeGitHub) VersionExists(version string) (bool, error) {
+	for _, v := range f.versions {
+		if v == version {
+			return true, f.err
+		}
+	}
+
+	return false, f.err
+}
+
+func TestResolveVersion(t *testing.T) {
+	github := &fakeGitHub{
+		latest: map[installer.Channel]string{
+			installer.ChannelStable: "1.1.0",
+			installer.ChannelBeta:   "1.2.0-rc.1",
+		},
+		versions: []string{"1.0.0", "1.1.0", "1.2.0-rc.1"},
+	}
+
+	tests := []struct {
+		name          string
+		version       string
+		channel       installer.Channel
+		expected      string
+		expectedError string
+	}{
+		{name: "latest", version: "latest", channel: installer.ChannelStable, expected: "1.1.0"},
+		{name: "empty", version: "", channel: installer.ChannelStable, expected: "1.1.0"},
+		{name: "latest beta", version: "latest", channel: installer.ChannelBeta, expected: "1.2.0-rc.1"},
+		{name: "concrete", version: "1.0.0", channel: installer.ChannelBeta, expected: "1.0.0"},
+		{name: "missing", version: "9.9.9", channel: installer.ChannelStable, expectedError: "version 9.9.9 not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := ResolveVersion(github, tt.version, tt.channel)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, version)
+		})
+	}
+
+	_, err := ResolveVersion(&fakeGitHub{err: errors.New("rate limited")}, "latest", installer.ChannelStable)
+	assert.EqualError(t, err, "failed to resolve the latest version: rate limited")
+}
@@ -0,0 +1,159 @@
+package sidecar
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxBinarySize caps the decompressed size of a binary extracted from a release
+// archive. Sentry is a few tens of MB, this leaves plenty of headroom while still
+// protecting against decompression bombs.
+var maxBinarySize int64 = 512 << 20
+
+// extractBinary extracts the regular file called name from the gzipped tarball at
+// archivePath and atomically writes it to destPath. Every other entry is ignored,
+// but the archive is rejected outright if it contains entries that try to escape
+// the archive root, links, or device files.
+func extractBinary(archivePath, name, destPath string) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to read gzip stream: %w", err)
+	}
+
+	defer gz.Close()
+
+	var (
+		tr       = tar.NewReader(gz)
+		tmpPath  string
+		extracts int
+	)
+
+	// Clean up our temp file if we bail before the rename.
+	defer func() {
+		if tmpPath != "" {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	for {
+		hdr, rerr := tr.Next()
+		if errors.Is(rerr, io.EOF) {
+			break
+		}
+
+		if rerr != nil {
+			return fmt.Errorf("failed to read archive: %w", rerr)
+		}
+
+		entry, serr := sanitizeArchiveEntry(hdr)
+		if serr != nil {
+			return serr
+		}
+
+		if hdr.Typeflag == tar.TypeDir || entry != name {
+			continue
+		}
+
+		if extracts++; extracts > 1 {
+			return fmt.Errorf("archive contains multiple %s entries", name)
+		}
+
+		if hdr.Size > maxBinarySize {
+			return fmt.Errorf("%s exceeds maximum size of %d bytes", name, maxBinarySize)
+		}
+
+		if tmpPath, err = writeTempFile(filepath.Dir(destPath), name, tr); err != nil {
+			return err
+		}
+	}
+
+	if extracts == 0 {
+		return fmt.Errorf("%s not found in archive", name)
+	}
+
+	if err := os.Rename(tmpPath, destPath); err != nil {
+		return fmt.Errorf("failed to move %s into place: %w", name, err)
+	}
+
+	tmpPath = ""
+
+	return nil
+}
+
+// sanitizeArchiveEntry returns the cleaned name of the archive entry, or an error if
+// the entry is something we refuse to handle.
+func sanitizeArchiveEntry(hdr *tar.Header) (string, error) {
+	switch hdr.Typeflag {
+	case tar.TypeReg, tar.TypeDir:
+	case tar.TypeSymlink, tar.TypeLink:
+		return "", fmt.Errorf("archive entry %q is a link, refusing to extract", hdr.Name)
+	case tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+		return "", fmt.Errorf("archive entry %q is a device file, refusing to extract", hdr.Name)
+	default:
+		return "", fmt.Errorf("archive entry %q has unsupported type %q", hdr.Name, hdr.Typeflag)
+	}
+
+	name := hdr.Name
+	if strings.Contains(name, `\`) || path.IsAbs(name) {
+		return "", fmt.Errorf("archive entry %q has an unsafe path", hdr.Name)
+	}
+
+	cleaned := path.Clean(name)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("archive entry %q escapes the archive root", hdr.Name)
+	}
+
+	return cleaned, nil
+}
+
+// writeTempFile copies r into a new executable temp file in dir, enforcing the
+// maxBinarySize limit, and returns the temp file's path.
+func writeTempFile(dir, name string, r io.Reader) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create binary directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, fmt.Sprintf(".%s-*.tmp", name))
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+
+	written, err := io.Copy(tmp, io.LimitReader(r, maxBinarySize+1))
+	if err == nil && written > maxBinarySize {
+		err = fmt.Errorf("%s exceeds maximum size of %d bytes", name, maxBinarySize)
+	}
+
+	if err == nil {
+		err = tmp.Chmod(0755)
+	}
+
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		os.Remove(tmp.Name())
+
+		return "", fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return tmp.Name(), nil
+}
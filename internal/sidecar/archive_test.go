@@ -0,0 +1,230 @@
+package sidecar
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractBinary(t *testing.T) {
+	tests := []struct {
+		name          string
+		entries       []archiveEntry
+		expectedError string
+	}{
+		{
+			name: "extracts only the sentry entry",
+			entries: []archiveEntry{
+				{name: "LICENSE", content: "MIT"},
+				{name: "README.md", content: "readme"},
+				{name: "sentry", content: "binary"},
+			},
+		},
+		{
+			name: "handles dot prefixed entries and directories",
+			entries: []archiveEntry{
+				{name: "./", typeflag: tar.TypeDir},
+				{name: "./sentry", content: "binary"},
+			},
+		},
+		{
+			name: "rejects path traversal",
+			entries: []archiveEntry{
+				{name: "../../etc/cron.d/evil", content: "* * * * * root evil"},
+				{name: "sentry", content: "binary"},
+			},
+			expectedError: "escapes the archive root",
+		},
+		{
+			name: "rejects absolute paths",
+			entries: []archiveEntry{
+				{name: "/usr/local/bin/sentry", content: "binary"},
+			},
+			expectedError: "unsafe path",
+		},
+		{
+			name: "rejects symlinks",
+			entries: []archiveEntry{
+				{name: "sentry", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"},
+			},
+			expectedError: "is a link",
+		},
+		{
+			name: "rejects hard links",
+			entries: []archiveEntry{
+				{name: "sentry", typeflag: tar.TypeLink, linkname: "/etc/passwd"},
+			},
+			expectedError: "is a link",
+		},
+		{
+			name: "rejects device files",
+			entries: []archiveEntry{
+				{name: "sentry", content: "binary"},
+				{name: "null", typeflag: tar.TypeChar},
+			},
+			expectedError: "is a device file",
+		},
+		{
+			name: "rejects duplicate entries",
+			entries: []archiveEntry{
+				{name: "sentry", content: "binary"},
+				{name: "./sentry", content: "other"},
+			},
+			expectedError: "multiple sentry entries",
+		},
+		{
+			name: "missing binary",
+			entries: []archiveEntry{
+				{name: "README.md", content: "readme"},
+			},
+			expectedError: "sentry not found in archive",
+		},
+		{
+			name: "rejects oversized binary",
+			entries: []archiveEntry{
+				{name: "sentry", size: 1025},
+			},
+			expectedError: "exceeds maximum size",
+		},
+	}
+
+	maxSize := maxBinarySize
+	maxBinarySize = 1024
+
+	defer func() { maxBinarySize = maxSize }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				dir         = t.TempDir()
+				archivePath = filepath.Join(dir, "archive.tar.gz")
+				binDir      = filepath.Join(dir, "bin")
+				destPath    = filepath.Join(binDir, "sentry")
+			)
+
+			require.NoError(t, os.WriteFile(archivePath, buildArchive(t, tt.entries...), 0600))
+			require.NoError(t, os.MkdirAll(binDir, 0755))
+			require.NoError(t, os.WriteFile(destPath, []byte("old"), 0600))
+
+			err := extractBinary(archivePath, "sentry", destPath)
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+
+				// The existing binary is untouched and nothing else was written.
+				got, rerr := os.ReadFile(destPath)
+				require.NoError(t, rerr)
+				assert.Equal(t, "old", string(got))
+				assertOnlyEntries(t, binDir, "sentry")
+				assert.NoFileExists(t, filepath.Join(dir, "..", "etc", "cron.d", "evil"))
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			got, err := os.ReadFile(destPath)
+			require.NoError(t, err)
+			assert.Equal(t, "binary", string(got))
+
+			info, err := os.Stat(destPath)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+			assertOnlyEntries(t, binDir, "sentry")
+		})
+	}
+
+	t.Run("rejects corrupt archives", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "archive.tar.gz")
+		require.NoError(t, os.WriteFile(archivePath, []byte("not a gzip stream"), 0600))
+
+		err := extractBinary(archivePath, "sentry", filepath.Join(t.TempDir(), "sentry"))
+		assert.ErrorContains(t, err, "failed to read gzip stream")
+	})
+}
+
+// archiveEntry describes a single entry written by buildArchive. If size is set and
+// content is empty, the entry is padded with zeroes to that size.
+type archiveEntry struct {
+	name     string
+	content  string
+	typeflag byte
+	linkname string
+	size     int64
+}
+
+// buildArchive returns a gzipped tarball containing the given entries.
+func buildArchive(t *testing.T, entries ...archiveEntry) []byte {
+	t.Helper()
+
+	var (
+		buf bytes.Buffer
+		gw  = gzip.NewWriter(&buf)
+		tw  = tar.NewWriter(gw)
+	)
+
+	for _, entry := range entries {
+		typeflag := entry.typeflag
+		if typeflag == 0 {
+			typeflag = tar.TypeReg
+		}
+
+		size := int64(len(entry.content))
+		if entry.size > 0 {
+			size = entry.size
+		}
+
+		if typeflag != tar.TypeReg {
+			size = 0
+		}
+
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     entry.name,
+			Mode:     0755,
+			Size:     size,
+			Typeflag: typeflag,
+			Linkname: entry.linkname,
+		}))
+
+		if typeflag != tar.TypeReg {
+			continue
+		}
+
+		if entry.content != "" {
+			_, err := tw.Write([]byte(entry.content))
+			require.NoError(t, err)
+
+			continue
+		}
+
+		_, err := tw.Write(make([]byte, size))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+
+	return buf.Bytes()
+}
+
+// assertOnlyEntries asserts dir contains exactly the named entries, ie: no temp files
+// were left behind.
+func assertOnlyEntries(t *testing.T, dir string, names ...string) {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	got := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		got = append(got, entry.Name())
+	}
+
+	assert.ElementsMatch(t, names, got)
+}
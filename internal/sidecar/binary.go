@@ -1,16 +1,24 @@
 package sidecar
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/service"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+	"github.com/ethpandaops/contributoor-installer/internal/supervisor"
 	"github.com/ethpandaops/contributoor-installer/internal/tui"
 	"github.com/mitchellh/go-homedir"
 	"github.com/sirupsen/logrus"
@@ -27,6 +35,7 @@ type binarySidecar struct {
 	logger       *logrus.Logger
 	sidecarCfg   ConfigManager
 	installerCfg *installer.Config
+	source       service.ReleaseSource
 	stdout       *os.File
 	stderr       *os.File
 }
@@ -38,8 +47,26 @@ func NewBinarySidecar(logger *logrus.Logger, sidecarCfg ConfigManager, installer
 		return nil, fmt.Errorf("failed to expand config path: %w", err)
 	}
 
+	source, err := service.NewReleaseSource(logger, installerCfg, filepath.Dir(sidecarCfg.GetConfigPath()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create release source: %w", err)
+	}
+
 	logsDir := filepath.Join(expandedDir, "logs")
 
+	policy, err := installerCfg.LogRotationPolicy(filepath.Dir(sidecarCfg.GetConfigPath()))
+	if err != nil {
+		logger.Warnf("Failed to load the log rotation policy, using the default: %v", err)
+	}
+
+	// Rotate log files that are due before opening them. A running sentry keeps writing
+	// to the same files, as they're rotated in place.
+	for _, name := range []string{"debug.log", "service.log"} {
+		if _, err := policy.Rotate(filepath.Join(logsDir, name)); err != nil {
+			logger.Warnf("Failed to rotate %s: %v", name, err)
+		}
+	}
+
 	// Open log files
 	stdout, err := os.OpenFile(filepath.Join(logsDir, "debug.log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
 	if err != nil {
@@ -59,157 +86,290 @@ func NewBinarySidecar(logger *logrus.Logger, sidecarCfg ConfigManager, installer
 		stderr:       stderr,
 		sidecarCfg:   sidecarCfg,
 		installerCfg: installerCfg,
+		source:       source,
 	}, nil
 }
 
-// Start starts the binary service.
+// Start starts the binary service under a supervisor, which restarts it if it exits.
 func (s *binarySidecar) Start() error {
 	cfg := s.sidecarCfg.Get()
 
-	binaryPath := filepath.Join(cfg.ContributoorDirectory, "bin", "sentry")
+	expandedDir, err := homedir.Expand(cfg.ContributoorDirectory)
+	if err != nil {
+		return fmt.Errorf("failed to expand config path: %w", err)
+	}
+
+	binaryPath := filepath.Join(expandedDir, "bin", "sentry")
 	if _, err := os.Stat(binaryPath); err != nil {
 		return fmt.Errorf("binary not found at %s - please reinstall", binaryPath)
 	}
 
-	expandedDir, err := homedir.Expand(cfg.ContributoorDirectory)
+	// A second supervisor would find the first one's socket, and report it as started.
+	running, err := s.IsRunning()
 	if err != nil {
-		return fmt.Errorf("failed to expand config path: %w", err)
+		return err
 	}
 
-	configPath := filepath.Join(expandedDir, "config.yaml")
-	cmd := exec.Command(binaryPath, "--config", configPath)
-	cmd.Stdout = s.stderr
-	cmd.Stderr = s.stdout
-
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start binary: %w", err)
+	if running {
+		return errors.New("contributoor is already running")
 	}
 
-	pidFile := filepath.Join(cfg.ContributoorDirectory, "contributoor.pid")
-	if err := os.WriteFile(pidFile, []byte(fmt.Sprintf("%d", cmd.Process.Pid)), 0600); err != nil {
-		return fmt.Errorf("failed to write pid file: %w", err)
+	cmd, err := supervisorCommand(filepath.Dir(s.sidecarCfg.GetConfigPath()))
+	if err != nil {
+		return fmt.Errorf("failed to build supervisor command: %w", err)
 	}
 
-	// Start a goroutine to wait for the process and clean up
-	go func() {
-		defer s.stdout.Close()
-		defer s.stderr.Close()
+	cmd.Stdout = s.stdout
+	cmd.Stderr = s.stderr
 
-		if err := cmd.Wait(); err != nil {
-			s.logger.Errorf("Process exited with error: %v", err)
-		}
+	// Give the supervisor a session of its own, so it outlives us and isn't sent the
+	// signals meant for us, eg: ctrl+c.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
 
-		// Clean up pid file
-		if err := os.Remove(pidFile); err != nil {
-			s.logger.Errorf("Failed to remove pid file: %v", err)
-		}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start supervisor: %w", err)
+	}
+
+	exited := make(chan error, 1)
+
+	go func() {
+		exited <- cmd.Wait()
 	}()
 
+	if err := waitForSupervisor(supervisorSocketPath(expandedDir), exited); err != nil {
+		return err
+	}
+
 	fmt.Printf("%sContributoor started successfully%s\n", tui.TerminalColorGreen, tui.TerminalColorReset)
 
 	return nil
 }
 
-// Stop stops the binary service.
+// Stop stops the binary service, and its supervisor. The binary is sent SIGTERM, and
+// then SIGKILL if it hasn't exited within the grace period. It returns once the binary
+// has exited.
 func (s *binarySidecar) Stop() error {
-	cfg := s.sidecarCfg.Get()
+	expandedDir, err := homedir.Expand(s.sidecarCfg.Get().ContributoorDirectory)
+	if err != nil {
+		return fmt.Errorf("failed to expand config path: %w", err)
+	}
 
-	pidFile := filepath.Join(cfg.ContributoorDirectory, "contributoor.pid")
+	var (
+		gracePeriod = s.installerCfg.StopGracePeriod
+		outcome     supervisor.StopOutcome
+	)
 
-	pidBytes, err := os.ReadFile(pidFile)
+	status, err := supervisor.Stop(supervisorSocketPath(expandedDir), gracePeriod)
+
+	switch {
+	case errors.Is(err, supervisor.ErrNotRunning):
+		if outcome, err = s.stopUnsupervised(); err != nil {
+			return err
+		}
+	case err != nil:
+		return fmt.Errorf("failed to stop supervisor: %w", err)
+	default:
+		outcome = status.StopOutcome
+	}
+
+	if outcome == supervisor.StopOutcomeKilled {
+		fmt.Printf(
+			"%sContributoor didn't stop within %s, so it was killed%s\n",
+			tui.TerminalColorYellow,
+			gracePeriod,
+			tui.TerminalColorReset,
+		)
+
+		return nil
+	}
+
+	fmt.Printf("%sContributoor stopped gracefully%s\n", tui.TerminalColorGreen, tui.TerminalColorReset)
+
+	return nil
+}
+
+// IsRunning checks if the binary service is running.
+func (s *binarySidecar) IsRunning() (bool, error) {
+	status, err := s.supervisorStatus()
 	if err != nil {
-		return fmt.Errorf("failed to read pid file: %w", err)
+		return false, err
 	}
 
-	pidStr := string(pidBytes)
-	if !regexp.MustCompile(`^\d+$`).MatchString(pidStr) {
-		return fmt.Errorf("invalid PID format")
+	if status != nil {
+		return true, nil
 	}
 
-	//nolint:gosec // sanitized.
-	cmd := exec.Command("kill", string(pidBytes))
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to stop process: %w", err)
+	return s.isRunningUnsupervised()
+}
+
+// Details returns whether the binary is installed, and the PID of its process.
+func (s *binarySidecar) Details() (*runner.Details, error) {
+	expandedDir, err := homedir.Expand(s.sidecarCfg.Get().ContributoorDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand config path: %w", err)
 	}
 
-	os.Remove(pidFile)
+	details := &runner.Details{}
 
-	// Close log files if they exist
-	if s.stdout != nil {
-		s.stdout.Close()
-		s.stdout = nil
+	if _, err := os.Stat(filepath.Join(expandedDir, "bin", "sentry")); err == nil {
+		details.Installed = true
 	}
 
-	if s.stderr != nil {
-		s.stderr.Close()
-		s.stderr = nil
+	status, err := s.supervisorStatus()
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Printf("%sContributoor stopped successfully%s\n", tui.TerminalColorGreen, tui.TerminalColorReset)
+	if status != nil {
+		details.PID = status.PID
+		details.Restarts = status.Restarts
+		details.LastExitCode = status.LastExitCode
 
-	return nil
+		return details, nil
+	}
+
+	if details.PID, err = s.unsupervisedPID(); err != nil {
+		return nil, err
+	}
+
+	return details, nil
 }
 
-// IsRunning checks if the binary service is running.
-func (s *binarySidecar) IsRunning() (bool, error) {
-	cfg := s.sidecarCfg.Get()
+// supervisorStatus returns the status of the binary's supervisor, or nil if there
+// isn't one running.
+func (s *binarySidecar) supervisorStatus() (*supervisor.Status, error) {
+	expandedDir, err := homedir.Expand(s.sidecarCfg.Get().ContributoorDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand config path: %w", err)
+	}
 
-	pidFile := filepath.Join(cfg.ContributoorDirectory, "contributoor.pid")
-	if _, err := os.Stat(pidFile); os.IsNotExist(err) {
-		return false, nil
+	status, err := supervisor.GetStatus(supervisorSocketPath(expandedDir))
+	if errors.Is(err, supervisor.ErrNotRunning) {
+		//nolint:nilnil // No supervisor isn't an error.
+		return nil, nil
 	}
 
-	pidBytes, err := os.ReadFile(pidFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get supervisor status: %w", err)
+	}
+
+	return status, nil
+}
+
+// stopUnsupervised stops a binary started by a version of the CLI that pre-dates the
+// supervisor, which tracked it with a PID file.
+func (s *binarySidecar) stopUnsupervised() (supervisor.StopOutcome, error) {
+	pid, err := s.unsupervisedPID()
+	if err != nil {
+		return "", err
+	}
+
+	if pid == 0 {
+		return "", errors.New("contributoor is not running")
+	}
+
+	outcome, err := stopProcess(pid, s.installerCfg.StopGracePeriod)
+	if err != nil {
+		return "", fmt.Errorf("failed to stop process: %w", err)
+	}
+
+	pidFile, err := s.pidFilePath()
+	if err != nil {
+		return "", err
+	}
+
+	os.Remove(pidFile)
+
+	return outcome, nil
+}
+
+// isRunningUnsupervised checks if a binary started by a version of the CLI that
+// pre-dates the supervisor is running.
+func (s *binarySidecar) isRunningUnsupervised() (bool, error) {
+	pid, err := s.unsupervisedPID()
 	if err != nil {
 		return false, err
 	}
 
-	pidStr := string(pidBytes)
-	if !regexp.MustCompile(`^\d+$`).MatchString(pidStr) {
-		return false, fmt.Errorf("invalid PID format")
+	return pid != 0, nil
+}
+
+// unsupervisedPID returns the PID of a binary started by a version of the CLI that
+// pre-dates the supervisor, or 0 if it's not running. A PID file that no longer refers
+// to a running binary is removed, as the PID may since have been reused.
+func (s *binarySidecar) unsupervisedPID() (int, error) {
+	pidFile, err := s.pidFilePath()
+	if err != nil {
+		return 0, err
 	}
 
-	// kill -0 just checks if process exists. It doesn't actually send a
-	// signal that affects the process.
-	cmd := exec.Command("kill", "-0", pidStr)
-	if err := cmd.Run(); err != nil {
+	pidBytes, err := os.ReadFile(pidFile)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to read pid file: %w", err)
+	}
+
+	pid, err := strconv.Atoi(string(pidBytes))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid PID format")
+	}
+
+	isSentry, err := isSentryProcess(pid, filepath.Join(filepath.Dir(pidFile), "bin"))
+	if err != nil {
+		return 0, err
+	}
+
+	if !isSentry {
 		os.Remove(pidFile)
 
-		//nolint:nilerr // We don't care about the error here.
-		return false, nil
+		return 0, nil
+	}
+
+	return pid, nil
+}
+
+// pidFilePath returns where a version of the CLI that pre-dates the supervisor wrote the
+// binary's PID.
+func (s *binarySidecar) pidFilePath() (string, error) {
+	expandedDir, err := homedir.Expand(s.sidecarCfg.Get().ContributoorDirectory)
+	if err != nil {
+		return "", fmt.Errorf("failed to expand config path: %w", err)
+	}
+
+	return filepath.Join(expandedDir, "contributoor.pid"), nil
+}
+
+// Logs writes the logs from the binary's stdout and stderr log files to w.
+func (s *binarySidecar) Logs(ctx context.Context, opts runner.LogOptions, w io.Writer) error {
+	dir, err := homedir.Expand(s.sidecarCfg.Get().ContributoorDirectory)
+	if err != nil {
+		return fmt.Errorf("failed to expand config path: %w", err)
 	}
 
-	return true, nil
+	return tailLogFiles(ctx, []string{
+		filepath.Join(dir, "logs", "debug.log"),
+		filepath.Join(dir, "logs", "service.log"),
+	}, opts, w)
 }
 
 // Update updates the binary service.
 func (s *binarySidecar) Update() error {
 	cfg := s.sidecarCfg.Get()
 
+	// Releases are only published under concrete versions, there's no vlatest to fetch.
+	if service.IsUnresolved(cfg.Version) {
+		return fmt.Errorf("version %q has no release, it must be resolved first, eg: with 'contributoor update'", cfg.Version)
+	}
+
 	expandedDir, err := homedir.Expand(cfg.ContributoorDirectory)
 	if err != nil {
 		return fmt.Errorf("failed to expand config path: %w", err)
 	}
 
-	binaryPath := filepath.Join(expandedDir, "bin", "sentry")
-	binaryDir := filepath.Dir(binaryPath)
-
-	// Download and verify checksums.
-	checksumURL := fmt.Sprintf(
-		"https://github.com/%s/%s/releases/download/v%s/contributoor_%s_checksums.txt",
-		s.installerCfg.GithubOrg,
-		s.installerCfg.GithubRepo,
-		cfg.Version,
-		cfg.Version,
-	)
-
-	//nolint:gosec // controlled url.
-	resp, err := http.Get(checksumURL)
-	if err != nil || resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download checksums: %w", err)
-	}
-
-	defer resp.Body.Close()
+	binaryDir := filepath.Join(expandedDir, "bin")
 
 	// Determine platform and arch
 	var (
@@ -221,36 +381,42 @@ func (s *binarySidecar) Update() error {
 		arch = "x86_64"
 	}
 
-	binaryURL := fmt.Sprintf(
-		"https://github.com/%s/%s/releases/download/v%s/contributoor_%s_%s_%s.tar.gz",
-		s.installerCfg.GithubOrg,
-		s.installerCfg.GithubRepo,
-		cfg.Version,
-		cfg.Version,
-		platform,
-		arch,
+	var (
+		archiveName   = fmt.Sprintf("contributoor_%s_%s_%s.tar.gz", cfg.Version, platform, arch)
+		checksumsName = fmt.Sprintf("contributoor_%s_checksums.txt", cfg.Version)
 	)
 
-	//nolint:gosec // controlled url.
-	resp, err = http.Get(binaryURL)
-	if err != nil || resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download binary: %w", err)
+	// Download checksums first, we need the expected digest before we trust the archive.
+	checksums, err := s.downloadChecksums(cfg.Version, checksumsName)
+	if err != nil {
+		return fmt.Errorf("failed to download checksums: %w", err)
 	}
 
-	defer resp.Body.Close()
+	expectedChecksum, ok := checksums[archiveName]
+	if !ok {
+		return fmt.Errorf("checksum not found for %s", archiveName)
+	}
 
 	// Create temp file for download
 	tmpFile, err := os.CreateTemp("", "contributoor-*.tar.gz")
 	if err != nil {
 		return fmt.Errorf("failed to create temp file: %w", err)
 	}
+
 	defer os.Remove(tmpFile.Name())
+	defer tmpFile.Close()
+
+	if err := s.source.Download(cfg.Version, archiveName, tmpFile); err != nil {
+		return fmt.Errorf("failed to download binary: %w", err)
+	}
 
-	// Copy download to temp file
-	if _, ioerr := io.Copy(tmpFile, resp.Body); ioerr != nil {
-		return fmt.Errorf("failed to write binary to temp file: %w", err)
+	// Verify the archive before we touch the running service or the existing binary.
+	if err := verifyChecksum(tmpFile.Name(), expectedChecksum); err != nil {
+		return fmt.Errorf("failed to verify %s: %w", archiveName, err)
 	}
 
+	fmt.Printf("%sVerified checksum: %s%s\n", tui.TerminalColorGreen, expectedChecksum, tui.TerminalColorReset)
+
 	// Stop service if running
 	running, err := s.IsRunning()
 	if err != nil {
@@ -263,20 +429,19 @@ func (s *binarySidecar) Update() error {
 		}
 	}
 
-	// Extract binary
-	if err := os.MkdirAll(binaryDir, 0755); err != nil {
-		return fmt.Errorf("failed to create binary directory: %w", err)
+	// Extract binary. Only the sentry entry is pulled out of the archive, and it's
+	// written atomically so a failed extraction never leaves a partial binary behind.
+	// Each version gets its own file, so previous versions remain available for rollback.
+	if err := extractBinary(tmpFile.Name(), "sentry", versionedBinaryPath(binaryDir, cfg.Version)); err != nil {
+		return fmt.Errorf("failed to extract binary: %w", err)
 	}
 
-	//nolint:gosec // binaryPath is controlled by us.
-	cmd := exec.Command("tar", "--no-same-owner", "-xzf", tmpFile.Name(), "-C", binaryDir)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to extract binary: %w", err)
+	if err := s.activateBinary(binaryDir, cfg.Version); err != nil {
+		return fmt.Errorf("failed to activate binary: %w", err)
 	}
 
-	// Set permissions
-	if err := os.Chmod(binaryPath, 0755); err != nil {
-		return fmt.Errorf("failed to set binary permissions: %w", err)
+	if err := s.pruneBinaries(binaryDir, cfg.Version); err != nil {
+		s.logger.Warnf("Failed to prune old binaries: %v", err)
 	}
 
 	fmt.Printf("%sBinary updated successfully%s\n", tui.TerminalColorGreen, tui.TerminalColorReset)
@@ -290,3 +455,194 @@ func (s *binarySidecar) Update() error {
 
 	return nil
 }
+
+// Rollback points the binary back at a previously installed version.
+func (s *binarySidecar) Rollback(version string) error {
+	expandedDir, err := homedir.Expand(s.sidecarCfg.Get().ContributoorDirectory)
+	if err != nil {
+		return fmt.Errorf("failed to expand config path: %w", err)
+	}
+
+	binaryDir := filepath.Join(expandedDir, "bin")
+
+	if _, err := os.Stat(versionedBinaryPath(binaryDir, version)); err != nil {
+		return fmt.Errorf("version %s is not installed, unable to roll back: %w", version, err)
+	}
+
+	if err := s.activateBinary(binaryDir, version); err != nil {
+		return fmt.Errorf("failed to activate binary: %w", err)
+	}
+
+	fmt.Printf("%sBinary rolled back to version %s%s\n", tui.TerminalColorGreen, version, tui.TerminalColorReset)
+
+	return nil
+}
+
+// activateBinary atomically points bin/sentry at the given installed version.
+func (s *binarySidecar) activateBinary(binaryDir, version string) error {
+	binaryPath := filepath.Join(binaryDir, "sentry")
+
+	// Installs that pre-date versioned binaries have a regular file here. Hold on to it
+	// under the version we're moving away from, so it can still be rolled back to.
+	if info, err := os.Lstat(binaryPath); err == nil && info.Mode().IsRegular() {
+		if previous := s.previousVersion(); previous != "" && previous != version {
+			if _, serr := os.Stat(versionedBinaryPath(binaryDir, previous)); os.IsNotExist(serr) {
+				if rerr := os.Rename(binaryPath, versionedBinaryPath(binaryDir, previous)); rerr != nil {
+					return fmt.Errorf("failed to preserve existing binary: %w", rerr)
+				}
+			}
+		}
+	}
+
+	// Symlink to a temp name and rename over the current pointer, so bin/sentry always
+	// resolves to a complete binary.
+	tmpLink := filepath.Join(binaryDir, fmt.Sprintf(".sentry-%d.link", time.Now().UnixNano()))
+	if err := os.Symlink(filepath.Base(versionedBinaryPath(binaryDir, version)), tmpLink); err != nil {
+		return fmt.Errorf("failed to create symlink: %w", err)
+	}
+
+	if err := os.Rename(tmpLink, binaryPath); err != nil {
+		os.Remove(tmpLink)
+
+		return fmt.Errorf("failed to update symlink: %w", err)
+	}
+
+	return nil
+}
+
+// pruneBinaries removes installed versions that are neither current, nor retained for rollback.
+func (s *binarySidecar) pruneBinaries(binaryDir, current string) error {
+	state, err := installer.LoadState(filepath.Dir(s.sidecarCfg.GetConfigPath()))
+	if err != nil {
+		return err
+	}
+
+	keep := map[string]bool{current: true}
+	for _, version := range state.PreviousVersions {
+		keep[version] = true
+	}
+
+	binaries, err := filepath.Glob(filepath.Join(binaryDir, "sentry-*"))
+	if err != nil {
+		return err
+	}
+
+	for _, binary := range binaries {
+		if keep[strings.TrimPrefix(filepath.Base(binary), "sentry-")] {
+			continue
+		}
+
+		if err := os.Remove(binary); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", binary, err)
+		}
+
+		s.logger.Debugf("Pruned old binary %s", binary)
+	}
+
+	return nil
+}
+
+// previousVersion returns the most recent known-good version recorded in installer state.
+func (s *binarySidecar) previousVersion() string {
+	state, err := installer.LoadState(filepath.Dir(s.sidecarCfg.GetConfigPath()))
+	if err != nil {
+		s.logger.Warnf("Failed to load installer state: %v", err)
+
+		return ""
+	}
+
+	return state.PreviousVersion()
+}
+
+// versionedBinaryPath returns the path a specific version of sentry is installed to.
+func versionedBinaryPath(binaryDir, version string) string {
+	return filepath.Join(binaryDir, fmt.Sprintf("sentry-%s", version))
+}
+
+// downloadChecksums downloads and parses the named checksums file of the release version.
+func (s *binarySidecar) downloadChecksums(version, asset string) (map[string]string, error) {
+	var buf bytes.Buffer
+
+	if err := s.source.Download(version, asset, &buf); err != nil {
+		return nil, err
+	}
+
+	return parseChecksums(&buf)
+}
+
+// supervisorCommand returns the command that runs the binary's supervisor, which is
+// this executable's supervise command.
+var supervisorCommand = func(configDir string) (*exec.Cmd, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	return exec.Command(executable, "--config-path", configDir, "supervise"), nil
+}
+
+// supervisorStartTimeout is how long the supervisor has to start listening on its
+// control socket.
+var supervisorStartTimeout = 10 * time.Second
+
+// SupervisorConfig returns the supervisor config for running the binary, as used by the
+// supervise command.
+func SupervisorConfig(sidecarCfg ConfigManager, installerCfg *installer.Config) (supervisor.Config, error) {
+	expandedDir, err := homedir.Expand(sidecarCfg.Get().ContributoorDirectory)
+	if err != nil {
+		return supervisor.Config{}, fmt.Errorf("failed to expand config path: %w", err)
+	}
+
+	cfg := supervisor.DefaultConfig(
+		supervisorSocketPath(expandedDir),
+		filepath.Join(expandedDir, "bin", "sentry"),
+		"--config", filepath.Join(expandedDir, "config.yaml"),
+	)
+	cfg.StopGracePeriod = installerCfg.StopGracePeriod
+
+	// sentry may run for weeks without the CLI running, so the supervisor rotates its
+	// logs too. The policy's reloaded each time, so changes apply without a restart.
+	logsDir := filepath.Join(expandedDir, "logs")
+	configDir := filepath.Dir(sidecarCfg.GetConfigPath())
+
+	cfg.RotateLogs = func() error {
+		policy, err := installerCfg.LogRotationPolicy(configDir)
+		if err != nil {
+			return err
+		}
+
+		return policy.RotateDir(logsDir)
+	}
+
+	return cfg, nil
+}
+
+// supervisorSocketPath returns the path of the supervisor's control socket.
+func supervisorSocketPath(contributoorDir string) string {
+	return filepath.Join(contributoorDir, "supervisor.sock")
+}
+
+// waitForSupervisor waits for a newly started supervisor to listen on its control
+// socket, failing early if it exits.
+func waitForSupervisor(socketPath string, exited <-chan error) error {
+	var (
+		deadline = time.After(supervisorStartTimeout)
+		ticker   = time.NewTicker(100 * time.Millisecond)
+	)
+
+	defer ticker.Stop()
+
+	for {
+		if _, err := supervisor.GetStatus(socketPath); err == nil {
+			return nil
+		}
+
+		select {
+		case err := <-exited:
+			return fmt.Errorf("supervisor exited during startup, see logs/service.log: %v", err)
+		case <-deadline:
+			return fmt.Errorf("supervisor didn't start within %s, see logs/service.log", supervisorStartTimeout)
+		case <-ticker.C:
+		}
+	}
+}
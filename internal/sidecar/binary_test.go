@@ -0,0 +1,580 @@
+package sidecar
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/mock"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+	"github.com/ethpandaops/contributoor-installer/internal/supervisor"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/mitchellh/go-homedir"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestParseChecksums(t *testing.T) {
+	digest := strings.Repeat("ab", sha256.Size)
+
+	tests := []struct {
+		name          string
+		input         string
+		want          map[string]string
+		expectedError string
+	}{
+		{
+			name:  "sha256sum format",
+			input: fmt.Sprintf("%s  contributoor_0.0.1_linux_x86_64.tar.gz\n%s  contributoor_0.0.1_darwin_arm64.tar.gz\n", digest, digest),
+			want: map[string]string{
+				"contributoor_0.0.1_linux_x86_64.tar.gz": digest,
+				"contributoor_0.0.1_darwin_arm64.tar.gz": digest,
+			},
+		},
+		{
+			name:  "binary mode and uppercase digest",
+			input: fmt.Sprintf("\n%s *sentry.tar.gz\n\n", strings.ToUpper(digest)),
+			want:  map[string]string{"sentry.tar.gz": digest},
+		},
+		{
+			name:          "malformed line",
+			input:         "not-a-checksum-line-at-all\n",
+			expectedError: "malformed checksum on line 1",
+		},
+		{
+			name:          "invalid digest",
+			input:         "abc123  sentry.tar.gz\n",
+			expectedError: "invalid sha256 digest for sentry.tar.gz",
+		},
+		{
+			name:          "empty file",
+			input:         "",
+			expectedError: "checksums file is empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChecksums(strings.NewReader(tt.input))
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.tar.gz")
+	require.NoError(t, os.WriteFile(path, []byte("contributoor"), 0600))
+
+	sum := sha256.Sum256([]byte("contributoor"))
+
+	t.Run("matching digest", func(t *testing.T) {
+		assert.NoError(t, verifyChecksum(path, hex.EncodeToString(sum[:])))
+	})
+
+	t.Run("matching digest is case insensitive", func(t *testing.T) {
+		assert.NoError(t, verifyChecksum(path, strings.ToUpper(hex.EncodeToString(sum[:]))))
+	})
+
+	t.Run("mismatched digest", func(t *testing.T) {
+		assert.ErrorContains(t, verifyChecksum(path, strings.Repeat("0", sha256.Size*2)), "checksum mismatch")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		assert.ErrorContains(t, verifyChecksum(filepath.Join(t.TempDir(), "missing"), ""), "failed to open file")
+	})
+}
+
+func TestBinarySidecar_Update(t *testing.T) {
+	const version = "1.2.3"
+
+	arch := runtime.GOARCH
+	if arch == "amd64" {
+		arch = "x86_64"
+	}
+
+	var (
+		archiveName   = fmt.Sprintf("contributoor_%s_%s_%s.tar.gz", version, runtime.GOOS, arch)
+		checksumsName = fmt.Sprintf("contributoor_%s_checksums.txt", version)
+		archive       = buildArchive(t, archiveEntry{name: "sentry", content: "#!/bin/sh\necho new\n"})
+		archiveSum    = sha256.Sum256(archive)
+	)
+
+	tests := []struct {
+		name          string
+		checksums     string
+		archive       []byte
+		expectedError string
+	}{
+		{
+			name:      "checksum matches",
+			checksums: fmt.Sprintf("%s  %s\n", hex.EncodeToString(archiveSum[:]), archiveName),
+			archive:   archive,
+		},
+		{
+			name:          "checksum mismatch",
+			checksums:     fmt.Sprintf("%s  %s\n", strings.Repeat("0", sha256.Size*2), archiveName),
+			archive:       archive,
+			expectedError: "checksum mismatch",
+		},
+		{
+			name:          "checksum missing for platform",
+			checksums:     fmt.Sprintf("%s  contributoor_%s_plan9_mips.tar.gz\n", hex.EncodeToString(archiveSum[:]), version),
+			archive:       archive,
+			expectedError: "checksum not found for " + archiveName,
+		},
+		{
+			name:          "checksums unavailable",
+			archive:       archive,
+			expectedError: "failed to download checksums",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Stand-in for a release mirror.
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case fmt.Sprintf("/v%s/%s", version, checksumsName):
+					if tt.checksums == "" {
+						w.WriteHeader(http.StatusNotFound)
+
+						return
+					}
+
+					_, _ = w.Write([]byte(tt.checksums))
+				case fmt.Sprintf("/v%s/%s", version, archiveName):
+					_, _ = w.Write(tt.archive)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			dir := t.TempDir()
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, "logs"), 0755))
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, "bin"), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "bin", "sentry"), []byte("old"), 0600))
+
+			// For failure cases, pretend sentry is running so we can assert it's left alone.
+			pidFile := filepath.Join(dir, "contributoor.pid")
+
+			if tt.expectedError != "" {
+				startUnsupervisedSentry(t, dir, "sleep", "60")
+			}
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cfg := mock.NewMockConfigManager(ctrl)
+			cfg.EXPECT().Get().Return(&config.Config{
+				Version:               version,
+				ContributoorDirectory: dir,
+				RunMethod:             config.RunMethod_RUN_METHOD_BINARY,
+			}).AnyTimes()
+			cfg.EXPECT().GetConfigPath().Return(filepath.Join(dir, "config.yaml")).AnyTimes()
+
+			// The installed binary pre-dates versioned binaries, and was recorded by the
+			// update command as the version we're moving away from.
+			state, err := installer.LoadState(dir)
+			require.NoError(t, err)
+
+			state.PushVersion("1.2.2", 2)
+			state.ReleaseSource = server.URL
+			require.NoError(t, state.Save())
+
+			bs, err := NewBinarySidecar(logrus.New(), cfg, installer.NewConfig())
+			require.NoError(t, err)
+
+			err = bs.Update()
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+
+				// The existing binary and running process must be untouched.
+				got, rerr := os.ReadFile(filepath.Join(dir, "bin", "sentry"))
+				require.NoError(t, rerr)
+				assert.Equal(t, "old", string(got))
+				assert.FileExists(t, pidFile)
+
+				running, rerr := bs.IsRunning()
+				require.NoError(t, rerr)
+				assert.True(t, running)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			got, err := os.ReadFile(filepath.Join(dir, "bin", "sentry"))
+			require.NoError(t, err)
+			assert.Equal(t, "#!/bin/sh\necho new\n", string(got))
+
+			target, err := os.Readlink(filepath.Join(dir, "bin", "sentry"))
+			require.NoError(t, err)
+			assert.Equal(t, "sentry-"+version, target)
+
+			// The legacy binary is kept around for rollback.
+			got, err = os.ReadFile(filepath.Join(dir, "bin", "sentry-1.2.2"))
+			require.NoError(t, err)
+			assert.Equal(t, "old", string(got))
+			assertOnlyEntries(t, filepath.Join(dir, "bin"), "sentry", "sentry-1.2.2", "sentry-"+version)
+		})
+	}
+}
+
+func TestBinarySidecar_UpdateUnresolvedVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "logs"), 0755))
+
+	cfg := mock.NewMockConfigManager(ctrl)
+	cfg.EXPECT().Get().Return(&config.Config{
+		Version:               "latest",
+		ContributoorDirectory: dir,
+		RunMethod:             config.RunMethod_RUN_METHOD_BINARY,
+	}).AnyTimes()
+	cfg.EXPECT().GetConfigPath().Return(filepath.Join(dir, "config.yaml")).AnyTimes()
+
+	bs, err := NewBinarySidecar(logrus.New(), cfg, installer.NewConfig())
+	require.NoError(t, err)
+
+	assert.ErrorContains(t, bs.Update(), `version "latest" has no release`)
+}
+func TestBinarySidecar_Rollback(t *testing.T) {
+	setup := func(t *testing.T, previousVersions ...string) (string, BinarySidecar) {
+		t.Helper()
+
+		dir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "logs"), 0755))
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "bin"), 0755))
+
+		for _, version := range append(previousVersions, "1.2.3") {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "bin", "sentry-"+version), []byte(version), 0600))
+		}
+
+		require.NoError(t, os.Symlink("sentry-1.2.3", filepath.Join(dir, "bin", "sentry")))
+
+		state, err := installer.LoadState(dir)
+		require.NoError(t, err)
+
+		state.PreviousVersions = previousVersions
+		require.NoError(t, state.Save())
+
+		ctrl := gomock.NewController(t)
+
+		cfg := mock.NewMockConfigManager(ctrl)
+		cfg.EXPECT().Get().Return(&config.Config{
+			Version:               "1.2.3",
+			ContributoorDirectory: dir,
+			RunMethod:             config.RunMethod_RUN_METHOD_BINARY,
+		}).AnyTimes()
+		cfg.EXPECT().GetConfigPath().Return(filepath.Join(dir, "config.yaml")).AnyTimes()
+
+		bs, err := NewBinarySidecar(logrus.New(), cfg, installer.NewConfig())
+		require.NoError(t, err)
+
+		return dir, bs
+	}
+
+	t.Run("switches to installed version", func(t *testing.T) {
+		dir, bs := setup(t, "1.2.1", "1.2.2")
+
+		require.NoError(t, bs.Rollback("1.2.2"))
+
+		got, err := os.ReadFile(filepath.Join(dir, "bin", "sentry"))
+		require.NoError(t, err)
+		assert.Equal(t, "1.2.2", string(got))
+
+		// Nothing is removed, so we can roll forward again.
+		assertOnlyEntries(t, filepath.Join(dir, "bin"), "sentry", "sentry-1.2.1", "sentry-1.2.2", "sentry-1.2.3")
+	})
+
+	t.Run("fails for version that isn't installed", func(t *testing.T) {
+		dir, bs := setup(t)
+
+		assert.ErrorContains(t, bs.Rollback("1.0.0"), "version 1.0.0 is not installed")
+
+		target, err := os.Readlink(filepath.Join(dir, "bin", "sentry"))
+		require.NoError(t, err)
+		assert.Equal(t, "sentry-1.2.3", target)
+	})
+}
+
+func TestBinarySidecar_PruneBinaries(t *testing.T) {
+	dir := t.TempDir()
+	binDir := filepath.Join(dir, "bin")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "logs"), 0755))
+	require.NoError(t, os.MkdirAll(binDir, 0755))
+
+	for _, version := range []string{"1.2.0", "1.2.1", "1.2.2", "1.2.3"} {
+		require.NoError(t, os.WriteFile(filepath.Join(binDir, "sentry-"+version), []byte(version), 0600))
+	}
+
+	require.NoError(t, os.Symlink("sentry-1.2.3", filepath.Join(binDir, "sentry")))
+
+	state, err := installer.LoadState(dir)
+	require.NoError(t, err)
+
+	state.PreviousVersions = []string{"1.2.1", "1.2.2"}
+	require.NoError(t, state.Save())
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := mock.NewMockConfigManager(ctrl)
+	cfg.EXPECT().Get().Return(&config.Config{ContributoorDirectory: dir}).AnyTimes()
+	cfg.EXPECT().GetConfigPath().Return(filepath.Join(dir, "config.yaml")).AnyTimes()
+
+	bs, err := NewBinarySidecar(logrus.New(), cfg, installer.NewConfig())
+	require.NoError(t, err)
+
+	sidecar, ok := bs.(*binarySidecar)
+	require.True(t, ok)
+
+	require.NoError(t, sidecar.pruneBinaries(binDir, "1.2.3"))
+	assertOnlyEntries(t, binDir, "sentry", "sentry-1.2.1", "sentry-1.2.2", "sentry-1.2.3")
+}
+
+func TestBinarySidecar_Details(t *testing.T) {
+	setup := func(t *testing.T) (string, BinarySidecar) {
+		t.Helper()
+
+		dir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "logs"), 0755))
+
+		ctrl := gomock.NewController(t)
+
+		cfg := mock.NewMockConfigManager(ctrl)
+		cfg.EXPECT().Get().Return(&config.Config{
+			Version:               "1.2.3",
+			ContributoorDirectory: dir,
+			RunMethod:             config.RunMethod_RUN_METHOD_BINARY,
+		}).AnyTimes()
+		cfg.EXPECT().GetConfigPath().Return(filepath.Join(dir, "config.yaml")).AnyTimes()
+
+		bs, err := NewBinarySidecar(logrus.New(), cfg, installer.NewConfig())
+		require.NoError(t, err)
+
+		return dir, bs
+	}
+
+	t.Run("not installed", func(t *testing.T) {
+		_, bs := setup(t)
+
+		details, err := bs.Details()
+		require.NoError(t, err)
+		assert.Equal(t, &runner.Details{}, details)
+	})
+
+	t.Run("installed under the home directory", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+
+		homedir.DisableCache = true
+		defer func() { homedir.DisableCache = false }()
+
+		dir := filepath.Join(home, ".contributoor")
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "logs"), 0755))
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "bin"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "bin", "sentry"), []byte("binary"), 0600))
+
+		ctrl := gomock.NewController(t)
+
+		cfg := mock.NewMockConfigManager(ctrl)
+		cfg.EXPECT().Get().Return(&config.Config{
+			Version:               "1.2.3",
+			ContributoorDirectory: "~/.contributoor",
+			RunMethod:             config.RunMethod_RUN_METHOD_BINARY,
+		}).AnyTimes()
+		cfg.EXPECT().GetConfigPath().Return(filepath.Join(dir, "config.yaml")).AnyTimes()
+
+		bs, err := NewBinarySidecar(logrus.New(), cfg, installer.NewConfig())
+		require.NoError(t, err)
+
+		details, err := bs.Details()
+		require.NoError(t, err)
+		assert.Equal(t, &runner.Details{Installed: true}, details)
+	})
+
+	t.Run("installed and running without a supervisor", func(t *testing.T) {
+		dir, bs := setup(t)
+
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "bin"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "bin", "sentry"), []byte("binary"), 0600))
+
+		pid, _ := startUnsupervisedSentry(t, dir, "sleep", "60")
+
+		details, err := bs.Details()
+		require.NoError(t, err)
+		assert.Equal(t, &runner.Details{Installed: true, PID: pid}, details)
+	})
+}
+
+func TestBinarySidecar_Supervised(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "logs"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "bin"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bin", "sentry"), []byte("#!/bin/sh\nexec sleep 60\n"), 0700))
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := mock.NewMockConfigManager(ctrl)
+	cfg.EXPECT().Get().Return(&config.Config{
+		Version:               "1.2.3",
+		ContributoorDirectory: dir,
+		RunMethod:             config.RunMethod_RUN_METHOD_BINARY,
+	}).AnyTimes()
+	cfg.EXPECT().GetConfigPath().Return(filepath.Join(dir, "config.yaml")).AnyTimes()
+
+	// The test binary can't run the supervise command, so it runs the helper below instead.
+	original := supervisorCommand
+	supervisorCommand = func(configDir string) (*exec.Cmd, error) {
+		assert.Equal(t, dir, configDir)
+
+		cmd := exec.Command(os.Args[0], "-test.run=^TestSupervisorHelperProcess$")
+		cmd.Env = append(os.Environ(), "CONTRIBUTOOR_TEST_SUPERVISOR_DIR="+dir)
+
+		return cmd, nil
+	}
+
+	t.Cleanup(func() {
+		supervisorCommand = original
+
+		_, _ = supervisor.Stop(supervisorSocketPath(dir), time.Second)
+	})
+
+	bs, err := NewBinarySidecar(logrus.New(), cfg, installer.NewConfig())
+	require.NoError(t, err)
+
+	running, err := bs.IsRunning()
+	require.NoError(t, err)
+	assert.False(t, running)
+
+	require.NoError(t, bs.Start())
+
+	running, err = bs.IsRunning()
+	require.NoError(t, err)
+	assert.True(t, running)
+
+	// A second supervisor isn't started alongside it.
+	assert.EqualError(t, bs.Start(), "contributoor is already running")
+
+	details, err := bs.Details()
+	require.NoError(t, err)
+	assert.True(t, details.Installed)
+	assert.NotZero(t, details.PID)
+	assert.Nil(t, details.LastExitCode)
+
+	// The supervisor leads a session, and so process group, of its own. It isn't
+	// stopped along with us.
+	status, err := supervisor.GetStatus(supervisorSocketPath(dir))
+	require.NoError(t, err)
+
+	pgid, err := syscall.Getpgid(status.SupervisorPID)
+	require.NoError(t, err)
+	assert.Equal(t, status.SupervisorPID, pgid)
+
+	require.NoError(t, bs.Stop())
+
+	running, err = bs.IsRunning()
+	require.NoError(t, err)
+	assert.False(t, running)
+	assert.ErrorIs(t, syscall.Kill(details.PID, 0), syscall.ESRCH)
+}
+
+func TestSupervisorConfig_RotateLogs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		dir     = t.TempDir()
+		logsDir = filepath.Join(dir, "logs")
+	)
+
+	require.NoError(t, os.MkdirAll(logsDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(logsDir, "debug.log"), bytes.Repeat([]byte("x"), 2048), 0600))
+
+	cfg := mock.NewMockConfigManager(ctrl)
+	cfg.EXPECT().Get().Return(&config.Config{ContributoorDirectory: dir}).AnyTimes()
+	cfg.EXPECT().GetConfigPath().Return(filepath.Join(dir, "config.yaml")).AnyTimes()
+
+	supervisorCfg, err := SupervisorConfig(cfg, installer.NewConfig())
+	require.NoError(t, err)
+	require.NotNil(t, supervisorCfg.RotateLogs)
+
+	// The default policy has nothing to rotate yet.
+	require.NoError(t, supervisorCfg.RotateLogs())
+
+	info, err := os.Stat(filepath.Join(logsDir, "debug.log"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2048), info.Size())
+
+	// The policy in the installer state is picked up without a restart.
+	state, err := installer.LoadState(dir)
+	require.NoError(t, err)
+
+	state.LogRotation.MaxSize = "1k"
+	require.NoError(t, state.Save())
+
+	require.NoError(t, supervisorCfg.RotateLogs())
+
+	info, err = os.Stat(filepath.Join(logsDir, "debug.log"))
+	require.NoError(t, err)
+	assert.Zero(t, info.Size())
+
+	rotated, err := filepath.Glob(filepath.Join(logsDir, "debug-*.log.gz"))
+	require.NoError(t, err)
+	assert.Len(t, rotated, 1)
+}
+
+// TestSupervisorHelperProcess isn't a real test. It's the supervisor started by
+// TestBinarySidecar_Supervised.
+func TestSupervisorHelperProcess(t *testing.T) {
+	dir := os.Getenv("CONTRIBUTOOR_TEST_SUPERVISOR_DIR")
+	if dir == "" {
+		t.Skip("only run as a helper process")
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := mock.NewMockConfigManager(ctrl)
+	cfg.EXPECT().Get().Return(&config.Config{ContributoorDirectory: dir}).AnyTimes()
+	cfg.EXPECT().GetConfigPath().Return(filepath.Join(dir, "config.yaml")).AnyTimes()
+
+	supervisorCfg, err := SupervisorConfig(cfg, installer.NewConfig())
+	require.NoError(t, err)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+	defer stop()
+
+	require.NoError(t, supervisor.New(logrus.New(), supervisorCfg).Run(ctx))
+}
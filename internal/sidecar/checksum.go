@@ -0,0 +1,81 @@
+package sidecar
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// maxChecksumsSize caps how much of a checksums file we're willing to read. Release
+// checksum files are a handful of lines, anything bigger is not what we asked for.
+const maxChecksumsSize = 1 << 20
+
+// parseChecksums parses a goreleaser style checksums file into a map of asset name
+// to lowercase hex encoded sha256 digest. Each line is expected to be in the format
+// produced by sha256sum, eg: "<digest>  <filename>".
+func parseChecksums(r io.Reader) (map[string]string, error) {
+	var (
+		checksums = make(map[string]string)
+		scanner   = bufio.NewScanner(io.LimitReader(r, maxChecksumsSize))
+		line      int
+	)
+
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("malformed checksum on line %d", line)
+		}
+
+		// sha256sum prefixes the filename with '*' when hashed in binary mode.
+		digest, name := strings.ToLower(fields[0]), strings.TrimPrefix(fields[1], "*")
+
+		if _, err := hex.DecodeString(digest); err != nil || len(digest) != sha256.Size*2 {
+			return nil, fmt.Errorf("invalid sha256 digest for %s on line %d", name, line)
+		}
+
+		checksums[name] = digest
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read checksums: %w", err)
+	}
+
+	if len(checksums) == 0 {
+		return nil, fmt.Errorf("checksums file is empty")
+	}
+
+	return checksums, nil
+}
+
+// verifyChecksum hashes the file at path and ensures it matches the expected digest.
+func verifyChecksum(path, expected string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return fmt.Errorf("failed to hash file: %w", err)
+	}
+
+	actual := hex.EncodeToString(hasher.Sum(nil))
+	if actual != strings.ToLower(expected) {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", expected, actual)
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package sidecar
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -14,6 +15,16 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// ErrConfigNotFound is returned when contributoor hasn't been installed, so there's no
+// config to load.
+var ErrConfigNotFound = errors.New("config not found")
+
+// Formats the config can be marshaled to.
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
 //go:generate mockgen -package mock -destination mock/config.mock.go github.com/ethpandaops/contributoor-installer/internal/sidecar ConfigManager
 
 // ConfigManager provides the configuration management system for the Contributoor sidecar.
@@ -56,7 +67,7 @@ func NewConfigService(logger *logrus.Logger, configPath string) (ConfigManager,
 	// Check directory exists
 	dirInfo, err := os.Stat(path)
 	if os.IsNotExist(err) {
-		return nil, fmt.Errorf("directory [%s] does not exist", path)
+		return nil, fmt.Errorf("%w: directory [%s] does not exist", ErrConfigNotFound, path)
 	}
 
 	if !dirInfo.IsDir() {
@@ -68,7 +79,24 @@ func NewConfigService(logger *logrus.Logger, configPath string) (ConfigManager,
 
 	// Check if config exists
 	if _, serr := os.Stat(fullConfigPath); os.IsNotExist(serr) {
-		return nil, fmt.Errorf("config file not found at [%s]. Please run 'install.sh' first", fullConfigPath)
+		return nil, fmt.Errorf("%w: config file not found at [%s]. Please run 'install.sh' first", ErrConfigNotFound, fullConfigPath)
+	}
+
+	// Bring the config up to the latest schema before loading it.
+	plan, err := PlanMigration(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate config: %w", err)
+	}
+
+	if plan.Pending() {
+		backupPath, merr := ApplyMigration(plan)
+		if merr != nil {
+			return nil, fmt.Errorf("failed to migrate config: %w", merr)
+		}
+
+		if backupPath != "" {
+			logger.Infof("Migrated config to schema version %d, the previous config was backed up to %s", plan.ToVersion, backupPath)
+		}
 	}
 
 	// Load existing config
@@ -99,20 +127,7 @@ func NewConfigService(logger *logrus.Logger, configPath string) (ConfigManager,
 
 	// Merge old config into new config
 	if err := mergeConfig(newConfig, oldConfig); err != nil {
-		return nil, fmt.Errorf("failed to migrate config: %w", err)
-	}
-
-	// Check if config needs migration by comparing versions
-	if oldConfig.Version != newConfig.Version {
-		// Perform version-specific migrations
-		if err := migrateConfig(newConfig, oldConfig); err != nil {
-			return nil, fmt.Errorf("failed to migrate config: %w", err)
-		}
-
-		// Save migrated config
-		if err := writeConfig(fullConfigPath, newConfig); err != nil {
-			return nil, fmt.Errorf("failed to save migrated config: %w", err)
-		}
+		return nil, fmt.Errorf("failed to merge config: %w", err)
 	}
 
 	return &configService{
@@ -125,7 +140,7 @@ func NewConfigService(logger *logrus.Logger, configPath string) (ConfigManager,
 func newDefaultConfig() *config.Config {
 	return &config.Config{
 		LogLevel:          logrus.InfoLevel.String(),
-		Version:           "latest",
+		Version:           "latest", // Resolved to a concrete version on install.
 		RunMethod:         config.RunMethod_RUN_METHOD_DOCKER,
 		NetworkName:       config.NetworkName_NETWORK_NAME_MAINNET,
 		BeaconNodeAddress: "",
@@ -190,31 +205,56 @@ func writeConfig(path string, cfg *config.Config) error {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
+	data, err := MarshalConfig(cfg, FormatYAML)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("error writing config file: %w", err)
+	}
+
+	return nil
+}
+
+// MarshalConfig marshals the config, or any part of it, in the given format. Field
+// names are camelCase, matching the config file.
+func MarshalConfig(cfg proto.Message, format string) ([]byte, error) {
 	// We wanna keep hold of the camelCase output in yaml.
 	jsonData, err := protojson.MarshalOptions{
 		UseProtoNames:   false, // This ensures we use camelCase.
 		EmitUnpopulated: false,
 	}.Marshal(cfg)
 	if err != nil {
-		return fmt.Errorf("error marshaling config to json: %w", err)
+		return nil, fmt.Errorf("error marshaling config to json: %w", err)
 	}
 
 	// Now marshal to map for YAML.
 	var jsonMap map[string]interface{}
 	if jerr := json.Unmarshal(jsonData, &jsonMap); jerr != nil {
-		return fmt.Errorf("error unmarshaling json: %w", jerr)
+		return nil, fmt.Errorf("error unmarshaling json: %w", jerr)
 	}
 
-	data, err := yaml.Marshal(jsonMap)
-	if err != nil {
-		return fmt.Errorf("error marshaling config: %w", err)
-	}
+	switch format {
+	case FormatYAML:
+		data, err := yaml.Marshal(jsonMap)
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling config: %w", err)
+		}
 
-	if err := os.WriteFile(path, data, 0600); err != nil {
-		return fmt.Errorf("error writing config file: %w", err)
-	}
+		return data, nil
+	case FormatJSON:
+		// Re-marshal rather than using protojson's output directly, as protojson
+		// deliberately randomises its whitespace.
+		data, err := json.MarshalIndent(jsonMap, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("error marshaling config: %w", err)
+		}
 
-	return nil
+		return append(data, '\n'), nil
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
 }
 
 // validate validates the config.
@@ -246,19 +286,3 @@ func mergeConfig(target, source *config.Config) error {
 
 	return nil
 }
-
-// migrateConfig handles version-specific migrations.
-func migrateConfig(target, source *config.Config) error {
-	/*
-		switch source.Version {
-			case "0.0.1":
-				For example, 0.0.1 -> 0.0.2, we might want to migrate the network name.
-				oldName := old.Network.Name
-				new.Network.Name = old.Network.Name + "_migrated"
-
-			Or perhaps we want to populate a new default value for a new field.
-			new.Foo = "bar"
-		}
-	*/
-	return nil
-}
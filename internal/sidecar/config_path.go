@@ -0,0 +1,230 @@
+package sidecar
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// GetConfigValue returns the value of the field at the given path, where a path is the
+// field's camelCase name as it appears in the config file, eg: "outputServer.address".
+// Message fields are returned as YAML.
+func GetConfigValue(cfg *config.Config, path string) (string, error) {
+	parent, fd, err := resolveConfigPath(cfg.ProtoReflect(), path, false)
+	if err != nil {
+		return "", err
+	}
+
+	if fd.IsList() || fd.IsMap() {
+		return "", fmt.Errorf("%s is a %s, which isn't supported", path, fieldType(fd))
+	}
+
+	// Unset fields, including those of unset messages, read as their defaults.
+	value := parent.Get(fd)
+
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		data, err := MarshalConfig(value.Message().Interface(), FormatYAML)
+		if err != nil {
+			return "", err
+		}
+
+		return strings.TrimSuffix(string(data), "\n"), nil
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(value.Enum()); ev != nil {
+			return string(ev.Name()), nil
+		}
+
+		return strconv.Itoa(int(value.Enum())), nil
+	case protoreflect.BytesKind:
+		return base64.StdEncoding.EncodeToString(value.Bytes()), nil
+	default:
+		return value.String(), nil
+	}
+}
+
+// SetConfigValue parses value according to the type of the field at the given path,
+// and sets it. Messages leading up to the field are created as required.
+func SetConfigValue(cfg *config.Config, path, value string) error {
+	parent, fd, err := resolveConfigPath(cfg.ProtoReflect(), path, true)
+	if err != nil {
+		return err
+	}
+
+	if fd.IsList() || fd.IsMap() || fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind {
+		return fmt.Errorf("%s is a %s, set its fields individually instead", path, fieldType(fd))
+	}
+
+	parsed, err := parseConfigValue(fd, value)
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %w", path, err)
+	}
+
+	parent.Set(fd, parsed)
+
+	return nil
+}
+
+// UnsetConfigValue clears the field at the given path, returning it to its default.
+func UnsetConfigValue(cfg *config.Config, path string) error {
+	parent, fd, err := resolveConfigPath(cfg.ProtoReflect(), path, false)
+	if err != nil {
+		return err
+	}
+
+	// If a message leading up to the field isn't set, there's nothing to clear.
+	if parent.IsValid() {
+		parent.Clear(fd)
+	}
+
+	return nil
+}
+
+// resolveConfigPath walks the path, returning the message holding the final field, and
+// the field itself. If mutable is set, messages along the way are created as required.
+// Otherwise, the returned message is invalid if any message along the way isn't set.
+func resolveConfigPath(msg protoreflect.Message, path string, mutable bool) (protoreflect.Message, protoreflect.FieldDescriptor, error) {
+	if path == "" {
+		return nil, nil, fmt.Errorf("path is required")
+	}
+
+	parts := strings.Split(path, ".")
+
+	for i, part := range parts {
+		fd := msg.Descriptor().Fields().ByJSONName(part)
+		if fd == nil {
+			return nil, nil, fmt.Errorf("unknown config field: %s", strings.Join(parts[:i+1], "."))
+		}
+
+		if i == len(parts)-1 {
+			return msg, fd, nil
+		}
+
+		if fd.Kind() != protoreflect.MessageKind || fd.IsList() || fd.IsMap() {
+			return nil, nil, fmt.Errorf("%s is a %s, not a message", strings.Join(parts[:i+1], "."), fieldType(fd))
+		}
+
+		if mutable {
+			msg = msg.Mutable(fd).Message()
+		} else {
+			msg = msg.Get(fd).Message()
+		}
+	}
+
+	return nil, nil, fmt.Errorf("invalid path: %s", path)
+}
+
+// parseConfigValue parses value into the type of the given field.
+func parseConfigValue(fd protoreflect.FieldDescriptor, value string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(value), nil
+	case protoreflect.BoolKind:
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("expected a bool, got %q", value)
+		}
+
+		return protoreflect.ValueOfBool(v), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("expected a 32-bit integer, got %q", value)
+		}
+
+		return protoreflect.ValueOfInt32(int32(v)), nil
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("expected a 64-bit integer, got %q", value)
+		}
+
+		return protoreflect.ValueOfInt64(v), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		v, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("expected an unsigned 32-bit integer, got %q", value)
+		}
+
+		return protoreflect.ValueOfUint32(uint32(v)), nil
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("expected an unsigned 64-bit integer, got %q", value)
+		}
+
+		return protoreflect.ValueOfUint64(v), nil
+	case protoreflect.FloatKind:
+		v, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("expected a number, got %q", value)
+		}
+
+		return protoreflect.ValueOfFloat32(float32(v)), nil
+	case protoreflect.DoubleKind:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("expected a number, got %q", value)
+		}
+
+		return protoreflect.ValueOfFloat64(v), nil
+	case protoreflect.BytesKind:
+		v, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("expected base64 encoded bytes")
+		}
+
+		return protoreflect.ValueOfBytes(v), nil
+	case protoreflect.EnumKind:
+		return parseEnumValue(fd.Enum(), value)
+	default:
+		return protoreflect.Value{}, fmt.Errorf("unsupported field type: %s", fieldType(fd))
+	}
+}
+
+// parseEnumValue parses an enum value by its full name (eg: "RUN_METHOD_DOCKER"), or the
+// unambiguous end of it (eg: "docker").
+func parseEnumValue(ed protoreflect.EnumDescriptor, value string) (protoreflect.Value, error) {
+	var (
+		upper   = strings.ToUpper(value)
+		values  = ed.Values()
+		names   = make([]string, 0, values.Len())
+		matches []protoreflect.EnumValueDescriptor
+	)
+
+	for i := 0; i < values.Len(); i++ {
+		ev := values.Get(i)
+		name := string(ev.Name())
+		names = append(names, name)
+
+		if name == upper {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+
+		if strings.HasSuffix(name, "_"+upper) {
+			matches = append(matches, ev)
+		}
+	}
+
+	if len(matches) == 1 {
+		return protoreflect.ValueOfEnum(matches[0].Number()), nil
+	}
+
+	return protoreflect.Value{}, fmt.Errorf("expected one of %s, got %q", strings.Join(names, ", "), value)
+}
+
+// fieldType returns a human readable type for the field.
+func fieldType(fd protoreflect.FieldDescriptor) string {
+	switch {
+	case fd.IsMap():
+		return "map"
+	case fd.IsList():
+		return "list"
+	default:
+		return fd.Kind().String()
+	}
+}
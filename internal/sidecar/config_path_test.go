@@ -0,0 +1,215 @@
+package sidecar
+
+import (
+	"testing"
+
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestGetConfigValue(t *testing.T) {
+	cfg := &config.Config{
+		Version:     "1.0.0",
+		RunMethod:   config.RunMethod_RUN_METHOD_DOCKER,
+		NetworkName: config.NetworkName_NETWORK_NAME_HOLESKY,
+		OutputServer: &config.OutputServer{
+			Address: "https://xatu.example.com",
+		},
+	}
+
+	tests := []struct {
+		name          string
+		cfg           *config.Config
+		path          string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "string",
+			cfg:      cfg,
+			path:     "version",
+			expected: "1.0.0",
+		},
+		{
+			name:     "enum",
+			cfg:      cfg,
+			path:     "runMethod",
+			expected: "RUN_METHOD_DOCKER",
+		},
+		{
+			name:     "nested field",
+			cfg:      cfg,
+			path:     "outputServer.address",
+			expected: "https://xatu.example.com",
+		},
+		{
+			name:     "message",
+			cfg:      cfg,
+			path:     "outputServer",
+			expected: "address: https://xatu.example.com",
+		},
+		{
+			name:     "field of unset message",
+			cfg:      &config.Config{},
+			path:     "outputServer.address",
+			expected: "",
+		},
+		{
+			name:          "unknown field",
+			cfg:           cfg,
+			path:          "outputServer.port",
+			expectedError: "unknown config field: outputServer.port",
+		},
+		{
+			name:          "proto name rather than camelCase",
+			cfg:           cfg,
+			path:          "run_method",
+			expectedError: "unknown config field: run_method",
+		},
+		{
+			name:          "path through a scalar",
+			cfg:           cfg,
+			path:          "version.major",
+			expectedError: "version is a string, not a message",
+		},
+		{
+			name:          "empty path",
+			cfg:           cfg,
+			path:          "",
+			expectedError: "path is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := GetConfigValue(tt.cfg, tt.path)
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, value)
+		})
+	}
+}
+
+func TestSetConfigValue(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          string
+		value         string
+		expected      *config.Config
+		expectedError string
+	}{
+		{
+			name:     "string",
+			path:     "beaconNodeAddress",
+			value:    "http://localhost:5052",
+			expected: &config.Config{BeaconNodeAddress: "http://localhost:5052"},
+		},
+		{
+			name:     "enum by full name",
+			path:     "networkName",
+			value:    "NETWORK_NAME_SEPOLIA",
+			expected: &config.Config{NetworkName: config.NetworkName_NETWORK_NAME_SEPOLIA},
+		},
+		{
+			name:     "enum by short name",
+			path:     "runMethod",
+			value:    "systemd",
+			expected: &config.Config{RunMethod: config.RunMethod_RUN_METHOD_SYSTEMD},
+		},
+		{
+			name:     "creates unset messages",
+			path:     "outputServer.address",
+			value:    "https://xatu.example.com",
+			expected: &config.Config{OutputServer: &config.OutputServer{Address: "https://xatu.example.com"}},
+		},
+		{
+			name:          "invalid enum",
+			path:          "runMethod",
+			value:         "kubernetes",
+			expectedError: "invalid value for runMethod: expected one of",
+		},
+		{
+			name:          "message",
+			path:          "outputServer",
+			value:         "https://xatu.example.com",
+			expectedError: "outputServer is a message, set its fields individually instead",
+		},
+		{
+			name:          "unknown field",
+			path:          "network",
+			value:         "mainnet",
+			expectedError: "unknown config field: network",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+
+			err := SetConfigValue(cfg, tt.path, tt.value)
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, proto.Equal(tt.expected, cfg), "expected %v, got %v", tt.expected, cfg)
+		})
+	}
+}
+
+func TestUnsetConfigValue(t *testing.T) {
+	t.Run("clears field", func(t *testing.T) {
+		cfg := &config.Config{
+			Version:      "1.0.0",
+			OutputServer: &config.OutputServer{Address: "https://xatu.example.com", Credentials: "creds"},
+		}
+
+		require.NoError(t, UnsetConfigValue(cfg, "outputServer.credentials"))
+
+		expected := &config.Config{
+			Version:      "1.0.0",
+			OutputServer: &config.OutputServer{Address: "https://xatu.example.com"},
+		}
+		assert.True(t, proto.Equal(expected, cfg), "expected %v, got %v", expected, cfg)
+	})
+
+	t.Run("field of unset message", func(t *testing.T) {
+		cfg := &config.Config{Version: "1.0.0"}
+
+		require.NoError(t, UnsetConfigValue(cfg, "outputServer.address"))
+		assert.Nil(t, cfg.OutputServer)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		assert.ErrorContains(t, UnsetConfigValue(&config.Config{}, "foo"), "unknown config field: foo")
+	})
+}
+
+func TestMarshalConfig(t *testing.T) {
+	cfg := &config.Config{
+		Version:   "1.0.0",
+		RunMethod: config.RunMethod_RUN_METHOD_BINARY,
+	}
+
+	data, err := MarshalConfig(cfg, FormatJSON)
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"runMethod\": \"RUN_METHOD_BINARY\",\n  \"version\": \"1.0.0\"\n}\n", string(data))
+
+	data, err = MarshalConfig(cfg, FormatYAML)
+	require.NoError(t, err)
+	assert.Equal(t, "runMethod: RUN_METHOD_BINARY\nversion: 1.0.0\n", string(data))
+
+	_, err = MarshalConfig(cfg, "toml")
+	assert.ErrorContains(t, err, "unsupported format: toml")
+}
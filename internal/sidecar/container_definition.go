@@ -0,0 +1,259 @@
+package sidecar
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethpandaops/contributoor-installer/internal/dockerapi"
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+	"gopkg.in/yaml.v3"
+)
+
+// Definition returns a definition of the container the installer runs, in the given
+// format, for the runtime behind the API.
+func (s *dockerSidecar) Definition(format runner.DefinitionFormat) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dockerTimeout)
+	defer cancel()
+
+	runtime, err := s.containerRuntime(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := installer.LoadState(filepath.Dir(s.configPath))
+	if err != nil {
+		return "", err
+	}
+
+	spec, err := s.containerSpec(runtime, s.pinnedImage(runtime, state, s.sidecarCfg.Get().Version), state.Container)
+	if err != nil {
+		return "", err
+	}
+
+	switch format {
+	case runner.DefinitionCompose:
+		return renderCompose(spec, state.GetPullPolicy(), s.installerCfg.StopGracePeriod)
+	case runner.DefinitionQuadlet:
+		return renderQuadlet(spec, runtime, state.GetPullPolicy(), s.installerCfg.StopGracePeriod), nil
+	default:
+		return "", fmt.Errorf("invalid definition format %q", format)
+	}
+}
+
+// composeFile is the subset of the compose file format the container needs.
+type composeFile struct {
+	Services map[string]composeService  `yaml:"services"`
+	Networks map[string]composeExternal `yaml:"networks,omitempty"`
+	Volumes  map[string]composeVolume   `yaml:"volumes,omitempty"`
+}
+
+type composeService struct {
+	Image           string            `yaml:"image"`
+	ContainerName   string            `yaml:"container_name"`
+	PullPolicy      string            `yaml:"pull_policy"`
+	Command         []string          `yaml:"command,flow"`
+	Environment     []string          `yaml:"environment"`
+	Volumes         []string          `yaml:"volumes"`
+	Labels          map[string]string `yaml:"labels"`
+	Restart         string            `yaml:"restart"`
+	StopGracePeriod string            `yaml:"stop_grace_period"`
+	NetworkMode     string            `yaml:"network_mode,omitempty"`
+	Networks        []string          `yaml:"networks,omitempty"`
+	CPUs            float64           `yaml:"cpus,omitempty"`
+	MemLimit        int64             `yaml:"mem_limit,omitempty"`
+	Logging         *composeLogging   `yaml:"logging,omitempty"`
+}
+
+type composeExternal struct {
+	External bool `yaml:"external"`
+}
+
+type composeVolume struct {
+	Name string `yaml:"name"`
+}
+
+type composeLogging struct {
+	Driver  string            `yaml:"driver"`
+	Options map[string]string `yaml:"options,omitempty"`
+}
+
+// isNetworkMode returns true if network is one of the runtime's network modes, rather
+// than the name of a network, eg: host.
+func isNetworkMode(network string) bool {
+	switch network {
+	case "", "default", "bridge", "host", "none":
+		return true
+	default:
+		return strings.HasPrefix(network, "container:")
+	}
+}
+
+// namedVolume returns the volume named in a bind, eg: data in data:/data, or empty if
+// the bind mounts a host path.
+func namedVolume(bind string) string {
+	source, _, _ := strings.Cut(bind, ":")
+	if strings.HasPrefix(source, "/") || strings.HasPrefix(source, ".") || strings.HasPrefix(source, "~") {
+		return ""
+	}
+
+	return source
+}
+
+// renderCompose renders the container as a compose file, for docker compose or
+// podman-compose. The service isn't named sentry, as the installer removes sentry
+// services it finds, from when it ran them with docker compose itself.
+func renderCompose(spec *dockerapi.ContainerSpec, policy installer.PullPolicy, stopGracePeriod time.Duration) (string, error) {
+	var (
+		file    = composeFile{}
+		service = composeService{
+			Image:           spec.Image,
+			ContainerName:   dockerContainerName,
+			PullPolicy:      string(policy),
+			Command:         spec.Cmd,
+			Environment:     spec.Env,
+			Volumes:         spec.HostConfig.Binds,
+			Labels:          spec.Labels,
+			Restart:         spec.HostConfig.RestartPolicy.Name,
+			StopGracePeriod: stopGracePeriod.String(),
+			CPUs:            float64(spec.HostConfig.NanoCPUs) / 1e9,
+			MemLimit:        spec.HostConfig.Memory,
+		}
+	)
+
+	// Compose puts services on a network of its own, unless told otherwise. A named
+	// network is expected to exist, as it would for the installer's container.
+	if network := spec.HostConfig.NetworkMode; isNetworkMode(network) {
+		service.NetworkMode = network
+	} else {
+		service.Networks = []string{network}
+		file.Networks = map[string]composeExternal{network: {External: true}}
+	}
+
+	// Named volumes would be prefixed with the project's name, unless they're named.
+	for _, bind := range spec.HostConfig.Binds {
+		if volume := namedVolume(bind); volume != "" {
+			if file.Volumes == nil {
+				file.Volumes = make(map[string]composeVolume)
+			}
+
+			file.Volumes[volume] = composeVolume{Name: volume}
+		}
+	}
+
+	if logConfig := spec.HostConfig.LogConfig; logConfig != nil {
+		service.Logging = &composeLogging{Driver: logConfig.Type, Options: logConfig.Config}
+	}
+
+	file.Services = map[string]composeService{dockerContainerName: service}
+
+	data, err := yaml.Marshal(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to render compose file: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// renderQuadlet renders the container as a quadlet .container file, from which podman
+// generates a systemd service. See podman-systemd.unit(5) for the keys. A rootless
+// container is started when the user's service manager is, which needs lingering to
+// happen on boot.
+func renderQuadlet(spec *dockerapi.ContainerSpec, runtime *runner.ContainerRuntime, policy installer.PullPolicy, stopGracePeriod time.Duration) string {
+	var (
+		b        bytes.Buffer
+		wantedBy = "multi-user.target"
+	)
+
+	if runtime.Rootless {
+		wantedBy = "default.target"
+	}
+
+	fmt.Fprintf(&b, "[Unit]\n")
+	fmt.Fprintf(&b, "Description=Contributoor\n")
+	fmt.Fprintf(&b, "Wants=network-online.target\n")
+	fmt.Fprintf(&b, "After=network-online.target\n")
+	fmt.Fprintf(&b, "\n[Container]\n")
+	fmt.Fprintf(&b, "ContainerName=%s\n", dockerContainerName)
+	fmt.Fprintf(&b, "Image=%s\n", spec.Image)
+	fmt.Fprintf(&b, "Pull=%s\n", policy)
+	fmt.Fprintf(&b, "Exec=%s\n", quadletArgs(spec.Cmd))
+
+	for _, env := range spec.Env {
+		fmt.Fprintf(&b, "Environment=%s\n", env)
+	}
+
+	for _, bind := range spec.HostConfig.Binds {
+		fmt.Fprintf(&b, "Volume=%s\n", bind)
+	}
+
+	for _, k := range sortedKeys(spec.Labels) {
+		fmt.Fprintf(&b, "Label=%s=%s\n", k, spec.Labels[k])
+	}
+
+	if spec.HostConfig.NetworkMode != "" {
+		fmt.Fprintf(&b, "Network=%s\n", spec.HostConfig.NetworkMode)
+	}
+
+	// Quadlets have no keys of their own for resource limits or log options.
+	if spec.HostConfig.NanoCPUs != 0 {
+		fmt.Fprintf(&b, "PodmanArgs=--cpus=%s\n", strconv.FormatFloat(float64(spec.HostConfig.NanoCPUs)/1e9, 'f', -1, 64))
+	}
+
+	if spec.HostConfig.Memory != 0 {
+		fmt.Fprintf(&b, "PodmanArgs=--memory=%d\n", spec.HostConfig.Memory)
+	}
+
+	if logConfig := spec.HostConfig.LogConfig; logConfig != nil {
+		fmt.Fprintf(&b, "LogDriver=%s\n", logConfig.Type)
+
+		for _, k := range sortedKeys(logConfig.Config) {
+			fmt.Fprintf(&b, "PodmanArgs=%s\n", quadletArgs([]string{"--log-opt=" + k + "=" + logConfig.Config[k]}))
+		}
+	}
+
+	fmt.Fprintf(&b, "StopTimeout=%d\n", int(stopGracePeriod.Seconds()))
+
+	// systemd restarts the container, rather than podman.
+	fmt.Fprintf(&b, "\n[Service]\n")
+	fmt.Fprintf(&b, "Restart=always\n")
+	fmt.Fprintf(&b, "TimeoutStopSec=%d\n", int((stopGracePeriod + 30*time.Second).Seconds()))
+	fmt.Fprintf(&b, "\n[Install]\n")
+	fmt.Fprintf(&b, "WantedBy=%s\n", wantedBy)
+
+	return b.String()
+}
+
+// sortedKeys returns the keys of m in order, so definitions are rendered the same way
+// each time.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// quadletArgs joins args for a quadlet, quoting those with spaces or quotes.
+func quadletArgs(args []string) string {
+	quoted := make([]string, len(args))
+
+	for i, arg := range args {
+		if strings.ContainsAny(arg, " \t\"'\\") {
+			arg = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+		}
+
+		quoted[i] = arg
+	}
+
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,141 @@
+package sidecar
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerSidecar_Definition(t *testing.T) {
+	t.Run("compose", func(t *testing.T) {
+		ds, _ := newTestDockerSidecar(t, &config.Config{Version: "1.0.0"})
+
+		definition, err := ds.Definition(runner.DefinitionCompose)
+		require.NoError(t, err)
+		assert.Equal(t, `services:
+    contributoor:
+        image: ethpandaops/contributoor:1.0.0
+        container_name: contributoor
+        pull_policy: missing
+        command: [sentry, --config, /config/config.yaml]
+        environment:
+            - CONTRIBUTOOR_CONFIG=/config/config.yaml
+        volumes:
+            - `+ds.configPath+`:/config/config.yaml:ro
+        labels:
+            `+dockerConfigLabel+`: `+filepath.Dir(ds.configPath)+`
+        restart: unless-stopped
+        stop_grace_period: `+ds.installerCfg.StopGracePeriod.String()+`
+`, definition)
+	})
+
+	t.Run("quadlet", func(t *testing.T) {
+		ds, server := newTestDockerSidecar(t, &config.Config{Version: "1.0.0"})
+		server.Podman, server.Rootless = true, true
+
+		state, err := installer.LoadState(filepath.Dir(ds.configPath))
+		require.NoError(t, err)
+
+		state.PullPolicy = installer.PullPolicyNever
+		require.NoError(t, state.Save())
+
+		definition, err := ds.Definition(runner.DefinitionQuadlet)
+		require.NoError(t, err)
+		assert.Contains(t, definition, "Image=docker.io/ethpandaops/contributoor:1.0.0\n")
+		assert.Contains(t, definition, "Pull=never\n")
+		assert.Contains(t, definition, "Volume="+ds.configPath+":/config/config.yaml:ro,z\n")
+		assert.Contains(t, definition, "WantedBy=default.target\n")
+	})
+
+	t.Run("pinned digest", func(t *testing.T) {
+		ds, _ := newTestDockerSidecar(t, &config.Config{Version: "1.0.0"})
+
+		state, err := installer.LoadState(filepath.Dir(ds.configPath))
+		require.NoError(t, err)
+
+		state.PinImage("1.0.0", "sha256:1000")
+		require.NoError(t, state.Save())
+
+		definition, err := ds.Definition(runner.DefinitionCompose)
+		require.NoError(t, err)
+		assert.Contains(t, definition, "image: ethpandaops/contributoor@sha256:1000\n")
+	})
+
+	t.Run("compose with container settings", func(t *testing.T) {
+		ds, _ := newTestDockerSidecar(t, &config.Config{Version: "1.0.0"})
+
+		state, err := installer.LoadState(filepath.Dir(ds.configPath))
+		require.NoError(t, err)
+
+		state.Container = installer.ContainerSettings{
+			Network:    "eth-docker_default",
+			CPUs:       2,
+			Memory:     "1g",
+			Volumes:    []string{"sentry-data:/data"},
+			LogDriver:  "journald",
+			LogOptions: map[string]string{"tag": "contributoor"},
+		}
+		require.NoError(t, state.Save())
+
+		definition, err := ds.Definition(runner.DefinitionCompose)
+		require.NoError(t, err)
+		assert.Contains(t, definition, `        networks:
+            - eth-docker_default
+        cpus: 2
+        mem_limit: 1073741824
+        logging:
+            driver: journald
+            options:
+                tag: contributoor
+networks:
+    eth-docker_default:
+        external: true
+volumes:
+    sentry-data:
+        name: sentry-data
+`)
+	})
+
+	t.Run("quadlet with container settings", func(t *testing.T) {
+		ds, server := newTestDockerSidecar(t, &config.Config{Version: "1.0.0"})
+		server.Podman = true
+
+		state, err := installer.LoadState(filepath.Dir(ds.configPath))
+		require.NoError(t, err)
+
+		state.Container = installer.ContainerSettings{
+			Network:    "host",
+			CPUs:       0.5,
+			Memory:     "512m",
+			LogDriver:  "journald",
+			LogOptions: map[string]string{"tag": "contributoor sentry"},
+		}
+		require.NoError(t, state.Save())
+
+		definition, err := ds.Definition(runner.DefinitionQuadlet)
+		require.NoError(t, err)
+		assert.Contains(t, definition, "Network=host\n")
+		assert.Contains(t, definition, "PodmanArgs=--cpus=0.5\n")
+		assert.Contains(t, definition, "PodmanArgs=--memory=536870912\n")
+		assert.Contains(t, definition, "LogDriver=journald\n")
+		assert.Contains(t, definition, "PodmanArgs=\"--log-opt=tag=contributoor sentry\"\n")
+		assert.Contains(t, definition, "WantedBy=multi-user.target\n")
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		ds, _ := newTestDockerSidecar(t, &config.Config{Version: "1.0.0"})
+
+		_, err := ds.Definition("helm")
+		assert.EqualError(t, err, `invalid definition format "helm"`)
+	})
+}
+
+func TestQuadletArgs(t *testing.T) {
+	assert.Equal(t, `sentry --log-level debug`, quadletArgs([]string{"sentry", "--log-level", "debug"}))
+	assert.Equal(t, `sh -c "echo \"hi there\""`, quadletArgs([]string{"sh", "-c", `echo "hi there"`}))
+}
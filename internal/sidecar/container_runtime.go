@@ -0,0 +1,166 @@
+package sidecar
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ethpandaops/contributoor-installer/internal/dockerapi"
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+	"github.com/mitchellh/go-homedir"
+)
+
+// podmanMachineTimeout is how long podman has to say where its machine's socket is.
+const podmanMachineTimeout = 10 * time.Second
+
+// dockerSockets returns the sockets docker serves its API on, rootful first, as that's
+// where docker is usually installed.
+var dockerSockets = func() []string {
+	sockets := []string{strings.TrimPrefix(dockerapi.DefaultDockerHost, "unix://")}
+
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		sockets = append(sockets, filepath.Join(dir, "docker.sock"))
+	}
+
+	// Docker Desktop's socket on macOS, when /var/run/docker.sock isn't linked to it.
+	if socket, err := homedir.Expand("~/.docker/run/docker.sock"); err == nil {
+		sockets = append(sockets, socket)
+	}
+
+	return sockets
+}
+
+// podmanSockets returns the sockets podman serves its docker compatible API on. A user's
+// own, rootless, podman comes first, as that's the way podman's usually run.
+var podmanSockets = func() []string {
+	var sockets []string
+
+	// On macOS, podman runs in a VM, which serves its API on a socket forwarded to us.
+	if runtime.GOOS == ArchDarwin {
+		if socket := podmanMachineSocket(); socket != "" {
+			sockets = append(sockets, socket)
+		}
+	}
+
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		sockets = append(sockets, filepath.Join(dir, "podman", "podman.sock"))
+	}
+
+	return append(sockets, "/run/podman/podman.sock")
+}
+
+// podmanMachineSocket returns the socket forwarded from the default podman machine, or
+// empty if there isn't one.
+func podmanMachineSocket() string {
+	ctx, cancel := context.WithTimeout(context.Background(), podmanMachineTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "podman", "machine", "inspect", "--format", "{{.ConnectionInfo.PodmanSocket.Path}}").Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+// containerEndpoint returns where the API for the runtime is: DOCKER_HOST if it's set,
+// along with docker's TLS environment, then the endpoint of docker's current context,
+// and otherwise the first of the runtime's sockets that exists. Without a runtime,
+// docker's sockets are tried before podman's. If none exist, the runtime's usual socket
+// is returned, so the error comes when it's used.
+func containerEndpoint(runtime installer.ContainerRuntime) (*dockerapi.Endpoint, error) {
+	if host := os.Getenv("DOCKER_HOST"); host != "" {
+		tlsConfig, err := dockerapi.EnvTLSConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		return &dockerapi.Endpoint{Host: host, TLS: tlsConfig}, nil
+	}
+
+	// Docker Desktop, colima and orbstack point docker at their daemon with a context,
+	// which podman doesn't use.
+	if runtime != installer.ContainerRuntimePodman {
+		endpoint, err := dockerapi.ContextEndpoint()
+		if err != nil {
+			return nil, err
+		}
+
+		if endpoint != nil {
+			return endpoint, nil
+		}
+	}
+
+	var sockets []string
+
+	switch runtime {
+	case installer.ContainerRuntimeDocker:
+		sockets = dockerSockets()
+	case installer.ContainerRuntimePodman:
+		sockets = podmanSockets()
+	default:
+		sockets = append(dockerSockets(), podmanSockets()...)
+	}
+
+	for _, socket := range sockets {
+		if _, err := os.Stat(socket); err == nil {
+			return &dockerapi.Endpoint{Host: "unix://" + socket}, nil
+		}
+	}
+
+	return &dockerapi.Endpoint{Host: "unix://" + sockets[0]}, nil
+}
+
+// detectContainerRuntime asks the API what runtime it is. If a runtime's been chosen, the
+// API must be that runtime's, so that eg: a DOCKER_HOST pointing at docker isn't used
+// when podman was chosen.
+func detectContainerRuntime(ctx context.Context, client *dockerapi.Client, chosen installer.ContainerRuntime) (*runner.ContainerRuntime, error) {
+	version, err := client.Version(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect the container runtime: %w", err)
+	}
+
+	info, err := client.Info(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect the container runtime: %w", err)
+	}
+
+	runtime := &runner.ContainerRuntime{
+		Name:     installer.ContainerRuntimeDocker,
+		Version:  version.Version,
+		Host:     client.Host(),
+		Rootless: info.Rootless(),
+	}
+
+	if version.IsPodman() {
+		runtime.Name = installer.ContainerRuntimePodman
+	}
+
+	if chosen != "" && chosen != runtime.Name {
+		return nil, fmt.Errorf("the container runtime is set to %s, but %s is %s", chosen, runtime.Host, runtime.Name)
+	}
+
+	return runtime, nil
+}
+
+// qualifyImage prefixes an image with docker.io, unless it already names a registry, eg:
+// ghcr.io/org/image. Podman resolves short names through its registries.conf, which
+// may search other registries first, or refuse short names without a terminal to ask.
+func qualifyImage(image string) string {
+	first, _, ok := strings.Cut(image, "/")
+	if ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return image
+	}
+
+	if !ok {
+		image = "library/" + image
+	}
+
+	return "docker.io/" + image
+}
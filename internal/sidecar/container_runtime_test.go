@@ -0,0 +1,210 @@
+package sidecar
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/contributoor-installer/internal/dockerapi"
+	"github.com/ethpandaops/contributoor-installer/internal/dockerapi/dockerapitest"
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerEndpoint(t *testing.T) {
+	var (
+		dir          = t.TempDir()
+		dockerSocket = filepath.Join(dir, "docker.sock")
+		podmanSocket = filepath.Join(dir, "podman.sock")
+		missing      = filepath.Join(dir, "missing.sock")
+		dockerConfig = filepath.Join(dir, "docker")
+	)
+
+	require.NoError(t, os.WriteFile(podmanSocket, nil, 0600))
+
+	originalDocker, originalPodman := dockerSockets, podmanSockets
+
+	t.Cleanup(func() {
+		dockerSockets, podmanSockets = originalDocker, originalPodman
+	})
+
+	dockerSockets = func() []string { return []string{dockerSocket} }
+	podmanSockets = func() []string { return []string{missing, podmanSocket} }
+
+	t.Setenv("DOCKER_HOST", "")
+	t.Setenv("DOCKER_CONTEXT", "")
+	t.Setenv("DOCKER_CONFIG", dockerConfig)
+
+	host := func(runtime installer.ContainerRuntime) string {
+		t.Helper()
+
+		endpoint, err := containerEndpoint(runtime)
+		require.NoError(t, err)
+
+		return endpoint.Host
+	}
+
+	// Without docker, auto finds podman.
+	assert.Equal(t, "unix://"+podmanSocket, host(""))
+	assert.Equal(t, "unix://"+podmanSocket, host(installer.ContainerRuntimePodman))
+
+	// Docker's usual socket is returned, even though it doesn't exist.
+	assert.Equal(t, "unix://"+dockerSocket, host(installer.ContainerRuntimeDocker))
+
+	// Docker's preferred, when both exist.
+	require.NoError(t, os.WriteFile(dockerSocket, nil, 0600))
+	assert.Equal(t, "unix://"+dockerSocket, host(""))
+
+	// Docker's current context comes before its sockets, eg: colima's.
+	writeDockerContext(t, dockerConfig, "colima", "unix:///Users/ethereum/.colima/default/docker.sock")
+	assert.Equal(t, "unix:///Users/ethereum/.colima/default/docker.sock", host(""))
+	assert.Equal(t, "unix:///Users/ethereum/.colima/default/docker.sock", host(installer.ContainerRuntimeDocker))
+	assert.Equal(t, "unix://"+podmanSocket, host(installer.ContainerRuntimePodman))
+
+	// DOCKER_HOST always wins.
+	t.Setenv("DOCKER_HOST", "tcp://127.0.0.1:2375")
+	assert.Equal(t, "tcp://127.0.0.1:2375", host(installer.ContainerRuntimePodman))
+	assert.Equal(t, "tcp://127.0.0.1:2375", host(""))
+}
+
+func TestPodmanMachineSocket(t *testing.T) {
+	bin := t.TempDir()
+	t.Setenv("PATH", bin)
+
+	// Without podman, there's no machine.
+	assert.Empty(t, podmanMachineSocket())
+
+	podman := "#!/bin/sh\n[ \"$*\" = \"machine inspect --format {{.ConnectionInfo.PodmanSocket.Path}}\" ] || exit 1\necho /Users/ethereum/.local/share/containers/podman/machine/podman.sock\n"
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "podman"), []byte(podman), 0700))
+
+	assert.Equal(t, "/Users/ethereum/.local/share/containers/podman/machine/podman.sock", podmanMachineSocket())
+}
+
+// writeDockerContext writes a docker context with the given docker endpoint, as docker
+// context create does, and makes it the current context.
+func writeDockerContext(t *testing.T, dockerConfig, name, host string) {
+	t.Helper()
+
+	digest := sha256.Sum256([]byte(name))
+	metaDir := filepath.Join(dockerConfig, "contexts", "meta", hex.EncodeToString(digest[:]))
+	require.NoError(t, os.MkdirAll(metaDir, 0755))
+
+	meta := fmt.Sprintf(`{"Name":%q,"Metadata":{},"Endpoints":{"docker":{"Host":%q,"SkipTLSVerify":false}}}`, name, host)
+	require.NoError(t, os.WriteFile(filepath.Join(metaDir, "meta.json"), []byte(meta), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dockerConfig, "config.json"), []byte(fmt.Sprintf(`{"currentContext":%q}`, name)), 0600))
+}
+
+func TestDetectContainerRuntime(t *testing.T) {
+	tests := []struct {
+		name          string
+		podman        bool
+		rootless      bool
+		chosen        installer.ContainerRuntime
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "docker",
+			expected: "docker 27.3.1",
+		},
+		{
+			name:     "rootless podman",
+			podman:   true,
+			rootless: true,
+			expected: "podman 5.2.2 (rootless)",
+		},
+		{
+			name:     "chosen runtime matches",
+			podman:   true,
+			chosen:   installer.ContainerRuntimePodman,
+			expected: "podman 5.2.2",
+		},
+		{
+			name:          "chosen runtime doesn't match",
+			chosen:        installer.ContainerRuntimePodman,
+			expectedError: "the container runtime is set to podman, but",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := dockerapitest.NewServer(t)
+			server.Podman, server.Rootless = tt.podman, tt.rootless
+
+			client, err := dockerapi.NewClient(server.Host())
+			require.NoError(t, err)
+
+			runtime, err := detectContainerRuntime(context.Background(), client, tt.chosen)
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, runtime.String())
+			assert.Equal(t, server.Host(), runtime.Host)
+		})
+	}
+}
+
+func TestQualifyImage(t *testing.T) {
+	tests := []struct {
+		image    string
+		expected string
+	}{
+		{image: "ethpandaops/contributoor", expected: "docker.io/ethpandaops/contributoor"},
+		{image: "busybox", expected: "docker.io/library/busybox"},
+		{image: "docker.io/ethpandaops/contributoor", expected: "docker.io/ethpandaops/contributoor"},
+		{image: "ghcr.io/ethpandaops/contributoor", expected: "ghcr.io/ethpandaops/contributoor"},
+		{image: "localhost/contributoor", expected: "localhost/contributoor"},
+		{image: "registry:5000/contributoor", expected: "registry:5000/contributoor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			assert.Equal(t, tt.expected, qualifyImage(tt.image))
+		})
+	}
+}
+
+func TestDockerSidecar_Podman(t *testing.T) {
+	ds, server := newTestDockerSidecar(t, &config.Config{Version: "1.0.0"})
+
+	server.Lock()
+	server.Podman, server.Rootless = true, true
+	server.Unlock()
+
+	server.AddImage("docker.io/ethpandaops/contributoor:1.0.0", "sha256:1000")
+
+	runtime, err := ds.ContainerRuntime()
+	require.NoError(t, err)
+	assert.Equal(t, &runner.ContainerRuntime{
+		Name:     installer.ContainerRuntimePodman,
+		Version:  "5.2.2",
+		Host:     server.Host(),
+		Rootless: true,
+	}, runtime)
+
+	require.NoError(t, ds.Start())
+
+	container := server.Container(dockerContainerName)
+	require.NotNil(t, container)
+
+	spec := server.Specs[container.ID]
+	assert.Equal(t, "docker.io/ethpandaops/contributoor@sha256:1000", spec.Image)
+	assert.Equal(t, []string{ds.configPath + ":/config/config.yaml:ro,z"}, spec.HostConfig.Binds)
+
+	details, err := ds.Details()
+	require.NoError(t, err)
+	assert.Equal(t, "podman 5.2.2 (rootless)", details.ContainerRuntime)
+	assert.Equal(t, "sha256:1000", details.ImageDigest)
+}
@@ -1,13 +1,20 @@
 package sidecar
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/ethpandaops/contributoor-installer/internal/dockerapi"
 	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
 	"github.com/ethpandaops/contributoor-installer/internal/tui"
 	"github.com/sirupsen/logrus"
 )
@@ -16,45 +23,182 @@ import (
 
 type DockerSidecar interface {
 	SidecarRunner
+
+	// Down stops and removes the container, keeping its image.
+	Down() error
+
+	// Purge stops and removes the container, its volumes and the images.
+	Purge() error
+
+	// ContainerRuntime returns the engine the container runs under.
+	ContainerRuntime() (*runner.ContainerRuntime, error)
+
+	// Definition returns a definition of the container in the given format, to run it
+	// the same way without the installer.
+	Definition(format runner.DefinitionFormat) (string, error)
 }
 
-// dockerSidecar is a basic service for interacting with the docker container.
+const (
+	// dockerContainerName is the name of the sentry container.
+	dockerContainerName = "contributoor"
+
+	// dockerConfigLabel marks the containers we manage, with the directory of the config
+	// they run as its value.
+	dockerConfigLabel = "io.ethpandaops.contributoor.config"
+
+	// dockerSpecLabel is a hash of the spec the container was created from.
+	dockerSpecLabel = "io.ethpandaops.contributoor.spec"
+
+	// composeServiceLabel is set by docker compose, which ran the sentry service before
+	// we managed the container ourselves.
+	composeServiceLabel = "com.docker.compose.service"
+
+	// composeOverrideFile customised the sentry service under docker compose. It's been
+	// replaced by the container settings in the installer state.
+	composeOverrideFile = "docker-compose.override.yml"
+
+	// dockerRestartPolicy restarts sentry if it exits, but not once it's been stopped,
+	// even if the docker daemon restarts.
+	dockerRestartPolicy = "unless-stopped"
+)
+
+var (
+	// dockerTimeout bounds each request to the Docker API, other than pulls and logs,
+	// which take as long as they take.
+	dockerTimeout = 2 * time.Minute
+
+	// sentryCommand is the command run in the container.
+	sentryCommand = []string{"sentry", "--config", "/config/config.yaml"}
+)
+
+// dockerSidecar is a service for running contributoor in a container, managed through the
+// Docker Engine API. It works with podman's docker compatible API too.
 type dockerSidecar struct {
 	logger       *logrus.Logger
-	composePath  string
 	configPath   string
 	sidecarCfg   ConfigManager
 	installerCfg *installer.Config
+	client       *dockerapi.Client
+
+	// chosenRuntime is the runtime set in the installer state, or empty to use whichever
+	// is found.
+	chosenRuntime installer.ContainerRuntime
+
+	// runtime is the runtime behind the API, detected on first use.
+	runtime *runner.ContainerRuntime
 }
 
-// NewDockerSidecar creates a new DockerSidecar.
+// NewDockerSidecar creates a new DockerSidecar, talking to the API at DOCKER_HOST, the
+// current docker context, or the socket of the container runtime set in the installer
+// state. Without one, docker's socket is used, or podman's if there's only podman.
 func NewDockerSidecar(logger *logrus.Logger, sidecarCfg ConfigManager, installerCfg *installer.Config) (DockerSidecar, error) {
-	composePath, err := findComposeFile()
+	state, err := installer.LoadState(filepath.Dir(sidecarCfg.GetConfigPath()))
 	if err != nil {
-		return nil, fmt.Errorf("failed to find docker-compose.yml: %w", err)
+		return nil, err
 	}
 
-	if err := validateComposePath(composePath); err != nil {
-		return nil, fmt.Errorf("invalid docker-compose file: %w", err)
+	endpoint, err := containerEndpoint(state.ContainerRuntime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the docker API: %w", err)
+	}
+
+	client, err := dockerapi.NewEndpointClient(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create docker client: %w", err)
 	}
 
 	return &dockerSidecar{
-		logger:       logger,
-		composePath:  filepath.Clean(composePath),
-		configPath:   sidecarCfg.GetConfigPath(),
-		sidecarCfg:   sidecarCfg,
-		installerCfg: installerCfg,
+		logger:        logger,
+		configPath:    sidecarCfg.GetConfigPath(),
+		sidecarCfg:    sidecarCfg,
+		installerCfg:  installerCfg,
+		client:        client,
+		chosenRuntime: state.ContainerRuntime,
 	}, nil
 }
 
-// Start starts the docker container using docker-compose.
+// Start starts the container, pulling the image first as the pull policy says. The
+// container is created if it doesn't exist, or recreated if it runs another image.
 func (s *dockerSidecar) Start() error {
-	//nolint:gosec // validateComposePath() and filepath.Clean() in-use.
-	cmd := exec.Command("docker", "compose", "-f", s.composePath, "up", "-d", "--pull", "always")
-	cmd.Env = s.getComposeEnv()
+	ctx, cancel := context.WithTimeout(context.Background(), dockerTimeout+s.installerCfg.StopGracePeriod)
+	defer cancel()
+
+	runtime, err := s.containerRuntime(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start containers: %w", err)
+	}
+
+	state, err := installer.LoadState(filepath.Dir(s.configPath))
+	if err != nil {
+		return fmt.Errorf("failed to start containers: %w", err)
+	}
+
+	version := s.sidecarCfg.Get().Version
+	ref := s.pinnedImage(runtime, state, version)
+
+	image, err := s.ensureImage(ctx, state, ref)
+	if err != nil {
+		return fmt.Errorf("failed to start containers: %w", err)
+	}
+
+	// A version that's never been pinned, eg: on first start, is pinned to whatever its
+	// tag resolved to.
+	if state.ImageDigest(version) == "" {
+		if ref, err = s.pinImage(ctx, state, runtime, version, image); err != nil {
+			return fmt.Errorf("failed to start containers: %w", err)
+		}
+	}
+
+	if err := s.removeComposeContainers(ctx); err != nil {
+		return fmt.Errorf("failed to start containers: %w", err)
+	}
+
+	if path := composeOverride(filepath.Dir(s.configPath)); path != "" {
+		fmt.Printf(
+			"%s%s is no longer applied. Move its settings to Container Settings in 'contributoor config', then remove it%s\n",
+			tui.TerminalColorYellow,
+			path,
+			tui.TerminalColorReset,
+		)
+	}
+
+	container, err := s.container(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start containers: %w", err)
+	}
+
+	spec, err := s.containerSpec(runtime, ref, state.Container)
+	if err != nil {
+		return fmt.Errorf("failed to start containers: %w", err)
+	}
+
+	hash, err := specHash(spec)
+	if err != nil {
+		return fmt.Errorf("failed to start containers: %w", err)
+	}
+
+	spec.Labels[dockerSpecLabel] = hash
+
+	// The version may have been repinned, eg: by an update of latest, or the spec may
+	// have changed since the container was created, including containers from before
+	// it was labelled.
+	if container != nil && (container.Image != image.ID || container.Config.Labels[dockerSpecLabel] != hash) {
+		if err := s.removeContainer(ctx, container.ID, false); err != nil {
+			return fmt.Errorf("failed to replace container: %w", err)
+		}
 
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to start containers: %w\nOutput: %s", err, string(output))
+		container = nil
+	}
+
+	id := ""
+	if container != nil {
+		id = container.ID
+	} else if id, err = s.client.ContainerCreate(ctx, dockerContainerName, spec); err != nil {
+		return fmt.Errorf("failed to start containers: %w", err)
+	}
+
+	if err := s.client.ContainerStart(ctx, id); err != nil {
+		return fmt.Errorf("failed to start containers: %w", err)
 	}
 
 	fmt.Printf("%sContributoor started successfully%s\n", tui.TerminalColorGreen, tui.TerminalColorReset)
@@ -62,19 +206,25 @@ func (s *dockerSidecar) Start() error {
 	return nil
 }
 
-// Stop stops and removes the docker container using docker-compose.
+// Stop stops the container, giving sentry the grace period to exit first. The container
+// is kept, so it's started again as it was.
 func (s *dockerSidecar) Stop() error {
-	// Stop and remove containers, volumes, and networks
-	//nolint:gosec // validateComposePath() and filepath.Clean() in-use.
-	cmd := exec.Command("docker", "compose", "-f", s.composePath, "down",
-		"--remove-orphans",
-		"-v",
-		"--rmi", "local",
-		"--timeout", "30")
-	cmd.Env = s.getComposeEnv()
+	ctx, cancel := context.WithTimeout(context.Background(), dockerTimeout+s.installerCfg.StopGracePeriod)
+	defer cancel()
 
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to stop containers: %w\nOutput: %s", err, string(output))
+	if err := s.removeComposeContainers(ctx); err != nil {
+		return fmt.Errorf("failed to stop containers: %w", err)
+	}
+
+	container, err := s.container(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to stop containers: %w", err)
+	}
+
+	if container != nil {
+		if err := s.client.ContainerStop(ctx, container.ID, s.installerCfg.StopGracePeriod); err != nil {
+			return fmt.Errorf("failed to stop containers: %w", err)
+		}
 	}
 
 	fmt.Printf("%sContributoor stopped successfully%s\n", tui.TerminalColorGreen, tui.TerminalColorReset)
@@ -82,20 +232,79 @@ func (s *dockerSidecar) Stop() error {
 	return nil
 }
 
-// IsRunning checks if the docker container is running.
+// Down stops and removes the container, keeping its image and volumes.
+func (s *dockerSidecar) Down() error {
+	ctx, cancel := context.WithTimeout(context.Background(), dockerTimeout+s.installerCfg.StopGracePeriod)
+	defer cancel()
+
+	if err := s.down(ctx, false); err != nil {
+		return fmt.Errorf("failed to remove containers: %w", err)
+	}
+
+	fmt.Printf("%sContributoor container removed%s\n", tui.TerminalColorGreen, tui.TerminalColorReset)
+
+	return nil
+}
+
+// Purge stops and removes the container along with its volumes, and then every image
+// of the repository, whatever its version.
+func (s *dockerSidecar) Purge() error {
+	ctx, cancel := context.WithTimeout(context.Background(), dockerTimeout+s.installerCfg.StopGracePeriod)
+	defer cancel()
+
+	if err := s.down(ctx, true); err != nil {
+		return fmt.Errorf("failed to remove containers: %w", err)
+	}
+
+	runtime, err := s.containerRuntime(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to remove images: %w", err)
+	}
+
+	images, err := s.client.ImageList(ctx, s.repository(runtime))
+	if err != nil {
+		return fmt.Errorf("failed to remove images: %w", err)
+	}
+
+	for _, image := range images {
+		if err := s.client.ImageRemove(ctx, image.ID); err != nil && !dockerapi.IsNotFound(err) {
+			return fmt.Errorf("failed to remove images: %w", err)
+		}
+	}
+
+	fmt.Printf(
+		"%sContributoor purged, removed its container, volumes and %d images%s\n",
+		tui.TerminalColorGreen,
+		len(images),
+		tui.TerminalColorReset,
+	)
+
+	return nil
+}
+
+// IsRunning checks if the container is running. One that's restarting isn't, so that a
+// crash loop is noticed.
 func (s *dockerSidecar) IsRunning() (bool, error) {
-	//nolint:gosec // validateComposePath() and filepath.Clean() in-use.
-	cmd := exec.Command("docker", "compose", "-f", s.composePath, "ps", "--format", "{{.State}}")
-	cmd.Env = s.getComposeEnv()
+	ctx, cancel := context.WithTimeout(context.Background(), dockerTimeout)
+	defer cancel()
+
+	container, err := s.container(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to check container status: %w", err)
+	}
+
+	if container != nil {
+		return container.State.Status == "running", nil
+	}
 
-	output, err := cmd.Output()
+	// It may still be running under docker compose, until it's next started or stopped.
+	legacy, err := s.composeContainers(ctx)
 	if err != nil {
 		return false, fmt.Errorf("failed to check container status: %w", err)
 	}
 
-	states := strings.Split(strings.TrimSpace(string(output)), "\n")
-	for _, state := range states {
-		if strings.Contains(strings.ToLower(state), "running") {
+	for _, c := range legacy {
+		if c.State == "running" {
 			return true, nil
 		}
 	}
@@ -103,92 +312,396 @@ func (s *dockerSidecar) IsRunning() (bool, error) {
 	return false, nil
 }
 
-// Update pulls the latest image and restarts the container.
+// Details returns the container's ID, health and restarts, the digest of its image, the
+// digest the version is pinned to and the runtime it runs under. The container counts
+// as installed if it exists, or the image for the configured version has been pulled.
+func (s *dockerSidecar) Details() (*runner.Details, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dockerTimeout)
+	defer cancel()
+
+	runtime, err := s.containerRuntime(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := installer.LoadState(filepath.Dir(s.configPath))
+	if err != nil {
+		return nil, err
+	}
+
+	version := s.sidecarCfg.Get().Version
+
+	details := &runner.Details{
+		ContainerRuntime: runtime.String(),
+		PinnedDigest:     state.ImageDigest(version),
+	}
+
+	// The image the container runs, or the one it'd be created from.
+	imageRef := s.pinnedImage(runtime, state, version)
+
+	container, err := s.container(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	if container != nil {
+		details.Installed = true
+		details.ContainerID = container.ID
+		details.ContainerHealth = container.Health()
+		details.Restarts = container.RestartCount
+		imageRef = container.Image
+
+		if container.State.Status == "exited" || container.State.Status == "dead" {
+			exitCode := container.State.ExitCode
+			details.LastExitCode = &exitCode
+		}
+	}
+
+	image, err := s.client.ImageInspect(ctx, imageRef)
+
+	switch {
+	case err == nil:
+		details.Installed = true
+		details.ImageDigest = image.Digest(s.installerCfg.DockerImage)
+	case !dockerapi.IsNotFound(err):
+		return nil, err
+	}
+
+	return details, nil
+}
+
+// Logs writes the container's logs to w.
+func (s *dockerSidecar) Logs(ctx context.Context, opts runner.LogOptions, w io.Writer) error {
+	container, err := s.container(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get container logs: %w", err)
+	}
+
+	if container == nil {
+		return errors.New("failed to get container logs: the container doesn't exist, start it with 'contributoor start'")
+	}
+
+	return s.client.ContainerLogs(ctx, container.ID, dockerapi.LogsOptions{
+		Follow: opts.Follow,
+		Tail:   opts.Tail,
+		Since:  opts.Since,
+	}, w)
+}
+
+// Update pulls the image for the configured version, and pins the version to the digest
+// its tag resolved to, verifying the image's signature first if there's a cosign public
+// key. The container picks it up on next start.
 func (s *dockerSidecar) Update() error {
-	cfg := s.sidecarCfg.Get()
+	ctx := context.Background()
+
+	runtime, err := s.containerRuntime(ctx)
+	if err != nil {
+		return err
+	}
 
-	image := fmt.Sprintf("%s:%s", s.installerCfg.DockerImage, cfg.Version)
+	state, err := installer.LoadState(filepath.Dir(s.configPath))
+	if err != nil {
+		return err
+	}
+
+	version := s.sidecarCfg.Get().Version
+	tag := s.image(runtime, version)
+
+	if err := s.client.ImagePull(ctx, tag, printPullProgress(os.Stdout)); err != nil {
+		return fmt.Errorf("failed to pull image %s: %w", tag, err)
+	}
+
+	image, err := s.client.ImageInspect(ctx, tag)
+	if err != nil {
+		return err
+	}
 
-	cmd := exec.Command("docker", "pull", image)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to pull image %s: %w\nOutput: %s", image, err, string(output))
+	ref, err := s.pinImage(ctx, state, runtime, version, image)
+	if err != nil {
+		return err
 	}
 
 	fmt.Printf(
-		"%sImage %s updated successfully%s\n",
+		"%sImage %s updated successfully, pinned to %s%s\n",
 		tui.TerminalColorGreen,
-		image,
+		tag,
+		ref,
 		tui.TerminalColorReset,
 	)
 
 	return nil
 }
 
-func (s *dockerSidecar) getComposeEnv() []string {
-	cfg := s.sidecarCfg.Get()
+// Rollback ensures the image for a previous version is available locally, by the digest
+// it was pinned to if it was. The container picks it up on next start, as the image
+// follows the config version.
+func (s *dockerSidecar) Rollback(version string) error {
+	runtime, err := s.containerRuntime(context.Background())
+	if err != nil {
+		return err
+	}
 
-	return append(os.Environ(),
-		fmt.Sprintf("CONTRIBUTOOR_CONFIG_PATH=%s", filepath.Dir(s.configPath)),
-		fmt.Sprintf("CONTRIBUTOOR_VERSION=%s", cfg.Version),
+	state, err := installer.LoadState(filepath.Dir(s.configPath))
+	if err != nil {
+		return err
+	}
+
+	image := s.pinnedImage(runtime, state, version)
+
+	// Prefer the image we already have, only pulling if it's since been removed.
+	if _, err := s.client.ImageInspect(context.Background(), image); err != nil {
+		if !dockerapi.IsNotFound(err) {
+			return err
+		}
+
+		if err := s.client.ImagePull(context.Background(), image, printPullProgress(os.Stdout)); err != nil {
+			return fmt.Errorf("failed to pull image %s: %w", image, err)
+		}
+	}
+
+	fmt.Printf(
+		"%sImage rolled back to %s%s\n",
+		tui.TerminalColorGreen,
+		image,
+		tui.TerminalColorReset,
 	)
+
+	return nil
+}
+
+// ensureImage pulls the image as the pull policy in the installer state says, and
+// returns it.
+func (s *dockerSidecar) ensureImage(ctx context.Context, state *installer.State, ref string) (*dockerapi.Image, error) {
+	policy := state.GetPullPolicy()
+
+	if policy != installer.PullPolicyAlways {
+		image, err := s.client.ImageInspect(ctx, ref)
+
+		switch {
+		case err == nil:
+			return image, nil
+		case !dockerapi.IsNotFound(err):
+			return nil, err
+		case policy == installer.PullPolicyNever:
+			return nil, fmt.Errorf("image %s isn't available locally and the pull policy is %s, pull it with 'docker pull %s'", ref, policy, ref)
+		}
+	}
+
+	// Pulls take as long as they take, regardless of ctx's deadline.
+	if err := s.client.ImagePull(context.Background(), ref, printPullProgress(os.Stdout)); err != nil {
+		return nil, err
+	}
+
+	return s.client.ImageInspect(ctx, ref)
+}
+
+// ContainerRuntime returns the engine behind the API, eg: rootless podman.
+func (s *dockerSidecar) ContainerRuntime() (*runner.ContainerRuntime, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dockerTimeout)
+	defer cancel()
+
+	return s.containerRuntime(ctx)
+}
+
+// containerRuntime detects the engine behind the API the first time it's needed.
+func (s *dockerSidecar) containerRuntime(ctx context.Context) (*runner.ContainerRuntime, error) {
+	if s.runtime != nil {
+		return s.runtime, nil
+	}
+
+	runtime, err := detectContainerRuntime(ctx, s.client, s.chosenRuntime)
+	if err != nil {
+		return nil, err
+	}
+
+	s.runtime = runtime
+
+	return runtime, nil
+}
+
+// repository returns the image repository, qualified with its registry for podman.
+func (s *dockerSidecar) repository(runtime *runner.ContainerRuntime) string {
+	if runtime.Name == installer.ContainerRuntimePodman {
+		return qualifyImage(s.installerCfg.DockerImage)
+	}
+
+	return s.installerCfg.DockerImage
+}
+
+// image returns the image reference for a version, eg: ethpandaops/contributoor:1.0.0.
+func (s *dockerSidecar) image(runtime *runner.ContainerRuntime, version string) string {
+	return fmt.Sprintf("%s:%s", s.repository(runtime), version)
 }
 
-func findComposeFile() (string, error) {
-	// Get binary directory
-	ex, err := os.Executable()
+// containerSpec returns the container to create for the image, customised by the
+// settings in the installer state.
+func (s *dockerSidecar) containerSpec(runtime *runner.ContainerRuntime, image string, settings installer.ContainerSettings) (*dockerapi.ContainerSpec, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid container settings: %w", err)
+	}
+
+	memory, err := installer.ParseMemory(settings.Memory)
 	if err != nil {
-		return "", fmt.Errorf("could not get executable path: %w", err)
+		return nil, fmt.Errorf("invalid container settings: %w", err)
+	}
+
+	bind := fmt.Sprintf("%s:/config/config.yaml:ro", s.configPath)
+
+	// Podman hosts are usually RHEL-family, where SELinux would stop the container
+	// reading the config unless it's relabelled.
+	if runtime.Name == installer.ContainerRuntimePodman {
+		bind += ",z"
 	}
 
-	binDir := filepath.Dir(ex)
+	spec := &dockerapi.ContainerSpec{
+		Image:  image,
+		Cmd:    sentryCommand,
+		Env:    append([]string{"CONTRIBUTOOR_CONFIG=/config/config.yaml"}, settings.Env...),
+		Labels: map[string]string{dockerConfigLabel: filepath.Dir(s.configPath)},
+		HostConfig: dockerapi.HostConfig{
+			Binds:         append([]string{bind}, settings.Volumes...),
+			RestartPolicy: dockerapi.RestartPolicy{Name: dockerRestartPolicy},
+			NetworkMode:   settings.Network,
+			NanoCPUs:      int64(settings.CPUs * 1e9),
+			Memory:        memory,
+		},
+	}
 
-	// Check release mode (next to binary)
-	composePath := filepath.Join(binDir, "docker-compose.yml")
-	if _, e := os.Stat(composePath); e == nil {
-		return composePath, nil
+	if settings.LogDriver != "" {
+		spec.HostConfig.LogConfig = &dockerapi.LogConfig{
+			Type:   settings.LogDriver,
+			Config: settings.LogOptions,
+		}
 	}
 
-	// Check dev mode paths
-	cwd, err := os.Getwd()
+	return spec, nil
+}
+
+// specHash returns a hash of the spec, labelled on the container so that it's recreated
+// once the spec changes, eg: when the container settings are edited.
+func specHash(spec *dockerapi.ContainerSpec) (string, error) {
+	data, err := json.Marshal(spec)
 	if err != nil {
-		return "", fmt.Errorf("could not get working directory: %w", err)
+		return "", fmt.Errorf("failed to hash container spec: %w", err)
 	}
 
-	// Try current directory
-	if _, err := os.Stat(filepath.Join(cwd, "docker-compose.yml")); err == nil {
-		return filepath.Join(cwd, "docker-compose.yml"), nil
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
+
+// container returns our container, or nil if it doesn't exist.
+func (s *dockerSidecar) container(ctx context.Context) (*dockerapi.Container, error) {
+	containers, err := s.client.ContainerList(ctx, map[string]string{
+		dockerConfigLabel: filepath.Dir(s.configPath),
+	}, true)
+	if err != nil {
+		return nil, err
 	}
 
-	// Try repo root
-	if _, err := os.Stat(filepath.Join(cwd, "..", "..", "docker-compose.yml")); err == nil {
-		return filepath.Join(cwd, "..", "..", "docker-compose.yml"), nil
+	if len(containers) == 0 {
+		return nil, nil
 	}
 
-	return "", fmt.Errorf("docker-compose.yml not found")
+	return s.client.ContainerInspect(ctx, containers[0].ID)
 }
 
-func validateComposePath(path string) error {
-	// Check if path exists and is a regular file
-	fi, err := os.Stat(path)
+// composeContainers returns the sentry containers docker compose created, before we
+// managed the container ourselves.
+func (s *dockerSidecar) composeContainers(ctx context.Context) ([]dockerapi.ContainerSummary, error) {
+	containers, err := s.client.ContainerList(ctx, map[string]string{composeServiceLabel: "sentry"}, true)
 	if err != nil {
-		return fmt.Errorf("invalid compose file path: %w", err)
+		return nil, err
 	}
 
-	if fi.IsDir() {
-		return fmt.Errorf("compose path is a directory, not a file")
+	var ours []dockerapi.ContainerSummary
+
+	for _, c := range containers {
+		if strings.HasPrefix(strings.TrimPrefix(c.Image, "docker.io/"), s.installerCfg.DockerImage+":") {
+			ours = append(ours, c)
+		}
+	}
+
+	return ours, nil
+}
+
+// composeOverride returns the path of the docker compose override file left in the
+// config directory, or empty if there isn't one.
+func composeOverride(configDir string) string {
+	path := filepath.Join(configDir, composeOverrideFile)
+	if _, err := os.Stat(path); err != nil {
+		return ""
 	}
 
-	// Ensure absolute path
-	absPath, err := filepath.Abs(path)
+	return path
+}
+
+// removeComposeContainers removes the containers docker compose created, so they don't
+// run alongside ours.
+func (s *dockerSidecar) removeComposeContainers(ctx context.Context) error {
+	containers, err := s.composeContainers(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get absolute path: %w", err)
+		return err
 	}
 
-	// Verify file extension
-	if !strings.HasSuffix(strings.ToLower(absPath), ".yml") &&
-		!strings.HasSuffix(strings.ToLower(absPath), ".yaml") {
-		return fmt.Errorf("compose file must have .yml or .yaml extension")
+	for _, c := range containers {
+		s.logger.Infof("Removing container %s, created by docker compose", c.ID)
+
+		if err := s.removeContainer(ctx, c.ID, true); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
+
+// down removes our container, and any docker compose created, along with their
+// anonymous volumes with volumes.
+func (s *dockerSidecar) down(ctx context.Context, volumes bool) error {
+	if err := s.removeComposeContainers(ctx); err != nil {
+		return err
+	}
+
+	container, err := s.container(ctx)
+	if err != nil || container == nil {
+		return err
+	}
+
+	return s.removeContainer(ctx, container.ID, volumes)
+}
+
+// removeContainer stops a container, giving sentry the grace period to exit, and then
+// removes it, along with its anonymous volumes with volumes.
+func (s *dockerSidecar) removeContainer(ctx context.Context, id string, volumes bool) error {
+	if err := s.client.ContainerStop(ctx, id, s.installerCfg.StopGracePeriod); err != nil {
+		return err
+	}
+
+	return s.client.ContainerRemove(ctx, id, volumes)
+}
+
+// printPullProgress returns a func that prints the progress of a pull to w, a line each
+// time a layer moves on, as docker pull does without a terminal.
+func printPullProgress(w io.Writer) func(dockerapi.PullProgress) {
+	layers := make(map[string]string)
+
+	return func(p dockerapi.PullProgress) {
+		if p.ID == "" {
+			fmt.Fprintln(w, p.Status)
+
+			return
+		}
+
+		if layers[p.ID] == p.Status {
+			return
+		}
+
+		layers[p.ID] = p.Status
+
+		if p.Total > 0 {
+			fmt.Fprintf(w, "%s: %s %.1fMB\n", p.ID, p.Status, float64(p.Total)/1024/1024)
+
+			return
+		}
+
+		fmt.Fprintf(w, "%s: %s\n", p.ID, p.Status)
+	}
+}
@@ -0,0 +1,527 @@
+package sidecar
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ethpandaops/contributoor-installer/internal/dockerapi"
+	"github.com/ethpandaops/contributoor-installer/internal/dockerapi/dockerapitest"
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/mock"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// newTestDockerSidecar returns a docker sidecar talking to a fake Engine API, running
+// the version in cfg.
+func newTestDockerSidecar(t *testing.T, cfg *config.Config) (*dockerSidecar, *dockerapitest.Server) {
+	t.Helper()
+
+	var (
+		ctrl       = gomock.NewController(t)
+		server     = dockerapitest.NewServer(t)
+		configPath = filepath.Join(t.TempDir(), "config.yaml")
+	)
+
+	t.Setenv("DOCKER_HOST", server.Host())
+
+	mockConfig := mock.NewMockConfigManager(ctrl)
+	mockConfig.EXPECT().Get().Return(cfg).AnyTimes()
+	mockConfig.EXPECT().GetConfigPath().Return(configPath).AnyTimes()
+
+	ds, err := NewDockerSidecar(logrus.New(), mockConfig, installer.NewConfig())
+	require.NoError(t, err)
+
+	return ds.(*dockerSidecar), server //nolint:errcheck // It's always a *dockerSidecar.
+}
+
+func TestDockerSidecar_Start(t *testing.T) {
+	cfg := &config.Config{Version: "1.0.0"}
+	ds, server := newTestDockerSidecar(t, cfg)
+
+	server.AddImage("ethpandaops/contributoor:1.0.0", "sha256:1000")
+	server.AddImage("ethpandaops/contributoor:1.1.0", "sha256:1100")
+
+	require.NoError(t, ds.Start())
+
+	container := server.Container(dockerContainerName)
+	require.NotNil(t, container)
+	assert.Equal(t, "running", container.State.Status)
+
+	// The image is run by the digest its tag resolved to.
+	spec := server.Specs[container.ID]
+	assert.Equal(t, "ethpandaops/contributoor@sha256:1000", spec.Image)
+	assert.Equal(t, sentryCommand, spec.Cmd)
+	assert.Equal(t, filepath.Dir(ds.configPath), spec.Labels[dockerConfigLabel])
+	assert.Equal(t, []string{ds.configPath + ":/config/config.yaml:ro"}, spec.HostConfig.Binds)
+	assert.Equal(t, "unless-stopped", spec.HostConfig.RestartPolicy.Name)
+
+	running, err := ds.IsRunning()
+	require.NoError(t, err)
+	assert.True(t, running)
+
+	// Starting again reuses the container.
+	require.NoError(t, ds.Start())
+	assert.Equal(t, container.ID, server.Container(dockerContainerName).ID)
+
+	// A new version replaces it.
+	cfg.Version = "1.1.0"
+
+	require.NoError(t, ds.Start())
+
+	replaced := server.Container(dockerContainerName)
+	require.NotNil(t, replaced)
+	assert.NotEqual(t, container.ID, replaced.ID)
+	assert.Equal(t, "ethpandaops/contributoor@sha256:1100", replaced.Config.Image)
+	assert.Len(t, server.Containers, 1)
+
+	// Pull errors are surfaced.
+	cfg.Version = "9.9.9"
+
+	assert.ErrorContains(t, ds.Start(), "manifest unknown")
+}
+
+func TestDockerSidecar_Stop(t *testing.T) {
+	ds, server := newTestDockerSidecar(t, &config.Config{Version: "1.0.0"})
+	server.AddImage("ethpandaops/contributoor:1.0.0", "sha256:1000")
+
+	// Stopping what isn't there is fine.
+	require.NoError(t, ds.Stop())
+
+	require.NoError(t, ds.Start())
+	require.NoError(t, ds.Stop())
+
+	// The container's kept, and started again as it was, without pulling.
+	container := server.Container(dockerContainerName)
+	require.NotNil(t, container)
+	assert.Equal(t, "exited", container.State.Status)
+
+	running, err := ds.IsRunning()
+	require.NoError(t, err)
+	assert.False(t, running)
+
+	server.Lock()
+	server.Requests = nil
+	server.Unlock()
+
+	require.NoError(t, ds.Start())
+	assert.Equal(t, container.ID, server.Container(dockerContainerName).ID)
+	assert.NotContains(t, server.Requests, "POST /images/create")
+	assert.NotContains(t, server.Requests, "POST /containers/create")
+}
+
+func TestDockerSidecar_Down(t *testing.T) {
+	ds, server := newTestDockerSidecar(t, &config.Config{Version: "1.0.0"})
+	server.AddImage("ethpandaops/contributoor:1.0.0", "sha256:1000")
+
+	require.NoError(t, ds.Down())
+
+	require.NoError(t, ds.Start())
+
+	id := server.Container(dockerContainerName).ID
+
+	require.NoError(t, ds.Down())
+
+	assert.Nil(t, server.Container(dockerContainerName))
+	assert.Contains(t, server.Requests, "DELETE /containers/"+id)
+	assert.Len(t, server.Images, 1, "the image is kept")
+}
+
+func TestDockerSidecar_Purge(t *testing.T) {
+	cfg := &config.Config{Version: "1.0.0"}
+	ds, server := newTestDockerSidecar(t, cfg)
+
+	server.AddImage("ethpandaops/contributoor:1.0.0", "sha256:1000")
+	server.AddImage("ethpandaops/contributoor:1.1.0", "sha256:1100")
+	server.AddImage("busybox:latest", "sha256:9ae9")
+
+	require.NoError(t, ds.Update())
+	require.NoError(t, ds.Start())
+
+	cfg.Version = "1.1.0"
+	require.NoError(t, ds.Update())
+
+	require.NoError(t, ds.client.ImagePull(context.Background(), "busybox:latest", nil))
+
+	require.NoError(t, ds.Purge())
+
+	assert.Nil(t, server.Container(dockerContainerName))
+	assert.Len(t, server.Images, 1)
+	assert.Contains(t, server.Images, "busybox:latest", "other images are kept")
+}
+
+func TestDockerSidecar_PullPolicy(t *testing.T) {
+	tests := []struct {
+		name          string
+		policy        installer.PullPolicy
+		pulled        bool
+		expectPull    bool
+		expectedError string
+	}{
+		{name: "always pulls", policy: installer.PullPolicyAlways, pulled: true, expectPull: true},
+		{name: "missing pulls when missing", policy: installer.PullPolicyMissing, expectPull: true},
+		{name: "missing uses what's there", policy: installer.PullPolicyMissing, pulled: true},
+		{name: "default is missing", pulled: true},
+		{name: "never uses what's there", policy: installer.PullPolicyNever, pulled: true},
+		{
+			name:          "never fails when missing",
+			policy:        installer.PullPolicyNever,
+			expectedError: "image ethpandaops/contributoor:1.0.0 isn't available locally and the pull policy is never",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds, server := newTestDockerSidecar(t, &config.Config{Version: "1.0.0"})
+			server.AddImage("ethpandaops/contributoor:1.0.0", "sha256:1000")
+
+			state, err := installer.LoadState(filepath.Dir(ds.configPath))
+			require.NoError(t, err)
+
+			state.PullPolicy = tt.policy
+			require.NoError(t, state.Save())
+
+			if tt.pulled {
+				require.NoError(t, ds.Update())
+			}
+
+			server.Lock()
+			server.Requests = nil
+			server.Unlock()
+
+			err = ds.Start()
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				assert.Nil(t, server.Container(dockerContainerName))
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectPull, slices.Contains(server.Requests, "POST /images/create"))
+		})
+	}
+}
+
+func TestDockerSidecar_StartMovedTag(t *testing.T) {
+	ds, server := newTestDockerSidecar(t, &config.Config{Version: "latest"})
+	server.AddImage("ethpandaops/contributoor:latest", "sha256:1000")
+
+	require.NoError(t, ds.Start())
+
+	container := server.Container(dockerContainerName)
+
+	// A new image is pulled for the same tag, eg: by an update.
+	server.AddImage("ethpandaops/contributoor:latest", "sha256:2000")
+	require.NoError(t, ds.Update())
+
+	require.NoError(t, ds.Start())
+
+	replaced := server.Container(dockerContainerName)
+	assert.NotEqual(t, container.ID, replaced.ID)
+	assert.NotEqual(t, container.Image, replaced.Image)
+}
+
+func TestDockerSidecar_PinnedDigest(t *testing.T) {
+	ds, server := newTestDockerSidecar(t, &config.Config{Version: "latest"})
+	server.AddImage("ethpandaops/contributoor:latest", "sha256:1000")
+
+	// The first start pins the version to the digest its tag resolved to.
+	require.NoError(t, ds.Start())
+
+	state, err := installer.LoadState(filepath.Dir(ds.configPath))
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:1000", state.ImageDigest("latest"))
+
+	container := server.Container(dockerContainerName)
+
+	// The tag moving doesn't change what's run, even when it's pulled on start.
+	server.AddImage("ethpandaops/contributoor:latest", "sha256:2000")
+
+	state.PullPolicy = installer.PullPolicyAlways
+	require.NoError(t, state.Save())
+
+	require.NoError(t, ds.Start())
+	assert.Equal(t, container.ID, server.Container(dockerContainerName).ID)
+
+	details, err := ds.Details()
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:1000", details.ImageDigest)
+	assert.Equal(t, "sha256:1000", details.PinnedDigest)
+
+	// Until it's updated, which repins it.
+	require.NoError(t, ds.Update())
+	require.NoError(t, ds.Start())
+
+	replaced := server.Container(dockerContainerName)
+	assert.NotEqual(t, container.ID, replaced.ID)
+	assert.Equal(t, "ethpandaops/contributoor@sha256:2000", replaced.Config.Image)
+
+	details, err = ds.Details()
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:2000", details.ImageDigest)
+	assert.Equal(t, "sha256:2000", details.PinnedDigest)
+}
+
+func TestDockerSidecar_VerifyImageSignature(t *testing.T) {
+	original := verifyImageSignature
+
+	t.Cleanup(func() {
+		verifyImageSignature = original
+	})
+
+	var verified []string
+
+	verifyImageSignature = func(_ context.Context, publicKey, ref string) error {
+		if publicKey != "/etc/contributoor/cosign.pub" {
+			return fmt.Errorf("unexpected key %s", publicKey)
+		}
+
+		verified = append(verified, ref)
+
+		if strings.HasSuffix(ref, "sha256:6666") {
+			return errors.New("no matching signatures")
+		}
+
+		return nil
+	}
+
+	ds, server := newTestDockerSidecar(t, &config.Config{Version: "1.0.0"})
+	server.AddImage("ethpandaops/contributoor:1.0.0", "sha256:1000")
+
+	state, err := installer.LoadState(filepath.Dir(ds.configPath))
+	require.NoError(t, err)
+
+	state.CosignPublicKey = "/etc/contributoor/cosign.pub"
+	require.NoError(t, state.Save())
+
+	// A signed image is verified by digest, then pinned.
+	require.NoError(t, ds.Update())
+	assert.Equal(t, []string{"ethpandaops/contributoor@sha256:1000"}, verified)
+
+	// An unsigned one fails the update, and isn't pinned.
+	server.AddImage("ethpandaops/contributoor:1.0.0", "sha256:6666")
+	assert.ErrorContains(t, ds.Update(), "failed to verify the signature of image ethpandaops/contributoor@sha256:6666: no matching signatures")
+
+	state, err = installer.LoadState(filepath.Dir(ds.configPath))
+	require.NoError(t, err)
+	assert.Equal(t, "sha256:1000", state.ImageDigest("1.0.0"))
+}
+
+func TestDockerSidecar_ContainerSettings(t *testing.T) {
+	ds, server := newTestDockerSidecar(t, &config.Config{Version: "1.0.0"})
+	server.AddImage("ethpandaops/contributoor:1.0.0", "sha256:1000")
+
+	state, err := installer.LoadState(filepath.Dir(ds.configPath))
+	require.NoError(t, err)
+
+	state.Container = installer.ContainerSettings{
+		Network:    "eth-docker_default",
+		CPUs:       1.5,
+		Memory:     "512m",
+		Volumes:    []string{"/data/jwt:/jwt:ro"},
+		Env:        []string{"TZ=UTC"},
+		LogDriver:  "json-file",
+		LogOptions: map[string]string{"max-size": "10m"},
+	}
+	require.NoError(t, state.Save())
+
+	require.NoError(t, ds.Start())
+
+	container := server.Container(dockerContainerName)
+	require.NotNil(t, container)
+
+	spec := server.Specs[container.ID]
+	assert.Equal(t, []string{"CONTRIBUTOOR_CONFIG=/config/config.yaml", "TZ=UTC"}, spec.Env)
+	assert.Equal(t, []string{ds.configPath + ":/config/config.yaml:ro", "/data/jwt:/jwt:ro"}, spec.HostConfig.Binds)
+	assert.Equal(t, "eth-docker_default", spec.HostConfig.NetworkMode)
+	assert.Equal(t, int64(1_500_000_000), spec.HostConfig.NanoCPUs)
+	assert.Equal(t, int64(512<<20), spec.HostConfig.Memory)
+	assert.Equal(t, &dockerapi.LogConfig{Type: "json-file", Config: map[string]string{"max-size": "10m"}}, spec.HostConfig.LogConfig)
+	assert.NotEmpty(t, spec.Labels[dockerSpecLabel])
+
+	// Unchanged settings reuse the container.
+	require.NoError(t, ds.Start())
+	assert.Equal(t, container.ID, server.Container(dockerContainerName).ID)
+
+	// Changed settings replace it.
+	state.Container.Memory = "1g"
+	require.NoError(t, state.Save())
+	require.NoError(t, ds.Start())
+
+	replaced := server.Container(dockerContainerName)
+	require.NotNil(t, replaced)
+	assert.NotEqual(t, container.ID, replaced.ID)
+	assert.Equal(t, int64(1<<30), server.Specs[replaced.ID].HostConfig.Memory)
+
+	// Invalid settings are refused, leaving the container as it was.
+	state.Container.Env = []string{"TZ"}
+	require.NoError(t, state.Save())
+	assert.ErrorContains(t, ds.Start(), `invalid container settings: invalid environment variable "TZ"`)
+	assert.Equal(t, replaced.ID, server.Container(dockerContainerName).ID)
+}
+
+func TestComposeOverride(t *testing.T) {
+	dir := t.TempDir()
+	assert.Empty(t, composeOverride(dir))
+
+	path := filepath.Join(dir, "docker-compose.override.yml")
+	require.NoError(t, os.WriteFile(path, []byte("services: {}\n"), 0600))
+	assert.Equal(t, path, composeOverride(dir))
+}
+
+func TestDockerSidecar_ComposeContainers(t *testing.T) {
+	ds, server := newTestDockerSidecar(t, &config.Config{Version: "1.0.0"})
+	server.AddImage("ethpandaops/contributoor:1.0.0", "sha256:1000")
+
+	composeContainer := func(id, image string) *dockerapi.Container {
+		c := &dockerapi.Container{ID: id, Name: "/contributoor-sentry-1"}
+		c.State.Status, c.State.Running = "running", true
+		c.Config.Image = image
+		c.Config.Labels = map[string]string{composeServiceLabel: "sentry"}
+
+		return c
+	}
+
+	// One compose created for us, and another project's sentry service.
+	server.AddContainer(composeContainer("legacy", "ethpandaops/contributoor:0.9.0"))
+	server.AddContainer(composeContainer("unrelated", "example/sentry:latest"))
+
+	// It's still running under docker compose, until started.
+	running, err := ds.IsRunning()
+	require.NoError(t, err)
+	assert.True(t, running)
+
+	require.NoError(t, ds.Start())
+
+	assert.Nil(t, server.Container("legacy"))
+	assert.NotNil(t, server.Container("unrelated"))
+	assert.NotNil(t, server.Container(dockerContainerName))
+}
+
+func TestDockerSidecar_Details(t *testing.T) {
+	ds, server := newTestDockerSidecar(t, &config.Config{Version: "1.0.0"})
+	server.AddImage("ethpandaops/contributoor:1.0.0", "sha256:1000")
+
+	// Nothing's been pulled.
+	details, err := ds.Details()
+	require.NoError(t, err)
+	assert.Equal(t, &runner.Details{ContainerRuntime: "docker 27.3.1"}, details)
+
+	// Pulled, but not started.
+	require.NoError(t, ds.Update())
+
+	details, err = ds.Details()
+	require.NoError(t, err)
+	assert.True(t, details.Installed)
+	assert.Empty(t, details.ContainerID)
+	assert.Equal(t, "sha256:1000", details.ImageDigest)
+
+	require.NoError(t, ds.Start())
+
+	container := server.Container(dockerContainerName)
+
+	server.Lock()
+	container.RestartCount = 3
+	container.State.Status, container.State.Running, container.State.ExitCode = "exited", false, 2
+	container.State.Health = &struct {
+		Status string `json:"Status"`
+	}{Status: "unhealthy"}
+	server.Unlock()
+
+	details, err = ds.Details()
+	require.NoError(t, err)
+	assert.True(t, details.Installed)
+	assert.Equal(t, container.ID, details.ContainerID)
+	assert.Equal(t, "unhealthy", details.ContainerHealth)
+	assert.Equal(t, 3, details.Restarts)
+	require.NotNil(t, details.LastExitCode)
+	assert.Equal(t, 2, *details.LastExitCode)
+	assert.Equal(t, "sha256:1000", details.ImageDigest)
+}
+
+func TestDockerSidecar_Logs(t *testing.T) {
+	ds, server := newTestDockerSidecar(t, &config.Config{Version: "1.0.0"})
+	server.AddImage("ethpandaops/contributoor:1.0.0", "sha256:1000")
+
+	var out bytes.Buffer
+
+	assert.ErrorContains(t, ds.Logs(context.Background(), runner.LogOptions{}, &out), "the container doesn't exist")
+
+	require.NoError(t, ds.Start())
+
+	id := server.Container(dockerContainerName).ID
+
+	server.Lock()
+	server.Logs[id] = "level=info msg=started\n"
+	server.Unlock()
+
+	require.NoError(t, ds.Logs(context.Background(), runner.LogOptions{Tail: 100}, &out))
+	assert.Equal(t, "level=info msg=started\n", out.String())
+}
+
+func TestDockerSidecar_Rollback(t *testing.T) {
+	ds, server := newTestDockerSidecar(t, &config.Config{Version: "1.1.0"})
+	server.AddImage("ethpandaops/contributoor:1.0.0", "sha256:1000")
+
+	// The previous image is pulled if it's gone.
+	require.NoError(t, ds.Rollback("1.0.0"))
+	assert.Contains(t, server.Requests, "POST /images/create")
+
+	// And used as is if it's there.
+	server.Lock()
+	server.Requests = nil
+	server.Unlock()
+
+	require.NoError(t, ds.Rollback("1.0.0"))
+	assert.NotContains(t, server.Requests, "POST /images/create")
+
+	assert.ErrorContains(t, ds.Rollback("0.1.0"), "failed to pull image ethpandaops/contributoor:0.1.0")
+
+	// A pinned version is rolled back to by its digest.
+	state, err := installer.LoadState(filepath.Dir(ds.configPath))
+	require.NoError(t, err)
+
+	server.AddImage("ethpandaops/contributoor:0.9.0", "sha256:0900")
+	state.PinImage("0.9.0", "sha256:0900")
+	require.NoError(t, state.Save())
+
+	require.NoError(t, ds.Rollback("0.9.0"))
+
+	server.Lock()
+	_, pulled := server.Images["ethpandaops/contributoor@sha256:0900"]
+	server.Unlock()
+
+	assert.True(t, pulled)
+}
+
+func TestPrintPullProgress(t *testing.T) {
+	var (
+		out      bytes.Buffer
+		progress = printPullProgress(&out)
+	)
+
+	for _, p := range []dockerapi.PullProgress{
+		{Status: "Pulling from ethpandaops/contributoor"},
+		{ID: "4b2c", Status: "Pulling fs layer"},
+		{ID: "4b2c", Status: "Downloading", Current: 1024, Total: 3 * 1024 * 1024},
+		{ID: "4b2c", Status: "Downloading", Current: 2048, Total: 3 * 1024 * 1024},
+		{ID: "4b2c", Status: "Pull complete"},
+	} {
+		progress(p)
+	}
+
+	assert.Equal(t, "Pulling from ethpandaops/contributoor\n4b2c: Pulling fs layer\n4b2c: Downloading 3.0MB\n4b2c: Pull complete\n", out.String())
+}
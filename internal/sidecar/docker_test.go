@@ -1,16 +1,14 @@
-package sidecar_test
+package sidecar
 
 import (
 	"context"
 	"fmt"
-	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/docker/go-connections/nat"
 	"github.com/ethpandaops/contributoor-installer/internal/installer"
-	"github.com/ethpandaops/contributoor-installer/internal/sidecar"
 	"github.com/ethpandaops/contributoor-installer/internal/sidecar/mock"
 	"github.com/ethpandaops/contributoor/pkg/config/v1"
 	"github.com/sirupsen/logrus"
@@ -20,19 +18,6 @@ import (
 	"go.uber.org/mock/gomock"
 )
 
-const composeFile = `
-services:
-  test:
-    image: busybox
-    command: ["sh", "-c", "while true; do echo 'Container is running'; sleep 1; done"]
-    healthcheck:
-      test: ["CMD-SHELL", "ps aux | grep -v grep | grep 'sleep' || exit 1"]
-      interval: 1s
-      timeout: 1s
-      retries: 3
-      start_period: 1s
-`
-
 // TestDockerService_Integration tests the docker sidecar.
 // We use test-containers to boot an instance of docker-in-docker.
 // We can then use this to test our docker service in isolation.
@@ -59,7 +44,15 @@ func TestDockerService_Integration(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
+	// Run busybox in place of sentry, so there's no need for a beacon node.
 	mockInstallerConfig := installer.NewConfig()
+	mockInstallerConfig.DockerImage = "busybox"
+
+	command := sentryCommand
+	sentryCommand = []string{"sh", "-c", "while true; do echo 'Container is running'; sleep 1; done"}
+
+	defer func() { sentryCommand = command }()
+
 	mockSidecarConfig := mock.NewMockConfigManager(ctrl)
 	mockSidecarConfig.EXPECT().Get().Return(cfg).AnyTimes()
 	mockSidecarConfig.EXPECT().GetConfigPath().Return(filepath.Join(tmpDir, "config.yaml")).AnyTimes()
@@ -96,15 +89,12 @@ func TestDockerService_Integration(t *testing.T) {
 	containerPort, err := container.MappedPort(ctx, nat.Port(fmt.Sprintf("%d/tcp", port)))
 	require.NoError(t, err)
 
-	// Create docker service with mock config
-	ds, err := sidecar.NewDockerSidecar(logger, mockSidecarConfig, mockInstallerConfig)
-	require.NoError(t, err)
-
 	// Set docker host to test container.
 	t.Setenv("DOCKER_HOST", fmt.Sprintf("tcp://localhost:%s", containerPort.Port()))
 
-	// Write out dummy compose file.
-	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "docker-compose.yml"), []byte(composeFile), 0644))
+	// Create docker service with mock config
+	ds, err := NewDockerSidecar(logger, mockSidecarConfig, mockInstallerConfig)
+	require.NoError(t, err)
 
 	// Run our tests in a real container.
 	t.Run("lifecycle", func(t *testing.T) {
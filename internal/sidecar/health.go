@@ -0,0 +1,88 @@
+package sidecar
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// healthCheckClient is the client used to probe the sidecar's health endpoint.
+var healthCheckClient = &http.Client{Timeout: 5 * time.Second}
+
+// HealthCheck describes how a freshly started sidecar is verified.
+type HealthCheck struct {
+	// Timeout is how long the sidecar is watched for. A zero timeout disables the check.
+	Timeout time.Duration
+	// Interval is how often the sidecar is polled.
+	Interval time.Duration
+	// URL is an optional endpoint exposed by sentry, eg: its metrics endpoint. If set,
+	// it must respond with a 2xx status at least once before the timeout elapses.
+	URL string
+}
+
+// Enabled returns true if the health check should be run.
+func (h HealthCheck) Enabled() bool {
+	return h.Timeout > 0
+}
+
+// WaitHealthy watches the runner for the duration of the health check. The sidecar
+// must stay running for the entire window, so a process that starts and then crash
+// loops is caught, rather than just one that fails to start.
+func WaitHealthy(runner SidecarRunner, check HealthCheck) error {
+	if !check.Enabled() {
+		return nil
+	}
+
+	interval := check.Interval
+	if interval <= 0 || interval > check.Timeout {
+		interval = check.Timeout
+	}
+
+	var (
+		start    = time.Now()
+		deadline = start.Add(check.Timeout)
+		healthy  = check.URL == ""
+		lastErr  error
+	)
+
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+
+		running, err := runner.IsRunning()
+		if err != nil {
+			return fmt.Errorf("failed to check if contributoor is running: %w", err)
+		}
+
+		if !running {
+			return fmt.Errorf("contributoor stopped running %s after start", time.Since(start).Round(time.Second))
+		}
+
+		if !healthy {
+			if lastErr = probeHealth(check.URL); lastErr == nil {
+				healthy = true
+			}
+		}
+	}
+
+	if !healthy {
+		return fmt.Errorf("%s did not become healthy within %s: %w", check.URL, check.Timeout, lastErr)
+	}
+
+	return nil
+}
+
+// probeHealth performs a single request against the health endpoint.
+func probeHealth(url string) error {
+	resp, err := healthCheckClient.Get(url)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return nil
+}
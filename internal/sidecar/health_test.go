@@ -0,0 +1,106 @@
+package sidecar
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/mock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWaitHealthy(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer healthy.Close()
+
+	unhealthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unhealthy.Close()
+
+	check := HealthCheck{
+		Timeout:  50 * time.Millisecond,
+		Interval: 10 * time.Millisecond,
+	}
+
+	tests := []struct {
+		name          string
+		url           string
+		setupMocks    func(*mock.MockBinarySidecar)
+		expectedError string
+	}{
+		{
+			name: "stays running",
+			setupMocks: func(b *mock.MockBinarySidecar) {
+				b.EXPECT().IsRunning().Return(true, nil).MinTimes(1)
+			},
+		},
+		{
+			name: "stays running and endpoint healthy",
+			url:  healthy.URL,
+			setupMocks: func(b *mock.MockBinarySidecar) {
+				b.EXPECT().IsRunning().Return(true, nil).MinTimes(1)
+			},
+		},
+		{
+			name: "stops running",
+			setupMocks: func(b *mock.MockBinarySidecar) {
+				gomock.InOrder(
+					b.EXPECT().IsRunning().Return(true, nil),
+					b.EXPECT().IsRunning().Return(false, nil),
+				)
+			},
+			expectedError: "contributoor stopped running",
+		},
+		{
+			name: "endpoint never healthy",
+			url:  unhealthy.URL,
+			setupMocks: func(b *mock.MockBinarySidecar) {
+				b.EXPECT().IsRunning().Return(true, nil).MinTimes(1)
+			},
+			expectedError: "did not become healthy",
+		},
+		{
+			name: "status check fails",
+			setupMocks: func(b *mock.MockBinarySidecar) {
+				b.EXPECT().IsRunning().Return(false, errors.New("boom"))
+			},
+			expectedError: "failed to check if contributoor is running",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			runner := mock.NewMockBinarySidecar(ctrl)
+			tt.setupMocks(runner)
+
+			check.URL = tt.url
+
+			err := WaitHealthy(runner, check)
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// No calls are expected on the runner.
+		assert.NoError(t, WaitHealthy(mock.NewMockBinarySidecar(ctrl), HealthCheck{}))
+	})
+}
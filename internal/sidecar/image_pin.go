@@ -0,0 +1,70 @@
+package sidecar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/ethpandaops/contributoor-installer/internal/dockerapi"
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+)
+
+// verifyImageSignature verifies the image's signature against a cosign public key. It's
+// a var so tests can stand in for cosign.
+var verifyImageSignature = func(ctx context.Context, publicKey, ref string) error {
+	cosign, err := exec.LookPath("cosign")
+	if err != nil {
+		return errors.New("cosign is needed to verify image signatures, see https://docs.sigstore.dev/cosign/system_config/installation")
+	}
+
+	out, err := exec.CommandContext(ctx, cosign, "verify", "--key", publicKey, ref).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// pinnedImage returns the image reference for a version, by digest if it's been pinned,
+// eg: ethpandaops/contributoor@sha256:abc..., otherwise by tag.
+func (s *dockerSidecar) pinnedImage(runtime *runner.ContainerRuntime, state *installer.State, version string) string {
+	if digest := state.ImageDigest(version); digest != "" {
+		return fmt.Sprintf("%s@%s", s.repository(runtime), digest)
+	}
+
+	return s.image(runtime, version)
+}
+
+// pinImage pins the version to the digest of the image its tag resolved to, and returns
+// the image's reference by digest. With a cosign public key in the state, the image's
+// signature must verify first. Images that haven't come from a registry, eg: ones built
+// locally, have no digest and are left unpinned.
+func (s *dockerSidecar) pinImage(ctx context.Context, state *installer.State, runtime *runner.ContainerRuntime, version string, image *dockerapi.Image) (string, error) {
+	digest := image.Digest(s.installerCfg.DockerImage)
+	if digest == "" {
+		if state.CosignPublicKey != "" {
+			return "", fmt.Errorf("image %s has no registry digest, so its signature can't be verified", s.image(runtime, version))
+		}
+
+		return s.image(runtime, version), nil
+	}
+
+	ref := fmt.Sprintf("%s@%s", s.repository(runtime), digest)
+
+	if state.CosignPublicKey != "" {
+		if err := verifyImageSignature(ctx, state.CosignPublicKey, ref); err != nil {
+			return "", fmt.Errorf("failed to verify the signature of image %s: %w", ref, err)
+		}
+	}
+
+	state.PinImage(version, digest)
+
+	if err := state.Save(); err != nil {
+		return "", err
+	}
+
+	return ref, nil
+}
@@ -0,0 +1,359 @@
+package sidecar
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+	"github.com/sirupsen/logrus"
+)
+
+// logPollInterval is how often log files are checked for new lines, when following.
+var logPollInterval = 250 * time.Millisecond
+
+// maxLogLineSize is the longest log line we'll read, longer lines are an error.
+const maxLogLineSize = 1024 * 1024
+
+var (
+	// logTimePattern matches the time of a logrus line, in either text or JSON format.
+	logTimePattern = regexp.MustCompile(`\btime"?[=:]"?([^"\s]+)`)
+
+	// logLevelPattern matches the level of a logrus line, in either text or JSON format.
+	logLevelPattern = regexp.MustCompile(`\blevel"?[=:]"?(\w+)`)
+)
+
+// logLine is a line read from one of the log files being tailed.
+type logLine struct {
+	time time.Time
+	text string
+}
+
+// parseLogTime returns the time of a logrus formatted log line, if it has one.
+func parseLogTime(line string) (time.Time, bool) {
+	match := logTimePattern.FindStringSubmatch(line)
+	if match == nil {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, match[1])
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+// parseLogLevel returns the level of a logrus formatted log line, if it has one.
+func parseLogLevel(line string) (logrus.Level, bool) {
+	match := logLevelPattern.FindStringSubmatch(line)
+	if match == nil {
+		return 0, false
+	}
+
+	level, err := logrus.ParseLevel(match[1])
+	if err != nil {
+		return 0, false
+	}
+
+	return level, true
+}
+
+// tailLogFiles writes the logs from the given files to w, interleaved by time. Lines
+// without a time of their own, such as stack traces, stay with the line before them.
+// Missing files are skipped, as a service doesn't necessarily write to all of them.
+func tailLogFiles(ctx context.Context, paths []string, opts runner.LogOptions, w io.Writer) error {
+	var (
+		lines   []logLine
+		tailers = make([]*logTailer, 0, len(paths))
+	)
+
+	for _, path := range paths {
+		tailer := &logTailer{path: path, since: opts.Since}
+
+		fileLines, err := tailer.readExisting(opts.Tail)
+		if err != nil {
+			return err
+		}
+
+		lines = append(lines, fileLines...)
+		tailers = append(tailers, tailer)
+	}
+
+	// Stable, so lines from the same file that share a time keep their order.
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].time.Before(lines[j].time)
+	})
+
+	if opts.Tail > 0 && len(lines) > opts.Tail {
+		lines = lines[len(lines)-opts.Tail:]
+	}
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line.text); err != nil {
+			return err
+		}
+	}
+
+	if !opts.Follow {
+		return nil
+	}
+
+	ticker := time.NewTicker(logPollInterval)
+	defer ticker.Stop()
+
+	defer func() {
+		for _, tailer := range tailers {
+			tailer.close()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			for _, tailer := range tailers {
+				if err := tailer.follow(w); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// logTailer tracks how far through a log file we've read.
+type logTailer struct {
+	path    string
+	since   time.Time
+	file    *os.File
+	info    os.FileInfo
+	offset  int64
+	partial []byte
+	skip    bool
+}
+
+// readExisting reads the lines already in the file, keeping at most the last tail lines.
+// Following picks up from wherever this leaves off.
+func (t *logTailer) readExisting(tail int) ([]logLine, error) {
+	file, err := os.Open(t.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+
+		return nil, fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	t.file, t.info, t.offset = file, info, info.Size()
+
+	var (
+		lines   []logLine
+		last    time.Time
+		scanner = bufio.NewScanner(io.LimitReader(file, info.Size()))
+	)
+
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLogLineSize)
+
+	for scanner.Scan() {
+		text := scanner.Text()
+
+		if lineTime, ok := parseLogTime(text); ok {
+			last = lineTime
+		}
+
+		if !t.since.IsZero() && last.Before(t.since) {
+			continue
+		}
+
+		lines = append(lines, logLine{time: last, text: text})
+
+		// Only ever hold on to what we might output.
+		if tail > 0 && len(lines) > tail*2 {
+			lines = append(lines[:0], lines[len(lines)-tail:]...)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read log file %s: %w", t.path, err)
+	}
+
+	if tail > 0 && len(lines) > tail {
+		lines = lines[len(lines)-tail:]
+	}
+
+	return lines, nil
+}
+
+// follow writes any complete lines appended to the file since we last looked. If the
+// file has been truncated or replaced, such as when rotated, it's read from the start.
+func (t *logTailer) follow(w io.Writer) error {
+	info, err := os.Stat(t.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	switch {
+	case t.file == nil || !os.SameFile(info, t.info):
+		// Finish off the old file before moving on to its replacement.
+		if t.file != nil {
+			if err := t.readNew(w); err != nil {
+				return err
+			}
+
+			t.close()
+		}
+
+		file, err := os.Open(t.path)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %w", err)
+		}
+
+		t.file, t.info, t.offset, t.partial = file, info, 0, nil
+	case info.Size() < t.offset:
+		t.offset, t.partial = 0, nil
+	}
+
+	return t.readNew(w)
+}
+
+// readNew writes the complete lines between our offset and the end of the file.
+func (t *logTailer) readNew(w io.Writer) error {
+	data := make([]byte, 32*1024)
+
+	for {
+		n, err := t.file.ReadAt(data, t.offset)
+		if n > 0 {
+			t.offset += int64(n)
+			t.partial = append(t.partial, data[:n]...)
+
+			if werr := t.writeLines(w); werr != nil {
+				return werr
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to read log file %s: %w", t.path, err)
+		}
+	}
+}
+
+// writeLines writes out any complete lines held in the partial buffer.
+func (t *logTailer) writeLines(w io.Writer) error {
+	for {
+		idx := bytes.IndexByte(t.partial, '\n')
+		if idx < 0 {
+			if len(t.partial) > maxLogLineSize {
+				return fmt.Errorf("log line in %s is longer than %d bytes", t.path, maxLogLineSize)
+			}
+
+			return nil
+		}
+
+		line := string(t.partial[:idx])
+		t.partial = t.partial[idx+1:]
+
+		if !t.since.IsZero() {
+			if lineTime, ok := parseLogTime(line); ok {
+				t.skip = lineTime.Before(t.since)
+			}
+
+			if t.skip {
+				continue
+			}
+		}
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+}
+
+func (t *logTailer) close() {
+	if t.file != nil {
+		t.file.Close()
+		t.file = nil
+	}
+}
+
+// LevelFilter is a writer that drops log lines below a minimum level. Lines without a
+// level of their own, such as stack traces, are kept or dropped along with the line
+// before them.
+type LevelFilter struct {
+	w       io.Writer
+	level   logrus.Level
+	partial []byte
+	drop    bool
+}
+
+// NewLevelFilter creates a LevelFilter, writing lines at or above level to w.
+func NewLevelFilter(w io.Writer, level logrus.Level) *LevelFilter {
+	return &LevelFilter{w: w, level: level}
+}
+
+// Write implements io.Writer.
+func (f *LevelFilter) Write(p []byte) (int, error) {
+	f.partial = append(f.partial, p...)
+
+	for {
+		idx := bytes.IndexByte(f.partial, '\n')
+		if idx < 0 {
+			return len(p), nil
+		}
+
+		if err := f.writeLine(f.partial[:idx+1]); err != nil {
+			return 0, err
+		}
+
+		f.partial = f.partial[idx+1:]
+	}
+}
+
+// Flush writes out any final line that wasn't terminated by a newline.
+func (f *LevelFilter) Flush() error {
+	if len(f.partial) == 0 {
+		return nil
+	}
+
+	err := f.writeLine(f.partial)
+	f.partial = nil
+
+	return err
+}
+
+func (f *LevelFilter) writeLine(line []byte) error {
+	// logrus levels are ordered from most to least severe.
+	if level, ok := parseLogLevel(strings.TrimSpace(string(line))); ok {
+		f.drop = level > f.level
+	}
+
+	if f.drop {
+		return nil
+	}
+
+	_, err := f.w.Write(line)
+
+	return err
+}
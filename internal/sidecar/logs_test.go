@@ -0,0 +1,181 @@
+package sidecar
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a bytes.Buffer that's safe to read while logs are being followed.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestTailLogFiles(t *testing.T) {
+	dir := t.TempDir()
+	stdout := filepath.Join(dir, "debug.log")
+	stderr := filepath.Join(dir, "service.log")
+
+	require.NoError(t, os.WriteFile(stdout, []byte(strings.Join([]string{
+		`time="2025-01-02T15:00:01Z" level=info msg=one`,
+		`time="2025-01-02T15:00:03Z" level=info msg=three`,
+		``,
+	}, "\n")), 0600))
+
+	require.NoError(t, os.WriteFile(stderr, []byte(strings.Join([]string{
+		`time="2025-01-02T15:00:02Z" level=error msg=two`,
+		`goroutine 1 [running]:`,
+		`time="2025-01-02T15:00:04Z" level=info msg=four`,
+		``,
+	}, "\n")), 0600))
+
+	paths := []string{stdout, stderr, filepath.Join(dir, "missing.log")}
+
+	tests := []struct {
+		name     string
+		opts     runner.LogOptions
+		expected []string
+	}{
+		{
+			name: "interleaves files by time",
+			expected: []string{
+				`time="2025-01-02T15:00:01Z" level=info msg=one`,
+				`time="2025-01-02T15:00:02Z" level=error msg=two`,
+				`goroutine 1 [running]:`,
+				`time="2025-01-02T15:00:03Z" level=info msg=three`,
+				`time="2025-01-02T15:00:04Z" level=info msg=four`,
+			},
+		},
+		{
+			name: "tail",
+			opts: runner.LogOptions{Tail: 2},
+			expected: []string{
+				`time="2025-01-02T15:00:03Z" level=info msg=three`,
+				`time="2025-01-02T15:00:04Z" level=info msg=four`,
+			},
+		},
+		{
+			name: "since",
+			opts: runner.LogOptions{Since: time.Date(2025, 1, 2, 15, 0, 2, 0, time.UTC)},
+			expected: []string{
+				`time="2025-01-02T15:00:02Z" level=error msg=two`,
+				`goroutine 1 [running]:`,
+				`time="2025-01-02T15:00:03Z" level=info msg=three`,
+				`time="2025-01-02T15:00:04Z" level=info msg=four`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			require.NoError(t, tailLogFiles(context.Background(), paths, tt.opts, &out))
+			assert.Equal(t, strings.Join(tt.expected, "\n")+"\n", out.String())
+		})
+	}
+}
+
+func TestTailLogFiles_Follow(t *testing.T) {
+	original := logPollInterval
+	logPollInterval = 10 * time.Millisecond
+
+	t.Cleanup(func() {
+		logPollInterval = original
+	})
+
+	path := filepath.Join(t.TempDir(), "service.log")
+	require.NoError(t, os.WriteFile(path, []byte("existing\n"), 0600))
+
+	var (
+		out         syncBuffer
+		ctx, cancel = context.WithCancel(context.Background())
+		done        = make(chan error)
+	)
+
+	go func() {
+		done <- tailLogFiles(ctx, []string{path}, runner.LogOptions{Follow: true}, &out)
+	}()
+
+	waitForOutput := func(expected string) {
+		t.Helper()
+
+		assert.Eventually(t, func() bool {
+			return out.String() == expected
+		}, time.Second, 5*time.Millisecond, "got %q", out.String())
+	}
+
+	waitForOutput("existing\n")
+
+	// Lines are only written once they're complete.
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+
+	_, err = file.WriteString("appen")
+	require.NoError(t, err)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, "existing\n", out.String())
+
+	_, err = file.WriteString("ded\n")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	waitForOutput("existing\nappended\n")
+
+	// A rotated file is picked up from the start.
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, os.WriteFile(path, []byte("rotated\n"), 0600))
+
+	waitForOutput("existing\nappended\nrotated\n")
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestLevelFilter(t *testing.T) {
+	var out bytes.Buffer
+
+	filter := NewLevelFilter(&out, logrus.WarnLevel)
+
+	// Written in awkward chunks, as a subprocess would.
+	for _, chunk := range []string{
+		"time=\"2025-01-02T15:00:01Z\" level=debug msg=one\nstack for one\n",
+		"{\"level\":\"warning\",\"msg\":\"two\"}\nstack ",
+		"for two\n",
+		"level=info msg=three\n",
+		"level=error msg=four",
+	} {
+		_, err := filter.Write([]byte(chunk))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, filter.Flush())
+
+	assert.Equal(t, "{\"level\":\"warning\",\"msg\":\"two\"}\nstack for two\nlevel=error msg=four", out.String())
+}
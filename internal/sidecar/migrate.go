@@ -0,0 +1,222 @@
+package sidecar
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/pmezard/go-difflib/difflib"
+	"google.golang.org/protobuf/encoding/protojson"
+	"gopkg.in/yaml.v3"
+)
+
+// migration upgrades the config from one schema version to the next. Migrations work on
+// the raw config, rather than config.Config, so they can still read fields that have
+// since been renamed or removed from the proto.
+//
+// The schema version is recorded after the config is written, so a migration may run
+// again if we're interrupted in between. Migrations must be safe to re-run.
+type migration struct {
+	// description is a short summary of the change, shown by `config migrate`.
+	description string
+
+	// migrate modifies the raw config in place.
+	migrate func(raw map[string]interface{}) error
+}
+
+// migrations is the ordered list of config migrations, where migrations[i] upgrades the
+// config from schema version i to i+1. Only ever append to it, a released migration
+// must not be changed, reordered or removed.
+var migrations = []migration{
+	{
+		description: "Start tracking the config schema version",
+		migrate:     func(map[string]interface{}) error { return nil },
+	},
+}
+
+// LatestConfigSchemaVersion returns the schema version configs are migrated to.
+func LatestConfigSchemaVersion() int {
+	return len(migrations)
+}
+
+// MigrationPlan describes the migrations pending for a config.
+type MigrationPlan struct {
+	// FromVersion is the schema version the config is currently at.
+	FromVersion int
+
+	// ToVersion is the schema version the config will be migrated to.
+	ToVersion int
+
+	// Steps describes each of the pending migrations, in order.
+	Steps []string
+
+	// Before and After are the config, before and after migrating. Both are normalised
+	// the same way, so that they only differ by the changes made by the migrations.
+	Before []byte
+	After  []byte
+
+	configDir string
+	state     *installer.State
+}
+
+// Pending reports whether there are migrations to apply.
+func (p *MigrationPlan) Pending() bool {
+	return p.FromVersion < p.ToVersion
+}
+
+// Changed reports whether the migrations change the config.
+func (p *MigrationPlan) Changed() bool {
+	return !bytes.Equal(p.Before, p.After)
+}
+
+// Diff returns a unified diff of the config, before and after migrating.
+func (p *MigrationPlan) Diff() (string, error) {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(p.Before)),
+		B:        difflib.SplitLines(string(p.After)),
+		FromFile: "config.yaml",
+		ToFile:   "config.yaml (migrated)",
+		Context:  3,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to diff config: %w", err)
+	}
+
+	return diff, nil
+}
+
+// PlanMigration works out the migrations pending for the config in configDir, and what
+// they would change, without touching anything on disk.
+func PlanMigration(configDir string) (*MigrationPlan, error) {
+	state, err := installer.LoadState(configDir)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := LatestConfigSchemaVersion()
+	if state.ConfigSchemaVersion > latest {
+		return nil, fmt.Errorf(
+			"config schema version %d is newer than the latest supported version %d, please update the installer",
+			state.ConfigSchemaVersion,
+			latest,
+		)
+	}
+
+	data, err := os.ReadFile(filepath.Join(configDir, "config.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	raw := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	before, err := yaml.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	plan := &MigrationPlan{
+		FromVersion: state.ConfigSchemaVersion,
+		ToVersion:   latest,
+		Before:      before,
+		configDir:   configDir,
+		state:       state,
+	}
+
+	for version := plan.FromVersion; version < plan.ToVersion; version++ {
+		step := migrations[version]
+
+		if err := step.migrate(raw); err != nil {
+			return nil, fmt.Errorf("failed to migrate config to schema version %d (%s): %w", version+1, step.description, err)
+		}
+
+		plan.Steps = append(plan.Steps, step.description)
+	}
+
+	// Make sure the migrated config is one we can load, before anything is written.
+	if err := checkRawConfig(raw); err != nil {
+		return nil, fmt.Errorf("migrated config is invalid: %w", err)
+	}
+
+	if plan.After, err = yaml.Marshal(raw); err != nil {
+		return nil, fmt.Errorf("failed to marshal migrated config: %w", err)
+	}
+
+	return plan, nil
+}
+
+// ApplyMigration writes the migrated config and records its new schema version. If the
+// config changes, it's backed up first, and the path of the backup is returned.
+func ApplyMigration(plan *MigrationPlan) (string, error) {
+	if !plan.Pending() {
+		return "", nil
+	}
+
+	var (
+		backupPath string
+		configPath = filepath.Join(plan.configDir, "config.yaml")
+	)
+
+	if plan.Changed() {
+		path, err := backupConfig(configPath)
+		if err != nil {
+			return "", err
+		}
+
+		backupPath = path
+
+		tmpPath := fmt.Sprintf("%s.tmp", configPath)
+		if err := os.WriteFile(tmpPath, plan.After, 0600); err != nil {
+			os.Remove(tmpPath)
+
+			return "", fmt.Errorf("error writing config file: %w", err)
+		}
+
+		if err := os.Rename(tmpPath, configPath); err != nil {
+			os.Remove(tmpPath)
+
+			return "", fmt.Errorf("failed to save config: %w", err)
+		}
+	}
+
+	plan.state.ConfigSchemaVersion = plan.ToVersion
+
+	if err := plan.state.Save(); err != nil {
+		return "", err
+	}
+
+	return backupPath, nil
+}
+
+// backupConfig copies the config to a timestamped file alongside it, returning its path.
+func backupConfig(configPath string) (string, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read config for backup: %w", err)
+	}
+
+	backupPath := fmt.Sprintf("%s.%s.bak", configPath, time.Now().UTC().Format("20060102T150405Z"))
+
+	if err := os.WriteFile(backupPath, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to back up config: %w", err)
+	}
+
+	return backupPath, nil
+}
+
+// checkRawConfig checks the raw config can be loaded into config.Config.
+func checkRawConfig(raw map[string]interface{}) error {
+	jsonBytes, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	return protojson.Unmarshal(jsonBytes, &config.Config{})
+}
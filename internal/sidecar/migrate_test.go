@@ -0,0 +1,230 @@
+package sidecar
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `version: latest
+contributoorDirectory: /tmp/contributoor
+runMethod: RUN_METHOD_DOCKER
+networkName: NETWORK_NAME_MAINNET
+beaconNodeAddress: http://localhost:5052
+`
+
+// withMigrations swaps out the registered migrations for the duration of the test.
+func withMigrations(t *testing.T, steps ...migration) {
+	t.Helper()
+
+	original := migrations
+	migrations = steps
+
+	t.Cleanup(func() {
+		migrations = original
+	})
+}
+
+// renameBeaconNode is an example migration, renaming a field that no longer exists.
+var renameBeaconNode = migration{
+	description: "Rename beaconNode to beaconNodeAddress",
+	migrate: func(raw map[string]interface{}) error {
+		if value, ok := raw["beaconNode"]; ok {
+			raw["beaconNodeAddress"] = value
+			delete(raw, "beaconNode")
+		}
+
+		return nil
+	},
+}
+
+func writeTestConfig(t *testing.T, content string, schemaVersion int) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0600))
+
+	if schemaVersion > 0 {
+		state, err := installer.LoadState(dir)
+		require.NoError(t, err)
+
+		state.ConfigSchemaVersion = schemaVersion
+		require.NoError(t, state.Save())
+	}
+
+	return dir
+}
+
+func TestMigrations(t *testing.T) {
+	// Each released migration, run against the config it was written for.
+	tests := []struct {
+		name     string
+		version  int
+		before   map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			name:     "1: start tracking schema version",
+			version:  1,
+			before:   map[string]interface{}{"version": "latest"},
+			expected: map[string]interface{}{"version": "latest"},
+		},
+	}
+
+	require.Len(t, tests, LatestConfigSchemaVersion(), "every migration should be tested")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, migrations[tt.version-1].migrate(tt.before))
+			assert.Equal(t, tt.expected, tt.before)
+
+			// Migrations may be re-run, if we're interrupted before recording the version.
+			require.NoError(t, migrations[tt.version-1].migrate(tt.before))
+			assert.Equal(t, tt.expected, tt.before)
+		})
+	}
+}
+
+func TestPlanMigration(t *testing.T) {
+	noop := migration{
+		description: "Nothing to change",
+		migrate:     func(map[string]interface{}) error { return nil },
+	}
+
+	t.Run("pending migrations with changes", func(t *testing.T) {
+		withMigrations(t, noop, renameBeaconNode)
+
+		dir := writeTestConfig(t, "version: latest\nbeaconNode: http://localhost:5052\n", 0)
+
+		plan, err := PlanMigration(dir)
+		require.NoError(t, err)
+
+		assert.True(t, plan.Pending())
+		assert.True(t, plan.Changed())
+		assert.Equal(t, 0, plan.FromVersion)
+		assert.Equal(t, 2, plan.ToVersion)
+		assert.Equal(t, []string{"Nothing to change", "Rename beaconNode to beaconNodeAddress"}, plan.Steps)
+
+		diff, err := plan.Diff()
+		require.NoError(t, err)
+		assert.Contains(t, diff, "-beaconNode: http://localhost:5052")
+		assert.Contains(t, diff, "+beaconNodeAddress: http://localhost:5052")
+
+		// Planning doesn't touch anything on disk.
+		assertOnlyEntries(t, dir, "config.yaml")
+	})
+
+	t.Run("only runs migrations newer than the recorded version", func(t *testing.T) {
+		withMigrations(t, renameBeaconNode, noop)
+
+		dir := writeTestConfig(t, testConfig, 1)
+
+		plan, err := PlanMigration(dir)
+		require.NoError(t, err)
+
+		assert.True(t, plan.Pending())
+		assert.False(t, plan.Changed())
+		assert.Equal(t, []string{"Nothing to change"}, plan.Steps)
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		withMigrations(t, noop)
+
+		plan, err := PlanMigration(writeTestConfig(t, testConfig, 1))
+		require.NoError(t, err)
+
+		assert.False(t, plan.Pending())
+		assert.Empty(t, plan.Steps)
+	})
+
+	t.Run("newer schema version", func(t *testing.T) {
+		withMigrations(t, noop)
+
+		_, err := PlanMigration(writeTestConfig(t, testConfig, 2))
+		assert.ErrorContains(t, err, "config schema version 2 is newer than the latest supported version 1")
+	})
+
+	t.Run("failed migration", func(t *testing.T) {
+		withMigrations(t, migration{
+			description: "Broken",
+			migrate:     func(map[string]interface{}) error { return errors.New("boom") },
+		})
+
+		_, err := PlanMigration(writeTestConfig(t, testConfig, 0))
+		assert.ErrorContains(t, err, "failed to migrate config to schema version 1 (Broken): boom")
+	})
+
+	t.Run("migration leaves an invalid config", func(t *testing.T) {
+		withMigrations(t, noop)
+
+		_, err := PlanMigration(writeTestConfig(t, "beaconNode: http://localhost:5052\n", 0))
+		assert.ErrorContains(t, err, "migrated config is invalid")
+	})
+}
+
+func TestApplyMigration(t *testing.T) {
+	t.Run("backs up and writes changed config", func(t *testing.T) {
+		withMigrations(t, renameBeaconNode)
+
+		dir := writeTestConfig(t, "version: latest\nbeaconNode: http://localhost:5052\n", 0)
+
+		plan, err := PlanMigration(dir)
+		require.NoError(t, err)
+
+		backupPath, err := ApplyMigration(plan)
+		require.NoError(t, err)
+		require.NotEmpty(t, backupPath)
+
+		backup, err := os.ReadFile(backupPath)
+		require.NoError(t, err)
+		assert.Equal(t, "version: latest\nbeaconNode: http://localhost:5052\n", string(backup))
+
+		migrated, err := os.ReadFile(filepath.Join(dir, "config.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, "beaconNodeAddress: http://localhost:5052\nversion: latest\n", string(migrated))
+
+		state, err := installer.LoadState(dir)
+		require.NoError(t, err)
+		assert.Equal(t, 1, state.ConfigSchemaVersion)
+	})
+
+	t.Run("records version without touching unchanged config", func(t *testing.T) {
+		withMigrations(t, renameBeaconNode)
+
+		dir := writeTestConfig(t, testConfig, 0)
+
+		plan, err := PlanMigration(dir)
+		require.NoError(t, err)
+
+		backupPath, err := ApplyMigration(plan)
+		require.NoError(t, err)
+		assert.Empty(t, backupPath)
+
+		data, err := os.ReadFile(filepath.Join(dir, "config.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, testConfig, string(data))
+
+		assertOnlyEntries(t, dir, "config.yaml", installer.StateFile)
+	})
+}
+
+func TestNewConfigService_Migrates(t *testing.T) {
+	withMigrations(t, renameBeaconNode)
+
+	dir := writeTestConfig(t, "version: latest\nrunMethod: RUN_METHOD_BINARY\nbeaconNode: http://localhost:5052\n", 0)
+
+	cfg, err := NewConfigService(logrus.New(), dir)
+	require.NoError(t, err)
+
+	assert.Equal(t, "http://localhost:5052", cfg.Get().BeaconNodeAddress)
+
+	state, err := installer.LoadState(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 1, state.ConfigSchemaVersion)
+}
@@ -10,8 +10,11 @@
 package mock
 
 import (
+	context "context"
+	io "io"
 	reflect "reflect"
 
+	runner "github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -38,6 +41,21 @@ func (m *MockBinarySidecar) EXPECT() *MockBinarySidecarMockRecorder {
 	return m.recorder
 }
 
+// Details mocks base method.
+func (m *MockBinarySidecar) Details() (*runner.Details, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Details")
+	ret0, _ := ret[0].(*runner.Details)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Details indicates an expected call of Details.
+func (mr *MockBinarySidecarMockRecorder) Details() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Details", reflect.TypeOf((*MockBinarySidecar)(nil).Details))
+}
+
 // IsRunning mocks base method.
 func (m *MockBinarySidecar) IsRunning() (bool, error) {
 	m.ctrl.T.Helper()
@@ -53,6 +71,34 @@ func (mr *MockBinarySidecarMockRecorder) IsRunning() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRunning", reflect.TypeOf((*MockBinarySidecar)(nil).IsRunning))
 }
 
+// Logs mocks base method.
+func (m *MockBinarySidecar) Logs(arg0 context.Context, arg1 runner.LogOptions, arg2 io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logs", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logs indicates an expected call of Logs.
+func (mr *MockBinarySidecarMockRecorder) Logs(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logs", reflect.TypeOf((*MockBinarySidecar)(nil).Logs), arg0, arg1, arg2)
+}
+
+// Rollback mocks base method.
+func (m *MockBinarySidecar) Rollback(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rollback", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Rollback indicates an expected call of Rollback.
+func (mr *MockBinarySidecarMockRecorder) Rollback(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockBinarySidecar)(nil).Rollback), arg0)
+}
+
 // Start mocks base method.
 func (m *MockBinarySidecar) Start() error {
 	m.ctrl.T.Helper()
@@ -10,8 +10,11 @@
 package mock
 
 import (
+	context "context"
+	io "io"
 	reflect "reflect"
 
+	runner "github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -38,6 +41,65 @@ func (m *MockDockerSidecar) EXPECT() *MockDockerSidecarMockRecorder {
 	return m.recorder
 }
 
+// ContainerRuntime mocks base method.
+func (m *MockDockerSidecar) ContainerRuntime() (*runner.ContainerRuntime, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ContainerRuntime")
+	ret0, _ := ret[0].(*runner.ContainerRuntime)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ContainerRuntime indicates an expected call of ContainerRuntime.
+func (mr *MockDockerSidecarMockRecorder) ContainerRuntime() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ContainerRuntime", reflect.TypeOf((*MockDockerSidecar)(nil).ContainerRuntime))
+}
+
+// Definition mocks base method.
+func (m *MockDockerSidecar) Definition(arg0 runner.DefinitionFormat) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Definition", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Definition indicates an expected call of Definition.
+func (mr *MockDockerSidecarMockRecorder) Definition(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Definition", reflect.TypeOf((*MockDockerSidecar)(nil).Definition), arg0)
+}
+
+// Details mocks base method.
+func (m *MockDockerSidecar) Details() (*runner.Details, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Details")
+	ret0, _ := ret[0].(*runner.Details)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Details indicates an expected call of Details.
+func (mr *MockDockerSidecarMockRecorder) Details() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Details", reflect.TypeOf((*MockDockerSidecar)(nil).Details))
+}
+
+// Down mocks base method.
+func (m *MockDockerSidecar) Down() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Down")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Down indicates an expected call of Down.
+func (mr *MockDockerSidecarMockRecorder) Down() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Down", reflect.TypeOf((*MockDockerSidecar)(nil).Down))
+}
+
 // IsRunning mocks base method.
 func (m *MockDockerSidecar) IsRunning() (bool, error) {
 	m.ctrl.T.Helper()
@@ -53,6 +115,48 @@ func (mr *MockDockerSidecarMockRecorder) IsRunning() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRunning", reflect.TypeOf((*MockDockerSidecar)(nil).IsRunning))
 }
 
+// Logs mocks base method.
+func (m *MockDockerSidecar) Logs(arg0 context.Context, arg1 runner.LogOptions, arg2 io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logs", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logs indicates an expected call of Logs.
+func (mr *MockDockerSidecarMockRecorder) Logs(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logs", reflect.TypeOf((*MockDockerSidecar)(nil).Logs), arg0, arg1, arg2)
+}
+
+// Purge mocks base method.
+func (m *MockDockerSidecar) Purge() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockDockerSidecarMockRecorder) Purge() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockDockerSidecar)(nil).Purge))
+}
+
+// Rollback mocks base method.
+func (m *MockDockerSidecar) Rollback(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rollback", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Rollback indicates an expected call of Rollback.
+func (mr *MockDockerSidecarMockRecorder) Rollback(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockDockerSidecar)(nil).Rollback), arg0)
+}
+
 // Start mocks base method.
 func (m *MockDockerSidecar) Start() error {
 	m.ctrl.T.Helper()
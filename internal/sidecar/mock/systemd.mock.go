@@ -10,8 +10,11 @@
 package mock
 
 import (
+	context "context"
+	io "io"
 	reflect "reflect"
 
+	runner "github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -38,6 +41,36 @@ func (m *MockSystemdSidecar) EXPECT() *MockSystemdSidecarMockRecorder {
 	return m.recorder
 }
 
+// Details mocks base method.
+func (m *MockSystemdSidecar) Details() (*runner.Details, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Details")
+	ret0, _ := ret[0].(*runner.Details)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Details indicates an expected call of Details.
+func (mr *MockSystemdSidecarMockRecorder) Details() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Details", reflect.TypeOf((*MockSystemdSidecar)(nil).Details))
+}
+
+// InstallUnit mocks base method.
+func (m *MockSystemdSidecar) InstallUnit() (runner.UnitChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstallUnit")
+	ret0, _ := ret[0].(runner.UnitChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InstallUnit indicates an expected call of InstallUnit.
+func (mr *MockSystemdSidecarMockRecorder) InstallUnit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstallUnit", reflect.TypeOf((*MockSystemdSidecar)(nil).InstallUnit))
+}
+
 // IsRunning mocks base method.
 func (m *MockSystemdSidecar) IsRunning() (bool, error) {
 	m.ctrl.T.Helper()
@@ -53,6 +86,34 @@ func (mr *MockSystemdSidecarMockRecorder) IsRunning() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRunning", reflect.TypeOf((*MockSystemdSidecar)(nil).IsRunning))
 }
 
+// Logs mocks base method.
+func (m *MockSystemdSidecar) Logs(arg0 context.Context, arg1 runner.LogOptions, arg2 io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logs", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logs indicates an expected call of Logs.
+func (mr *MockSystemdSidecarMockRecorder) Logs(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logs", reflect.TypeOf((*MockSystemdSidecar)(nil).Logs), arg0, arg1, arg2)
+}
+
+// Rollback mocks base method.
+func (m *MockSystemdSidecar) Rollback(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rollback", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Rollback indicates an expected call of Rollback.
+func (mr *MockSystemdSidecarMockRecorder) Rollback(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockSystemdSidecar)(nil).Rollback), arg0)
+}
+
 // Start mocks base method.
 func (m *MockSystemdSidecar) Start() error {
 	m.ctrl.T.Helper()
@@ -81,6 +142,35 @@ func (mr *MockSystemdSidecarMockRecorder) Stop() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockSystemdSidecar)(nil).Stop))
 }
 
+// UninstallUnit mocks base method.
+func (m *MockSystemdSidecar) UninstallUnit() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UninstallUnit")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UninstallUnit indicates an expected call of UninstallUnit.
+func (mr *MockSystemdSidecarMockRecorder) UninstallUnit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UninstallUnit", reflect.TypeOf((*MockSystemdSidecar)(nil).UninstallUnit))
+}
+
+// UnitDiff mocks base method.
+func (m *MockSystemdSidecar) UnitDiff() (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnitDiff")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnitDiff indicates an expected call of UnitDiff.
+func (mr *MockSystemdSidecarMockRecorder) UnitDiff() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnitDiff", reflect.TypeOf((*MockSystemdSidecar)(nil).UnitDiff))
+}
+
 // Update mocks base method.
 func (m *MockSystemdSidecar) Update() error {
 	m.ctrl.T.Helper()
@@ -0,0 +1,127 @@
+package sidecar
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ethpandaops/contributoor-installer/internal/supervisor"
+)
+
+var (
+	// processPollInterval is how often a stopping process is checked for exit.
+	processPollInterval = 100 * time.Millisecond
+
+	// processKillTimeout is how long a process has to disappear after SIGKILL.
+	processKillTimeout = 5 * time.Second
+)
+
+// isSentryProcess reports whether the process with the given PID is running one of the
+// sentry binaries installed in binaryDir. It guards against signalling an unrelated
+// process that has been given a stale PID.
+func isSentryProcess(pid int, binaryDir string) (bool, error) {
+	exe, err := processExecutable(pid)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to identify process %d: %w", pid, err)
+	}
+
+	// The kernel reports the resolved path, so resolve ours too, eg: /tmp on macOS.
+	if resolved, rerr := filepath.EvalSymlinks(binaryDir); rerr == nil {
+		binaryDir = resolved
+	}
+
+	// Every installed version is named sentry-<version>, and a binary that's been
+	// replaced since the process started is reported with a " (deleted)" suffix.
+	return strings.HasPrefix(exe, filepath.Join(binaryDir, "sentry")), nil
+}
+
+// processExecutable returns the path of the executable the process is running. It
+// returns an error wrapping os.ErrNotExist if there's no such process.
+func processExecutable(pid int) (string, error) {
+	procDir := fmt.Sprintf("/proc/%d", pid)
+
+	if _, err := os.Stat("/proc/self"); err == nil {
+		exe, err := os.Readlink(filepath.Join(procDir, "exe"))
+		if err == nil {
+			return exe, nil
+		}
+
+		if errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+
+		// The exe link isn't readable for processes owned by other users, but the
+		// command line is.
+		cmdline, cerr := os.ReadFile(filepath.Join(procDir, "cmdline"))
+		if cerr != nil {
+			return "", cerr
+		}
+
+		exe, _, _ = strings.Cut(string(cmdline), "\x00")
+
+		return exe, nil
+	}
+
+	// There's no procfs on macOS, ask ps instead. It fails if there's no such process.
+	//nolint:gosec // pid is an int.
+	out, err := exec.Command("ps", "-p", strconv.Itoa(pid), "-o", "comm=").Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", os.ErrNotExist, err)
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// stopProcess stops the process with the given PID. It's sent SIGTERM, then SIGKILL if
+// it hasn't exited within the grace period. It returns once the process has exited.
+func stopProcess(pid int, gracePeriod time.Duration) (supervisor.StopOutcome, error) {
+	if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+		if errors.Is(err, syscall.ESRCH) {
+			return supervisor.StopOutcomeGraceful, nil
+		}
+
+		return "", fmt.Errorf("failed to send SIGTERM to process %d: %w", pid, err)
+	}
+
+	if waitForProcessExit(pid, gracePeriod) {
+		return supervisor.StopOutcomeGraceful, nil
+	}
+
+	if err := syscall.Kill(pid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return "", fmt.Errorf("failed to send SIGKILL to process %d: %w", pid, err)
+	}
+
+	if !waitForProcessExit(pid, processKillTimeout) {
+		return "", fmt.Errorf("process %d didn't exit after SIGKILL", pid)
+	}
+
+	return supervisor.StopOutcomeKilled, nil
+}
+
+// waitForProcessExit waits up to timeout for the process to exit, reporting whether it did.
+func waitForProcessExit(pid int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		// Signal 0 only checks the process exists.
+		if err := syscall.Kill(pid, 0); errors.Is(err, syscall.ESRCH) {
+			return true
+		}
+
+		if time.Now().After(deadline) {
+			return false
+		}
+
+		time.Sleep(processPollInterval)
+	}
+}
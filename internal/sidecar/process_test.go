@@ -0,0 +1,229 @@
+package sidecar
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/mock"
+	"github.com/ethpandaops/contributoor-installer/internal/supervisor"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/mitchellh/go-homedir"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// startUnsupervisedSentry starts a stand-in for a sentry binary started by a version of
+// the CLI that pre-dates the supervisor, and records its PID file. The stand-in is a copy
+// of the named command, installed alongside sentry so it passes as one. The returned
+// channel is closed once it has exited.
+func startUnsupervisedSentry(t *testing.T, dir, name string, args ...string) (int, <-chan struct{}) {
+	t.Helper()
+
+	path, err := exec.LookPath(name)
+	require.NoError(t, err)
+
+	src, err := os.Open(path)
+	require.NoError(t, err)
+
+	defer src.Close()
+
+	binaryPath := filepath.Join(dir, "bin", "sentry-unsupervised")
+	require.NoError(t, os.MkdirAll(filepath.Dir(binaryPath), 0755))
+
+	dst, err := os.OpenFile(binaryPath, os.O_CREATE|os.O_WRONLY, 0700)
+	require.NoError(t, err)
+
+	_, err = io.Copy(dst, src)
+	require.NoError(t, err)
+	require.NoError(t, dst.Close())
+
+	// Multi-call binaries, such as busybox, decide what to run from their name.
+	cmd := exec.Command(binaryPath, args...)
+	cmd.Args[0] = name
+
+	require.NoError(t, cmd.Start())
+
+	exited := make(chan struct{})
+
+	go func() {
+		defer close(exited)
+
+		_ = cmd.Wait()
+	}()
+
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		<-exited
+	})
+
+	pid := cmd.Process.Pid
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "contributoor.pid"), []byte(strconv.Itoa(pid)), 0600))
+
+	return pid, exited
+}
+
+func TestIsSentryProcess(t *testing.T) {
+	dir := t.TempDir()
+	pid, exited := startUnsupervisedSentry(t, dir, "sleep", "60")
+
+	isSentry, err := isSentryProcess(pid, filepath.Join(dir, "bin"))
+	require.NoError(t, err)
+	assert.True(t, isSentry)
+
+	// Our own process isn't sentry.
+	isSentry, err = isSentryProcess(os.Getpid(), filepath.Join(dir, "bin"))
+	require.NoError(t, err)
+	assert.False(t, isSentry)
+
+	require.NoError(t, syscall.Kill(pid, syscall.SIGKILL))
+	<-exited
+
+	isSentry, err = isSentryProcess(pid, filepath.Join(dir, "bin"))
+	require.NoError(t, err)
+	assert.False(t, isSentry)
+}
+
+func TestBinarySidecar_StopUnsupervised(t *testing.T) {
+	original := processPollInterval
+	processPollInterval = 10 * time.Millisecond
+
+	t.Cleanup(func() {
+		processPollInterval = original
+	})
+
+	setup := func(t *testing.T) (string, BinarySidecar) {
+		t.Helper()
+
+		dir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "logs"), 0755))
+
+		ctrl := gomock.NewController(t)
+
+		cfg := mock.NewMockConfigManager(ctrl)
+		cfg.EXPECT().Get().Return(&config.Config{
+			ContributoorDirectory: dir,
+			RunMethod:             config.RunMethod_RUN_METHOD_BINARY,
+		}).AnyTimes()
+		cfg.EXPECT().GetConfigPath().Return(filepath.Join(dir, "config.yaml")).AnyTimes()
+
+		installerCfg := installer.NewConfig()
+		installerCfg.StopGracePeriod = 200 * time.Millisecond
+
+		bs, err := NewBinarySidecar(logrus.New(), cfg, installerCfg)
+		require.NoError(t, err)
+
+		return dir, bs
+	}
+
+	t.Run("exits gracefully", func(t *testing.T) {
+		dir, bs := setup(t)
+		_, exited := startUnsupervisedSentry(t, dir, "sleep", "60")
+
+		running, err := bs.IsRunning()
+		require.NoError(t, err)
+		assert.True(t, running)
+
+		outcome, err := bs.(*binarySidecar).stopUnsupervised()
+		require.NoError(t, err)
+		assert.Equal(t, supervisor.StopOutcomeGraceful, outcome)
+		assert.NoFileExists(t, filepath.Join(dir, "contributoor.pid"))
+
+		// Stop only returns once the process has gone, though our reaper may lag behind.
+		select {
+		case <-exited:
+		case <-time.After(time.Second):
+			t.Fatal("process is still running")
+		}
+	})
+
+	t.Run("killed after the grace period", func(t *testing.T) {
+		dir, bs := setup(t)
+		_, exited := startUnsupervisedSentry(t, dir, "sh", "-c", `trap "" TERM; while true; do sleep 0.01; done`)
+
+		// Give the shell a moment to install its trap.
+		time.Sleep(100 * time.Millisecond)
+
+		start := time.Now()
+
+		outcome, err := bs.(*binarySidecar).stopUnsupervised()
+		require.NoError(t, err)
+		assert.Equal(t, supervisor.StopOutcomeKilled, outcome)
+		assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+
+		select {
+		case <-exited:
+		case <-time.After(time.Second):
+			t.Fatal("process is still running")
+		}
+	})
+
+	t.Run("installed under the home directory", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+
+		homedir.DisableCache = true
+		defer func() { homedir.DisableCache = false }()
+
+		dir := filepath.Join(home, ".contributoor")
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "logs"), 0755))
+
+		ctrl := gomock.NewController(t)
+
+		cfg := mock.NewMockConfigManager(ctrl)
+		cfg.EXPECT().Get().Return(&config.Config{
+			ContributoorDirectory: "~/.contributoor",
+			RunMethod:             config.RunMethod_RUN_METHOD_BINARY,
+		}).AnyTimes()
+		cfg.EXPECT().GetConfigPath().Return(filepath.Join(dir, "config.yaml")).AnyTimes()
+
+		installerCfg := installer.NewConfig()
+		installerCfg.StopGracePeriod = 200 * time.Millisecond
+
+		bs, err := NewBinarySidecar(logrus.New(), cfg, installerCfg)
+		require.NoError(t, err)
+
+		_, exited := startUnsupervisedSentry(t, dir, "sleep", "60")
+
+		running, err := bs.IsRunning()
+		require.NoError(t, err)
+		assert.True(t, running)
+
+		outcome, err := bs.(*binarySidecar).stopUnsupervised()
+		require.NoError(t, err)
+		assert.Equal(t, supervisor.StopOutcomeGraceful, outcome)
+		assert.NoFileExists(t, filepath.Join(dir, "contributoor.pid"))
+
+		select {
+		case <-exited:
+		case <-time.After(time.Second):
+			t.Fatal("process is still running")
+		}
+	})
+
+	t.Run("reused PID is left alone", func(t *testing.T) {
+		dir, bs := setup(t)
+
+		// Our own PID, as though it had been reused since sentry exited.
+		pidFile := filepath.Join(dir, "contributoor.pid")
+		require.NoError(t, os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())), 0600))
+
+		running, err := bs.IsRunning()
+		require.NoError(t, err)
+		assert.False(t, running)
+		assert.NoFileExists(t, pidFile)
+
+		require.NoError(t, os.WriteFile(pidFile, []byte(strconv.Itoa(os.Getpid())), 0600))
+
+		_, err = bs.(*binarySidecar).stopUnsupervised()
+		assert.ErrorContains(t, err, "contributoor is not running")
+	})
+}
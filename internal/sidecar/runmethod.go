@@ -0,0 +1,298 @@
+package sidecar
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+	"github.com/ethpandaops/contributoor-installer/internal/tui"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+)
+
+// Runners holds the runner for each run method.
+type Runners struct {
+	Docker  DockerSidecar
+	Systemd SystemdSidecar
+	Binary  BinarySidecar
+}
+
+// For returns the runner for the given run method.
+func (r *Runners) For(method config.RunMethod) (SidecarRunner, error) {
+	switch method {
+	case config.RunMethod_RUN_METHOD_DOCKER:
+		return r.Docker, nil
+	case config.RunMethod_RUN_METHOD_SYSTEMD:
+		return r.Systemd, nil
+	case config.RunMethod_RUN_METHOD_BINARY:
+		return r.Binary, nil
+	default:
+		return nil, fmt.Errorf("invalid sidecar run method: %s", method)
+	}
+}
+
+// runMethodSwitch moves contributoor from one run method to another, keeping track of
+// how to undo each step.
+type runMethodSwitch struct {
+	w          io.Writer
+	sidecarCfg ConfigManager
+	runners    *Runners
+	from, to   config.RunMethod
+
+	// undo holds the steps that undo what's been done so far, in the order they were done.
+	undo []func() error
+}
+
+// SwitchRunMethod moves contributoor from one run method to another. It provisions the
+// new run method (pulling the image, downloading the binary or installing the unit),
+// stops the old one, saves the new run method to the config and starts it. Once it's
+// running, the old run method is cleaned up. If any step fails, the steps before it are
+// undone, leaving contributoor on the old run method, and running if it was.
+func SwitchRunMethod(w io.Writer, sidecarCfg ConfigManager, runners *Runners, from, to config.RunMethod) error {
+	if from == to {
+		return fmt.Errorf("already using the %s run method", runMethodName(to))
+	}
+
+	oldRunner, err := runners.For(from)
+	if err != nil {
+		return err
+	}
+
+	newRunner, err := runners.For(to)
+	if err != nil {
+		return err
+	}
+
+	s := &runMethodSwitch{
+		w:          w,
+		sidecarCfg: sidecarCfg,
+		runners:    runners,
+		from:       from,
+		to:         to,
+	}
+
+	running, err := oldRunner.IsRunning()
+	if err != nil {
+		return fmt.Errorf("failed to check if %s is running: %w", runMethodName(from), err)
+	}
+
+	if err := s.run(oldRunner, newRunner, running); err != nil {
+		return s.rollback(err)
+	}
+
+	// The new run method is up, so there's no going back. Failing to clean up only
+	// leaves something unused behind.
+	if err := s.cleanUp(); err != nil {
+		fmt.Fprintf(w, "%sFailed to clean up %s: %v%s\n", tui.TerminalColorYellow, runMethodName(from), err, tui.TerminalColorReset)
+	}
+
+	fmt.Fprintf(w, "%sSwitched from %s to %s%s\n", tui.TerminalColorGreen, runMethodName(from), runMethodName(to), tui.TerminalColorReset)
+
+	return nil
+}
+
+// run does each step of the switch, recording how to undo it.
+func (s *runMethodSwitch) run(oldRunner, newRunner SidecarRunner, running bool) error {
+	fmt.Fprintf(s.w, "Provisioning %s\n", runMethodName(s.to))
+
+	if err := s.provision(); err != nil {
+		return fmt.Errorf("failed to provision %s: %w", runMethodName(s.to), err)
+	}
+
+	if running {
+		fmt.Fprintf(s.w, "Stopping %s\n", runMethodName(s.from))
+
+		if err := oldRunner.Stop(); err != nil {
+			return fmt.Errorf("failed to stop %s: %w", runMethodName(s.from), err)
+		}
+
+		s.undo = append(s.undo, oldRunner.Start)
+	}
+
+	if err := s.setRunMethod(s.to); err != nil {
+		return err
+	}
+
+	s.undo = append(s.undo, func() error {
+		return s.setRunMethod(s.from)
+	})
+
+	fmt.Fprintf(s.w, "Starting %s\n", runMethodName(s.to))
+
+	// A runner may be left half started, eg: a container that keeps restarting.
+	s.undo = append(s.undo, func() error {
+		if running, _ := newRunner.IsRunning(); running {
+			return newRunner.Stop()
+		}
+
+		return nil
+	})
+
+	if err := newRunner.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", runMethodName(s.to), err)
+	}
+
+	started, err := newRunner.IsRunning()
+	if err != nil {
+		return fmt.Errorf("failed to check if %s is running: %w", runMethodName(s.to), err)
+	}
+
+	if !started {
+		return fmt.Errorf("%s isn't running after it was started", runMethodName(s.to))
+	}
+
+	return nil
+}
+
+// provision installs what the new run method needs to run.
+func (s *runMethodSwitch) provision() error {
+	switch s.to {
+	case config.RunMethod_RUN_METHOD_DOCKER:
+		return s.runners.Docker.Update()
+	case config.RunMethod_RUN_METHOD_BINARY:
+		return s.provisionBinary()
+	case config.RunMethod_RUN_METHOD_SYSTEMD:
+		// systemd runs the binary.
+		if err := s.provisionBinary(); err != nil {
+			return err
+		}
+
+		// launchd users install the plist themselves.
+		if runtime.GOOS == ArchDarwin {
+			return nil
+		}
+
+		change, err := s.runners.Systemd.InstallUnit()
+		if err != nil {
+			return err
+		}
+
+		// A unit we created is enabled, so it mustn't outlive a failed switch.
+		if change == runner.UnitCreated {
+			s.undo = append(s.undo, s.runners.Systemd.UninstallUnit)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("invalid sidecar run method: %s", s.to)
+	}
+}
+
+// provisionBinary downloads and verifies the binary, unless it's already installed.
+func (s *runMethodSwitch) provisionBinary() error {
+	details, err := s.runners.Binary.Details()
+	if err != nil {
+		return fmt.Errorf("failed to check for the binary: %w", err)
+	}
+
+	if details.Installed {
+		return nil
+	}
+
+	return s.runners.Binary.Update()
+}
+
+// setRunMethod saves the run method to the config.
+func (s *runMethodSwitch) setRunMethod(method config.RunMethod) error {
+	if err := s.sidecarCfg.Update(func(cfg *config.Config) {
+		cfg.RunMethod = method
+	}); err != nil {
+		return fmt.Errorf("failed to save run method: %w", err)
+	}
+
+	return nil
+}
+
+// cleanUp removes what only the old run method used. Docker's stopped container is
+// removed, keeping its image, and a systemd unit would start again on boot. The binary
+// may still be used by systemd, so it's kept.
+func (s *runMethodSwitch) cleanUp() error {
+	switch {
+	case s.from == config.RunMethod_RUN_METHOD_DOCKER:
+		return s.runners.Docker.Down()
+	case s.from == config.RunMethod_RUN_METHOD_SYSTEMD && runtime.GOOS != ArchDarwin:
+		return s.runners.Systemd.UninstallUnit()
+	default:
+		return nil
+	}
+}
+
+// rollback undoes the steps done so far, most recent first, and returns err along with
+// any failures to undo them.
+func (s *runMethodSwitch) rollback(err error) error {
+	fmt.Fprintf(s.w, "%s%v, rolling back to %s%s\n", tui.TerminalColorYellow, err, runMethodName(s.from), tui.TerminalColorReset)
+
+	var undoErrs []error
+
+	for i := len(s.undo) - 1; i >= 0; i-- {
+		if uerr := s.undo[i](); uerr != nil {
+			undoErrs = append(undoErrs, uerr)
+		}
+	}
+
+	if len(undoErrs) > 0 {
+		return fmt.Errorf("%w, and rolling back failed: %w", err, errors.Join(undoErrs...))
+	}
+
+	return err
+}
+
+// SwitchScope moves the systemd unit to another scope, recorded in the installer state.
+// The unit of the old scope is stopped, disabled and removed first, as nothing would
+// manage it once the scope is forgotten. If that fails, eg: without root for a system
+// unit, the switch is refused and the old unit is left as it was. The caller installs
+// the unit of the new scope.
+func SwitchScope(w io.Writer, sidecarCfg ConfigManager, systemd SystemdSidecar, to installer.SystemdScope) error {
+	state, err := installer.LoadState(filepath.Dir(sidecarCfg.GetConfigPath()))
+	if err != nil {
+		return err
+	}
+
+	from := state.GetSystemdScope()
+	if from == to {
+		return nil
+	}
+
+	running, err := systemd.IsRunning()
+	if err != nil {
+		return fmt.Errorf("failed to check if the %s unit is running: %w", from, err)
+	}
+
+	if running {
+		fmt.Fprintf(w, "Stopping the %s unit\n", from)
+
+		if err := systemd.Stop(); err != nil {
+			return fmt.Errorf("failed to stop the %s unit, so it's kept: %w", from, err)
+		}
+	}
+
+	if err := systemd.UninstallUnit(); err != nil {
+		// Leave the old unit as we found it.
+		if running {
+			if serr := systemd.Start(); serr != nil {
+				err = fmt.Errorf("%w, and restarting it failed: %w", err, serr)
+			}
+		}
+
+		return fmt.Errorf("failed to remove the %s unit, so it's kept: %w", from, err)
+	}
+
+	state.SystemdScope = to
+
+	if err := state.Save(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Switched from a %s unit to a %s unit, the %s unit was removed\n", from, to, from)
+
+	return nil
+}
+
+// runMethodName returns the name of a run method, as used in the config, eg: docker.
+func runMethodName(method config.RunMethod) string {
+	return strings.ToLower(method.DisplayName())
+}
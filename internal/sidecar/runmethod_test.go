@@ -0,0 +1,316 @@
+package sidecar
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/mock"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSwitchRunMethod(t *testing.T) {
+	type mocks struct {
+		docker  *mock.MockDockerSidecar
+		systemd *mock.MockSystemdSidecar
+		binary  *mock.MockBinarySidecar
+	}
+
+	tests := []struct {
+		name              string
+		from, to          config.RunMethod
+		setupMocks        func(m *mocks)
+		expectedRunMethod config.RunMethod
+		expectedOutput    string
+		expectedError     string
+	}{
+		{
+			name: "docker to systemd removes the container",
+			from: config.RunMethod_RUN_METHOD_DOCKER,
+			to:   config.RunMethod_RUN_METHOD_SYSTEMD,
+			setupMocks: func(m *mocks) {
+				gomock.InOrder(
+					m.docker.EXPECT().IsRunning().Return(true, nil),
+					m.binary.EXPECT().Details().Return(&runner.Details{}, nil),
+					m.binary.EXPECT().Update().Return(nil),
+					m.systemd.EXPECT().InstallUnit().Return(runner.UnitCreated, nil),
+					m.docker.EXPECT().Stop().Return(nil),
+					m.systemd.EXPECT().Start().Return(nil),
+					m.systemd.EXPECT().IsRunning().Return(true, nil),
+					m.docker.EXPECT().Down().Return(nil),
+				)
+			},
+			expectedRunMethod: config.RunMethod_RUN_METHOD_SYSTEMD,
+			expectedOutput:    "Switched from docker to systemd",
+		},
+		{
+			name: "systemd to docker removes the unit",
+			from: config.RunMethod_RUN_METHOD_SYSTEMD,
+			to:   config.RunMethod_RUN_METHOD_DOCKER,
+			setupMocks: func(m *mocks) {
+				gomock.InOrder(
+					m.systemd.EXPECT().IsRunning().Return(true, nil),
+					m.docker.EXPECT().Update().Return(nil),
+					m.systemd.EXPECT().Stop().Return(nil),
+					m.docker.EXPECT().Start().Return(nil),
+					m.docker.EXPECT().IsRunning().Return(true, nil),
+					m.systemd.EXPECT().UninstallUnit().Return(nil),
+				)
+			},
+			expectedRunMethod: config.RunMethod_RUN_METHOD_DOCKER,
+			expectedOutput:    "Switched from systemd to docker",
+		},
+		{
+			name: "stopped binary to docker is started",
+			from: config.RunMethod_RUN_METHOD_BINARY,
+			to:   config.RunMethod_RUN_METHOD_DOCKER,
+			setupMocks: func(m *mocks) {
+				gomock.InOrder(
+					m.binary.EXPECT().IsRunning().Return(false, nil),
+					m.docker.EXPECT().Update().Return(nil),
+					m.docker.EXPECT().Start().Return(nil),
+					m.docker.EXPECT().IsRunning().Return(true, nil),
+				)
+			},
+			expectedRunMethod: config.RunMethod_RUN_METHOD_DOCKER,
+			expectedOutput:    "Switched from binary to docker",
+		},
+		{
+			name: "failing to clean up still switches",
+			from: config.RunMethod_RUN_METHOD_SYSTEMD,
+			to:   config.RunMethod_RUN_METHOD_BINARY,
+			setupMocks: func(m *mocks) {
+				gomock.InOrder(
+					m.systemd.EXPECT().IsRunning().Return(false, nil),
+					m.binary.EXPECT().Details().Return(&runner.Details{Installed: true}, nil),
+					m.binary.EXPECT().Start().Return(nil),
+					m.binary.EXPECT().IsRunning().Return(true, nil),
+					m.systemd.EXPECT().UninstallUnit().Return(errors.New("not authorized to disable contributoor.service")),
+				)
+			},
+			expectedRunMethod: config.RunMethod_RUN_METHOD_BINARY,
+			expectedOutput:    "Failed to clean up systemd: not authorized to disable contributoor.service",
+		},
+		{
+			name: "provisioning fails",
+			from: config.RunMethod_RUN_METHOD_BINARY,
+			to:   config.RunMethod_RUN_METHOD_DOCKER,
+			setupMocks: func(m *mocks) {
+				m.binary.EXPECT().IsRunning().Return(true, nil)
+				m.docker.EXPECT().Update().Return(errors.New("pull access denied"))
+			},
+			expectedRunMethod: config.RunMethod_RUN_METHOD_BINARY,
+			expectedError:     "failed to provision docker: pull access denied",
+		},
+		{
+			name: "start fails and rolls back",
+			from: config.RunMethod_RUN_METHOD_DOCKER,
+			to:   config.RunMethod_RUN_METHOD_SYSTEMD,
+			setupMocks: func(m *mocks) {
+				gomock.InOrder(
+					m.docker.EXPECT().IsRunning().Return(true, nil),
+					m.binary.EXPECT().Details().Return(&runner.Details{Installed: true}, nil),
+					m.systemd.EXPECT().InstallUnit().Return(runner.UnitCreated, nil),
+					m.docker.EXPECT().Stop().Return(nil),
+					m.systemd.EXPECT().Start().Return(errors.New("job failed")),
+					m.systemd.EXPECT().IsRunning().Return(false, nil),
+					m.docker.EXPECT().Start().Return(nil),
+					m.systemd.EXPECT().UninstallUnit().Return(nil),
+				)
+			},
+			expectedRunMethod: config.RunMethod_RUN_METHOD_DOCKER,
+			expectedOutput:    "rolling back to docker",
+			expectedError:     "failed to start systemd: job failed",
+		},
+		{
+			name: "not running after start",
+			from: config.RunMethod_RUN_METHOD_SYSTEMD,
+			to:   config.RunMethod_RUN_METHOD_DOCKER,
+			setupMocks: func(m *mocks) {
+				gomock.InOrder(
+					m.systemd.EXPECT().IsRunning().Return(false, nil),
+					m.docker.EXPECT().Update().Return(nil),
+					m.docker.EXPECT().Start().Return(nil),
+					m.docker.EXPECT().IsRunning().Return(false, nil),
+					m.docker.EXPECT().IsRunning().Return(false, nil),
+				)
+			},
+			expectedRunMethod: config.RunMethod_RUN_METHOD_SYSTEMD,
+			expectedError:     "docker isn't running after it was started",
+		},
+		{
+			name: "rolling back fails",
+			from: config.RunMethod_RUN_METHOD_BINARY,
+			to:   config.RunMethod_RUN_METHOD_DOCKER,
+			setupMocks: func(m *mocks) {
+				gomock.InOrder(
+					m.binary.EXPECT().IsRunning().Return(true, nil),
+					m.docker.EXPECT().Update().Return(nil),
+					m.binary.EXPECT().Stop().Return(nil),
+					m.docker.EXPECT().Start().Return(errors.New("port is already allocated")),
+					m.docker.EXPECT().IsRunning().Return(false, nil),
+					m.binary.EXPECT().Start().Return(errors.New("binary not found")),
+				)
+			},
+			expectedRunMethod: config.RunMethod_RUN_METHOD_BINARY,
+			expectedError:     "failed to start docker: port is already allocated, and rolling back failed: binary not found",
+		},
+		{
+			name:              "same run method",
+			from:              config.RunMethod_RUN_METHOD_DOCKER,
+			to:                config.RunMethod_RUN_METHOD_DOCKER,
+			expectedRunMethod: config.RunMethod_RUN_METHOD_DOCKER,
+			expectedError:     "already using the docker run method",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := &mocks{
+				docker:  mock.NewMockDockerSidecar(ctrl),
+				systemd: mock.NewMockSystemdSidecar(ctrl),
+				binary:  mock.NewMockBinarySidecar(ctrl),
+			}
+
+			if tt.setupMocks != nil {
+				tt.setupMocks(m)
+			}
+
+			cfg := &config.Config{RunMethod: tt.from}
+
+			mockConfig := mock.NewMockConfigManager(ctrl)
+			mockConfig.EXPECT().Update(gomock.Any()).DoAndReturn(func(fn func(*config.Config)) error {
+				fn(cfg)
+
+				return nil
+			}).AnyTimes()
+
+			var out bytes.Buffer
+
+			err := SwitchRunMethod(&out, mockConfig, &Runners{
+				Docker:  m.docker,
+				Systemd: m.systemd,
+				Binary:  m.binary,
+			}, tt.from, tt.to)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Contains(t, out.String(), tt.expectedOutput)
+			assert.Equal(t, tt.expectedRunMethod, cfg.RunMethod)
+		})
+	}
+}
+
+func TestSwitchScope(t *testing.T) {
+	tests := []struct {
+		name           string
+		to             installer.SystemdScope
+		setupMocks     func(s *mock.MockSystemdSidecar)
+		expectedScope  installer.SystemdScope
+		expectedOutput string
+		expectedError  string
+	}{
+		{
+			name: "removes the stopped unit",
+			to:   installer.SystemdScopeUser,
+			setupMocks: func(s *mock.MockSystemdSidecar) {
+				gomock.InOrder(
+					s.EXPECT().IsRunning().Return(false, nil),
+					s.EXPECT().UninstallUnit().Return(nil),
+				)
+			},
+			expectedScope:  installer.SystemdScopeUser,
+			expectedOutput: "Switched from a system unit to a user unit, the system unit was removed",
+		},
+		{
+			name: "stops the running unit first",
+			to:   installer.SystemdScopeUser,
+			setupMocks: func(s *mock.MockSystemdSidecar) {
+				gomock.InOrder(
+					s.EXPECT().IsRunning().Return(true, nil),
+					s.EXPECT().Stop().Return(nil),
+					s.EXPECT().UninstallUnit().Return(nil),
+				)
+			},
+			expectedScope:  installer.SystemdScopeUser,
+			expectedOutput: "Stopping the system unit",
+		},
+		{
+			name: "refuses when the unit can't be stopped",
+			to:   installer.SystemdScopeUser,
+			setupMocks: func(s *mock.MockSystemdSidecar) {
+				gomock.InOrder(
+					s.EXPECT().IsRunning().Return(true, nil),
+					s.EXPECT().Stop().Return(errors.New("interactive authentication required")),
+				)
+			},
+			expectedScope: installer.SystemdScopeSystem,
+			expectedError: "failed to stop the system unit, so it's kept: interactive authentication required",
+		},
+		{
+			name: "refuses and restarts when the unit can't be removed",
+			to:   installer.SystemdScopeUser,
+			setupMocks: func(s *mock.MockSystemdSidecar) {
+				gomock.InOrder(
+					s.EXPECT().IsRunning().Return(true, nil),
+					s.EXPECT().Stop().Return(nil),
+					s.EXPECT().UninstallUnit().Return(errors.New("permission denied")),
+					s.EXPECT().Start().Return(nil),
+				)
+			},
+			expectedScope: installer.SystemdScopeSystem,
+			expectedError: "failed to remove the system unit, so it's kept: permission denied",
+		},
+		{
+			name:          "same scope",
+			to:            installer.SystemdScopeSystem,
+			expectedScope: installer.SystemdScopeSystem,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			configDir := t.TempDir()
+
+			mockConfig := mock.NewMockConfigManager(ctrl)
+			mockConfig.EXPECT().GetConfigPath().Return(filepath.Join(configDir, "config.yaml")).AnyTimes()
+
+			mockSystemd := mock.NewMockSystemdSidecar(ctrl)
+			if tt.setupMocks != nil {
+				tt.setupMocks(mockSystemd)
+			}
+
+			var out bytes.Buffer
+
+			err := SwitchScope(&out, mockConfig, mockSystemd, tt.to)
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Contains(t, out.String(), tt.expectedOutput)
+
+			state, err := installer.LoadState(configDir)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedScope, state.GetSystemdScope())
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"fmt"
+
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+)
+
+// ContainerRuntime is the engine the docker run method runs the container under, as
+// detected through its API.
+type ContainerRuntime struct {
+	// Name is docker or podman.
+	Name installer.ContainerRuntime
+
+	// Version is the engine's version, eg: 5.2.2.
+	Version string
+
+	// Host is the address of its API, eg: unix:///run/user/1000/podman/podman.sock.
+	Host string
+
+	// Rootless is true if the engine runs without root, eg: podman run by a user.
+	Rootless bool
+}
+
+// String describes the runtime, eg: podman 5.2.2 (rootless).
+func (r *ContainerRuntime) String() string {
+	s := fmt.Sprintf("%s %s", r.Name, r.Version)
+	if r.Rootless {
+		s += " (rootless)"
+	}
+
+	return s
+}
+
+// DefinitionFormat is a format the container's definition can be exported in, to run
+// it without the installer.
+type DefinitionFormat string
+
+const (
+	// DefinitionCompose is a compose file, for docker compose or podman-compose.
+	DefinitionCompose DefinitionFormat = "compose"
+
+	// DefinitionQuadlet is a quadlet .container file, for podman run by systemd.
+	DefinitionQuadlet DefinitionFormat = "quadlet"
+)
+
+// ParseDefinitionFormat parses a definition format, eg: quadlet.
+func ParseDefinitionFormat(format string) (DefinitionFormat, error) {
+	switch DefinitionFormat(format) {
+	case DefinitionCompose, DefinitionQuadlet:
+		return DefinitionFormat(format), nil
+	default:
+		return "", fmt.Errorf("invalid definition format %q, expected %s or %s", format, DefinitionCompose, DefinitionQuadlet)
+	}
+}
@@ -0,0 +1,50 @@
+// Package runner holds types shared by the sidecar runners and their consumers. They
+// live outside the sidecar package so the generated mocks can use them, without
+// creating an import cycle for the sidecar package's own tests.
+package runner
+
+// Details holds run method specific details about the service. Fields that don't apply
+// to the run method in use are left empty.
+type Details struct {
+	// Installed is false if the service hasn't been installed for the run method.
+	Installed bool
+
+	// PID is the process ID of the binary, or the launchd managed process.
+	PID int
+
+	// Restarts is the number of times the binary's supervisor, or systemd, has
+	// restarted it.
+	Restarts int
+
+	// LastExitCode is the exit code of the last time the binary exited under its
+	// supervisor or systemd, or nil if it hasn't.
+	LastExitCode *int
+
+	// ContainerID is the ID of the docker container.
+	ContainerID string
+
+	// ContainerHealth is the health of the docker container, eg: healthy, or empty if
+	// its image has no healthcheck.
+	ContainerHealth string
+
+	// ImageDigest is the content digest of the docker image, eg: sha256:abc...
+	ImageDigest string
+
+	// PinnedDigest is the digest the configured version's docker image is pinned to,
+	// eg: sha256:abc..., or empty if it isn't pinned yet.
+	PinnedDigest string
+
+	// ContainerRuntime describes the engine running the container, eg: podman 5.2.2
+	// (rootless).
+	ContainerRuntime string
+
+	// UnitScope is the scope of the systemd unit, system or user.
+	UnitScope string
+
+	// UnitState is the active state of the systemd unit, eg: active or failed.
+	UnitState string
+
+	// UnitSubState is the sub state of the systemd unit, which says more about the
+	// active state, eg: running or exit-code.
+	UnitSubState string
+}
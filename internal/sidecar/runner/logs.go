@@ -0,0 +1,15 @@
+package runner
+
+import "time"
+
+// LogOptions controls which logs a runner returns.
+type LogOptions struct {
+	// Follow keeps streaming new logs until cancelled.
+	Follow bool
+
+	// Tail is the number of lines to return from the end of the logs, or 0 for all.
+	Tail int
+
+	// Since excludes logs from before the given time, unless it's zero.
+	Since time.Time
+}
@@ -0,0 +1,15 @@
+package runner
+
+// UnitChange is what installing the systemd unit did to the unit file.
+type UnitChange string
+
+const (
+	// UnitCreated means there was no unit file, so it was created and enabled.
+	UnitCreated UnitChange = "created"
+
+	// UnitUpdated means the unit file differed from the config, so it was replaced.
+	UnitUpdated UnitChange = "updated"
+
+	// UnitUnchanged means the unit file already matched the config.
+	UnitUnchanged UnitChange = "unchanged"
+)
@@ -1,5 +1,12 @@
 package sidecar
 
+import (
+	"context"
+	"io"
+
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+)
+
 // RunMethods defines the possible ways to run the contributoor service.
 const (
 	RunMethodDocker  = "docker"
@@ -23,6 +30,16 @@ type SidecarRunner interface {
 	// Update updates the service.
 	Update() error
 
+	// Rollback switches the service back to a previously installed version.
+	Rollback(version string) error
+
 	// IsRunning checks if the service is running.
 	IsRunning() (bool, error)
+
+	// Details returns run method specific details about the service.
+	Details() (*runner.Details, error)
+
+	// Logs writes the service's logs to w. With opts.Follow, it keeps writing new logs
+	// until ctx is cancelled.
+	Logs(ctx context.Context, opts runner.LogOptions, w io.Writer) error
 }
@@ -1,20 +1,44 @@
 package sidecar
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"os/exec"
+	"path/filepath"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
 	"github.com/ethpandaops/contributoor-installer/internal/tui"
+	"github.com/mitchellh/go-homedir"
 	"github.com/sirupsen/logrus"
 )
 
+// launchdPIDPattern matches the PID in the output of `launchctl list <label>`.
+var launchdPIDPattern = regexp.MustCompile(`"PID"\s*=\s*(\d+);`)
+
 //go:generate mockgen -package mock -destination mock/systemd.mock.go github.com/ethpandaops/contributoor-installer/internal/sidecar SystemdSidecar
 
 type SystemdSidecar interface {
 	SidecarRunner
+
+	// UnitDiff returns a unified diff from the installed unit file to the one generated
+	// from the config. It's empty if they match.
+	UnitDiff() (string, error)
+
+	// InstallUnit writes the unit file generated from the config, unless it's already up
+	// to date, and reloads systemd. A new unit is enabled too, but not started.
+	InstallUnit() (runner.UnitChange, error)
+
+	// UninstallUnit disables and removes the unit file, if it's installed, and reloads
+	// systemd. The unit should be stopped first.
+	UninstallUnit() error
 }
 
 // systemdSidecar is a service for managing the contributoor service (systemd on Linux, launchd on macOS).
@@ -60,6 +84,30 @@ func (s *systemdSidecar) IsRunning() (bool, error) {
 	return s.isRunningSystemd()
 }
 
+// Details returns the state of the service, and the PID of its process.
+func (s *systemdSidecar) Details() (*runner.Details, error) {
+	if runtime.GOOS == ArchDarwin {
+		if err := s.checkDaemonExists(); err != nil {
+			//nolint:nilerr // A missing service means it isn't installed.
+			return &runner.Details{}, nil
+		}
+
+		return s.detailsLaunchd()
+	}
+
+	return s.detailsSystemd()
+}
+
+// Logs writes the service's logs to w. On Linux they're read from journald, and on macOS
+// from the files launchd redirects the service's output to.
+func (s *systemdSidecar) Logs(ctx context.Context, opts runner.LogOptions, w io.Writer) error {
+	if runtime.GOOS == ArchDarwin {
+		return s.logsLaunchd(ctx, opts, w)
+	}
+
+	return s.logsSystemd(ctx, opts, w)
+}
+
 // Update updates the service.
 func (s *systemdSidecar) Update() error {
 	// Stop service if running
@@ -87,60 +135,202 @@ func (s *systemdSidecar) Update() error {
 	return s.reloadSystemd()
 }
 
-func (s *systemdSidecar) startSystemd() error {
-	if err := s.checkDaemonExists(); err != nil {
-		return fmt.Errorf("service not found: %w", err)
+// Rollback switches the underlying binary back to a previously installed version.
+func (s *systemdSidecar) Rollback(version string) error {
+	// systemd + launchd are underpinned by the binary sidecar.
+	binarySidecar, err := NewBinarySidecar(s.logger, s.sidecarCfg, s.installerCfg)
+	if err != nil {
+		return fmt.Errorf("failed to create binary sidecar: %w", err)
 	}
 
-	cmd := exec.Command("sudo", "systemctl", "start", "contributoor.service")
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to start service: %s: %w", string(output), err)
+	if err := binarySidecar.Rollback(version); err != nil {
+		return fmt.Errorf("failed to roll back binary: %w", err)
+	}
+
+	return nil
+}
+
+func (s *systemdSidecar) startSystemd() error {
+	if err := s.withSystemd(func(ctx context.Context, conn systemdConn) error {
+		return runSystemdJob(ctx, conn, "start", conn.StartUnitContext)
+	}); err != nil {
+		return err
 	}
 
 	fmt.Printf("%sContributoor started successfully%s\n", tui.TerminalColorGreen, tui.TerminalColorReset)
 
+	s.warnIfNotLingering()
+
 	return nil
 }
 
 func (s *systemdSidecar) stopSystemd() error {
-	if err := s.checkDaemonExists(); err != nil {
-		return fmt.Errorf("service not found: %w", err)
+	return s.withSystemd(func(ctx context.Context, conn systemdConn) error {
+		if err := runSystemdJob(ctx, conn, "stop", conn.StopUnitContext); err != nil {
+			return err
+		}
+
+		fmt.Printf("%sContributoor stopped successfully%s\n", tui.TerminalColorGreen, tui.TerminalColorReset)
+
+		return nil
+	})
+}
+
+func (s *systemdSidecar) isRunningSystemd() (bool, error) {
+	var running bool
+
+	err := s.withSystemd(func(ctx context.Context, conn systemdConn) error {
+		unit, err := getSystemdUnitStatus(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		running = unit.running()
+
+		return nil
+	})
+
+	return running, err
+}
+
+func (s *systemdSidecar) detailsSystemd() (*runner.Details, error) {
+	scope, err := s.scope()
+	if err != nil {
+		return nil, err
 	}
 
-	cmd := exec.Command("sudo", "systemctl", "stop", "contributoor.service")
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to stop service: %s: %w", string(output), err)
+	// The scope says where to look for the unit, even if it isn't there.
+	details := &runner.Details{UnitScope: string(scope)}
+
+	err = connectSystemd(scope, func(ctx context.Context, conn systemdConn) error {
+		unit, err := getSystemdUnitStatus(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		if !unit.installed() {
+			return nil
+		}
+
+		details.Installed = true
+		details.UnitState = unit.ActiveState
+		details.UnitSubState = unit.SubState
+		details.PID = unit.MainPID
+		details.Restarts = unit.Restarts
+		details.LastExitCode = unit.LastExitCode
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Printf("%sContributoor stopped successfully%s\n", tui.TerminalColorGreen, tui.TerminalColorReset)
+	return details, nil
+}
+
+func (s *systemdSidecar) logsSystemd(ctx context.Context, opts runner.LogOptions, w io.Writer) error {
+	scope, err := s.scope()
+	if err != nil {
+		return err
+	}
+
+	args := []string{"journalctl", "--unit", systemdUnit, "--no-pager", "--output", "cat"}
+
+	if opts.Follow {
+		args = append(args, "--follow")
+	}
+
+	if opts.Tail > 0 {
+		args = append(args, "--lines", strconv.Itoa(opts.Tail))
+	}
+
+	if !opts.Since.IsZero() {
+		args = append(args, "--since", opts.Since.Local().Format(time.DateTime))
+	}
+
+	// User units log to the user's own journal. The system journal is readable by the
+	// systemd-journal and adm groups, so it isn't read through sudo, which can't prompt
+	// without a TTY.
+	if scope == installer.SystemdScopeUser {
+		args = append([]string{args[0], "--user"}, args[1:]...)
+	}
+
+	var stderr bytes.Buffer
+
+	//nolint:gosec // The arguments are ours.
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+
+	if journalPermissionDenied(stderr.String()) {
+		fmt.Fprintf(
+			w,
+			"%sContributoor's logs are in the system journal, which you don't have permission to read. Add yourself to the systemd-journal group, eg: 'sudo usermod -aG systemd-journal $USER', and log in again%s\n",
+			tui.TerminalColorYellow,
+			tui.TerminalColorReset,
+		)
+
+		return nil
+	}
+
+	if _, werr := stderr.WriteTo(w); werr != nil {
+		return fmt.Errorf("failed to write journal errors: %w", werr)
+	}
+
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to read journal: %w", err)
+	}
 
 	return nil
 }
 
-func (s *systemdSidecar) isRunningSystemd() (bool, error) {
-	if err := s.checkDaemonExists(); err != nil {
-		//nolint:nilerr // We want to return false if the service doesn't exist.
-		return false, nil
+// journalPermissionDenied returns whether journalctl's stderr says the journal couldn't
+// be read for a lack of permission. journalctl doesn't always fail when it can't, it
+// can just show nothing.
+func journalPermissionDenied(stderr string) bool {
+	for _, msg := range []string{
+		"insufficient permissions",
+		"not seeing messages from other users and the system",
+		"Permission denied",
+	} {
+		if strings.Contains(stderr, msg) {
+			return true
+		}
 	}
 
-	cmd := exec.Command("sudo", "systemctl", "is-active", "contributoor.service")
+	return false
+}
+
+func (s *systemdSidecar) reloadSystemd() error {
+	return s.withSystemd(func(ctx context.Context, conn systemdConn) error {
+		if err := conn.ReloadContext(ctx); err != nil {
+			return systemdError("reload", err)
+		}
 
-	output, err := cmd.CombinedOutput()
+		return nil
+	})
+}
+
+// scope returns the scope of the systemd unit, from the installer state.
+func (s *systemdSidecar) scope() (installer.SystemdScope, error) {
+	state, err := installer.LoadState(filepath.Dir(s.sidecarCfg.GetConfigPath()))
 	if err != nil {
-		//nolint:nilerr // We want to return false if the service doesn't exist.
-		return false, nil
+		return "", err
 	}
 
-	return strings.TrimSpace(string(output)) == "active", nil
+	return state.GetSystemdScope(), nil
 }
 
-func (s *systemdSidecar) reloadSystemd() error {
-	cmd := exec.Command("sudo", "systemctl", "daemon-reload")
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return fmt.Errorf("failed to reload systemd: %s: %w", string(output), err)
+// withSystemd connects to the systemd managing the unit, and calls fn with the connection.
+func (s *systemdSidecar) withSystemd(fn func(ctx context.Context, conn systemdConn) error) error {
+	scope, err := s.scope()
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return connectSystemd(scope, fn)
 }
 
 func (s *systemdSidecar) startLaunchd() error {
@@ -210,6 +400,38 @@ func (s *systemdSidecar) isRunningLaunchd() (bool, error) {
 	return false, nil
 }
 
+func (s *systemdSidecar) detailsLaunchd() (*runner.Details, error) {
+	details := &runner.Details{Installed: true}
+
+	cmd := exec.Command("sudo", "launchctl", "list", "io.ethpandaops.contributoor")
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		//nolint:nilerr // The service isn't loaded, so there's nothing more to report.
+		return details, nil
+	}
+
+	// Only a running service has a PID, eg: "PID" = 1234;
+	if match := launchdPIDPattern.FindSubmatch(output); match != nil {
+		details.PID, _ = strconv.Atoi(string(match[1]))
+	}
+
+	return details, nil
+}
+
+func (s *systemdSidecar) logsLaunchd(ctx context.Context, opts runner.LogOptions, w io.Writer) error {
+	dir, err := homedir.Expand(s.sidecarCfg.Get().ContributoorDirectory)
+	if err != nil {
+		return fmt.Errorf("failed to expand config path: %w", err)
+	}
+
+	// Matches the StandardOutPath and StandardErrorPath of the launchd plist.
+	return tailLogFiles(ctx, []string{
+		filepath.Join(dir, "logs", "service.log"),
+		filepath.Join(dir, "logs", "error.log"),
+	}, opts, w)
+}
+
 func (s *systemdSidecar) reloadLaunchd() error {
 	cmd := exec.Command("sudo", "launchctl", "unload", "/Library/LaunchDaemons/io.ethpandaops.contributoor.plist")
 	if output, err := cmd.CombinedOutput(); err != nil {
@@ -224,25 +446,11 @@ func (s *systemdSidecar) reloadLaunchd() error {
 	return nil
 }
 
+// checkDaemonExists checks the launchd service is installed.
 func (s *systemdSidecar) checkDaemonExists() error {
-	if runtime.GOOS == ArchDarwin {
-		// Check if plist file exists
-		cmd := exec.Command("sudo", "test", "-f", "/Library/LaunchDaemons/io.ethpandaops.contributoor.plist")
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("service not installed")
-		}
-
-		return nil
-	}
-
-	cmd := exec.Command("sudo", "systemctl", "list-unit-files", "contributoor.service")
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to list service: %s: %w", string(output), err)
-	}
-
-	if !strings.Contains(string(output), "contributoor.service") {
+	// Check if plist file exists
+	cmd := exec.Command("sudo", "test", "-f", "/Library/LaunchDaemons/io.ethpandaops.contributoor.plist")
+	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("service not installed")
 	}
 
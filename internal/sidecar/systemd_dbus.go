@@ -0,0 +1,196 @@
+package sidecar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	systemd "github.com/coreos/go-systemd/v22/dbus"
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/godbus/dbus/v5"
+)
+
+// systemdUnit is the name of the contributoor systemd unit.
+const systemdUnit = "contributoor.service"
+
+// systemdTimeout bounds each conversation with systemd, including waiting for jobs.
+var systemdTimeout = 2 * time.Minute
+
+// systemdConn is the part of systemd's D-Bus API (org.freedesktop.systemd1) we use. It's
+// satisfied by go-systemd's connection, and faked in tests.
+type systemdConn interface {
+	StartUnitContext(ctx context.Context, name, mode string, ch chan<- string) (int, error)
+	StopUnitContext(ctx context.Context, name, mode string, ch chan<- string) (int, error)
+	ReloadContext(ctx context.Context) error
+	EnableUnitFilesContext(ctx context.Context, files []string, runtime, force bool) (bool, []systemd.EnableUnitFileChange, error)
+	DisableUnitFilesContext(ctx context.Context, files []string, runtime bool) ([]systemd.DisableUnitFileChange, error)
+	GetAllPropertiesContext(ctx context.Context, unit string) (map[string]interface{}, error)
+	Close()
+}
+
+// newSystemdConn connects to systemd. For system units, as root, it uses systemd's
+// private socket, and otherwise the system bus, where polkit decides what we're allowed
+// to do. For user units, it connects to the user's own service manager.
+var newSystemdConn = func(ctx context.Context, scope installer.SystemdScope) (systemdConn, error) {
+	if scope == installer.SystemdScopeUser {
+		return systemd.NewUserConnectionContext(ctx)
+	}
+
+	return systemd.NewWithContext(ctx)
+}
+
+// D-Bus errors returned when polkit hasn't authorized us.
+const (
+	dbusErrorAccessDenied                     = "org.freedesktop.DBus.Error.AccessDenied"
+	dbusErrorInteractiveAuthorizationRequired = "org.freedesktop.DBus.Error.InteractiveAuthorizationRequired"
+)
+
+// Values of ExecMainCode, which say how the main process exited.
+const (
+	cldExited = 1
+)
+
+// systemdUnitStatus is the state of the contributoor unit, as reported by systemd.
+type systemdUnitStatus struct {
+	LoadState   string
+	ActiveState string
+	SubState    string
+	MainPID     int
+	Restarts    int
+
+	// LastExitCode is the exit status of the main process the last time it exited, or
+	// -1 if it was killed by a signal. It's nil if it hasn't exited.
+	LastExitCode *int
+}
+
+// installed reports whether the unit file exists.
+func (u *systemdUnitStatus) installed() bool {
+	return u.LoadState != "" && u.LoadState != "not-found"
+}
+
+// running reports whether the unit is up.
+func (u *systemdUnitStatus) running() bool {
+	return u.ActiveState == "active" || u.ActiveState == "reloading"
+}
+
+// connectSystemd connects to the systemd managing units of the given scope, and calls
+// fn with the connection.
+func connectSystemd(scope installer.SystemdScope, fn func(ctx context.Context, conn systemdConn) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), systemdTimeout)
+	defer cancel()
+
+	conn, err := newSystemdConn(ctx, scope)
+	if err != nil {
+		if scope == installer.SystemdScopeUser {
+			// There's no user bus without a login session, eg: after su or sudo.
+			return fmt.Errorf(
+				"failed to connect to your user's systemd, log in as the user directly rather than through su or sudo: %w",
+				err,
+			)
+		}
+
+		return fmt.Errorf("failed to connect to systemd: %w", err)
+	}
+
+	defer conn.Close()
+
+	return fn(ctx, conn)
+}
+
+// getSystemdUnitStatus returns the state of the contributoor unit.
+func getSystemdUnitStatus(ctx context.Context, conn systemdConn) (*systemdUnitStatus, error) {
+	props, err := conn.GetAllPropertiesContext(ctx, systemdUnit)
+	if err != nil {
+		return nil, systemdError("get the status of", err)
+	}
+
+	var (
+		loadState, _   = props["LoadState"].(string)
+		activeState, _ = props["ActiveState"].(string)
+		subState, _    = props["SubState"].(string)
+		mainPID, _     = props["MainPID"].(uint32)
+		restarts, _    = props["NRestarts"].(uint32)
+	)
+
+	unit := &systemdUnitStatus{
+		LoadState:   loadState,
+		ActiveState: activeState,
+		SubState:    subState,
+		MainPID:     int(mainPID),
+		Restarts:    int(restarts),
+	}
+
+	if exitedAt, _ := props["ExecMainExitTimestamp"].(uint64); exitedAt != 0 {
+		var (
+			code, _   = props["ExecMainCode"].(int32)
+			status, _ = props["ExecMainStatus"].(int32)
+			exitCode  = -1
+		)
+
+		// Otherwise the status is the signal that killed it.
+		if code == cldExited {
+			exitCode = int(status)
+		}
+
+		unit.LastExitCode = &exitCode
+	}
+
+	return unit, nil
+}
+
+// runSystemdJob queues a job for the contributoor unit, such as starting it, and waits
+// for systemd to finish it.
+func runSystemdJob(
+	ctx context.Context,
+	conn systemdConn,
+	action string,
+	queue func(ctx context.Context, name, mode string, ch chan<- string) (int, error),
+) error {
+	unit, err := getSystemdUnitStatus(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	if !unit.installed() {
+		return fmt.Errorf("service not found: service not installed")
+	}
+
+	result := make(chan string, 1)
+
+	if _, err := queue(ctx, systemdUnit, "replace", result); err != nil {
+		return systemdError(action, err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting to %s %s: %w", action, systemdUnit, ctx.Err())
+	case res := <-result:
+		if res == "done" {
+			return nil
+		}
+
+		// The unit's state says why, eg: failed (exit-code).
+		if unit, err := getSystemdUnitStatus(ctx, conn); err == nil {
+			return fmt.Errorf("failed to %s %s: job %s, unit is %s (%s)", action, systemdUnit, res, unit.ActiveState, unit.SubState)
+		}
+
+		return fmt.Errorf("failed to %s %s: job %s", action, systemdUnit, res)
+	}
+}
+
+// systemdError wraps an error from systemd, explaining how to fix authorization failures.
+func systemdError(action string, err error) error {
+	var dbusErr dbus.Error
+	if errors.As(err, &dbusErr) &&
+		(dbusErr.Name == dbusErrorAccessDenied || dbusErr.Name == dbusErrorInteractiveAuthorizationRequired) {
+		return fmt.Errorf(
+			"not authorized to %s %s, run as root or allow it with a polkit rule: %w",
+			action,
+			systemdUnit,
+			err,
+		)
+	}
+
+	return fmt.Errorf("failed to %s %s: %w", action, systemdUnit, err)
+}
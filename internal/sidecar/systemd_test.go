@@ -0,0 +1,452 @@
+package sidecar
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	systemd "github.com/coreos/go-systemd/v22/dbus"
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/mock"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+	"github.com/ethpandaops/contributoor/pkg/config/v1"
+	"github.com/godbus/dbus/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// fakeSystemd is a fake of systemd's D-Bus API.
+type fakeSystemd struct {
+	// props are the unit's properties, replaced by jobProps once a job has run.
+	props    map[string]interface{}
+	jobProps map[string]interface{}
+	propsErr error
+
+	// jobResult is the result of queued jobs, eg: done or failed.
+	jobResult  string
+	jobErr     error
+	reloadErr  error
+	enableErr  error
+	disableErr error
+
+	// scope is the scope of the systemd the sidecar connected to.
+	scope installer.SystemdScope
+
+	calls  []string
+	closed bool
+}
+
+func (f *fakeSystemd) StartUnitContext(_ context.Context, name, mode string, ch chan<- string) (int, error) {
+	return f.queueJob("start "+name+" "+mode, ch)
+}
+
+func (f *fakeSystemd) StopUnitContext(_ context.Context, name, mode string, ch chan<- string) (int, error) {
+	return f.queueJob("stop "+name+" "+mode, ch)
+}
+
+func (f *fakeSystemd) ReloadContext(_ context.Context) error {
+	f.calls = append(f.calls, "reload")
+
+	return f.reloadErr
+}
+
+func (f *fakeSystemd) EnableUnitFilesContext(
+	_ context.Context,
+	files []string,
+	_, _ bool,
+) (bool, []systemd.EnableUnitFileChange, error) {
+	f.calls = append(f.calls, "enable "+strings.Join(files, " "))
+
+	return false, nil, f.enableErr
+}
+
+func (f *fakeSystemd) DisableUnitFilesContext(
+	_ context.Context,
+	files []string,
+	_ bool,
+) ([]systemd.DisableUnitFileChange, error) {
+	f.calls = append(f.calls, "disable "+strings.Join(files, " "))
+
+	return nil, f.disableErr
+}
+
+func (f *fakeSystemd) GetAllPropertiesContext(_ context.Context, unit string) (map[string]interface{}, error) {
+	f.calls = append(f.calls, "properties "+unit)
+
+	return f.props, f.propsErr
+}
+
+func (f *fakeSystemd) Close() {
+	f.closed = true
+}
+
+func (f *fakeSystemd) queueJob(call string, ch chan<- string) (int, error) {
+	f.calls = append(f.calls, call)
+
+	if f.jobErr != nil {
+		return 0, f.jobErr
+	}
+
+	if f.jobProps != nil {
+		f.props = f.jobProps
+	}
+
+	ch <- f.jobResult
+
+	return 1, nil
+}
+
+// useFakeSystemd connects the sidecar to the fake, rather than the system bus.
+func useFakeSystemd(t *testing.T, fake *fakeSystemd, connErr error) {
+	t.Helper()
+
+	original := newSystemdConn
+	newSystemdConn = func(_ context.Context, scope installer.SystemdScope) (systemdConn, error) {
+		if connErr != nil {
+			return nil, connErr
+		}
+
+		fake.scope = scope
+
+		return fake, nil
+	}
+
+	t.Cleanup(func() {
+		newSystemdConn = original
+	})
+}
+
+// newTestSystemdSidecar returns a systemd sidecar for contributoor installed in dir,
+// whose unit has the given scope. An empty scope leaves the installer state unset.
+func newTestSystemdSidecar(t *testing.T, dir string, scope installer.SystemdScope) *systemdSidecar {
+	t.Helper()
+
+	configDir := t.TempDir()
+
+	if scope != "" {
+		state, err := installer.LoadState(configDir)
+		require.NoError(t, err)
+
+		state.SystemdScope = scope
+		require.NoError(t, state.Save())
+	}
+
+	ctrl := gomock.NewController(t)
+
+	cfg := mock.NewMockConfigManager(ctrl)
+	cfg.EXPECT().Get().Return(&config.Config{
+		ContributoorDirectory: dir,
+		RunMethod:             config.RunMethod_RUN_METHOD_SYSTEMD,
+	}).AnyTimes()
+	cfg.EXPECT().GetConfigPath().Return(filepath.Join(configDir, "config.yaml")).AnyTimes()
+
+	s, err := NewSystemdSidecar(logrus.New(), cfg, installer.NewConfig())
+	require.NoError(t, err)
+
+	//nolint:forcetypeassert // It's ours.
+	return s.(*systemdSidecar)
+}
+
+var (
+	accessDenied = dbus.Error{
+		Name: dbusErrorAccessDenied,
+		Body: []interface{}{"Interactive authentication required."},
+	}
+
+	runningUnit = map[string]interface{}{
+		"LoadState":   "loaded",
+		"ActiveState": "active",
+		"SubState":    "running",
+		"MainPID":     uint32(1234),
+		"NRestarts":   uint32(2),
+	}
+
+	failedUnit = map[string]interface{}{
+		"LoadState":             "loaded",
+		"ActiveState":           "failed",
+		"SubState":              "failed",
+		"NRestarts":             uint32(5),
+		"ExecMainCode":          int32(1),
+		"ExecMainStatus":        int32(3),
+		"ExecMainExitTimestamp": uint64(1735830245000000),
+	}
+
+	stoppedUnit = map[string]interface{}{
+		"LoadState":   "loaded",
+		"ActiveState": "inactive",
+		"SubState":    "dead",
+	}
+
+	missingUnit = map[string]interface{}{
+		"LoadState":   "not-found",
+		"ActiveState": "inactive",
+		"SubState":    "dead",
+	}
+)
+
+func TestSystemdSidecar_Details(t *testing.T) {
+	exitCode := func(code int) *int {
+		return &code
+	}
+
+	tests := []struct {
+		name          string
+		scope         installer.SystemdScope
+		props         map[string]interface{}
+		propsErr      error
+		expected      *runner.Details
+		expectedError string
+	}{
+		{
+			name:  "running",
+			props: runningUnit,
+			expected: &runner.Details{
+				Installed:    true,
+				PID:          1234,
+				Restarts:     2,
+				UnitScope:    "system",
+				UnitState:    "active",
+				UnitSubState: "running",
+			},
+		},
+		{
+			name:  "failed",
+			props: failedUnit,
+			expected: &runner.Details{
+				Installed:    true,
+				Restarts:     5,
+				LastExitCode: exitCode(3),
+				UnitScope:    "system",
+				UnitState:    "failed",
+				UnitSubState: "failed",
+			},
+		},
+		{
+			name: "killed by a signal",
+			props: map[string]interface{}{
+				"LoadState":             "loaded",
+				"ActiveState":           "failed",
+				"SubState":              "failed",
+				"ExecMainCode":          int32(2),
+				"ExecMainStatus":        int32(9),
+				"ExecMainExitTimestamp": uint64(1735830245000000),
+			},
+			expected: &runner.Details{
+				Installed:    true,
+				LastExitCode: exitCode(-1),
+				UnitScope:    "system",
+				UnitState:    "failed",
+				UnitSubState: "failed",
+			},
+		},
+		{
+			name:  "user unit",
+			scope: installer.SystemdScopeUser,
+			props: runningUnit,
+			expected: &runner.Details{
+				Installed:    true,
+				PID:          1234,
+				Restarts:     2,
+				UnitScope:    "user",
+				UnitState:    "active",
+				UnitSubState: "running",
+			},
+		},
+		{
+			name:     "not installed",
+			props:    missingUnit,
+			expected: &runner.Details{UnitScope: "system"},
+		},
+		{
+			name:          "not authorized",
+			propsErr:      accessDenied,
+			expectedError: "not authorized to get the status of contributoor.service, run as root or allow it with a polkit rule",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeSystemd{props: tt.props, propsErr: tt.propsErr}
+			useFakeSystemd(t, fake, nil)
+
+			details, err := newTestSystemdSidecar(t, t.TempDir(), tt.scope).detailsSystemd()
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, details)
+			assert.Equal(t, installer.SystemdScope(details.UnitScope), fake.scope)
+			assert.True(t, fake.closed)
+		})
+	}
+}
+
+func TestSystemdSidecar_IsRunning(t *testing.T) {
+	tests := []struct {
+		name          string
+		props         map[string]interface{}
+		propsErr      error
+		connErr       error
+		expected      bool
+		expectedError string
+	}{
+		{
+			name:     "active",
+			props:    runningUnit,
+			expected: true,
+		},
+		{
+			name:  "failed",
+			props: failedUnit,
+		},
+		{
+			name:  "not installed",
+			props: missingUnit,
+		},
+		{
+			// Failures to ask are reported, rather than treated as not running.
+			name:          "bus unavailable",
+			connErr:       errors.New("no such file or directory"),
+			expectedError: "failed to connect to systemd: no such file or directory",
+		},
+		{
+			name:          "properties unavailable",
+			propsErr:      errors.New("boom"),
+			expectedError: "failed to get the status of contributoor.service: boom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFakeSystemd(t, &fakeSystemd{props: tt.props, propsErr: tt.propsErr}, tt.connErr)
+
+			running, err := newTestSystemdSidecar(t, t.TempDir(), "").isRunningSystemd()
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, running)
+		})
+	}
+}
+
+func TestSystemdSidecar_Jobs(t *testing.T) {
+	tests := []struct {
+		name          string
+		run           func(*systemdSidecar) error
+		fake          *fakeSystemd
+		expectedCalls []string
+		expectedError string
+	}{
+		{
+			name: "start",
+			run:  (*systemdSidecar).startSystemd,
+			fake: &fakeSystemd{props: stoppedUnit, jobResult: "done"},
+			expectedCalls: []string{
+				"properties contributoor.service",
+				"start contributoor.service replace",
+			},
+		},
+		{
+			name: "start fails",
+			run:  (*systemdSidecar).startSystemd,
+			fake: &fakeSystemd{
+				props:     stoppedUnit,
+				jobProps:  failedUnit,
+				jobResult: "failed",
+			},
+			expectedError: "failed to start contributoor.service: job failed, unit is failed (failed)",
+		},
+		{
+			name:          "start not authorized",
+			run:           (*systemdSidecar).startSystemd,
+			fake:          &fakeSystemd{props: stoppedUnit, jobErr: accessDenied},
+			expectedError: "not authorized to start contributoor.service, run as root or allow it with a polkit rule",
+		},
+		{
+			name:          "start not installed",
+			run:           (*systemdSidecar).startSystemd,
+			fake:          &fakeSystemd{props: missingUnit},
+			expectedError: "service not installed",
+			expectedCalls: []string{"properties contributoor.service"},
+		},
+		{
+			name: "stop",
+			run:  (*systemdSidecar).stopSystemd,
+			fake: &fakeSystemd{props: runningUnit, jobResult: "done"},
+			expectedCalls: []string{
+				"properties contributoor.service",
+				"stop contributoor.service replace",
+			},
+		},
+		{
+			name:          "stop canceled",
+			run:           (*systemdSidecar).stopSystemd,
+			fake:          &fakeSystemd{props: runningUnit, jobResult: "canceled"},
+			expectedError: "failed to stop contributoor.service: job canceled, unit is active (running)",
+		},
+		{
+			name:          "reload",
+			run:           (*systemdSidecar).reloadSystemd,
+			fake:          &fakeSystemd{},
+			expectedCalls: []string{"reload"},
+		},
+		{
+			name:          "reload not authorized",
+			run:           (*systemdSidecar).reloadSystemd,
+			fake:          &fakeSystemd{reloadErr: accessDenied},
+			expectedError: "not authorized to reload contributoor.service",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useFakeSystemd(t, tt.fake, nil)
+
+			err := tt.run(newTestSystemdSidecar(t, t.TempDir(), ""))
+
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			if tt.expectedCalls != nil {
+				assert.Equal(t, tt.expectedCalls, tt.fake.calls)
+			}
+
+			assert.True(t, tt.fake.closed)
+		})
+	}
+}
+
+func TestJournalPermissionDenied(t *testing.T) {
+	tests := []struct {
+		stderr   string
+		expected bool
+	}{
+		{stderr: "", expected: false},
+		{stderr: "-- No entries --\n", expected: false},
+		{stderr: "No journal files were opened due to insufficient permissions.\n", expected: true},
+		{stderr: "Hint: You are currently not seeing messages from other users and the system.\n      Users in groups 'adm', 'systemd-journal' can see all messages.\n", expected: true},
+		{stderr: "Failed to open journal: Permission denied\n", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.stderr, func(t *testing.T) {
+			assert.Equal(t, tt.expected, journalPermissionDenied(tt.stderr))
+		})
+	}
+}
@@ -0,0 +1,401 @@
+package sidecar
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/template"
+
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+	"github.com/ethpandaops/contributoor-installer/internal/tui"
+	"github.com/mitchellh/go-homedir"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// systemdUnitTemplate is the contributoor unit file. With the default installer config,
+// the system unit is the one install.sh has always written, with the contributoor
+// directory left writable, which ProtectSystem and ProtectHome would make read-only.
+// User units run as the user already, and can't use the hardening directives that need
+// root to set up.
+var systemdUnitTemplate = template.Must(template.New("contributoor.service").
+	Funcs(template.FuncMap{"quote": systemdQuote}).
+	Parse(`[Unit]
+Description=Contributoor Service
+After=network-online.target
+Wants=network-online.target
+StartLimitIntervalSec=0
+
+[Service]
+Type=simple
+{{- if not .UserScope }}
+User={{ .User }}
+Group={{ .Group }}
+{{- end }}
+ExecStart={{ quote .BinaryPath }} --config {{ quote .ConfigPath }}
+WorkingDirectory={{ .WorkingDirectory }}
+Restart={{ .Restart }}
+RestartSec={{ .RestartSeconds }}
+
+# Environment setup
+Environment={{ quote (print "HOME=" .Home) }}
+Environment={{ quote (print "USER=" .User) }}
+Environment=PATH=/usr/local/bin:/usr/bin:/bin
+{{- if or .MemoryMax .CPUQuota .LimitNOFILE }}
+
+# Resource limits
+{{- with .MemoryMax }}
+MemoryMax={{ . }}
+{{- end }}
+{{- with .CPUQuota }}
+CPUQuota={{ . }}
+{{- end }}
+{{- with .LimitNOFILE }}
+LimitNOFILE={{ . }}
+{{- end }}
+{{- end }}
+
+# Hardening
+NoNewPrivileges={{ .NoNewPrivileges }}
+{{- if not .UserScope }}
+{{- with .ProtectSystem }}
+ProtectSystem={{ . }}
+{{- end }}
+{{- with .ProtectHome }}
+ProtectHome={{ . }}
+{{- end }}
+{{- if or .ProtectSystem .ProtectHome }}
+ReadWritePaths={{ quote .WorkingDirectory }}
+{{- end }}
+PrivateTmp={{ .PrivateTmp }}
+{{- end }}
+
+[Install]
+{{- if .UserScope }}
+WantedBy=default.target
+{{- else }}
+WantedBy=multi-user.target
+{{- end }}
+`))
+
+// systemdUnitParams fills in systemdUnitTemplate.
+type systemdUnitParams struct {
+	UserScope        bool
+	User             string
+	Group            string
+	Home             string
+	BinaryPath       string
+	ConfigPath       string
+	WorkingDirectory string
+	Restart          string
+	RestartSeconds   int
+	MemoryMax        string
+	CPUQuota         string
+	LimitNOFILE      int
+	NoNewPrivileges  bool
+	ProtectSystem    string
+	ProtectHome      string
+	PrivateTmp       bool
+}
+
+// systemdUnitUser returns the user the service runs as. That's whoever ran the CLI, even
+// if they ran it with sudo.
+var systemdUnitUser = func() (*user.User, error) {
+	if name := os.Getenv("SUDO_USER"); name != "" && os.Geteuid() == 0 {
+		return user.Lookup(name)
+	}
+
+	return user.Current()
+}
+
+// systemdLingerDir holds a file for each user with lingering enabled, see loginctl(1).
+var systemdLingerDir = "/var/lib/systemd/linger"
+
+// systemdUnitFile is the unit file generated from the config, and the one installed.
+type systemdUnitFile struct {
+	scope installer.SystemdScope
+	path  string
+
+	// generated is the unit file generated from the config.
+	generated []byte
+
+	// installed is the unit file that's installed, or nil if there isn't one.
+	installed []byte
+}
+
+// UnitDiff returns a unified diff from the installed unit file to the one generated
+// from the config. It's empty if they match.
+func (s *systemdSidecar) UnitDiff() (string, error) {
+	unit, err := s.renderUnitFile()
+	if err != nil {
+		return "", err
+	}
+
+	fromFile := unit.path
+	if unit.installed == nil {
+		fromFile = "/dev/null"
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(unit.installed)),
+		B:        difflib.SplitLines(string(unit.generated)),
+		FromFile: fromFile,
+		ToFile:   unit.path + " (generated)",
+		Context:  3,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to diff unit file: %w", err)
+	}
+
+	return diff, nil
+}
+
+// InstallUnit writes the unit file generated from the config, unless it's already up to
+// date, and reloads systemd. A new unit is enabled too, but not started.
+func (s *systemdSidecar) InstallUnit() (runner.UnitChange, error) {
+	unit, err := s.renderUnitFile()
+	if err != nil {
+		return "", err
+	}
+
+	change := runner.UnitUpdated
+
+	switch {
+	case unit.installed == nil:
+		change = runner.UnitCreated
+	case bytes.Equal(unit.installed, unit.generated):
+		return runner.UnitUnchanged, nil
+	}
+
+	if err := writeUnitFile(unit.path, unit.generated); err != nil {
+		return "", fmt.Errorf("failed to write unit file: %w", err)
+	}
+
+	if err := connectSystemd(unit.scope, func(ctx context.Context, conn systemdConn) error {
+		if change == runner.UnitCreated {
+			if _, _, err := conn.EnableUnitFilesContext(ctx, []string{unit.path}, false, false); err != nil {
+				return systemdError("enable", err)
+			}
+		}
+
+		if err := conn.ReloadContext(ctx); err != nil {
+			return systemdError("reload", err)
+		}
+
+		return nil
+	}); err != nil {
+		return "", err
+	}
+
+	s.warnIfNotLingering()
+
+	return change, nil
+}
+
+// UninstallUnit disables and removes the unit file, if it's installed, and reloads
+// systemd. The unit should be stopped first.
+func (s *systemdSidecar) UninstallUnit() error {
+	if runtime.GOOS == ArchDarwin {
+		return errors.New("the service file is only managed for systemd, not launchd")
+	}
+
+	scope, err := s.scope()
+	if err != nil {
+		return err
+	}
+
+	path, err := s.unitPath(scope)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return connectSystemd(scope, func(ctx context.Context, conn systemdConn) error {
+		if _, err := conn.DisableUnitFilesContext(ctx, []string{systemdUnit}, false); err != nil {
+			return systemdError("disable", err)
+		}
+
+		if err := removeUnitFile(path); err != nil {
+			return fmt.Errorf("failed to remove unit file: %w", err)
+		}
+
+		if err := conn.ReloadContext(ctx); err != nil {
+			return systemdError("reload", err)
+		}
+
+		return nil
+	})
+}
+
+// unitPath returns where the unit file of the given scope is installed.
+func (s *systemdSidecar) unitPath(scope installer.SystemdScope) (string, error) {
+	if scope != installer.SystemdScopeUser {
+		return s.installerCfg.SystemdUnit.Path, nil
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the user unit directory: %w", err)
+	}
+
+	return filepath.Join(configDir, "systemd", "user", systemdUnit), nil
+}
+
+// renderUnitFile generates the unit file from the config, and reads the installed one.
+func (s *systemdSidecar) renderUnitFile() (*systemdUnitFile, error) {
+	if runtime.GOOS == ArchDarwin {
+		return nil, errors.New("the service file is only generated for systemd, not launchd")
+	}
+
+	scope, err := s.scope()
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := s.unitPath(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	unit := &systemdUnitFile{
+		scope: scope,
+		path:  path,
+	}
+
+	unit.generated, err = s.renderUnit(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	unit.installed, err = os.ReadFile(unit.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read unit file: %w", err)
+	}
+
+	return unit, nil
+}
+
+// renderUnit generates the unit file of the given scope from the config.
+func (s *systemdSidecar) renderUnit(scope installer.SystemdScope) ([]byte, error) {
+	dir, err := homedir.Expand(s.sidecarCfg.Get().ContributoorDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand config path: %w", err)
+	}
+
+	u, err := systemdUnitUser()
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up the service user: %w", err)
+	}
+
+	// The user's primary group, falling back to a group named after them.
+	group := u.Username
+	if g, gerr := user.LookupGroupId(u.Gid); gerr == nil {
+		group = g.Name
+	}
+
+	unitCfg := s.installerCfg.SystemdUnit
+
+	var buf bytes.Buffer
+
+	if err := systemdUnitTemplate.Execute(&buf, systemdUnitParams{
+		UserScope:        scope == installer.SystemdScopeUser,
+		User:             u.Username,
+		Group:            group,
+		Home:             u.HomeDir,
+		BinaryPath:       filepath.Join(dir, "bin", "sentry"),
+		ConfigPath:       filepath.Join(dir, "config.yaml"),
+		WorkingDirectory: dir,
+		Restart:          unitCfg.Restart,
+		RestartSeconds:   int(unitCfg.RestartSec.Seconds()),
+		MemoryMax:        unitCfg.MemoryMax,
+		CPUQuota:         unitCfg.CPUQuota,
+		LimitNOFILE:      unitCfg.LimitNOFILE,
+		NoNewPrivileges:  unitCfg.NoNewPrivileges,
+		ProtectSystem:    unitCfg.ProtectSystem,
+		ProtectHome:      unitCfg.ProtectHome,
+		PrivateTmp:       unitCfg.PrivateTmp,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to render unit file: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// warnIfNotLingering warns that systemd stops a user unit when the user logs out, unless
+// they've enabled lingering.
+func (s *systemdSidecar) warnIfNotLingering() {
+	if scope, err := s.scope(); err != nil || scope != installer.SystemdScopeUser {
+		return
+	}
+
+	u, err := systemdUnitUser()
+	if err != nil {
+		return
+	}
+
+	if lingerEnabled(u.Username) {
+		return
+	}
+
+	fmt.Printf(
+		"%sLingering isn't enabled for %s, so systemd stops contributoor when they log out. To keep it running, enable it with (or ask an admin to):%s\n",
+		tui.TerminalColorYellow,
+		u.Username,
+		tui.TerminalColorReset,
+	)
+	fmt.Printf("    loginctl enable-linger %s\n", u.Username)
+}
+
+// lingerEnabled reports whether the user's units keep running after they log out.
+func lingerEnabled(username string) bool {
+	_, err := os.Stat(filepath.Join(systemdLingerDir, username))
+
+	return err == nil
+}
+
+// writeUnitFile writes the unit file. It's not written through sudo, which can't
+// prompt for a password when the CLI isn't run from a terminal, so writing a system
+// unit needs the CLI to be run as root.
+var writeUnitFile = func(path string, data []byte) error {
+	// The user unit directory may not exist yet.
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return unitFileError(err)
+	}
+
+	//nolint:gosec // Unit files are world readable.
+	return unitFileError(os.WriteFile(path, data, 0644))
+}
+
+// removeUnitFile removes the unit file, which needs root for a system unit too.
+var removeUnitFile = func(path string) error {
+	return unitFileError(os.Remove(path))
+}
+
+// unitFileError explains how to get past a permission error on the unit file.
+func unitFileError(err error) error {
+	if !errors.Is(err, os.ErrPermission) {
+		return err
+	}
+
+	return fmt.Errorf("%w, run this again as root, eg: with sudo and --config-path set to your config directory, or switch to a user unit with 'contributoor service install --scope user'", err)
+}
+
+// systemdQuote quotes a value for a unit file, if it needs it, and escapes specifiers.
+func systemdQuote(value string) string {
+	value = strings.ReplaceAll(value, "%", "%%")
+
+	if !strings.ContainsAny(value, " \t\"\\'") {
+		return value
+	}
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
@@ -0,0 +1,362 @@
+package sidecar
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethpandaops/contributoor-installer/internal/installer"
+	"github.com/ethpandaops/contributoor-installer/internal/sidecar/runner"
+	"github.com/mitchellh/go-homedir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The unit generated for the ethereum user, with contributoor in ~/.contributoor. It's
+// the unit install.sh writes, with ~/.contributoor left writable.
+const defaultUnit = `[Unit]
+Description=Contributoor Service
+After=network-online.target
+Wants=network-online.target
+StartLimitIntervalSec=0
+
+[Service]
+Type=simple
+User=ethereum
+Group=ethereum
+ExecStart=/home/ethereum/.contributoor/bin/sentry --config /home/ethereum/.contributoor/config.yaml
+WorkingDirectory=/home/ethereum/.contributoor
+Restart=always
+RestartSec=5
+
+# Environment setup
+Environment=HOME=/home/ethereum
+Environment=USER=ethereum
+Environment=PATH=/usr/local/bin:/usr/bin:/bin
+
+# Hardening
+NoNewPrivileges=true
+ProtectSystem=full
+ProtectHome=read-only
+ReadWritePaths=/home/ethereum/.contributoor
+PrivateTmp=true
+
+[Install]
+WantedBy=multi-user.target
+`
+
+// newTestUnitSidecar returns a systemd sidecar that runs as the ethereum user, and
+// installs system units in a temporary directory, and user units in another.
+func newTestUnitSidecar(
+	t *testing.T,
+	dir string,
+	scope installer.SystemdScope,
+	configure func(*installer.SystemdUnitConfig),
+) *systemdSidecar {
+	t.Helper()
+
+	originalUser, originalLingerDir := systemdUnitUser, systemdLingerDir
+	systemdUnitUser = func() (*user.User, error) {
+		// A GID that shouldn't exist, so the group falls back to the user's name.
+		return &user.User{Username: "ethereum", Gid: "4294967294", HomeDir: "/home/ethereum"}, nil
+	}
+	systemdLingerDir = t.TempDir()
+
+	t.Cleanup(func() {
+		systemdUnitUser, systemdLingerDir = originalUser, originalLingerDir
+	})
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	s := newTestSystemdSidecar(t, dir, scope)
+	s.installerCfg.SystemdUnit.Path = filepath.Join(t.TempDir(), "contributoor.service")
+
+	if configure != nil {
+		configure(&s.installerCfg.SystemdUnit)
+	}
+
+	return s
+}
+
+func TestSystemdSidecar_RenderUnit(t *testing.T) {
+	tests := []struct {
+		name      string
+		dir       string
+		scope     installer.SystemdScope
+		configure func(*installer.SystemdUnitConfig)
+		expected  func(unit string) string
+	}{
+		{
+			name:     "default",
+			dir:      "/home/ethereum/.contributoor",
+			expected: func(unit string) string { return unit },
+		},
+		{
+			name: "resource limits and restart policy",
+			dir:  "/home/ethereum/.contributoor",
+			configure: func(cfg *installer.SystemdUnitConfig) {
+				cfg.Restart = "on-failure"
+				cfg.RestartSec = 30 * time.Second
+				cfg.MemoryMax = "2G"
+				cfg.CPUQuota = "200%"
+				cfg.LimitNOFILE = 65536
+			},
+			expected: func(unit string) string {
+				unit = strings.Replace(unit, "Restart=always\nRestartSec=5", "Restart=on-failure\nRestartSec=30", 1)
+
+				return strings.Replace(unit, "\n# Hardening", "\n# Resource limits\nMemoryMax=2G\nCPUQuota=200%\nLimitNOFILE=65536\n\n# Hardening", 1)
+			},
+		},
+		{
+			name: "hardening disabled",
+			dir:  "/home/ethereum/.contributoor",
+			configure: func(cfg *installer.SystemdUnitConfig) {
+				cfg.NoNewPrivileges = false
+				cfg.ProtectSystem = ""
+				cfg.ProtectHome = ""
+				cfg.PrivateTmp = false
+			},
+			expected: func(unit string) string {
+				return strings.Replace(
+					unit,
+					"NoNewPrivileges=true\nProtectSystem=full\nProtectHome=read-only\nReadWritePaths=/home/ethereum/.contributoor\nPrivateTmp=true",
+					"NoNewPrivileges=false\nPrivateTmp=false",
+					1,
+				)
+			},
+		},
+		{
+			name:  "user unit",
+			dir:   "/home/ethereum/.contributoor",
+			scope: installer.SystemdScopeUser,
+			expected: func(unit string) string {
+				unit = strings.Replace(unit, "User=ethereum\nGroup=ethereum\n", "", 1)
+				unit = strings.Replace(unit, "\nProtectSystem=full\nProtectHome=read-only\nReadWritePaths=/home/ethereum/.contributoor\nPrivateTmp=true", "", 1)
+
+				return strings.Replace(unit, "WantedBy=multi-user.target", "WantedBy=default.target", 1)
+			},
+		},
+		{
+			name: "paths are quoted and escaped",
+			dir:  "/home/ethereum/my 100% contributoor",
+			expected: func(unit string) string {
+				unit = strings.Replace(
+					unit,
+					"ExecStart=/home/ethereum/.contributoor/bin/sentry --config /home/ethereum/.contributoor/config.yaml",
+					`ExecStart="/home/ethereum/my 100%% contributoor/bin/sentry" --config "/home/ethereum/my 100%% contributoor/config.yaml"`,
+					1,
+				)
+
+				unit = strings.Replace(unit, "ReadWritePaths=/home/ethereum/.contributoor", `ReadWritePaths="/home/ethereum/my 100%% contributoor"`, 1)
+
+				return strings.Replace(unit, "WorkingDirectory=/home/ethereum/.contributoor", "WorkingDirectory=/home/ethereum/my 100% contributoor", 1)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope := tt.scope
+			if scope == "" {
+				scope = installer.SystemdScopeSystem
+			}
+
+			unit, err := newTestUnitSidecar(t, tt.dir, scope, tt.configure).renderUnit(scope)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected(defaultUnit), string(unit))
+		})
+	}
+}
+
+func TestSystemdSidecar_RenderUnit_WritableDirectory(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	homedir.DisableCache = true
+	defer func() { homedir.DisableCache = false }()
+
+	tests := []struct {
+		name      string
+		configure func(*installer.SystemdUnitConfig)
+	}{
+		{name: "home read-only"},
+		{
+			name: "everything read-only",
+			configure: func(cfg *installer.SystemdUnitConfig) {
+				cfg.ProtectSystem = "strict"
+				cfg.ProtectHome = ""
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unit, err := newTestUnitSidecar(t, "~/.contributoor", installer.SystemdScopeSystem, tt.configure).renderUnit(installer.SystemdScopeSystem)
+			require.NoError(t, err)
+
+			// sentry writes its data and logs in the contributoor directory, which the
+			// hardening would otherwise make read-only.
+			assert.Contains(t, strings.Split(string(unit), "\n"), "ReadWritePaths="+filepath.Join(home, ".contributoor"))
+		})
+	}
+}
+
+func TestSystemdSidecar_InstallUnit(t *testing.T) {
+	const dir = "/home/ethereum/.contributoor"
+
+	t.Run("creates and enables a missing unit", func(t *testing.T) {
+		s := newTestUnitSidecar(t, dir, "", nil)
+		fake := &fakeSystemd{}
+		useFakeSystemd(t, fake, nil)
+
+		diff, err := s.UnitDiff()
+		require.NoError(t, err)
+		assert.Contains(t, diff, "--- /dev/null")
+		assert.Contains(t, diff, "+ExecStart=")
+
+		change, err := s.InstallUnit()
+		require.NoError(t, err)
+		assert.Equal(t, runner.UnitCreated, change)
+		assert.Equal(t, []string{"enable " + s.installerCfg.SystemdUnit.Path, "reload"}, fake.calls)
+
+		data, err := os.ReadFile(s.installerCfg.SystemdUnit.Path)
+		require.NoError(t, err)
+		assert.Equal(t, defaultUnit, string(data))
+	})
+
+	t.Run("leaves an up to date unit alone", func(t *testing.T) {
+		s := newTestUnitSidecar(t, dir, "", nil)
+		fake := &fakeSystemd{}
+		useFakeSystemd(t, fake, nil)
+
+		require.NoError(t, os.WriteFile(s.installerCfg.SystemdUnit.Path, []byte(defaultUnit), 0600))
+
+		diff, err := s.UnitDiff()
+		require.NoError(t, err)
+		assert.Empty(t, diff)
+
+		change, err := s.InstallUnit()
+		require.NoError(t, err)
+		assert.Equal(t, runner.UnitUnchanged, change)
+		assert.Empty(t, fake.calls)
+	})
+
+	t.Run("updates a changed unit", func(t *testing.T) {
+		s := newTestUnitSidecar(t, dir, "", func(cfg *installer.SystemdUnitConfig) {
+			cfg.MemoryMax = "1G"
+		})
+		fake := &fakeSystemd{}
+		useFakeSystemd(t, fake, nil)
+
+		require.NoError(t, os.WriteFile(s.installerCfg.SystemdUnit.Path, []byte(defaultUnit), 0600))
+
+		diff, err := s.UnitDiff()
+		require.NoError(t, err)
+		assert.Contains(t, diff, "--- "+s.installerCfg.SystemdUnit.Path)
+		assert.Contains(t, diff, "+MemoryMax=1G")
+
+		change, err := s.InstallUnit()
+		require.NoError(t, err)
+		assert.Equal(t, runner.UnitUpdated, change)
+
+		// It's already enabled.
+		assert.Equal(t, []string{"reload"}, fake.calls)
+
+		data, err := os.ReadFile(s.installerCfg.SystemdUnit.Path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "MemoryMax=1G")
+	})
+
+	t.Run("not authorized to reload", func(t *testing.T) {
+		s := newTestUnitSidecar(t, dir, "", nil)
+		useFakeSystemd(t, &fakeSystemd{reloadErr: accessDenied}, nil)
+
+		require.NoError(t, os.WriteFile(s.installerCfg.SystemdUnit.Path, []byte("[Unit]\n"), 0600))
+
+		_, err := s.InstallUnit()
+		assert.ErrorContains(t, err, "not authorized to reload contributoor.service")
+	})
+
+	t.Run("user unit", func(t *testing.T) {
+		s := newTestUnitSidecar(t, dir, installer.SystemdScopeUser, nil)
+		fake := &fakeSystemd{}
+		useFakeSystemd(t, fake, nil)
+
+		change, err := s.InstallUnit()
+		require.NoError(t, err)
+		assert.Equal(t, runner.UnitCreated, change)
+
+		// It's installed in the user's config, and enabled and reloaded by their systemd.
+		path := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "systemd", "user", "contributoor.service")
+		assert.FileExists(t, path)
+		assert.NoFileExists(t, s.installerCfg.SystemdUnit.Path)
+		assert.Equal(t, []string{"enable " + path, "reload"}, fake.calls)
+		assert.Equal(t, installer.SystemdScopeUser, fake.scope)
+	})
+}
+
+func TestSystemdSidecar_UninstallUnit(t *testing.T) {
+	const dir = "/home/ethereum/.contributoor"
+
+	t.Run("disables and removes the unit", func(t *testing.T) {
+		s := newTestUnitSidecar(t, dir, "", nil)
+		fake := &fakeSystemd{}
+		useFakeSystemd(t, fake, nil)
+
+		require.NoError(t, os.WriteFile(s.installerCfg.SystemdUnit.Path, []byte(defaultUnit), 0600))
+
+		require.NoError(t, s.UninstallUnit())
+		assert.Equal(t, []string{"disable contributoor.service", "reload"}, fake.calls)
+		assert.NoFileExists(t, s.installerCfg.SystemdUnit.Path)
+	})
+
+	t.Run("not installed", func(t *testing.T) {
+		s := newTestUnitSidecar(t, dir, "", nil)
+		fake := &fakeSystemd{}
+		useFakeSystemd(t, fake, nil)
+
+		require.NoError(t, s.UninstallUnit())
+		assert.Empty(t, fake.calls)
+	})
+
+	t.Run("not authorized to disable", func(t *testing.T) {
+		s := newTestUnitSidecar(t, dir, "", nil)
+		useFakeSystemd(t, &fakeSystemd{disableErr: accessDenied}, nil)
+
+		require.NoError(t, os.WriteFile(s.installerCfg.SystemdUnit.Path, []byte(defaultUnit), 0600))
+
+		assert.ErrorContains(t, s.UninstallUnit(), "not authorized to disable contributoor.service")
+
+		// The unit is left in place, so it's still managed.
+		assert.FileExists(t, s.installerCfg.SystemdUnit.Path)
+	})
+}
+
+func TestUnitFileError(t *testing.T) {
+	err := unitFileError(&os.PathError{Op: "open", Path: "/etc/systemd/system/contributoor.service", Err: os.ErrPermission})
+	assert.ErrorIs(t, err, os.ErrPermission)
+	assert.ErrorContains(t, err, "run this again as root")
+	assert.ErrorContains(t, err, "contributoor service install --scope user")
+
+	assert.ErrorIs(t, unitFileError(os.ErrNotExist), os.ErrNotExist)
+	assert.NotContains(t, unitFileError(os.ErrNotExist).Error(), "as root")
+	assert.NoError(t, unitFileError(nil))
+}
+
+func TestLingerEnabled(t *testing.T) {
+	original := systemdLingerDir
+	systemdLingerDir = t.TempDir()
+
+	t.Cleanup(func() {
+		systemdLingerDir = original
+	})
+
+	assert.False(t, lingerEnabled("ethereum"))
+
+	// loginctl enable-linger creates an empty file named after the user.
+	require.NoError(t, os.WriteFile(filepath.Join(systemdLingerDir, "ethereum"), nil, 0600))
+	assert.True(t, lingerEnabled("ethereum"))
+}
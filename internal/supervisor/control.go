@@ -0,0 +1,138 @@
+package supervisor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	commandStatus = "status"
+	commandStop   = "stop"
+)
+
+var (
+	// ErrNotRunning is returned when there's no supervisor listening on the control socket.
+	ErrNotRunning = errors.New("supervisor is not running")
+
+	// dialTimeout is how long clients wait to connect to the control socket.
+	dialTimeout = 2 * time.Second
+
+	// responseTimeout is how long clients wait for a response. Stopping waits for the
+	// process to exit, so it's given this on top of the grace period.
+	responseTimeout = 30 * time.Second
+)
+
+// request is a command sent to the control socket.
+type request struct {
+	Command string `json:"command"`
+
+	// GracePeriod is how long the process has to exit when stopping it.
+	GracePeriod time.Duration `json:"gracePeriod,omitempty"`
+}
+
+// response is the reply to a request.
+type response struct {
+	Status *Status `json:"status,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// GetStatus returns the status of the supervisor listening on the control socket.
+func GetStatus(socketPath string) (*Status, error) {
+	return call(socketPath, request{Command: commandStatus})
+}
+
+// Stop asks the supervisor listening on the control socket to stop the process and
+// exit. The process is sent SIGTERM, then SIGKILL if it hasn't exited within the grace
+// period. It returns once the process has exited, with the outcome in the returned status.
+func Stop(socketPath string, gracePeriod time.Duration) (*Status, error) {
+	return call(socketPath, request{Command: commandStop, GracePeriod: gracePeriod})
+}
+
+func call(socketPath string, req request) (*Status, error) {
+	conn, err := net.DialTimeout("unix", socketPath, dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNotRunning, err)
+	}
+
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(req.GracePeriod + responseTimeout)); err != nil {
+		return nil, fmt.Errorf("failed to set deadline: %w", err)
+	}
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("failed to send %s command: %w", req.Command, err)
+	}
+
+	var resp response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read %s response: %w", req.Command, err)
+	}
+
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+
+	return resp.Status, nil
+}
+
+// serve handles connections to the control socket until the listener is closed.
+func (s *Supervisor) serve(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.handlers.Add(1)
+
+		go func() {
+			defer s.handlers.Done()
+
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *Supervisor) handle(conn net.Conn) {
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(responseTimeout)); err != nil {
+		return
+	}
+
+	var (
+		req  request
+		resp response
+	)
+
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		return
+	}
+
+	switch req.Command {
+	case commandStatus:
+		status := s.Status()
+		resp.Status = &status
+	case commandStop:
+		s.requestStop(req.GracePeriod)
+
+		// Reply once the process has exited and the supervisor is done.
+		<-s.stopped
+
+		status := s.Status()
+		resp.Status = &status
+	default:
+		resp.Error = fmt.Sprintf("unknown command: %s", req.Command)
+	}
+
+	// Stopping may have outlasted the original deadline.
+	if err := conn.SetWriteDeadline(time.Now().Add(responseTimeout)); err != nil {
+		return
+	}
+
+	_ = json.NewEncoder(conn).Encode(resp)
+}
@@ -0,0 +1,364 @@
+// Package supervisor keeps a process running, restarting it with exponential backoff
+// when it exits. It's how the binary run method keeps sentry alive after the CLI that
+// started it has exited. Other processes talk to a running supervisor over a unix
+// control socket, see GetStatus and Stop.
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Config configures a Supervisor.
+type Config struct {
+	// Command is the path of the supervised process, followed by its arguments.
+	Command []string
+
+	// Stdout and Stderr receive the supervised process's output. They default to the
+	// supervisor's own.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// SocketPath is the path of the control socket.
+	SocketPath string
+
+	// MinBackoff is the delay before the first restart, doubling on each restart after
+	// that up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// StableAfter is how long the process must run for before the backoff is reset.
+	StableAfter time.Duration
+
+	// StopGracePeriod is how long the process has to exit after SIGTERM, before it's
+	// killed. Stop requests may ask for a different grace period.
+	StopGracePeriod time.Duration
+
+	// RotateLogs, if set, is called every RotateInterval while the process is
+	// supervised, to rotate the log files Stdout and Stderr write to. They must be
+	// rotated in place, as the process keeps writing to them.
+	RotateLogs     func() error
+	RotateInterval time.Duration
+}
+
+// DefaultConfig returns the default supervisor configuration for the given command.
+func DefaultConfig(socketPath string, command ...string) Config {
+	return Config{
+		Command:         command,
+		Stdout:          os.Stdout,
+		Stderr:          os.Stderr,
+		SocketPath:      socketPath,
+		MinBackoff:      time.Second,
+		MaxBackoff:      5 * time.Minute,
+		StableAfter:     time.Minute,
+		StopGracePeriod: 30 * time.Second,
+		RotateInterval:  10 * time.Minute,
+	}
+}
+
+// StopOutcome is how a process was stopped.
+type StopOutcome string
+
+const (
+	// StopOutcomeGraceful means the process exited within the grace period.
+	StopOutcomeGraceful StopOutcome = "graceful"
+
+	// StopOutcomeKilled means the process didn't exit within the grace period, and was
+	// killed with SIGKILL.
+	StopOutcomeKilled StopOutcome = "killed"
+)
+
+// Status is a snapshot of the supervised process.
+type Status struct {
+	// SupervisorPID is the process ID of the supervisor.
+	SupervisorPID int `json:"supervisorPid"`
+
+	// PID is the process ID of the supervised process, or 0 while it's waiting to be
+	// restarted.
+	PID int `json:"pid"`
+
+	// StartedAt is when the supervised process was last started.
+	StartedAt time.Time `json:"startedAt"`
+
+	// Restarts is the number of times the supervised process has been restarted.
+	Restarts int `json:"restarts"`
+
+	// LastExitCode is the exit code of the last time the supervised process exited, or
+	// -1 if it was killed by a signal. It's nil if the process hasn't exited.
+	LastExitCode *int `json:"lastExitCode,omitempty"`
+
+	// LastExitAt is when the supervised process last exited.
+	LastExitAt time.Time `json:"lastExitAt,omitempty"`
+
+	// StopOutcome is how the supervised process was stopped, once it has been. It's
+	// empty if a stop was requested while it was waiting to be restarted.
+	StopOutcome StopOutcome `json:"stopOutcome,omitempty"`
+}
+
+// Supervisor runs a process, restarting it whenever it exits, until it's stopped.
+type Supervisor struct {
+	log *logrus.Logger
+	cfg Config
+
+	mu     sync.Mutex
+	status Status
+
+	stopOnce  sync.Once
+	stopGrace time.Duration
+	stopping  chan struct{}
+	stopped   chan struct{}
+	handlers  sync.WaitGroup
+}
+
+// New creates a new Supervisor.
+func New(log *logrus.Logger, cfg Config) *Supervisor {
+	return &Supervisor{
+		log:      log,
+		cfg:      cfg,
+		status:   Status{SupervisorPID: os.Getpid()},
+		stopping: make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+// Run supervises the process until ctx is cancelled, or a stop is requested over the
+// control socket. The process is stopped before Run returns.
+func (s *Supervisor) Run(ctx context.Context) error {
+	if len(s.cfg.Command) == 0 {
+		return errors.New("no command to supervise")
+	}
+
+	listener, err := s.listen()
+	if err != nil {
+		return err
+	}
+
+	served := make(chan struct{})
+
+	go func() {
+		defer close(served)
+
+		s.serve(listener)
+	}()
+
+	// Stop accepting connections, then let in-flight ones, such as the stop request,
+	// get their response before returning.
+	defer func() {
+		listener.Close()
+		os.Remove(s.cfg.SocketPath)
+		close(s.stopped)
+
+		<-served
+		s.handlers.Wait()
+	}()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.requestStop(0)
+		case <-s.stopping:
+		}
+	}()
+
+	if s.cfg.RotateLogs != nil && s.cfg.RotateInterval > 0 {
+		rotated := make(chan struct{})
+
+		go func() {
+			defer close(rotated)
+
+			s.rotateLogs()
+		}()
+
+		defer func() { <-rotated }()
+	}
+
+	backoff := s.cfg.MinBackoff
+
+	for {
+		startedAt := time.Now()
+
+		cmd, exited, err := s.start()
+		if err != nil {
+			s.log.Errorf("Failed to start %s: %v", s.cfg.Command[0], err)
+		} else {
+			select {
+			case <-exited:
+				s.recordExit(cmd.ProcessState)
+
+				if time.Since(startedAt) >= s.cfg.StableAfter {
+					backoff = s.cfg.MinBackoff
+				}
+			case <-s.stopping:
+				s.terminate(cmd, exited)
+
+				return nil
+			}
+		}
+
+		s.log.Infof("Restarting %s in %s", s.cfg.Command[0], backoff)
+
+		select {
+		case <-time.After(backoff):
+		case <-s.stopping:
+			return nil
+		}
+
+		s.mu.Lock()
+		s.status.Restarts++
+		s.mu.Unlock()
+
+		backoff = min(backoff*2, s.cfg.MaxBackoff)
+	}
+}
+
+// Status returns a snapshot of the supervised process.
+func (s *Supervisor) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.status
+}
+
+// rotateLogs rotates the log files every RotateInterval, until a stop is requested.
+func (s *Supervisor) rotateLogs() {
+	ticker := time.NewTicker(s.cfg.RotateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.cfg.RotateLogs(); err != nil {
+				s.log.Warnf("Failed to rotate logs: %v", err)
+			}
+		case <-s.stopping:
+			return
+		}
+	}
+}
+
+// listen creates the control socket, replacing a stale one left behind by a supervisor
+// that didn't exit cleanly.
+func (s *Supervisor) listen() (net.Listener, error) {
+	if _, err := os.Stat(s.cfg.SocketPath); err == nil {
+		if _, err := GetStatus(s.cfg.SocketPath); err == nil {
+			return nil, fmt.Errorf("already supervised, control socket %s is in use", s.cfg.SocketPath)
+		}
+
+		if err := os.Remove(s.cfg.SocketPath); err != nil {
+			return nil, fmt.Errorf("failed to remove stale control socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", s.cfg.SocketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create control socket: %w", err)
+	}
+
+	if err := os.Chmod(s.cfg.SocketPath, 0600); err != nil {
+		listener.Close()
+
+		return nil, fmt.Errorf("failed to secure control socket: %w", err)
+	}
+
+	return listener, nil
+}
+
+// start starts the process. The returned channel is closed once it has exited.
+func (s *Supervisor) start() (*exec.Cmd, <-chan struct{}, error) {
+	//nolint:gosec // the command is ours.
+	cmd := exec.Command(s.cfg.Command[0], s.cfg.Command[1:]...)
+	cmd.Stdout = s.cfg.Stdout
+	cmd.Stderr = s.cfg.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, nil, err
+	}
+
+	s.mu.Lock()
+	s.status.PID = cmd.Process.Pid
+	s.status.StartedAt = time.Now().UTC()
+	s.mu.Unlock()
+
+	s.log.Infof("Started %s with PID %d", s.cfg.Command[0], cmd.Process.Pid)
+
+	exited := make(chan struct{})
+
+	go func() {
+		defer close(exited)
+
+		_ = cmd.Wait()
+	}()
+
+	return cmd, exited, nil
+}
+
+// recordExit records how the process exited.
+func (s *Supervisor) recordExit(state *os.ProcessState) {
+	code := state.ExitCode()
+
+	s.mu.Lock()
+	s.status.PID = 0
+	s.status.LastExitCode = &code
+	s.status.LastExitAt = time.Now().UTC()
+	s.mu.Unlock()
+
+	s.log.Warnf("%s exited with code %d", s.cfg.Command[0], code)
+}
+
+// terminate asks the process to exit, killing it if it hasn't by the end of the grace
+// period.
+func (s *Supervisor) terminate(cmd *exec.Cmd, exited <-chan struct{}) {
+	s.mu.Lock()
+	grace := s.stopGrace
+	s.mu.Unlock()
+
+	outcome := StopOutcomeGraceful
+
+	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		s.log.Warnf("Failed to signal %s: %v", s.cfg.Command[0], err)
+	}
+
+	select {
+	case <-exited:
+	case <-time.After(grace):
+		s.log.Warnf("%s didn't exit within %s, killing it", s.cfg.Command[0], grace)
+
+		outcome = StopOutcomeKilled
+
+		_ = cmd.Process.Kill()
+
+		<-exited
+	}
+
+	s.recordExit(cmd.ProcessState)
+
+	s.mu.Lock()
+	s.status.StopOutcome = outcome
+	s.mu.Unlock()
+}
+
+// requestStop asks Run to stop the process, giving it the grace period to exit. Zero
+// uses the configured grace period.
+func (s *Supervisor) requestStop(grace time.Duration) {
+	s.stopOnce.Do(func() {
+		if grace <= 0 {
+			grace = s.cfg.StopGracePeriod
+		}
+
+		s.mu.Lock()
+		s.stopGrace = grace
+		s.mu.Unlock()
+
+		close(s.stopping)
+	})
+}
@@ -0,0 +1,207 @@
+package supervisor
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig(t *testing.T, command ...string) Config {
+	t.Helper()
+
+	return Config{
+		Command:         command,
+		SocketPath:      filepath.Join(t.TempDir(), "s.sock"),
+		MinBackoff:      10 * time.Millisecond,
+		MaxBackoff:      40 * time.Millisecond,
+		StableAfter:     time.Hour,
+		StopGracePeriod: time.Second,
+	}
+}
+
+// runSupervisor runs a supervisor in the background, waiting for its control socket.
+// The returned channel receives the result of Run.
+func runSupervisor(t *testing.T, ctx context.Context, cfg Config) <-chan error {
+	t.Helper()
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- New(logrus.New(), cfg).Run(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		_, err := GetStatus(cfg.SocketPath)
+
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	t.Cleanup(func() {
+		_, _ = Stop(cfg.SocketPath, time.Second)
+	})
+
+	return done
+}
+
+func TestSupervisor_RestartsWithBackoff(t *testing.T) {
+	cfg := testConfig(t, "sh", "-c", "exit 3")
+	done := runSupervisor(t, context.Background(), cfg)
+
+	var status *Status
+
+	require.Eventually(t, func() bool {
+		var err error
+
+		status, err = GetStatus(cfg.SocketPath)
+
+		return err == nil && status.Restarts >= 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, os.Getpid(), status.SupervisorPID)
+	require.NotNil(t, status.LastExitCode)
+	assert.Equal(t, 3, *status.LastExitCode)
+	assert.False(t, status.LastExitAt.IsZero())
+
+	_, err := Stop(cfg.SocketPath, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, <-done)
+	assert.NoFileExists(t, cfg.SocketPath)
+}
+
+func TestSupervisor_Stop(t *testing.T) {
+	cfg := testConfig(t, "sleep", "60")
+	done := runSupervisor(t, context.Background(), cfg)
+
+	status, err := GetStatus(cfg.SocketPath)
+	require.NoError(t, err)
+	require.NotZero(t, status.PID)
+	assert.Zero(t, status.Restarts)
+	assert.Nil(t, status.LastExitCode)
+
+	pid := status.PID
+
+	// Stop only returns once the process has exited.
+	status, err = Stop(cfg.SocketPath, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, <-done)
+
+	assert.Zero(t, status.PID)
+	require.NotNil(t, status.LastExitCode)
+	assert.Equal(t, -1, *status.LastExitCode)
+	assert.Equal(t, StopOutcomeGraceful, status.StopOutcome)
+	assert.ErrorIs(t, syscall.Kill(pid, 0), syscall.ESRCH)
+
+	_, err = GetStatus(cfg.SocketPath)
+	assert.ErrorIs(t, err, ErrNotRunning)
+}
+
+func TestSupervisor_StopGracePeriod(t *testing.T) {
+	const gracePeriod = 100 * time.Millisecond
+
+	cfg := testConfig(t, "sh", "-c", `trap "" TERM; while true; do sleep 0.01; done`)
+	done := runSupervisor(t, context.Background(), cfg)
+
+	// Give the shell a moment to install its trap.
+	time.Sleep(100 * time.Millisecond)
+
+	start := time.Now()
+
+	// The grace period asked for overrides the configured one.
+	status, err := Stop(cfg.SocketPath, gracePeriod)
+	require.NoError(t, err)
+	require.NoError(t, <-done)
+
+	assert.Equal(t, StopOutcomeKilled, status.StopOutcome)
+	assert.GreaterOrEqual(t, time.Since(start), gracePeriod)
+	assert.Less(t, time.Since(start), cfg.StopGracePeriod)
+}
+
+func TestSupervisor_ContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	cfg := testConfig(t, "sleep", "60")
+	done := runSupervisor(t, ctx, cfg)
+
+	cancel()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("supervisor didn't stop")
+	}
+
+	assert.NoFileExists(t, cfg.SocketPath)
+}
+
+func TestSupervisor_RotateLogs(t *testing.T) {
+	var rotations atomic.Int32
+
+	cfg := testConfig(t, "sleep", "60")
+	cfg.RotateInterval = 10 * time.Millisecond
+	cfg.RotateLogs = func() error {
+		rotations.Add(1)
+
+		return nil
+	}
+
+	done := runSupervisor(t, context.Background(), cfg)
+
+	require.Eventually(t, func() bool {
+		return rotations.Load() >= 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	_, err := Stop(cfg.SocketPath, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, <-done)
+
+	// Rotation stops along with the supervisor.
+	stoppedAt := rotations.Load()
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, stoppedAt, rotations.Load())
+}
+
+func TestSupervisor_ControlSocket(t *testing.T) {
+	t.Run("in use", func(t *testing.T) {
+		cfg := testConfig(t, "sleep", "60")
+		runSupervisor(t, context.Background(), cfg)
+
+		err := New(logrus.New(), cfg).Run(context.Background())
+		assert.ErrorContains(t, err, "already supervised")
+	})
+
+	t.Run("stale", func(t *testing.T) {
+		cfg := testConfig(t, "sleep", "60")
+
+		// Leave a socket behind that nothing is listening on.
+		listener, err := net.Listen("unix", cfg.SocketPath)
+		require.NoError(t, err)
+
+		listener.(*net.UnixListener).SetUnlinkOnClose(false)
+		require.NoError(t, listener.Close())
+
+		_, err = GetStatus(cfg.SocketPath)
+		require.ErrorIs(t, err, ErrNotRunning)
+
+		done := runSupervisor(t, context.Background(), cfg)
+
+		_, err = Stop(cfg.SocketPath, time.Second)
+		require.NoError(t, err)
+		require.NoError(t, <-done)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := GetStatus(filepath.Join(t.TempDir(), "missing.sock"))
+		assert.ErrorIs(t, err, ErrNotRunning)
+	})
+}